@@ -35,6 +35,12 @@ func (rnr *bindRunner) Run(ctx context.Context, s *step, first bool) error {
 		return keys[i] < keys[j]
 	})
 	for _, k := range keys {
+		if o.store.shared != nil && isSharedKey(k) {
+			if err := o.store.shared.bind(k, cond[k], store); err != nil {
+				return err
+			}
+			continue
+		}
 		if lo.Contains(reservedStoreRootKeys, k) {
 			return fmt.Errorf("%q is reserved", k)
 		}
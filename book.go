@@ -21,11 +21,35 @@ const noDesc = "[No Description]"
 
 // book - Aggregated settings. runbook settings and run settings are aggregated.
 type book struct {
-	desc                 string
-	labels               []string
-	runners              map[string]any
-	vars                 map[string]any
-	rawSteps             []map[string]any
+	// id - ID of the runbook specified by `id:`. If empty, the ID is generated using the path
+	id      string
+	desc    string
+	labels  []string
+	runners map[string]any
+	vars    map[string]any
+	// consts - Values that are bound into vars and cannot be overridden
+	consts map[string]any
+	// lazyVars - Expressions of the vars evaluated on first use
+	lazyVars map[string]string
+	// varsSchema - JSON Schema to validate vars
+	varsSchema map[string]any
+	// secrets - Var names or expression paths whose values are masked in outputs
+	secrets []string
+	// storeSeed - Values of the store loaded by LoadStore
+	storeSeed map[string]any
+	// startStep, endStep, runSteps - Steps selected to be run
+	startStep string
+	endStep   string
+	runSteps  []string
+	// dotenv - Environment variables loaded by EnvFile to expand the runbooks
+	dotenv map[string]string
+	// replaySteps - Results of the steps loaded by ReplaySteps
+	replaySteps     map[string]any
+	rawSteps        []map[string]any
+	beforeEachSteps []map[string]any
+	afterEachSteps  []map[string]any
+	// templates - Step templates used by `use:`
+	templates            map[string]map[string]any
 	hostRules            hostRules
 	debug                bool
 	ifCond               string
@@ -41,7 +65,12 @@ type book struct {
 	profile              bool
 	intervalStr          string
 	interval             time.Duration
+	timeoutStr           string
+	timeout              time.Duration
 	loop                 *Loop
+	rawCases             any
+	cases                []map[string]any
+	caseIndex            *int
 	concurrency          []string
 	useMap               bool
 	t                    *testing.T
@@ -50,6 +79,7 @@ type book struct {
 	trace                bool
 	failFast             bool
 	skipIncluded         bool
+	includeMaxDepth      int
 	openApi3DocLocations []string
 	grpcNoTLS            bool
 	grpcProtos           []string
@@ -71,28 +101,30 @@ type book struct {
 	capturers            capturers
 	stdout               io.Writer
 	stderr               io.Writer
+	// updateSnapshots - Update the snapshot files of `snapshot:` instead of comparing
+	updateSnapshots bool
+	// softAssertions - Evaluate all the conditions joined by `&&` in `test:` and report every failure
+	softAssertions bool
+	// sharedStore - Enable `shared.*` shared across runbooks in one RunN
+	sharedStore bool
 	// Skip some errors for `runn list`
 	loadOnly bool
 }
 
 func LoadBook(path string) (*book, error) {
-	return loadBook(path, nil)
+	return loadBook(path, nil, os.LookupEnv)
 }
 
-func loadBook(path string, store map[string]any) (*book, error) {
+// loadBook loads the runbook expanding the environment variables looked up by lookupEnv.
+func loadBook(path string, store map[string]any, lookupEnv func(string) (string, bool)) (*book, error) {
 	fp, err := fetchPath(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load runbook %s: %w", path, err)
 	}
-	f, err := os.Open(fp)
+	bk, err := readBook(fp, lookupEnv)
 	if err != nil {
 		return nil, fmt.Errorf("failed to load runbook %s: %w", path, err)
 	}
-	bk, err := parseBook(f)
-	if err != nil {
-		_ = f.Close()
-		return nil, fmt.Errorf("failed to load runbook %s: %w", path, err)
-	}
 	bk.path = fp
 	if err := bk.parseRunners(store); err != nil {
 		return nil, err
@@ -100,7 +132,10 @@ func loadBook(path string, store map[string]any) (*book, error) {
 	if err := bk.parseVars(store); err != nil {
 		return nil, err
 	}
-	if err := f.Close(); err != nil {
+	if err := bk.parseConsts(store); err != nil {
+		return nil, fmt.Errorf("failed to load runbook %s: %w", path, err)
+	}
+	if err := bk.parseCases(); err != nil {
 		return nil, fmt.Errorf("failed to load runbook %s: %w", path, err)
 	}
 
@@ -393,6 +428,19 @@ func (bk *book) parseDBRunnerWithDetailed(name string, b []byte) (bool, error) {
 		return false, err
 	}
 	r.trace = c.Trace
+	r.snapshot = c.Snapshot
+	r.pool, err = c.connPool()
+	if err != nil {
+		return false, err
+	}
+	root, err := bk.generateOperatorRoot()
+	if err != nil {
+		return false, err
+	}
+	r.tls, err = c.dbTLS(root)
+	if err != nil {
+		return false, err
+	}
 	bk.dbRunners[name] = r
 	return true, nil
 }
@@ -515,6 +563,7 @@ func (bk *book) generateOperatorRoot() (string, error) {
 
 func (bk *book) merge(loaded *book) error {
 	bk.path = loaded.path
+	bk.id = loaded.id
 	bk.desc = loaded.desc
 	bk.labels = loaded.labels
 	bk.ifCond = loaded.ifCond
@@ -540,8 +589,24 @@ func (bk *book) merge(loaded *book) error {
 	for k, v := range loaded.vars {
 		bk.vars[k] = v
 	}
+	for k, v := range loaded.consts {
+		bk.consts[k] = v
+	}
+	for k, v := range loaded.lazyVars {
+		if bk.lazyVars == nil {
+			bk.lazyVars = map[string]string{}
+		}
+		bk.lazyVars[k] = v
+	}
+	if loaded.varsSchema != nil {
+		bk.varsSchema = loaded.varsSchema
+	}
+	bk.secrets = append(bk.secrets, loaded.secrets...)
 	bk.runnerErrs = loaded.runnerErrs
 	bk.rawSteps = loaded.rawSteps
+	bk.beforeEachSteps = loaded.beforeEachSteps
+	bk.afterEachSteps = loaded.afterEachSteps
+	bk.templates = loaded.templates
 	bk.hostRules = loaded.hostRules
 	bk.stepKeys = loaded.stepKeys
 	if !bk.debug {
@@ -557,6 +622,7 @@ func (bk *book) merge(loaded *book) error {
 		bk.trace = loaded.trace
 	}
 	bk.loop = loaded.loop
+	bk.cases = loaded.cases
 	bk.openApi3DocLocations = loaded.openApi3DocLocations
 	bk.grpcNoTLS = loaded.grpcNoTLS
 	bk.grpcProtos = loaded.grpcProtos
@@ -564,6 +630,9 @@ func (bk *book) merge(loaded *book) error {
 	if loaded.intervalStr != "" {
 		bk.interval = loaded.interval
 	}
+	if loaded.timeoutStr != "" {
+		bk.timeout = loaded.timeout
+	}
 	return nil
 }
 
@@ -602,6 +671,7 @@ func newBook() *book {
 	return &book{
 		runners:     map[string]any{},
 		vars:        map[string]any{},
+		consts:      map[string]any{},
 		rawSteps:    []map[string]any{},
 		funcs:       map[string]any{},
 		httpRunners: map[string]*httpRunner{},
@@ -616,8 +686,52 @@ func newBook() *book {
 	}
 }
 
+// readBook reads the runbook file and returns the validated book.
+// The book is cached, so the runbook included many times is read and validated only once.
+func readBook(fp string, lookupEnv func(string) (string, bool)) (*book, error) {
+	fi, err := os.Stat(fp)
+	if err != nil {
+		return nil, err
+	}
+	if bk, ok := globalBookCache.get(fp, fi, lookupEnv); ok {
+		return bk, nil
+	}
+	b, err := os.ReadFile(fp)
+	if err != nil {
+		return nil, err
+	}
+	b, err = decryptSOPSIfEncrypted(b, fp, sopsFormatYAML)
+	if err != nil {
+		return nil, err
+	}
+	// The dotenv files of `envFiles:` are loaded before expanding the environment variables
+	envFiles := runbookEnvFiles(b)
+	dotenv := map[string]string{}
+	if err := loadEnvFiles(dotenv, filepath.Dir(fp), envFiles...); err != nil {
+		return nil, err
+	}
+	envs := map[string]envValue{}
+	bk, err := parseBookWithEnv(b, recordEnv(layeredLookupEnv(lookupEnv, dotenv), envs))
+	if err != nil {
+		return nil, err
+	}
+	if err := globalBookCache.set(fp, fi, envFiles, envs, bk); err != nil {
+		return nil, err
+	}
+	return bk, nil
+}
+
 func parseBook(in io.Reader) (*book, error) {
-	rb, err := ParseRunbook(in)
+	b, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	return parseBookWithEnv(b, os.LookupEnv)
+}
+
+// parseBookWithEnv parses the runbook expanding the environment variables looked up by lookupEnv.
+func parseBookWithEnv(b []byte, lookupEnv func(string) (string, bool)) (*book, error) {
+	rb, err := parseRunbook(b, lookupEnv)
 	if err != nil {
 		return nil, err
 	}
@@ -649,6 +763,14 @@ func parseBook(in io.Reader) (*book, error) {
 		bk.interval = d
 	}
 
+	if bk.timeoutStr != "" {
+		d, err := parseDuration(bk.timeoutStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %w", err)
+		}
+		bk.timeout = d
+	}
+
 	for k := range bk.runners {
 		if err := validateRunnerKey(k); err != nil {
 			return nil, err
@@ -661,14 +783,32 @@ func parseBook(in io.Reader) (*book, error) {
 		}
 	}
 
+	for k, s := range bk.templates {
+		if err := validateTemplate(s); err != nil {
+			return nil, fmt.Errorf("invalid templates.%s. %w: %s", k, err, s)
+		}
+	}
+
+	for i, s := range bk.beforeEachSteps {
+		if err := validateStepKeys(s); err != nil {
+			return nil, fmt.Errorf("invalid hooks.%s[%d]. %w: %s", beforeEachHookKey, i, err, s)
+		}
+	}
+
+	for i, s := range bk.afterEachSteps {
+		if err := validateStepKeys(s); err != nil {
+			return nil, fmt.Errorf("invalid hooks.%s[%d]. %w: %s", afterEachHookKey, i, err, s)
+		}
+	}
+
 	return bk, nil
 }
 
 func validateRunnerKey(k string) error {
-	if k == includeRunnerKey || k == testRunnerKey || k == dumpRunnerKey || k == execRunnerKey || k == bindRunnerKey {
+	if k == includeRunnerKey || k == testRunnerKey || k == dumpRunnerKey || k == execRunnerKey || k == bindRunnerKey || k == snapshotRunnerKey || k == parallelRunnerKey || k == groupRunnerKey {
 		return fmt.Errorf("runner name %q is reserved for built-in runner", k)
 	}
-	if k == ifSectionKey || k == descSectionKey || k == loopSectionKey {
+	if k == ifSectionKey || k == descSectionKey || k == nameSectionKey || k == loopSectionKey || k == retrySectionKey || k == eventuallySectionKey || k == maxLatencySectionKey || k == expectErrorSectionKey || k == needsSectionKey || k == deferSectionKey || k == skipSectionKey || k == onlySectionKey || k == gotoSectionKey || k == forceSectionKey || k == useSectionKey || k == withSectionKey {
 		return fmt.Errorf("runner name %q is reserved for built-in section", k)
 	}
 	return nil
@@ -680,7 +820,7 @@ func validateStepKeys(s map[string]any) error {
 	}
 	custom := 0
 	for k := range s {
-		if k == testRunnerKey || k == dumpRunnerKey || k == bindRunnerKey || k == ifSectionKey || k == descSectionKey || k == loopSectionKey {
+		if k == testRunnerKey || k == dumpRunnerKey || k == bindRunnerKey || k == snapshotRunnerKey || k == ifSectionKey || k == descSectionKey || k == nameSectionKey || k == loopSectionKey || k == retrySectionKey || k == eventuallySectionKey || k == maxLatencySectionKey || k == expectErrorSectionKey || k == needsSectionKey || k == deferSectionKey || k == skipSectionKey || k == onlySectionKey || k == gotoSectionKey || k == forceSectionKey || k == useSectionKey || k == withSectionKey {
 			continue
 		}
 		custom += 1
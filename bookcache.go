@@ -0,0 +1,182 @@
+package runn
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/mitchellh/copystructure"
+)
+
+// bookCacheMaxEntries - Max number of the books cached. The oldest book is evicted when exceeded
+const bookCacheMaxEntries = 128
+
+// bookCache - Cache of the books loaded from the runbook files keyed by the paths.
+// The runbook included many times ( e.g. by many runbooks or in loops ) is read and validated only once
+// while the file is not modified and the environment variables referred by it are not changed.
+type bookCache struct {
+	entries map[string]*bookCacheEntry
+	// paths - Paths of the entries in the order of caching
+	paths []string
+	mu    sync.Mutex
+}
+
+type bookCacheEntry struct {
+	size    int64
+	modTime time.Time
+	// envFiles - Dotenv files of `envFiles:` in the runbook
+	envFiles []string
+	// envs - Environment variables referred while expanding the runbook
+	envs map[string]envValue
+	bk   *book
+}
+
+// envValue - Value of the environment variable. ok is false if the environment variable is not set
+type envValue struct {
+	v  string
+	ok bool
+}
+
+var globalBookCache = newBookCache()
+
+func newBookCache() *bookCache {
+	return &bookCache{entries: map[string]*bookCacheEntry{}}
+}
+
+// get returns the copy of the cached book, because the book is modified while loading ( e.g. by the options ).
+func (c *bookCache) get(p string, fi os.FileInfo, lookupEnv func(string) (string, bool)) (*book, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	e, ok := c.entries[p]
+	if !ok {
+		return nil, false
+	}
+	if e.size != fi.Size() || !e.modTime.Equal(fi.ModTime()) {
+		return nil, false
+	}
+	if len(e.envFiles) > 0 {
+		dotenv := map[string]string{}
+		if err := loadEnvFiles(dotenv, filepath.Dir(p), e.envFiles...); err != nil {
+			return nil, false
+		}
+		lookupEnv = layeredLookupEnv(lookupEnv, dotenv)
+	}
+	for k, ev := range e.envs {
+		if v, ok := lookupEnv(k); v != ev.v || ok != ev.ok {
+			return nil, false
+		}
+	}
+	bk, err := e.bk.clone()
+	if err != nil {
+		return nil, false
+	}
+	return bk, true
+}
+
+func (c *bookCache) set(p string, fi os.FileInfo, envFiles []string, envs map[string]envValue, bk *book) error {
+	cbk, err := bk.clone()
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[p]; !ok {
+		if len(c.paths) >= bookCacheMaxEntries {
+			delete(c.entries, c.paths[0])
+			c.paths = c.paths[1:]
+		}
+		c.paths = append(c.paths, p)
+	}
+	c.entries[p] = &bookCacheEntry{
+		size:     fi.Size(),
+		modTime:  fi.ModTime(),
+		envFiles: envFiles,
+		envs:     envs,
+		bk:       cbk,
+	}
+	return nil
+}
+
+// recordEnv returns the function to look up the environment variables that records the values looked up into envs.
+func recordEnv(lookupEnv func(string) (string, bool), envs map[string]envValue) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := lookupEnv(k)
+		envs[k] = envValue{v: v, ok: ok}
+		return v, ok
+	}
+}
+
+// clone returns the copy of the whole book.
+// The values of the runbook are deep copied, because they are modified while loading and running.
+func (bk *book) clone() (*book, error) {
+	c := *bk
+	var err error
+	if c.runners, err = deepCopy(bk.runners); err != nil {
+		return nil, err
+	}
+	if c.vars, err = deepCopy(bk.vars); err != nil {
+		return nil, err
+	}
+	if c.consts, err = deepCopy(bk.consts); err != nil {
+		return nil, err
+	}
+	if c.varsSchema, err = deepCopy(bk.varsSchema); err != nil {
+		return nil, err
+	}
+	if c.rawSteps, err = deepCopy(bk.rawSteps); err != nil {
+		return nil, err
+	}
+	if c.beforeEachSteps, err = deepCopy(bk.beforeEachSteps); err != nil {
+		return nil, err
+	}
+	if c.afterEachSteps, err = deepCopy(bk.afterEachSteps); err != nil {
+		return nil, err
+	}
+	if c.templates, err = deepCopy(bk.templates); err != nil {
+		return nil, err
+	}
+	if c.rawCases, err = deepCopy(bk.rawCases); err != nil {
+		return nil, err
+	}
+	if bk.loop != nil {
+		l := *bk.loop
+		c.loop = &l
+	}
+	c.labels = append([]string(nil), bk.labels...)
+	c.secrets = append([]string(nil), bk.secrets...)
+	c.stepKeys = append([]string(nil), bk.stepKeys...)
+	c.concurrency = append([]string(nil), bk.concurrency...)
+	c.hostRules = append(hostRules(nil), bk.hostRules...)
+	c.lazyVars = maps.Clone(bk.lazyVars)
+	c.dotenv = maps.Clone(bk.dotenv)
+	c.funcs = maps.Clone(bk.funcs)
+	c.httpRunners = maps.Clone(bk.httpRunners)
+	c.dbRunners = maps.Clone(bk.dbRunners)
+	c.grpcRunners = maps.Clone(bk.grpcRunners)
+	c.cdpRunners = maps.Clone(bk.cdpRunners)
+	c.sshRunners = maps.Clone(bk.sshRunners)
+	c.runnerErrs = maps.Clone(bk.runnerErrs)
+	return &c, nil
+}
+
+func deepCopy[T any](v T) (T, error) {
+	var zero T
+	if any(v) == nil {
+		return zero, nil
+	}
+	c, err := copystructure.Copy(v)
+	if err != nil {
+		return zero, err
+	}
+	if c == nil {
+		return zero, nil
+	}
+	cv, ok := c.(T)
+	if !ok {
+		return zero, fmt.Errorf("failed to copy: %v", v)
+	}
+	return cv, nil
+}
@@ -0,0 +1,88 @@
+package runn
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestReadBookCache(t *testing.T) {
+	t.Setenv("RUNN_TEST_BOOK_CACHE", "alice")
+	p := filepath.Join(t.TempDir(), "book.yml")
+	write := func(desc string, mtime time.Time) {
+		t.Helper()
+		rb := fmt.Sprintf("desc: %s\nvars:\n  user: ${RUNN_TEST_BOOK_CACHE}\nsteps:\n  -\n    test: true\n", desc)
+		if err := os.WriteFile(p, []byte(rb), 0600); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Chtimes(p, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	now := time.Now()
+	write("first", now)
+
+	bk, err := readBook(p, os.LookupEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Modify the loaded book
+	bk.vars["user"] = "modified"
+	bk.rawSteps = bk.rawSteps[:0]
+
+	got, err := readBook(p, os.LookupEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.vars["user"] != "alice" || len(got.rawSteps) != 1 {
+		t.Errorf("the cached book should not be modified: %v %v", got.vars, got.rawSteps)
+	}
+
+	// The environment variable referred by the runbook is changed
+	t.Setenv("RUNN_TEST_BOOK_CACHE", "bob")
+	got, err = readBook(p, os.LookupEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.vars["user"] != "bob" {
+		t.Errorf("got %v\nwant %v", got.vars["user"], "bob")
+	}
+
+	// The runbook is modified
+	write("second", now.Add(time.Second))
+	got, err = readBook(p, os.LookupEnv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.desc != "second" {
+		t.Errorf("got %v\nwant %v", got.desc, "second")
+	}
+}
+
+func TestBookCacheEviction(t *testing.T) {
+	c := newBookCache()
+	p := filepath.Join(t.TempDir(), "book.yml")
+	if err := os.WriteFile(p, []byte("desc: test\nsteps: []\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i <= bookCacheMaxEntries; i++ {
+		if err := c.set(fmt.Sprintf("%s.%d", p, i), fi, nil, nil, newBook()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := len(c.entries); got != bookCacheMaxEntries {
+		t.Errorf("got %v\nwant %v", got, bookCacheMaxEntries)
+	}
+	if _, ok := c.get(fmt.Sprintf("%s.%d", p, 0), fi, os.LookupEnv); ok {
+		t.Error("the oldest book should be evicted")
+	}
+	if _, ok := c.get(fmt.Sprintf("%s.%d", p, bookCacheMaxEntries), fi, os.LookupEnv); !ok {
+		t.Error("the latest book should be cached")
+	}
+}
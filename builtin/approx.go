@@ -0,0 +1,71 @@
+package builtin
+
+import (
+	"math"
+	"time"
+
+	"github.com/k1LoW/duration"
+	"github.com/spf13/cast"
+)
+
+const approxEpsilon = 1e-9
+
+// Approx returns true if the difference between the numbers x and y is within the tolerance.
+// The rounding error of floating point numbers is also tolerated ( e.g. `approx(10.05, 10, 0.05)` is true ).
+func Approx(x, y, tolerance any) bool {
+	if x == nil || y == nil || tolerance == nil {
+		return false
+	}
+	fx, err := cast.ToFloat64E(x)
+	if err != nil {
+		return false
+	}
+	fy, err := cast.ToFloat64E(y)
+	if err != nil {
+		return false
+	}
+	tol, err := cast.ToFloat64E(tolerance)
+	if err != nil || tol < 0 {
+		return false
+	}
+	eps := approxEpsilon * math.Max(math.Abs(fx), math.Abs(fy))
+	return math.Abs(fx-fy) <= tol+eps
+}
+
+// Within returns true if the difference between the times t1 and t2 is within the duration d.
+// The times are converted as Time, and d is the duration string ( e.g. `5sec`, `500ms` ), time.Duration or seconds.
+func Within(t1, t2, d any) bool {
+	tt1 := Time(t1)
+	tt2 := Time(t2)
+	if tt1.IsZero() || tt2.IsZero() {
+		return false
+	}
+	dd, ok := toDuration(d)
+	if !ok || dd < 0 {
+		return false
+	}
+	diff := tt1.Sub(tt2)
+	if diff < 0 {
+		diff = -diff
+	}
+	return diff <= dd
+}
+
+func toDuration(v any) (time.Duration, bool) {
+	switch vv := v.(type) {
+	case time.Duration:
+		return vv, true
+	case string:
+		d, err := duration.Parse(vv)
+		if err != nil {
+			return 0, false
+		}
+		return d, true
+	default:
+		f, err := cast.ToFloat64E(v)
+		if err != nil {
+			return 0, false
+		}
+		return time.Duration(f * float64(time.Second)), true
+	}
+}
@@ -0,0 +1,55 @@
+package builtin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestApprox(t *testing.T) {
+	tests := []struct {
+		x         any
+		y         any
+		tolerance any
+		want      bool
+	}{
+		{0.1 + 0.2, 0.3, 0.000001, true},
+		{10.05, 10, 0.01, false},
+		{10.05, 10, 0.05, true},
+		{100, 101, 1, true},
+		{"1.5", 1.5, 0, true},
+		{1, 2, -1, false},
+		{"a", 1, 1, false},
+		{1, nil, 1, false},
+	}
+	for _, tt := range tests {
+		if got := Approx(tt.x, tt.y, tt.tolerance); got != tt.want {
+			t.Errorf("Approx(%v, %v, %v): got %v\nwant %v", tt.x, tt.y, tt.tolerance, got, tt.want)
+		}
+	}
+}
+
+func TestWithin(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		t1   any
+		t2   any
+		d    any
+		want bool
+	}{
+		{now.Add(-3 * time.Second), now, "5sec", true},
+		{now, now.Add(-3 * time.Second), "5s", true},
+		{now.Add(-10 * time.Second), now, "5sec", false},
+		{now.Add(-300 * time.Millisecond), now, 0.5, true},
+		{now.Add(-time.Minute), now, time.Minute, true},
+		{"2024-01-01T00:00:03Z", "2024-01-01T00:00:00Z", "3sec", true},
+		{1704067203, "2024-01-01T00:00:00Z", "2sec", false},
+		{"invalid", now, "5sec", false},
+		{now, now, "invalid", false},
+		{now, now, -1, false},
+	}
+	for _, tt := range tests {
+		if got := Within(tt.t1, tt.t2, tt.d); got != tt.want {
+			t.Errorf("Within(%v, %v, %v): got %v\nwant %v", tt.t1, tt.t2, tt.d, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package builtin
+
+import (
+	"crypto/hmac"
+	"crypto/md5"  //nolint:gosec
+	"crypto/sha1" //nolint:gosec
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/spf13/cast"
+)
+
+func MD5(v any) string {
+	s := md5.Sum(toBytes(v)) //nolint:gosec
+	return hex.EncodeToString(s[:])
+}
+
+func SHA1(v any) string {
+	s := sha1.Sum(toBytes(v)) //nolint:gosec
+	return hex.EncodeToString(s[:])
+}
+
+func SHA256(v any) string {
+	s := sha256.Sum256(toBytes(v))
+	return hex.EncodeToString(s[:])
+}
+
+func SHA512(v any) string {
+	s := sha512.Sum512(toBytes(v))
+	return hex.EncodeToString(s[:])
+}
+
+// HMAC returns the hex encoded HMAC of the message. alg is one of md5, sha1, sha256 and sha512.
+func HMAC(alg string, key, msg any) (string, error) {
+	var fn func() hash.Hash
+	switch strings.ToLower(alg) {
+	case "md5":
+		fn = md5.New
+	case "sha1":
+		fn = sha1.New
+	case "sha256":
+		fn = sha256.New
+	case "sha512":
+		fn = sha512.New
+	default:
+		return "", fmt.Errorf("unsupported algorithm: %s", alg)
+	}
+	h := hmac.New(fn, toBytes(key))
+	_, _ = h.Write(toBytes(msg))
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// Sign returns the base64url encoded signature of the message.
+// alg is the algorithm of JWS ( HS256, RS256, PS256, ES256, EdDSA, etc. ) and key is the secret or the PEM encoded private key.
+func Sign(alg, key string, msg any) (string, error) {
+	m, err := signingMethod(alg)
+	if err != nil {
+		return "", err
+	}
+	k, err := signingKey(m, key)
+	if err != nil {
+		return "", err
+	}
+	return m.Sign(string(toBytes(msg)), k)
+}
+
+// Verify verifies the base64url encoded signature of the message.
+// alg is the algorithm of JWS ( HS256, RS256, PS256, ES256, EdDSA, etc. ) and key is the secret or the PEM encoded public key.
+func Verify(alg, key string, msg any, sig string) (bool, error) {
+	m, err := signingMethod(alg)
+	if err != nil {
+		return false, err
+	}
+	k, err := verifyingKey(m, key)
+	if err != nil {
+		return false, err
+	}
+	return m.Verify(string(toBytes(msg)), sig, k) == nil, nil
+}
+
+func signingMethod(alg string) (jwt.SigningMethod, error) {
+	m := jwt.GetSigningMethod(alg)
+	if m == nil || m == jwt.SigningMethodNone {
+		return nil, fmt.Errorf("unsupported algorithm: %s", alg)
+	}
+	return m, nil
+}
+
+func signingKey(m jwt.SigningMethod, key string) (any, error) {
+	switch m.(type) {
+	case *jwt.SigningMethodHMAC:
+		return []byte(key), nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		return jwt.ParseRSAPrivateKeyFromPEM([]byte(key))
+	case *jwt.SigningMethodECDSA:
+		return jwt.ParseECPrivateKeyFromPEM([]byte(key))
+	case *jwt.SigningMethodEd25519:
+		return jwt.ParseEdPrivateKeyFromPEM([]byte(key))
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", m.Alg())
+	}
+}
+
+func verifyingKey(m jwt.SigningMethod, key string) (any, error) {
+	switch m.(type) {
+	case *jwt.SigningMethodHMAC:
+		return []byte(key), nil
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		return jwt.ParseRSAPublicKeyFromPEM([]byte(key))
+	case *jwt.SigningMethodECDSA:
+		return jwt.ParseECPublicKeyFromPEM([]byte(key))
+	case *jwt.SigningMethodEd25519:
+		return jwt.ParseEdPublicKeyFromPEM([]byte(key))
+	default:
+		return nil, fmt.Errorf("unsupported algorithm: %s", m.Alg())
+	}
+}
+
+func toBytes(v any) []byte {
+	switch vv := v.(type) {
+	case []byte:
+		return vv
+	case string:
+		return []byte(vv)
+	default:
+		return []byte(cast.ToString(v))
+	}
+}
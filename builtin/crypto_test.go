@@ -0,0 +1,129 @@
+package builtin
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	tests := []struct {
+		fn   func(any) string
+		in   any
+		want string
+	}{
+		{MD5, "hello", "5d41402abc4b2a76b9719d911017c592"},
+		{SHA1, "hello", "aaf4c61ddcc5e8a2dabede0f3b482cd9aea9434d"},
+		{SHA256, "hello", "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{SHA256, []byte("hello"), "2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"},
+		{SHA512, "hello", "9b71d224bd62f3785d96d46ad3ea3d73319bfbc2890caadae2dff72519673ca72323c3d99ba5c11d7c7acc6e14b8c5da0c4663475c2e5c3adef46f73bcdec043"},
+	}
+	for _, tt := range tests {
+		if got := tt.fn(tt.in); got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestHMAC(t *testing.T) {
+	tests := []struct {
+		alg     string
+		want    string
+		wantErr bool
+	}{
+		{"sha256", "9307b3b915efb5171ff14d8cb55fbcc798c6c0ef1456d66ded1a6aa723a58b7b", false},
+		{"SHA256", "9307b3b915efb5171ff14d8cb55fbcc798c6c0ef1456d66ded1a6aa723a58b7b", false},
+		{"sha3", "", true},
+	}
+	for _, tt := range tests {
+		got, err := HMAC(tt.alg, "key", "hello")
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+		}
+		if got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestSignAndVerify(t *testing.T) {
+	rsaPriv, rsaPub := rsaKeys(t)
+	ecPriv, ecPub := ecKeys(t)
+	tests := []struct {
+		alg     string
+		signKey string
+		verKey  string
+	}{
+		{"HS256", "secret", "secret"},
+		{"RS256", rsaPriv, rsaPub},
+		{"PS256", rsaPriv, rsaPub},
+		{"ES256", ecPriv, ecPub},
+	}
+	for _, tt := range tests {
+		t.Run(tt.alg, func(t *testing.T) {
+			sig, err := Sign(tt.alg, tt.signKey, "hello")
+			if err != nil {
+				t.Fatal(err)
+			}
+			ok, err := Verify(tt.alg, tt.verKey, "hello", sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !ok {
+				t.Error("want verified")
+			}
+			ok, err = Verify(tt.alg, tt.verKey, "tampered", sig)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if ok {
+				t.Error("want not verified")
+			}
+		})
+	}
+	if _, err := Sign("none", "", "hello"); err == nil {
+		t.Error("want error")
+	}
+}
+
+func rsaKeys(t *testing.T) (string, string) {
+	t.Helper()
+	k, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+}
+
+func ecKeys(t *testing.T) (string, string) {
+	t.Helper()
+	k, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	priv, err := x509.MarshalECPrivateKey(k)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, err := x509.MarshalPKIXPublicKey(&k.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: priv})),
+		string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pub}))
+}
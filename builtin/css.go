@@ -0,0 +1,37 @@
+package builtin
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/andybalholm/cascadia"
+	"github.com/spf13/cast"
+)
+
+// CSS selects the elements of the HTML document using the CSS selector and returns the texts ( or the values of the attribute ).
+// The result is the value if one element matches, the list of the values if multiple elements match, or nil if no element matches.
+func CSS(selector string, v any, attr ...string) (any, error) {
+	if len(attr) > 1 {
+		return nil, fmt.Errorf("invalid attribute: %v", attr)
+	}
+	sel, err := cascadia.Compile(selector)
+	if err != nil {
+		return nil, fmt.Errorf("invalid css selector: %w", err)
+	}
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(cast.ToString(v)))
+	if err != nil {
+		return nil, err
+	}
+	var values []any
+	doc.FindMatcher(sel).Each(func(_ int, s *goquery.Selection) {
+		if len(attr) == 0 {
+			values = append(values, s.Text())
+			return
+		}
+		if a, ok := s.Attr(attr[0]); ok {
+			values = append(values, a)
+		}
+	})
+	return unwrap(values), nil
+}
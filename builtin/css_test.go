@@ -0,0 +1,44 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCSS(t *testing.T) {
+	const page = `<html><body>
+<h1 class="title">Users</h1>
+<ul id="users"><li><a href="/users/1">alice</a></li><li><a href="/users/2">bob</a></li></ul>
+</body></html>`
+	tests := []struct {
+		selector string
+		attr     []string
+		want     any
+		wantErr  bool
+	}{
+		{"h1.title", nil, "Users", false},
+		{"#users a", nil, []any{"alice", "bob"}, false},
+		{"#users a", []string{"href"}, []any{"/users/1", "/users/2"}, false},
+		{"#users a", []string{"title"}, nil, false},
+		{"table", nil, nil, false},
+		{"#users[", nil, nil, true},
+		{"a", []string{"href", "title"}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := CSS(tt.selector, page, tt.attr...)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("%s: got %v", tt.selector, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %s", tt.selector)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Errorf("%s: %s", tt.selector, diff)
+		}
+	}
+}
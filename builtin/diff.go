@@ -2,13 +2,20 @@ package builtin
 
 import (
 	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/google/go-cmp/cmp/cmpopts"
 )
 
-func Diff(x, y any, ignoreKeys ...string) string {
-	d, err := diff(x, y, ignoreKeys...)
+var identRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// Diff returns the difference between two values.
+// ignores are the keys ( e.g. `updatedAt` ) or the paths ( e.g. `.user.updatedAt`, `.items[*].id` ) to ignore.
+func Diff(x, y any, ignores ...string) string {
+	d, err := diff(x, y, ignores...)
 	if err != nil {
 		panic(err)
 	}
@@ -16,33 +23,137 @@ func Diff(x, y any, ignoreKeys ...string) string {
 	return d
 }
 
-func diff(x, y any, ignoreKeys ...string) (string, error) {
-	// normalize values
-	bx, err := json.Marshal(x)
+// Changes returns the difference between two values as the list of `{"path": ..., "x": ..., "y": ...}`.
+// The value of the missing side is nil.
+func Changes(x, y any, ignores ...string) []any {
+	vx, vy, err := normalize(x, y)
+	if err != nil {
+		panic(err)
+	}
+	r := &changesReporter{}
+	_ = cmp.Equal(vx, vy, append(ignoreOptions(ignores), cmp.Reporter(r))...)
+	return r.changes
+}
+
+func diff(x, y any, ignores ...string) (string, error) {
+	vx, vy, err := normalize(x, y)
 	if err != nil {
 		return "", err
 	}
+	return cmp.Diff(vx, vy, ignoreOptions(ignores)...), nil
+}
+
+// normalize values
+func normalize(x, y any) (any, any, error) {
+	bx, err := json.Marshal(x)
+	if err != nil {
+		return nil, nil, err
+	}
 	var vx any
 	if err := json.Unmarshal(bx, &vx); err != nil {
-		return "", err
+		return nil, nil, err
 	}
 	by, err := json.Marshal(y)
 	if err != nil {
-		return "", err
+		return nil, nil, err
 	}
 	var vy any
 	if err := json.Unmarshal(by, &vy); err != nil {
-		return "", err
+		return nil, nil, err
+	}
+	return vx, vy, nil
+}
+
+func ignoreOptions(ignores []string) []cmp.Option {
+	var keys, paths []string
+	for _, ignore := range ignores {
+		if strings.HasPrefix(ignore, ".") || strings.HasPrefix(ignore, "[") {
+			paths = append(paths, ignore)
+			continue
+		}
+		keys = append(keys, ignore)
 	}
+	return []cmp.Option{
+		cmpopts.IgnoreMapEntries(func(key string, val any) bool {
+			for _, ignore := range keys {
+				if key == ignore {
+					return true
+				}
+			}
+			return false
+		}),
+		cmp.FilterPath(func(p cmp.Path) bool {
+			ps := pathString(p)
+			for _, ignore := range paths {
+				if matchPath(ignore, ps) {
+					return true
+				}
+			}
+			return false
+		}, cmp.Ignore()),
+	}
+}
 
-	diff := cmp.Diff(vx, vy, cmpopts.IgnoreMapEntries(func(key string, val any) bool {
-		for _, ignore := range ignoreKeys {
-			if key == ignore {
-				return true
+// pathString returns the path like `.items[0].id` .
+func pathString(p cmp.Path) string {
+	var b strings.Builder
+	for _, s := range p {
+		switch ss := s.(type) {
+		case cmp.MapIndex:
+			k := fmt.Sprintf("%v", ss.Key())
+			if identRe.MatchString(k) {
+				_, _ = b.WriteString("." + k)
+			} else {
+				_, _ = b.WriteString(fmt.Sprintf("[%q]", k))
+			}
+		case cmp.SliceIndex:
+			i, iy := ss.SplitKeys()
+			if i < 0 {
+				i = iy
 			}
+			_, _ = b.WriteString(fmt.Sprintf("[%d]", i))
 		}
-		return false
-	}))
+	}
+	return b.String()
+}
+
+// matchPath reports whether the path matches the pattern. `[*]` in the pattern matches any index.
+func matchPath(pattern, p string) bool {
+	if !strings.Contains(pattern, "[*]") {
+		return pattern == p
+	}
+	re := strings.ReplaceAll(regexp.QuoteMeta(pattern), `\[\*\]`, `\[\d+\]`)
+	return regexp.MustCompile("^" + re + "$").MatchString(p)
+}
+
+type changesReporter struct {
+	path    cmp.Path
+	changes []any
+}
+
+func (r *changesReporter) PushStep(s cmp.PathStep) {
+	r.path = append(r.path, s)
+}
+
+func (r *changesReporter) Report(rs cmp.Result) {
+	if rs.Equal() {
+		return
+	}
+	vx, vy := r.path.Last().Values()
+	var x, y any
+	if vx.IsValid() {
+		x = vx.Interface()
+	}
+	if vy.IsValid() {
+		y = vy.Interface()
+	}
+	r.changes = append(r.changes, map[string]any{
+		"path": pathString(r.path),
+		"x":    x,
+		"y":    y,
+	})
+}
 
-	return diff, nil
+func (r *changesReporter) PopStep() {
+	r.path = r.path[:len(r.path)-1]
 }
@@ -0,0 +1,74 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffWithIgnorePaths(t *testing.T) {
+	x := map[string]any{
+		"user":  map[string]any{"name": "alice", "updatedAt": "2024-01-01"},
+		"items": []any{map[string]any{"id": 1, "name": "a"}, map[string]any{"id": 2, "name": "b"}},
+	}
+	y := map[string]any{
+		"user":  map[string]any{"name": "alice", "updatedAt": "2024-02-01"},
+		"items": []any{map[string]any{"id": 3, "name": "a"}, map[string]any{"id": 4, "name": "b"}},
+	}
+	tests := []struct {
+		ignores []string
+		want    bool
+	}{
+		{nil, false},
+		{[]string{".user.updatedAt"}, false},
+		{[]string{".user.updatedAt", ".items[*].id"}, true},
+		{[]string{".user.updatedAt", ".items[0].id"}, false},
+		{[]string{".user.updatedAt", ".items[0].id", ".items[1].id"}, true},
+		{[]string{"updatedAt", "id"}, true},
+		{[]string{".updatedAt", "id"}, false},
+	}
+	for _, tt := range tests {
+		got := Diff(x, y, tt.ignores...) == ""
+		if got != tt.want {
+			t.Errorf("%v: got %v\nwant %v", tt.ignores, got, tt.want)
+		}
+	}
+}
+
+func TestChanges(t *testing.T) {
+	tests := []struct {
+		x       any
+		y       any
+		ignores []string
+		want    []any
+	}{
+		{map[string]any{"foo": 1}, map[string]any{"foo": 1}, nil, nil},
+		{
+			map[string]any{"foo": 1, "bar": "a", "baz": true},
+			map[string]any{"foo": 2, "bar": "a", "qux": true},
+			nil,
+			[]any{
+				map[string]any{"path": ".baz", "x": true, "y": nil},
+				map[string]any{"path": ".foo", "x": float64(1), "y": float64(2)},
+				map[string]any{"path": ".qux", "x": nil, "y": true},
+			},
+		},
+		{
+			map[string]any{"items": []any{"a", "b"}, "content-type": "json"},
+			map[string]any{"items": []any{"a", "c", "d"}, "content-type": "xml"},
+			nil,
+			[]any{
+				map[string]any{"path": `["content-type"]`, "x": "json", "y": "xml"},
+				map[string]any{"path": ".items[1]", "x": "b", "y": "c"},
+				map[string]any{"path": ".items[2]", "x": nil, "y": "d"},
+			},
+		},
+		{map[string]any{"foo": 1, "bar": 1}, map[string]any{"foo": 2, "bar": 2}, []string{".foo", "bar"}, nil},
+	}
+	for _, tt := range tests {
+		got := Changes(tt.x, tt.y, tt.ignores...)
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
@@ -0,0 +1,60 @@
+package builtin
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"io"
+	"mime/quotedprintable"
+	"strings"
+)
+
+// ToBase64URL returns the base64url encoded string without padding.
+func ToBase64URL(v any) string {
+	return base64.RawURLEncoding.EncodeToString(toBytes(v))
+}
+
+// FromBase64URL decodes the base64url encoded string with or without padding.
+func FromBase64URL(s string) (string, error) {
+	b, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(s, "="))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// ToHex returns the hex encoded string.
+func ToHex(v any) string {
+	return hex.EncodeToString(toBytes(v))
+}
+
+// FromHex decodes the hex encoded string.
+func FromHex(s string) (string, error) {
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
+
+// ToQuotedPrintable returns the quoted-printable encoded string.
+func ToQuotedPrintable(v any) (string, error) {
+	buf := new(bytes.Buffer)
+	w := quotedprintable.NewWriter(buf)
+	if _, err := w.Write(toBytes(v)); err != nil {
+		return "", err
+	}
+	if err := w.Close(); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// FromQuotedPrintable decodes the quoted-printable encoded string.
+func FromQuotedPrintable(s string) (string, error) {
+	b, err := io.ReadAll(quotedprintable.NewReader(bytes.NewReader([]byte(s))))
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
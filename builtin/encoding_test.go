@@ -0,0 +1,57 @@
+package builtin
+
+import (
+	"testing"
+)
+
+func TestEncoding(t *testing.T) {
+	tests := []struct {
+		name   string
+		in     any
+		encode func(v any) (string, error)
+		decode func(s string) (string, error)
+		want   string
+	}{
+		{"base64url", "a?b>c", func(v any) (string, error) { return ToBase64URL(v), nil }, FromBase64URL, "YT9iPmM"},
+		{"base64url []byte", []byte{0xfb, 0xff}, func(v any) (string, error) { return ToBase64URL(v), nil }, FromBase64URL, "-_8"},
+		{"hex", "runn", func(v any) (string, error) { return ToHex(v), nil }, FromHex, "72756e6e"},
+		{"quoted-printable", "café=1", ToQuotedPrintable, FromQuotedPrintable, "caf=C3=A9=3D1"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.encode(tt.in)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("got %v\nwant %v", got, tt.want)
+			}
+			decoded, err := tt.decode(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if decoded != string(toBytes(tt.in)) {
+				t.Errorf("got %v\nwant %v", decoded, tt.in)
+			}
+		})
+	}
+}
+
+func TestFromBase64URLWithPadding(t *testing.T) {
+	got, err := FromBase64URL("YT9iPmM=")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "a?b>c"; got != want {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	if _, err := FromBase64URL("!!!"); err == nil {
+		t.Error("want error")
+	}
+	if _, err := FromHex("zz"); err == nil {
+		t.Error("want error")
+	}
+}
@@ -2,9 +2,63 @@ package builtin
 
 import (
 	"fmt"
+	"sort"
+	"sync"
 	"testing"
 )
 
+func TestNewFakerWithSeed(t *testing.T) {
+	a := NewFakerWithSeed(1)
+	b := NewFakerWithSeed(1)
+	for i := 0; i < 3; i++ {
+		if got, want := a.Email(), b.Email(); got != want {
+			t.Errorf("got %v\nwant %v", got, want)
+		}
+		if got, want := a.UUID(), b.UUID(); got != want {
+			t.Errorf("got %v\nwant %v", got, want)
+		}
+	}
+}
+
+func TestNewFakerWithSeedConcurrent(t *testing.T) {
+	const n = 100
+	want := make([]string, 0, n)
+	b := NewFakerWithSeed(1)
+	for i := 0; i < n; i++ {
+		want = append(want, b.UUID())
+	}
+	sort.Strings(want)
+
+	// The Faker is shared by the operators run concurrently
+	a := NewFakerWithSeed(1)
+	got := make([]string, n)
+	var wg sync.WaitGroup
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			got[i] = a.UUID()
+		}(i)
+	}
+	wg.Wait()
+	sort.Strings(got)
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("got %v\nwant %v", got[i], want[i])
+		}
+	}
+}
+
+func TestFloatRange(t *testing.T) {
+	faker := NewFaker()
+	for i := 0; i < 10; i++ {
+		got := faker.FloatRange(10, 20)
+		if got < 10 || got > 20 {
+			t.Errorf("got %v", got)
+		}
+	}
+}
+
 func TestDigitN(t *testing.T) {
 	tests := []struct {
 		n          int
@@ -0,0 +1,58 @@
+package builtin
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+
+	"github.com/google/uuid"
+	"github.com/oklog/ulid/v2"
+)
+
+const (
+	nanoidAlphabet    = "_-0123456789abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	nanoidDefaultSize = 21
+)
+
+// UUID returns the random UUID ( version 4 ).
+func UUID() string {
+	return uuid.NewString()
+}
+
+// UUIDv7 returns the time-ordered UUID ( version 7 ).
+func UUIDv7() (string, error) {
+	u, err := uuid.NewV7()
+	if err != nil {
+		return "", err
+	}
+	return u.String(), nil
+}
+
+// ULID returns the ULID.
+func ULID() string {
+	return ulid.Make().String()
+}
+
+// NanoID returns the Nano ID. The default size is 21.
+func NanoID(size ...int) (string, error) {
+	n := nanoidDefaultSize
+	if len(size) > 1 {
+		return "", fmt.Errorf("invalid size: %v", size)
+	}
+	if len(size) == 1 {
+		n = size[0]
+	}
+	if n <= 0 {
+		return "", fmt.Errorf("invalid size: %d", n)
+	}
+	max := big.NewInt(int64(len(nanoidAlphabet)))
+	id := make([]byte, n)
+	for i := range id {
+		r, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		id[i] = nanoidAlphabet[r.Int64()]
+	}
+	return string(id), nil
+}
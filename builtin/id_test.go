@@ -0,0 +1,44 @@
+package builtin
+
+import (
+	"regexp"
+	"testing"
+)
+
+func TestID(t *testing.T) {
+	uuidv7, err := UUIDv7()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nanoid, err := NanoID()
+	if err != nil {
+		t.Fatal(err)
+	}
+	nanoid10, err := NanoID(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		got  string
+		want *regexp.Regexp
+	}{
+		{UUID(), regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{uuidv7, regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-7[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)},
+		{ULID(), regexp.MustCompile(`^[0-9A-HJKMNP-TV-Z]{26}$`)},
+		{nanoid, regexp.MustCompile(`^[0-9A-Za-z_-]{21}$`)},
+		{nanoid10, regexp.MustCompile(`^[0-9A-Za-z_-]{10}$`)},
+	}
+	for _, tt := range tests {
+		if !tt.want.MatchString(tt.got) {
+			t.Errorf("got %v\nwant %v", tt.got, tt.want)
+		}
+	}
+	if UUID() == UUID() || ULID() == ULID() {
+		t.Error("generated the same id")
+	}
+	for _, size := range [][]int{{0}, {-1}, {1, 2}} {
+		if _, err := NanoID(size...); err == nil {
+			t.Errorf("want error: %v", size)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"math"
+
+	"github.com/spf13/cast"
+)
+
+// pixelTolerance is the ratio of the difference of the color channel to regard the pixels as the same.
+// It absorbs the noise of the lossy compression ( e.g. JPEG ).
+const pixelTolerance = 0.1
+
+// ImageDiff returns the ratio ( 0.0 - 1.0 ) of the different pixels between the images x and y ( PNG, JPEG or GIF ).
+// The images of different sizes are regarded as completely different ( 1.0 ).
+func ImageDiff(x, y any) (float64, error) {
+	ix, err := decodeImage(x)
+	if err != nil {
+		return 0, err
+	}
+	iy, err := decodeImage(y)
+	if err != nil {
+		return 0, err
+	}
+	bx := ix.Bounds()
+	by := iy.Bounds()
+	if bx.Dx() != by.Dx() || bx.Dy() != by.Dy() {
+		return 1, nil
+	}
+	total := bx.Dx() * bx.Dy()
+	if total == 0 {
+		return 0, nil
+	}
+	diff := 0
+	for j := 0; j < bx.Dy(); j++ {
+		for i := 0; i < bx.Dx(); i++ {
+			if !samePixel(ix.At(bx.Min.X+i, bx.Min.Y+j), iy.At(by.Min.X+i, by.Min.Y+j)) {
+				diff++
+			}
+		}
+	}
+	return float64(diff) / float64(total), nil
+}
+
+// MatchImage returns true if the ratio of the different pixels between the images x and y is within the threshold ( 0.0 - 1.0 ).
+func MatchImage(x, y, threshold any) (bool, error) {
+	th, err := cast.ToFloat64E(threshold)
+	if err != nil {
+		return false, fmt.Errorf("invalid threshold: %v", threshold)
+	}
+	d, err := ImageDiff(x, y)
+	if err != nil {
+		return false, err
+	}
+	return d <= th, nil
+}
+
+func samePixel(cx, cy color.Color) bool {
+	rx, gx, bx, ax := cx.RGBA()
+	ry, gy, by, ay := cy.RGBA()
+	const limit = pixelTolerance * 0xffff
+	for _, d := range []float64{
+		math.Abs(float64(rx) - float64(ry)),
+		math.Abs(float64(gx) - float64(gy)),
+		math.Abs(float64(bx) - float64(by)),
+		math.Abs(float64(ax) - float64(ay)),
+	} {
+		if d > limit {
+			return false
+		}
+	}
+	return true
+}
+
+func decodeImage(v any) (image.Image, error) {
+	if i, ok := v.(image.Image); ok {
+		return i, nil
+	}
+	i, _, err := image.Decode(bytes.NewReader(toBytes(v)))
+	if err != nil {
+		return nil, fmt.Errorf("invalid image: %w", err)
+	}
+	return i, nil
+}
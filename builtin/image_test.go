@@ -0,0 +1,82 @@
+package builtin
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestImageDiff(t *testing.T) {
+	base := newTestImage(t, 10, 10, nil)
+	tests := []struct {
+		name    string
+		x       any
+		y       any
+		want    float64
+		wantErr bool
+	}{
+		{"same", base, base, 0, false},
+		{"same as string", string(base), base, 0, false},
+		{"noise", base, newTestImage(t, 10, 10, map[image.Point]color.Color{{0, 0}: color.RGBA{R: 5, A: 255}}), 0, false},
+		{"different pixels", base, newTestImage(t, 10, 10, map[image.Point]color.Color{{0, 0}: color.White, {9, 9}: color.White}), 0.02, false},
+		{"different size", base, newTestImage(t, 5, 5, nil), 1, false},
+		{"not image", base, "not image", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ImageDiff(tt.x, tt.y)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got %v\nwantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMatchImage(t *testing.T) {
+	base := newTestImage(t, 10, 10, nil)
+	changed := newTestImage(t, 10, 10, map[image.Point]color.Color{{0, 0}: color.White, {9, 9}: color.White})
+	tests := []struct {
+		threshold any
+		want      bool
+		wantErr   bool
+	}{
+		{0, false, false},
+		{0.01, false, false},
+		{0.02, true, false},
+		{"0.05", true, false},
+		{"invalid", false, true},
+	}
+	for _, tt := range tests {
+		got, err := MatchImage(base, changed, tt.threshold)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("threshold %v: got %v\nwant %v", tt.threshold, got, tt.want)
+		}
+	}
+}
+
+func newTestImage(t *testing.T, w, h int, pixels map[image.Point]color.Color) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.Black)
+		}
+	}
+	for p, c := range pixels {
+		img.Set(p.X, p.Y, c)
+	}
+	buf := new(bytes.Buffer)
+	if err := png.Encode(buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,39 @@
+package builtin
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/itchyny/gojq"
+)
+
+// JQ runs the jq query against the value.
+// It returns the value if the query outputs one value, or the list of values if the query outputs multiple values.
+func JQ(query string, v any) (any, error) {
+	q, err := gojq.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("invalid jq query: %w", err)
+	}
+	// normalize values
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var in any
+	if err := json.Unmarshal(b, &in); err != nil {
+		return nil, err
+	}
+	var outs []any
+	iter := q.Run(in)
+	for {
+		out, ok := iter.Next()
+		if !ok {
+			break
+		}
+		if err, ok := out.(error); ok {
+			return nil, err
+		}
+		outs = append(outs, out)
+	}
+	return unwrap(outs), nil
+}
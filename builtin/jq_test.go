@@ -0,0 +1,46 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestJQ(t *testing.T) {
+	v := map[string]any{
+		"users": []map[string]any{
+			{"name": "alice", "team": "a"},
+			{"name": "bob", "team": "b"},
+			{"name": "carol", "team": "a"},
+		},
+	}
+	tests := []struct {
+		query   string
+		want    any
+		wantErr bool
+	}{
+		{".users[0].name", "alice", false},
+		{".users[].name", []any{"alice", "bob", "carol"}, false},
+		{"[.users[].name]", []any{"alice", "bob", "carol"}, false},
+		{"[.users | group_by(.team)[] | length]", []any{2, 1}, false},
+		{".users[] | select(.name == \"dave\")", nil, false},
+		{".users[", nil, true},
+		{".users | error(\"failed\")", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := JQ(tt.query, v)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("%s: got %v", tt.query, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %s", tt.query)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Errorf("%s: %s", tt.query, diff)
+		}
+	}
+}
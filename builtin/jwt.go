@@ -0,0 +1,53 @@
+package builtin
+
+import (
+	"github.com/golang-jwt/jwt/v4"
+)
+
+type JWT struct{}
+
+func NewJWT() *JWT {
+	return &JWT{}
+}
+
+// Encode returns the token signed with the key. alg is the algorithm of JWS ( HS256, RS256, ES256, etc. ).
+func (j *JWT) Encode(claims map[string]any, alg, key string) (string, error) {
+	m, err := signingMethod(alg)
+	if err != nil {
+		return "", err
+	}
+	k, err := signingKey(m, key)
+	if err != nil {
+		return "", err
+	}
+	return jwt.NewWithClaims(m, jwt.MapClaims(claims)).SignedString(k)
+}
+
+// Decode verifies the token with the key and returns the claims.
+// The algorithm of the token must be alg.
+func (j *JWT) Decode(token, alg, key string) (map[string]any, error) {
+	m, err := signingMethod(alg)
+	if err != nil {
+		return nil, err
+	}
+	k, err := verifyingKey(m, key)
+	if err != nil {
+		return nil, err
+	}
+	claims := jwt.MapClaims{}
+	if _, err := jwt.ParseWithClaims(token, claims, func(*jwt.Token) (any, error) {
+		return k, nil
+	}, jwt.WithValidMethods([]string{m.Alg()})); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// DecodeUnverified returns the claims of the token without verifying the signature.
+func (j *JWT) DecodeUnverified(token string) (map[string]any, error) {
+	claims := jwt.MapClaims{}
+	if _, _, err := jwt.NewParser().ParseUnverified(token, claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
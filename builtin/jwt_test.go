@@ -0,0 +1,62 @@
+package builtin
+
+import (
+	"testing"
+)
+
+func TestJWT(t *testing.T) {
+	rsaPriv, rsaPub := rsaKeys(t)
+	tests := []struct {
+		alg       string
+		signKey   string
+		verAlg    string
+		verKey    string
+		wantErr   bool
+		wantClaim string
+	}{
+		{"HS256", "secret", "HS256", "secret", false, "alice"},
+		{"HS256", "secret", "HS256", "wrong", true, ""},
+		{"RS256", rsaPriv, "RS256", rsaPub, false, "alice"},
+		{"HS256", "secret", "RS256", rsaPub, true, ""},
+	}
+	j := NewJWT()
+	for _, tt := range tests {
+		t.Run(tt.alg+"_"+tt.verAlg, func(t *testing.T) {
+			token, err := j.Encode(map[string]any{"sub": "alice"}, tt.alg, tt.signKey)
+			if err != nil {
+				t.Fatal(err)
+			}
+			claims, err := j.Decode(token, tt.verAlg, tt.verKey)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+			if got := claims["sub"]; got != tt.wantClaim {
+				t.Errorf("got %v\nwant %v", got, tt.wantClaim)
+			}
+		})
+	}
+}
+
+func TestJWTDecodeUnverified(t *testing.T) {
+	j := NewJWT()
+	token, err := j.Encode(map[string]any{"sub": "alice"}, "HS256", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := j.DecodeUnverified(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := claims["sub"]; got != "alice" {
+		t.Errorf("got %v", got)
+	}
+	if _, err := j.DecodeUnverified("invalid"); err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,162 @@
+package builtin
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/spf13/cast"
+)
+
+const (
+	protoEqualOptionFloatTolerance      = "floatTolerance"
+	protoEqualOptionIgnoreUnknownFields = "ignoreUnknownFields"
+)
+
+type protoEqualOptions struct {
+	floatTolerance      float64
+	ignoreUnknownFields bool
+}
+
+// ProtoEqual returns true if the message x ( actual ) equals the message y ( expected ) in proto semantics,
+// for the messages converted to the maps ( e.g. `current.res.message` of gRPC Runner ).
+//   - The missing field equals the field of the default value ( e.g. `0`, `""`, `false`, `[]` ).
+//   - The 64-bit integers encoded as the strings equal the numbers ( e.g. `"10"` and `10` ).
+//   - The floats are compared with the tolerance of `floatTolerance` option.
+//   - The fields of x not in y are ignored with `ignoreUnknownFields` option.
+func ProtoEqual(x, y any, opts ...any) (bool, error) {
+	o, err := parseProtoEqualOptions(opts)
+	if err != nil {
+		return false, err
+	}
+	vx, vy, err := normalize(x, y)
+	if err != nil {
+		return false, err
+	}
+	return protoEqual(vx, vy, o), nil
+}
+
+func parseProtoEqualOptions(opts []any) (*protoEqualOptions, error) {
+	o := &protoEqualOptions{}
+	for _, opt := range opts {
+		m, ok := opt.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid option: %v", opt)
+		}
+		for k, v := range m {
+			switch k {
+			case protoEqualOptionFloatTolerance:
+				f, err := cast.ToFloat64E(v)
+				if err != nil || f < 0 {
+					return nil, fmt.Errorf("invalid %s: %v", k, v)
+				}
+				o.floatTolerance = f
+			case protoEqualOptionIgnoreUnknownFields:
+				b, err := cast.ToBoolE(v)
+				if err != nil {
+					return nil, fmt.Errorf("invalid %s: %v", k, v)
+				}
+				o.ignoreUnknownFields = b
+			default:
+				return nil, fmt.Errorf("invalid option key: %s", k)
+			}
+		}
+	}
+	return o, nil
+}
+
+func protoEqual(x, y any, o *protoEqualOptions) bool {
+	switch vy := y.(type) {
+	case map[string]any:
+		vx, ok := x.(map[string]any)
+		if !ok {
+			return x == nil && isProtoDefault(y)
+		}
+		for k, v := range vy {
+			if !protoEqual(vx[k], v, o) {
+				return false
+			}
+		}
+		for k, v := range vx {
+			if _, ok := vy[k]; ok {
+				continue
+			}
+			if o.ignoreUnknownFields {
+				continue
+			}
+			if !isProtoDefault(v) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		vx, ok := x.([]any)
+		if !ok {
+			return x == nil && isProtoDefault(y)
+		}
+		if len(vx) != len(vy) {
+			return false
+		}
+		for i := range vy {
+			if !protoEqual(vx[i], vy[i], o) {
+				return false
+			}
+		}
+		return true
+	case nil:
+		return isProtoDefault(x)
+	}
+	if x == nil {
+		return isProtoDefault(y)
+	}
+	_, sx := x.(string)
+	_, sy := y.(string)
+	fx, okx := protoNumber(x)
+	fy, oky := protoNumber(y)
+	if okx && oky && !(sx && sy) {
+		eps := approxEpsilon * math.Max(math.Abs(fx), math.Abs(fy))
+		return math.Abs(fx-fy) <= o.floatTolerance+eps
+	}
+	return reflect.DeepEqual(x, y)
+}
+
+// protoNumber returns the number of the value. The 64-bit integers are encoded as the strings by protojson.
+func protoNumber(v any) (float64, bool) {
+	switch vv := v.(type) {
+	case float64:
+		return vv, true
+	case string:
+		f, err := strconv.ParseFloat(vv, 64)
+		if err != nil {
+			return 0, false
+		}
+		return f, true
+	default:
+		return 0, false
+	}
+}
+
+func isProtoDefault(v any) bool {
+	switch vv := v.(type) {
+	case nil:
+		return true
+	case bool:
+		return !vv
+	case float64:
+		return vv == 0
+	case string:
+		return vv == ""
+	case []any:
+		return len(vv) == 0
+	case map[string]any:
+		for _, v := range vv {
+			if !isProtoDefault(v) {
+				return false
+			}
+		}
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,41 @@
+package builtin
+
+import (
+	"testing"
+)
+
+func TestProtoEqual(t *testing.T) {
+	tests := []struct {
+		x       any
+		y       any
+		opts    []any
+		want    bool
+		wantErr bool
+	}{
+		{map[string]any{"name": "alice", "age": 0}, map[string]any{"name": "alice"}, nil, true, false},
+		{map[string]any{"name": "alice"}, map[string]any{"name": "alice", "tags": []any{}, "active": false, "nested": nil}, nil, true, false},
+		{map[string]any{"name": "alice", "age": 20}, map[string]any{"name": "alice"}, nil, false, false},
+		{map[string]any{"name": "alice", "age": 20}, map[string]any{"name": "alice"}, []any{map[string]any{"ignoreUnknownFields": true}}, true, false},
+		{map[string]any{"id": "9007199254740993"}, map[string]any{"id": 9007199254740993}, nil, true, false},
+		{map[string]any{"id": "10"}, map[string]any{"id": "10.0"}, nil, false, false},
+		{map[string]any{"score": 0.1 + 0.2}, map[string]any{"score": 0.3}, nil, true, false},
+		{map[string]any{"score": 1.001}, map[string]any{"score": 1.0}, nil, false, false},
+		{map[string]any{"score": 1.001}, map[string]any{"score": 1.0}, []any{map[string]any{"floatTolerance": 0.01}}, true, false},
+		{map[string]any{"items": []any{map[string]any{"id": 1, "note": ""}}}, map[string]any{"items": []any{map[string]any{"id": 1}}}, nil, true, false},
+		{map[string]any{"items": []any{1, 2}}, map[string]any{"items": []any{2, 1}}, nil, false, false},
+		{map[string]any{"nested": map[string]any{"v": 0}}, map[string]any{"nested": nil}, nil, true, false},
+		{map[string]any{"nested": map[string]any{"v": 1}}, map[string]any{}, nil, false, false},
+		{map[string]any{}, map[string]any{}, []any{map[string]any{"unknown": true}}, false, true},
+		{map[string]any{}, map[string]any{}, []any{"invalid"}, false, true},
+	}
+	for _, tt := range tests {
+		got, err := ProtoEqual(tt.x, tt.y, tt.opts...)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ProtoEqual(%v, %v): got %v\nwantErr %v", tt.x, tt.y, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("ProtoEqual(%v, %v): got %v\nwant %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package builtin
+
+import (
+	"regexp"
+
+	"github.com/spf13/cast"
+)
+
+// NewRegexp returns the functions of `re` ( `re.find`, `re.findAll`, `re.captures` and `re.capturesAll` ).
+func NewRegexp() map[string]any {
+	return map[string]any{
+		"find":        RegexpFind,
+		"findAll":     RegexpFindAll,
+		"captures":    RegexpCaptures,
+		"capturesAll": RegexpCapturesAll,
+	}
+}
+
+// RegexpFind returns the first match of the pattern. It returns the empty string if there is no match.
+func RegexpFind(pattern string, v any) (string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", err
+	}
+	return re.FindString(cast.ToString(v)), nil
+}
+
+// RegexpFindAll returns all matches of the pattern.
+func RegexpFindAll(pattern string, v any) ([]any, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	all := []any{}
+	for _, m := range re.FindAllString(cast.ToString(v), -1) {
+		all = append(all, m)
+	}
+	return all, nil
+}
+
+// RegexpCaptures returns the named capture groups of the first match as the map.
+// It returns the empty map if there is no match.
+func RegexpCaptures(pattern string, v any) (map[string]any, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	m := re.FindStringSubmatch(cast.ToString(v))
+	if m == nil {
+		return map[string]any{}, nil
+	}
+	return captures(re, m), nil
+}
+
+// RegexpCapturesAll returns the named capture groups of all matches as the list of maps.
+func RegexpCapturesAll(pattern string, v any) ([]any, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	all := []any{}
+	for _, m := range re.FindAllStringSubmatch(cast.ToString(v), -1) {
+		all = append(all, captures(re, m))
+	}
+	return all, nil
+}
+
+func captures(re *regexp.Regexp, m []string) map[string]any {
+	c := map[string]any{}
+	for i, n := range re.SubexpNames() {
+		if i == 0 || n == "" {
+			continue
+		}
+		c[n] = m[i]
+	}
+	return c
+}
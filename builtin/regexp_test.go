@@ -0,0 +1,91 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRegexpFind(t *testing.T) {
+	tests := []struct {
+		pattern string
+		v       any
+		want    string
+		wantAll []any
+		wantErr bool
+	}{
+		{`\d+`, "a1b22c333", "1", []any{"1", "22", "333"}, false},
+		{`\d+`, 12345, "12345", []any{"12345"}, false},
+		{`\d+`, "abc", "", []any{}, false},
+		{`(`, "abc", "", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := RegexpFind(tt.pattern, tt.v)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %s", tt.pattern)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+		gotAll, err := RegexpFindAll(tt.pattern, tt.v)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if diff := cmp.Diff(gotAll, tt.wantAll); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestRegexpCaptures(t *testing.T) {
+	tests := []struct {
+		pattern string
+		v       any
+		want    map[string]any
+		wantAll []any
+		wantErr bool
+	}{
+		{
+			`<a href="(?P<href>[^"]+)">(?P<text>[^<]*)</a>`,
+			`<a href="/foo">Foo</a><a href="/bar">Bar</a>`,
+			map[string]any{"href": "/foo", "text": "Foo"},
+			[]any{map[string]any{"href": "/foo", "text": "Foo"}, map[string]any{"href": "/bar", "text": "Bar"}},
+			false,
+		},
+		{`(\d+)-(?P<minor>\d+)`, "1-2", map[string]any{"minor": "2"}, []any{map[string]any{"minor": "2"}}, false},
+		{`(?P<num>\d+)`, "abc", map[string]any{}, []any{}, false},
+		{`(?P<num>`, "abc", nil, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := RegexpCaptures(tt.pattern, tt.v)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %s", tt.pattern)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+		gotAll, err := RegexpCapturesAll(tt.pattern, tt.v)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if diff := cmp.Diff(gotAll, tt.wantAll); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package builtin
+
+import (
+	"reflect"
+)
+
+// MatchObject returns true if x matches the expected object y.
+// The keys of the maps not in y are ignored, and the lists are compared element by element with the same length.
+func MatchObject(x, y any) bool {
+	vx, vy, err := normalize(x, y)
+	if err != nil {
+		return false
+	}
+	return matchObject(vx, vy)
+}
+
+// SubsetOf returns true if x is the subset of y.
+// The keys of the maps not in x are ignored, and each element of the lists of x is contained in the list of y regardless of order.
+func SubsetOf(x, y any) bool {
+	vx, vy, err := normalize(x, y)
+	if err != nil {
+		return false
+	}
+	return subsetOf(vx, vy)
+}
+
+// ContainsAll returns true if the list x contains all the elements of the list y.
+// The elements are matched as SubsetOf, so the keys of the maps not in the elements of y are ignored.
+func ContainsAll(x, y any) bool {
+	vx, vy, err := normalize(x, y)
+	if err != nil {
+		return false
+	}
+	lx, ok := vx.([]any)
+	if !ok {
+		return false
+	}
+	ly, ok := vy.([]any)
+	if !ok {
+		return false
+	}
+	return containsAll(lx, ly)
+}
+
+func matchObject(x, y any) bool {
+	switch vy := y.(type) {
+	case map[string]any:
+		vx, ok := x.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range vy {
+			vv, ok := vx[k]
+			if !ok || !matchObject(vv, v) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		vx, ok := x.([]any)
+		if !ok || len(vx) != len(vy) {
+			return false
+		}
+		for i := range vy {
+			if !matchObject(vx[i], vy[i]) {
+				return false
+			}
+		}
+		return true
+	default:
+		return reflect.DeepEqual(x, y)
+	}
+}
+
+func subsetOf(x, y any) bool {
+	switch vx := x.(type) {
+	case map[string]any:
+		vy, ok := y.(map[string]any)
+		if !ok {
+			return false
+		}
+		for k, v := range vx {
+			vv, ok := vy[k]
+			if !ok || !subsetOf(v, vv) {
+				return false
+			}
+		}
+		return true
+	case []any:
+		vy, ok := y.([]any)
+		if !ok {
+			return false
+		}
+		return containsAll(vy, vx)
+	default:
+		return reflect.DeepEqual(x, y)
+	}
+}
+
+func containsAll(x, y []any) bool {
+	for _, vy := range y {
+		found := false
+		for _, vx := range x {
+			if subsetOf(vy, vx) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
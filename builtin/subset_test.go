@@ -0,0 +1,76 @@
+package builtin
+
+import (
+	"testing"
+)
+
+func TestMatchObject(t *testing.T) {
+	tests := []struct {
+		x    any
+		y    any
+		want bool
+	}{
+		{1, 1, true},
+		{1, 1.0, true},
+		{1, "1", false},
+		{map[string]any{"id": 1, "name": "alice", "createdAt": "2024-01-01"}, map[string]any{"name": "alice"}, true},
+		{map[string]any{"id": 1, "name": "alice"}, map[string]any{"name": "bob"}, false},
+		{map[string]any{"id": 1}, map[string]any{"name": "alice"}, false},
+		{map[string]any{"user": map[string]any{"id": 1, "name": "alice"}}, map[string]any{"user": map[string]any{"name": "alice"}}, true},
+		{[]any{map[string]any{"id": 1, "name": "alice"}, map[string]any{"id": 2, "name": "bob"}}, []any{map[string]any{"name": "alice"}, map[string]any{"name": "bob"}}, true},
+		{[]any{map[string]any{"id": 1, "name": "alice"}, map[string]any{"id": 2, "name": "bob"}}, []any{map[string]any{"name": "bob"}, map[string]any{"name": "alice"}}, false},
+		{[]any{1, 2, 3}, []any{1, 2}, false},
+		{map[string]any{"tags": []any{"a", "b"}}, map[string]any{"tags": "a"}, false},
+		{map[string]any{"v": nil}, map[string]any{"v": nil}, true},
+	}
+	for _, tt := range tests {
+		if got := MatchObject(tt.x, tt.y); got != tt.want {
+			t.Errorf("MatchObject(%v, %v): got %v\nwant %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestSubsetOf(t *testing.T) {
+	tests := []struct {
+		x    any
+		y    any
+		want bool
+	}{
+		{1, 1, true},
+		{map[string]any{"name": "alice"}, map[string]any{"id": 1, "name": "alice"}, true},
+		{map[string]any{"id": 1, "name": "alice"}, map[string]any{"name": "alice"}, false},
+		{[]any{3, 1}, []any{1, 2, 3}, true},
+		{[]any{4}, []any{1, 2, 3}, false},
+		{[]any{}, []any{1, 2, 3}, true},
+		{map[string]any{"users": []any{map[string]any{"name": "bob"}}}, map[string]any{"users": []any{map[string]any{"id": 1, "name": "alice"}, map[string]any{"id": 2, "name": "bob"}}}, true},
+		{map[string]any{"users": []any{map[string]any{"name": "carol"}}}, map[string]any{"users": []any{map[string]any{"id": 1, "name": "alice"}}}, false},
+		{[]any{1}, map[string]any{"a": 1}, false},
+	}
+	for _, tt := range tests {
+		if got := SubsetOf(tt.x, tt.y); got != tt.want {
+			t.Errorf("SubsetOf(%v, %v): got %v\nwant %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
+
+func TestContainsAll(t *testing.T) {
+	tests := []struct {
+		x    any
+		y    any
+		want bool
+	}{
+		{[]any{1, 2, 3}, []any{3, 1}, true},
+		{[]any{1, 2, 3}, []any{4}, false},
+		{[]any{1, 2, 3}, []any{}, true},
+		{[]any{"a", "b"}, []string{"b"}, true},
+		{[]any{map[string]any{"id": 1, "name": "alice"}, map[string]any{"id": 2, "name": "bob"}}, []any{map[string]any{"name": "bob"}}, true},
+		{[]any{map[string]any{"id": 1, "name": "alice"}}, []any{map[string]any{"name": "bob"}}, false},
+		{map[string]any{"a": 1}, []any{1}, false},
+		{[]any{1}, 1, false},
+	}
+	for _, tt := range tests {
+		if got := ContainsAll(tt.x, tt.y); got != tt.want {
+			t.Errorf("ContainsAll(%v, %v): got %v\nwant %v", tt.x, tt.y, got, tt.want)
+		}
+	}
+}
@@ -1,15 +1,33 @@
 package builtin
 
 import (
+	"math"
 	"time"
 
 	"github.com/araddon/dateparse"
+	"github.com/spf13/cast"
 )
 
+// Time converts the value ( date string, UNIX time in seconds or time.Time ) to time.Time.
+// It returns the zero time if the value cannot be converted.
 func Time(v any) time.Time {
-	t, err := dateparse.ParseStrict(v.(string))
-	if err != nil {
+	switch vv := v.(type) {
+	case nil:
 		return time.Time{}
+	case time.Time:
+		return vv
+	case string:
+		t, err := dateparse.ParseStrict(vv)
+		if err != nil {
+			return time.Time{}
+		}
+		return t
+	default:
+		f, err := cast.ToFloat64E(v)
+		if err != nil {
+			return time.Time{}
+		}
+		sec, frac := math.Modf(f)
+		return time.Unix(int64(sec), int64(frac*1e9))
 	}
-	return t
 }
@@ -15,6 +15,11 @@ func TestTime(t *testing.T) {
 	}{
 		{now.String(), now},
 		{"err", time.Time{}},
+		{now, now},
+		{1704164645, time.Unix(1704164645, 0)},
+		{float64(1704164645.5), time.Unix(1704164645, 500000000)},
+		{nil, time.Time{}},
+		{[]string{"err"}, time.Time{}},
 	}
 	for _, tt := range tests {
 		got := Time(tt.v)
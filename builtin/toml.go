@@ -0,0 +1,33 @@
+package builtin
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+
+	"github.com/BurntSushi/toml"
+)
+
+// FromTOML parses the TOML string into the map.
+func FromTOML(in string) (map[string]any, error) {
+	v := map[string]any{}
+	if _, err := toml.Decode(in, &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ToTOML serializes the map into the TOML string.
+func ToTOML(v any) (string, error) {
+	// The top level of TOML is always a table
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Map, reflect.Struct:
+	default:
+		return "", fmt.Errorf("unsupported type: %T", v)
+	}
+	buf := new(bytes.Buffer)
+	if err := toml.NewEncoder(buf).Encode(v); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
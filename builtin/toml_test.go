@@ -0,0 +1,46 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestTOML(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    map[string]any
+		wantErr bool
+	}{
+		{"foo = \"bar\"\n", map[string]any{"foo": "bar"}, false},
+		{"[server]\n  port = 8080\n", map[string]any{"server": map[string]any{"port": int64(8080)}}, false},
+		{"foo = \n", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := FromTOML(tt.in)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %s", tt.in)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+		dumped, err := ToTOML(got)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if dumped != tt.in {
+			t.Errorf("got %q\nwant %q", dumped, tt.in)
+		}
+	}
+	if _, err := ToTOML(1); err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,60 @@
+package builtin
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/antchfx/htmlquery"
+	"github.com/antchfx/xmlquery"
+	"github.com/antchfx/xpath"
+	"github.com/spf13/cast"
+)
+
+var htmlRe = regexp.MustCompile(`(?i)^\s*(<!doctype\s+html|<html)`)
+
+// XPath evaluates the XPath expression against the XML or HTML document.
+// The result of the node-set is the value if one node matches, the list of the values if multiple nodes match, or nil if no node matches.
+func XPath(expr string, v any) (any, error) {
+	e, err := xpath.Compile(expr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xpath: %w", err)
+	}
+	s := cast.ToString(v)
+	var nav xpath.NodeNavigator
+	if htmlRe.MatchString(s) {
+		doc, err := htmlquery.Parse(strings.NewReader(s))
+		if err != nil {
+			return nil, err
+		}
+		nav = htmlquery.CreateXPathNavigator(doc)
+	} else {
+		doc, err := xmlquery.Parse(strings.NewReader(s))
+		if err != nil {
+			return nil, err
+		}
+		nav = xmlquery.CreateXPathNavigator(doc)
+	}
+	switch r := e.Evaluate(nav).(type) {
+	case *xpath.NodeIterator:
+		var values []any
+		for r.MoveNext() {
+			values = append(values, r.Current().Value())
+		}
+		return unwrap(values), nil
+	default:
+		return r, nil
+	}
+}
+
+// unwrap returns the value if the list has one value, the list if the list has multiple values, or nil if the list is empty.
+func unwrap(values []any) any {
+	switch len(values) {
+	case 0:
+		return nil
+	case 1:
+		return values[0]
+	default:
+		return values
+	}
+}
@@ -0,0 +1,54 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestXPath(t *testing.T) {
+	const soap = `<?xml version="1.0" encoding="UTF-8"?>
+<Envelope>
+  <Body>
+    <GetUserResponse>
+      <User id="1"><Name>alice</Name></User>
+      <User id="2"><Name>bob</Name></User>
+    </GetUserResponse>
+  </Body>
+</Envelope>`
+	const page = `<!DOCTYPE html>
+<html><head><title>Users</title></head>
+<body><ul><li><a href="/users/1">alice</a></li></ul></body></html>`
+	tests := []struct {
+		expr    string
+		v       any
+		want    any
+		wantErr bool
+	}{
+		{"//User[@id='1']/Name", soap, "alice", false},
+		{"//User/Name", soap, []any{"alice", "bob"}, false},
+		{"//User/@id", soap, []any{"1", "2"}, false},
+		{"count(//User)", soap, float64(2), false},
+		{"//Group", soap, nil, false},
+		{"//title", page, "Users", false},
+		{"//a/@href", []byte(page), "/users/1", false},
+		{"//User[", soap, nil, true},
+		{"//User", "<Envelope>", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := XPath(tt.expr, tt.v)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("%s: got %v", tt.expr, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %s", tt.expr)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Errorf("%s: %s", tt.expr, diff)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package builtin
+
+import (
+	"github.com/goccy/go-yaml"
+)
+
+// FromYAML parses the YAML string into the value.
+func FromYAML(in string) (any, error) {
+	var v any
+	if err := yaml.Unmarshal([]byte(in), &v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// ToYAML serializes the value into the YAML string.
+func ToYAML(v any) (string, error) {
+	b, err := yaml.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(b), nil
+}
@@ -0,0 +1,43 @@
+package builtin
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestYAML(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    any
+		wantErr bool
+	}{
+		{"foo: bar\n", map[string]any{"foo": "bar"}, false},
+		{"- 1\n- two\n", []any{uint64(1), "two"}, false},
+		{"foo: {bar", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := FromYAML(tt.in)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %s", tt.in)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+		dumped, err := ToYAML(got)
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if dumped != tt.in {
+			t.Errorf("got %q\nwant %q", dumped, tt.in)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/multierr"
+)
+
+// parseCases parses `cases:` ( list of the values of cases, or the path of the file of them ).
+func (bk *book) parseCases() error {
+	if bk.rawCases == nil {
+		return nil
+	}
+	root, err := bk.generateOperatorRoot()
+	if err != nil {
+		return err
+	}
+	v, err := evaluateSchema(bk.rawCases, root, nil)
+	if err != nil {
+		return fmt.Errorf("invalid cases: %w", err)
+	}
+	vv, ok := normalize(v).([]any)
+	if !ok {
+		return fmt.Errorf("invalid cases: %v", v)
+	}
+	if len(vv) == 0 {
+		return fmt.Errorf("invalid cases: no cases")
+	}
+	bk.cases = nil
+	for i, c := range vv {
+		cm, ok := c.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid cases[%d]: %v", i, c)
+		}
+		bk.cases = append(bk.cases, cm)
+	}
+	return nil
+}
+
+// runCase - Run only the case i of `cases:` with the values of the case bound into vars.
+func runCase(i int) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if i < 0 || i >= len(bk.cases) {
+			return fmt.Errorf("invalid case index: %d", i)
+		}
+		for k, v := range bk.cases[i] {
+			bk.vars[k] = v
+		}
+		bk.desc = caseDesc(bk.desc, i)
+		bk.caseIndex = &i
+		return nil
+	}
+}
+
+func caseDesc(desc string, i int) string {
+	return fmt.Sprintf("%s (cases[%d])", desc, i)
+}
+
+// newCaseOperators creates the operators for each case of `cases:` of the runbook.
+func newCaseOperators(b Option, opts []Option, n int) ([]*operator, error) {
+	var ops []*operator
+	for i := 0; i < n; i++ {
+		o, err := New(append([]Option{b, runCase(i)}, opts...)...)
+		if err != nil {
+			return nil, err
+		}
+		ops = append(ops, o)
+	}
+	return ops, nil
+}
+
+// generateCaseIDs generates the IDs of the operators of the cases from the ID of the first case generated using the path.
+func generateCaseIDs(ops []*operator) error {
+	ids := map[string]string{}
+	for _, o := range ops {
+		if o.caseIndex != nil && *o.caseIndex == 0 {
+			ids[o.bookPath] = o.id
+		}
+	}
+	for _, o := range ops {
+		if o.caseIndex == nil {
+			continue
+		}
+		id, err := generateID(fmt.Sprintf("%s/cases[%d]", ids[o.bookPath], *o.caseIndex))
+		if err != nil {
+			return err
+		}
+		o.id = id
+	}
+	return nil
+}
+
+// runCases runs the runbook once per case of `cases:` with the values of the case bound into vars.
+func (o *operator) runCases(ctx context.Context) error {
+	vars := o.store.vars
+	defer func() {
+		o.store.vars = vars
+	}()
+	var cerr error
+	for i, c := range o.cases {
+		o.store.vars = make(map[string]any, len(vars)+len(c))
+		for k, v := range vars {
+			o.store.vars[k] = v
+		}
+		for k, v := range c {
+			o.store.vars[k] = v
+		}
+		var err error
+		if o.loop != nil {
+			err = o.runLoop(ctx)
+		} else {
+			err = o.runInternal(ctx)
+		}
+		if err != nil {
+			cerr = multierr.Append(cerr, fmt.Errorf("cases[%d]: %w", i, err))
+		}
+	}
+	return cerr
+}
@@ -0,0 +1,141 @@
+package runn
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCases(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	tests := []struct {
+		book      string
+		wantDescs []string
+	}{
+		{
+			"testdata/book/cases.yml",
+			[]string{
+				"Run the runbook once per case (cases[0])",
+				"Run the runbook once per case (cases[1])",
+			},
+		},
+		{
+			"testdata/book/cases_csv.yml",
+			[]string{
+				"Run the runbook once per row of CSV (cases[0])",
+				"Run the runbook once per row of CSV (cases[1])",
+				"Run the runbook once per row of CSV (cases[2])",
+			},
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			ops, err := Load(tt.book)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var descs []string
+			ids := map[string]struct{}{}
+			for _, o := range ops.Operators() {
+				descs = append(descs, o.Desc())
+				ids[o.ID()] = struct{}{}
+			}
+			if diff := cmp.Diff(descs, tt.wantDescs); diff != "" {
+				t.Error(diff)
+			}
+			if len(ids) != len(tt.wantDescs) {
+				t.Errorf("the IDs of the cases should be unique: %v", ids)
+			}
+			if err := ops.RunN(ctx); err != nil {
+				t.Fatal(err)
+			}
+			r := ops.Result()
+			if len(r.RunResults) != len(tt.wantDescs) {
+				t.Errorf("got %v\nwant %v", len(r.RunResults), len(tt.wantDescs))
+			}
+			for _, rr := range r.RunResults {
+				if rr.Err != nil {
+					t.Errorf("%s: %v", rr.Desc, rr.Err)
+				}
+			}
+		})
+	}
+}
+
+func TestRunCases(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/cases.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// The vars of the last case are not left
+	if _, ok := o.store.vars["name"]; ok {
+		t.Errorf("vars should be restored: %v", o.store.vars)
+	}
+
+	o.cases[1]["greeting"] = "hello charlie"
+	err = o.Run(ctx)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if !strings.Contains(err.Error(), "cases[1]") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestParseCases(t *testing.T) {
+	tests := []struct {
+		rawCases any
+		want     []map[string]any
+		wantErr  bool
+	}{
+		{nil, nil, false},
+		{[]any{map[string]any{"a": "b"}}, []map[string]any{{"a": "b"}}, false},
+		{"csv://testdata/cases.csv", []map[string]any{
+			{"name": "alice", "greeting": "hello alice"},
+			{"name": "bob", "greeting": "hello bob"},
+			{"name": "charlie", "greeting": "hello charlie"},
+		}, false},
+		{[]any{}, nil, true},
+		{[]any{"a"}, nil, true},
+		{map[string]any{"a": 1}, nil, true},
+	}
+	for _, tt := range tests {
+		bk := newBook()
+		bk.rawCases = tt.rawCases
+		if err := bk.parseCases(); err != nil {
+			if !tt.wantErr {
+				t.Errorf("got error: %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %v", tt.rawCases)
+			continue
+		}
+		if diff := cmp.Diff(bk.cases, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+/*
+Copyright © 2022 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/k1LoW/runn"
+	"github.com/spf13/cobra"
+)
+
+// runbookExts - Extensions of the runbook files completed as the arguments.
+var runbookExts = []string{"yml", "yaml"}
+
+// setRunbookCompletions sets the completions of the runbook paths as the arguments,
+// and the completions of --id, --label and --var discovered from the runbooks of the arguments.
+func setRunbookCompletions(cmd *cobra.Command) {
+	cmd.ValidArgsFunction = func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		return runbookExts, cobra.ShellCompDirectiveFilterFileExt
+	}
+	completions := map[string]func(ops []runbookCompletion) ([]string, cobra.ShellCompDirective){
+		"id": func(ops []runbookCompletion) ([]string, cobra.ShellCompDirective) {
+			var ids []string
+			for _, o := range ops {
+				ids = append(ids, completionWithDesc(o.ID(), o.Desc()))
+			}
+			return ids, cobra.ShellCompDirectiveNoFileComp
+		},
+		"label": func(ops []runbookCompletion) ([]string, cobra.ShellCompDirective) {
+			var labels []string
+			for _, o := range ops {
+				labels = append(labels, o.Labels()...)
+			}
+			return uniqSorted(labels), cobra.ShellCompDirectiveNoFileComp
+		},
+		"var": func(ops []runbookCompletion) ([]string, cobra.ShellCompDirective) {
+			var keys []string
+			for _, o := range ops {
+				for _, k := range o.VarKeys() {
+					keys = append(keys, k+":")
+				}
+			}
+			return uniqSorted(keys), cobra.ShellCompDirectiveNoSpace | cobra.ShellCompDirectiveNoFileComp
+		},
+	}
+	for name, fn := range completions {
+		if cmd.Flags().Lookup(name) == nil {
+			continue
+		}
+		fn := fn
+		_ = cmd.RegisterFlagCompletionFunc(name, func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+			ops, err := loadRunbooksForCompletion(args)
+			if err != nil {
+				return nil, cobra.ShellCompDirectiveNoFileComp
+			}
+			return fn(ops)
+		})
+	}
+}
+
+// runbookCompletion - Runbook providing the candidates of the completions.
+type runbookCompletion interface {
+	ID() string
+	Desc() string
+	Labels() []string
+	VarKeys() []string
+}
+
+// loadRunbooksForCompletion loads the runbooks of the arguments without running them.
+// flgs.ToOpts is not used because it reads the runbook IDs from STDIN.
+func loadRunbooksForCompletion(args []string) ([]runbookCompletion, error) {
+	if len(args) == 0 {
+		return nil, nil
+	}
+	pathp := strings.Join(args, string(filepath.ListSeparator))
+	o, err := runn.Load(pathp, runn.LoadOnly(), runn.Scopes(flgs.Scopes...))
+	if err != nil {
+		return nil, err
+	}
+	var ops []runbookCompletion
+	for _, oo := range o.Operators() {
+		ops = append(ops, oo)
+	}
+	return ops, nil
+}
+
+func completionWithDesc(v, desc string) string {
+	if desc == "" {
+		return v
+	}
+	return v + "\t" + desc
+}
+
+func uniqSorted(s []string) []string {
+	m := map[string]struct{}{}
+	var u []string
+	for _, v := range s {
+		if _, ok := m[v]; ok {
+			continue
+		}
+		m[v] = struct{}{}
+		u = append(u, v)
+	}
+	sort.Strings(u)
+	return u
+}
@@ -0,0 +1,106 @@
+/*
+Copyright © 2022 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k1LoW/runn"
+	"github.com/spf13/cobra"
+)
+
+// curlCmd represents the curl command.
+var curlCmd = &cobra.Command{
+	Use:   "curl [PATH_PATTERN ...]",
+	Short: "export HTTP steps of runbooks as curl commands",
+	Long:  `export HTTP steps of runbooks as the equivalent curl commands.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := flgs.ToOpts()
+		if err != nil {
+			return err
+		}
+		pathp := strings.Join(args, string(filepath.ListSeparator))
+		opts = append(opts, runn.LoadOnly())
+
+		// setup cache dir
+		if err := runn.SetCacheDir(flgs.CacheDir); err != nil {
+			return err
+		}
+		defer func() {
+			if !flgs.RetainCacheDir {
+				_ = runn.RemoveCacheDir()
+			}
+		}()
+
+		o, err := runn.Load(pathp, opts...)
+		if err != nil {
+			return err
+		}
+		cmds, err := o.CurlCommands(flgs.ResolveVars)
+		if err != nil {
+			return err
+		}
+
+		if flgs.Format == "json" {
+			b, err := json.MarshalIndent(cmds, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Println(string(b))
+			return nil
+		}
+		for i, c := range cmds {
+			if i > 0 {
+				_, _ = fmt.Fprintln(os.Stdout)
+			}
+			comment := fmt.Sprintf("# %s %s", c.BookPath, c.Step)
+			if c.Desc != "" {
+				comment = fmt.Sprintf("%s: %s", comment, c.Desc)
+			}
+			_, _ = fmt.Fprintln(os.Stdout, comment)
+			_, _ = fmt.Fprintln(os.Stdout, c.Command)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(curlCmd)
+	curlCmd.Flags().BoolVarP(&flgs.ResolveVars, "resolve-vars", "", false, flgs.Usage("ResolveVars"))
+	curlCmd.Flags().StringSliceVarP(&flgs.Vars, "var", "", []string{}, flgs.Usage("Vars"))
+	curlCmd.Flags().StringSliceVarP(&flgs.Runners, "runner", "", []string{}, flgs.Usage("Runners"))
+	curlCmd.Flags().StringSliceVarP(&flgs.Overlays, "overlay", "", []string{}, flgs.Usage("Overlays"))
+	curlCmd.Flags().StringSliceVarP(&flgs.Underlays, "underlay", "", []string{}, flgs.Usage("Underlays"))
+	curlCmd.Flags().StringVarP(&flgs.RunMatch, "run", "", "", flgs.Usage("RunMatch"))
+	curlCmd.Flags().StringSliceVarP(&flgs.RunIDs, "id", "", []string{}, flgs.Usage("RunIDs"))
+	curlCmd.Flags().StringSliceVarP(&flgs.RunLabels, "label", "", []string{}, flgs.Usage("RunLabels"))
+	curlCmd.Flags().StringSliceVarP(&flgs.EnvFiles, "env-file", "", []string{}, flgs.Usage("EnvFiles"))
+	curlCmd.Flags().StringVarP(&flgs.CacheDir, "cache-dir", "", "", flgs.Usage("CacheDir"))
+	curlCmd.Flags().StringVarP(&flgs.Format, "format", "", "", flgs.Usage("Format"))
+	curlCmd.Flags().BoolVarP(&flgs.RetainCacheDir, "retain-cache-dir", "", false, flgs.Usage("RetainCacheDir"))
+	setRunbookCompletions(curlCmd)
+}
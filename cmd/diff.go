@@ -0,0 +1,115 @@
+/*
+Copyright © 2022 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/k1LoW/runn"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// diffCmd represents the diff command.
+var diffCmd = &cobra.Command{
+	Use:   "diff [RESULT_A] [RESULT_B]",
+	Short: "compare two results of runs of runbooks",
+	Long: `compare two results of runs of runbooks (e.g. staging vs production runs of the same runbooks) and report the differences of the outcomes, the statuses and the selected fields of the steps.
+The results are the outputs of "runn run --format json" or the files dumped by "runn run --steps-out".`,
+	Args: cobra.ExactArgs(2),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		a, err := os.ReadFile(filepath.Clean(args[0]))
+		if err != nil {
+			return err
+		}
+		b, err := os.ReadFile(filepath.Clean(args[1]))
+		if err != nil {
+			return err
+		}
+		diffs, err := runn.DiffResults(a, b, flgs.DiffFields...)
+		if err != nil {
+			return err
+		}
+
+		if flgs.Format == "json" {
+			if diffs == nil {
+				diffs = []*runn.ResultDiff{}
+			}
+			b, err := json.MarshalIndent(diffs, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Println(string(b))
+		} else if len(diffs) > 0 {
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"runbook", "step", "field", args[0], args[1]})
+			table.SetAutoWrapText(false)
+			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+			table.SetAutoFormatHeaders(false)
+			table.SetCenterSeparator("")
+			table.SetColumnSeparator("")
+			table.SetRowSeparator("-")
+			table.SetHeaderLine(true)
+			table.SetBorder(false)
+			for _, d := range diffs {
+				p := d.Runbook
+				if !flgs.Long {
+					p = runn.ShortenPath(p)
+				}
+				table.Append([]string{p, d.Step, d.Field, diffValue(d.A), diffValue(d.B)})
+			}
+			table.Render()
+		}
+
+		if len(diffs) > 0 {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+// diffValue returns the value of the result to be printed. "-" means that the value does not exist.
+func diffValue(v any) string {
+	switch vv := v.(type) {
+	case nil:
+		return "-"
+	case string:
+		return vv
+	default:
+		b, err := json.Marshal(vv)
+		if err != nil {
+			return fmt.Sprintf("%v", vv)
+		}
+		return string(b)
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(diffCmd)
+	diffCmd.Flags().BoolVarP(&flgs.Long, "long", "l", false, flgs.Usage("Long"))
+	diffCmd.Flags().StringSliceVarP(&flgs.DiffFields, "field", "", []string{}, flgs.Usage("DiffFields"))
+	diffCmd.Flags().StringVarP(&flgs.Format, "format", "", "", flgs.Usage("Format"))
+}
@@ -0,0 +1,160 @@
+/*
+Copyright © 2022 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k1LoW/runn"
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+)
+
+// doctorCmd represents the doctor command.
+var doctorCmd = &cobra.Command{
+	Use:   "doctor [PATH_PATTERN ...]",
+	Short: "check connectivity of runners of runbooks",
+	Long:  `check connectivity of runners of runbooks (TCP/TLS handshake, DB ping, gRPC reflection and SSH authentication) without running the steps.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		ctx := context.Background()
+		pathp := strings.Join(args, string(filepath.ListSeparator))
+		opts, err := flgs.ToOpts()
+		if err != nil {
+			return err
+		}
+		opts = append(opts, runn.LoadOnly())
+
+		// setup cache dir
+		if err := runn.SetCacheDir(flgs.CacheDir); err != nil {
+			return err
+		}
+		defer func() {
+			if !flgs.RetainCacheDir {
+				_ = runn.RemoveCacheDir()
+			}
+		}()
+
+		o, err := runn.Load(pathp, opts...)
+		if err != nil {
+			return err
+		}
+		ds, err := o.Diagnose(ctx)
+		o.Close()
+		if err != nil {
+			return err
+		}
+		failed := false
+		for _, d := range ds {
+			if d.Err != nil {
+				failed = true
+			}
+		}
+
+		if flgs.Format == "json" {
+			type diagnosis struct {
+				BookPath   string `json:"path"`
+				RunnerKey  string `json:"runner"`
+				RunnerType string `json:"type"`
+				Target     string `json:"target"`
+				Check      string `json:"check,omitempty"`
+				Skipped    bool   `json:"skipped"`
+				Error      string `json:"error,omitempty"`
+				Elapsed    int64  `json:"elapsed_ms"`
+			}
+			out := []diagnosis{}
+			for _, d := range ds {
+				dd := diagnosis{
+					BookPath:   d.BookPath,
+					RunnerKey:  d.RunnerKey,
+					RunnerType: string(d.RunnerType),
+					Target:     d.Target,
+					Check:      d.Check,
+					Skipped:    d.Skipped,
+					Elapsed:    d.Elapsed.Milliseconds(),
+				}
+				if d.Err != nil {
+					dd.Error = d.Err.Error()
+				}
+				out = append(out, dd)
+			}
+			b, err := json.MarshalIndent(out, "", "  ")
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Println(string(b))
+		} else {
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"runner", "type", "target", "check", "result", "path"})
+			table.SetAutoWrapText(false)
+			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+			table.SetAutoFormatHeaders(false)
+			table.SetCenterSeparator("")
+			table.SetColumnSeparator("")
+			table.SetRowSeparator("-")
+			table.SetHeaderLine(true)
+			table.SetBorder(false)
+			for _, d := range ds {
+				result := "ok"
+				switch {
+				case d.Skipped:
+					result = "skipped"
+				case d.Err != nil:
+					msg, _, _ := strings.Cut(d.Err.Error(), "\n")
+					result = fmt.Sprintf("ng: %s", msg)
+				}
+				p := d.BookPath
+				if !flgs.Long {
+					p = runn.ShortenPath(p)
+				}
+				table.Append([]string{d.RunnerKey, string(d.RunnerType), d.Target, d.Check, result, p})
+			}
+			table.Render()
+		}
+
+		if failed {
+			os.Exit(1)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+	doctorCmd.Flags().BoolVarP(&flgs.Long, "long", "l", false, flgs.Usage("Long"))
+	doctorCmd.Flags().StringSliceVarP(&flgs.Vars, "var", "", []string{}, flgs.Usage("Vars"))
+	doctorCmd.Flags().StringSliceVarP(&flgs.Runners, "runner", "", []string{}, flgs.Usage("Runners"))
+	doctorCmd.Flags().StringSliceVarP(&flgs.Overlays, "overlay", "", []string{}, flgs.Usage("Overlays"))
+	doctorCmd.Flags().StringSliceVarP(&flgs.Underlays, "underlay", "", []string{}, flgs.Usage("Underlays"))
+	doctorCmd.Flags().StringVarP(&flgs.RunMatch, "run", "", "", flgs.Usage("RunMatch"))
+	doctorCmd.Flags().StringSliceVarP(&flgs.RunIDs, "id", "", []string{}, flgs.Usage("RunIDs"))
+	doctorCmd.Flags().StringSliceVarP(&flgs.RunLabels, "label", "", []string{}, flgs.Usage("RunLabels"))
+	doctorCmd.Flags().StringVarP(&flgs.CacheDir, "cache-dir", "", "", flgs.Usage("CacheDir"))
+	doctorCmd.Flags().BoolVarP(&flgs.RetainCacheDir, "retain-cache-dir", "", false, flgs.Usage("RetainCacheDir"))
+	doctorCmd.Flags().StringVarP(&flgs.Format, "format", "", "", flgs.Usage("Format"))
+	setRunbookCompletions(doctorCmd)
+}
@@ -0,0 +1,61 @@
+/*
+Copyright © 2022 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k1LoW/runn"
+	"github.com/spf13/cobra"
+)
+
+// fmtCmd represents the fmt command.
+var fmtCmd = &cobra.Command{
+	Use:   "fmt [PATH_PATTERN ...]",
+	Short: "format runbooks",
+	Long:  `format runbooks in the canonical style (key order, indentation and quoting), preserving comments.`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		pathp := strings.Join(args, string(filepath.ListSeparator))
+		paths, err := runn.Format(pathp, !flgs.FmtCheck)
+		if err != nil {
+			return err
+		}
+		for _, p := range paths {
+			_, _ = fmt.Fprintln(os.Stdout, p)
+		}
+		if flgs.FmtCheck && len(paths) > 0 {
+			return errors.New("unformatted runbooks found")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(fmtCmd)
+	fmtCmd.Flags().BoolVarP(&flgs.FmtCheck, "check", "", false, flgs.Usage("FmtCheck"))
+	setRunbookCompletions(fmtCmd)
+}
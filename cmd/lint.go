@@ -0,0 +1,81 @@
+/*
+Copyright © 2022 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k1LoW/runn"
+	"github.com/spf13/cobra"
+)
+
+// lintCmd represents the lint command.
+var lintCmd = &cobra.Command{
+	Use:   "lint [PATH_PATTERN ...]",
+	Short: "lint runbooks",
+	Long:  `lint runbooks beyond YAML syntax (unknown runners, unused runners, undefined vars, duplicate step keys, deprecated features).`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		cfg := &runn.LintConfig{}
+		if flgs.LintConfig != "" {
+			c, err := runn.LoadLintConfig(flgs.LintConfig)
+			if err != nil {
+				return err
+			}
+			cfg = c
+		}
+		// The vars and runners given by the flags are defined outside the runbook
+		for _, v := range flgs.Vars {
+			k, _, _ := strings.Cut(v, ":")
+			cfg.KnownVars = append(cfg.KnownVars, strings.Split(k, ".")[0])
+		}
+		for _, r := range flgs.Runners {
+			k, _, _ := strings.Cut(r, ":")
+			cfg.KnownRunners = append(cfg.KnownRunners, k)
+		}
+
+		pathp := strings.Join(args, string(filepath.ListSeparator))
+		issues, err := runn.Lint(pathp, cfg)
+		if err != nil {
+			return err
+		}
+		for _, i := range issues {
+			_, _ = fmt.Fprintln(os.Stdout, i.String())
+		}
+		if runn.HasLintErrors(issues) {
+			return errors.New("lint errors found")
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(lintCmd)
+	lintCmd.Flags().StringVarP(&flgs.LintConfig, "config", "", "", flgs.Usage("LintConfig"))
+	lintCmd.Flags().StringSliceVarP(&flgs.Vars, "var", "", []string{}, flgs.Usage("Vars"))
+	lintCmd.Flags().StringSliceVarP(&flgs.Runners, "runner", "", []string{}, flgs.Usage("Runners"))
+	setRunbookCompletions(lintCmd)
+}
@@ -77,9 +77,9 @@ var listCmd = &cobra.Command{
 			return err
 		}
 		for _, oo := range selected {
-			id := oo.ID()
-			if !flgs.Long {
-				id = id[:7]
+			id := oo.ShortID()
+			if flgs.Long {
+				id = oo.ID()
 			}
 			desc := oo.Desc()
 			p := oo.BookPath()
@@ -115,4 +115,5 @@ func init() {
 	listCmd.Flags().IntVarP(&flgs.ShardN, "shard-n", "", 0, flgs.Usage("ShardN"))
 	listCmd.Flags().StringVarP(&flgs.CacheDir, "cache-dir", "", "", flgs.Usage("CacheDir"))
 	listCmd.Flags().BoolVarP(&flgs.RetainCacheDir, "retain-cache-dir", "", false, flgs.Usage("RetainCacheDir"))
+	setRunbookCompletions(listCmd)
 }
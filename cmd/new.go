@@ -29,6 +29,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/k1LoW/runn"
 	"github.com/k1LoW/runn/capture"
@@ -75,6 +76,7 @@ var newCmd = &cobra.Command{
 				}
 			}
 		}
+		rb.SetHARAllowHosts(flgs.HARAllowHosts...)
 		for _, args := range al {
 			if err := rb.AppendStep(args...); err != nil {
 				return err
@@ -122,6 +124,7 @@ func init() {
 	newCmd.Flags().StringVarP(&flgs.Desc, "desc", "", "", flgs.Usage("Desc"))
 	newCmd.Flags().StringVarP(&flgs.Out, "out", "", "", flgs.Usage("Out"))
 	newCmd.Flags().BoolVarP(&flgs.AndRun, "and-run", "", false, flgs.Usage("AndRun"))
+	newCmd.Flags().StringSliceVarP(&flgs.HARAllowHosts, "har-allow-host", "", []string{}, flgs.Usage("HARAllowHosts"))
 	newCmd.Flags().BoolVarP(&flgs.GRPCNoTLS, "grpc-no-tls", "", false, flgs.Usage("GRPCNoTLS"))
 	newCmd.Flags().StringSliceVarP(&flgs.GRPCProtos, "grpc-proto", "", []string{}, flgs.Usage("GRPCProtos"))
 	newCmd.Flags().StringSliceVarP(&flgs.GRPCImportPaths, "grpc-import-path", "", []string{}, flgs.Usage("GRPCImportPaths"))
@@ -185,10 +188,21 @@ func runAndCapture(ctx context.Context, o *os.File, fn func(*os.File) error) err
 }
 
 func argsListFromStdin(in io.Reader) [][]string {
-	var al [][]string
+	var (
+		al    [][]string
+		lines []string
+	)
 	scanner := bufio.NewScanner(in)
 	for scanner.Scan() {
-		al = append(al, []string{scanner.Text()})
+		lines = append(lines, scanner.Text())
+	}
+	all := strings.TrimSpace(strings.Join(lines, "\n"))
+	if strings.HasPrefix(all, "fetch(") || strings.HasPrefix(all, "await fetch(") {
+		// The fetch() snippet spans multiple lines
+		return [][]string{{all}}
+	}
+	for _, l := range lines {
+		al = append(al, []string{l})
 	}
 	return al
 }
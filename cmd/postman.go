@@ -0,0 +1,89 @@
+/*
+Copyright © 2022 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k1LoW/runn"
+	"github.com/spf13/cobra"
+)
+
+// postmanCmd represents the postman command.
+var postmanCmd = &cobra.Command{
+	Use:   "postman [PATH_PATTERN ...]",
+	Short: "export runbooks as Postman collection",
+	Long:  `export runbooks as Postman collection (v2.1). Each runbook is converted to the folder, each HTTP step to the request, and test: of the step to pm.test().`,
+	Args:  cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		opts, err := flgs.ToOpts()
+		if err != nil {
+			return err
+		}
+		pathp := strings.Join(args, string(filepath.ListSeparator))
+		opts = append(opts, runn.LoadOnly())
+
+		// setup cache dir
+		if err := runn.SetCacheDir(flgs.CacheDir); err != nil {
+			return err
+		}
+		defer func() {
+			if !flgs.RetainCacheDir {
+				_ = runn.RemoveCacheDir()
+			}
+		}()
+
+		o, err := runn.Load(pathp, opts...)
+		if err != nil {
+			return err
+		}
+
+		if flgs.Out == "" {
+			return o.ExportPostmanCollection(os.Stdout, flgs.CollectionName)
+		}
+		f, err := os.Create(filepath.Clean(flgs.Out))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return o.ExportPostmanCollection(f, flgs.CollectionName)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(postmanCmd)
+	postmanCmd.Flags().StringVarP(&flgs.CollectionName, "name", "", "runn", flgs.Usage("CollectionName"))
+	postmanCmd.Flags().StringVarP(&flgs.Out, "out", "", "", flgs.Usage("Out"))
+	postmanCmd.Flags().StringSliceVarP(&flgs.Vars, "var", "", []string{}, flgs.Usage("Vars"))
+	postmanCmd.Flags().StringSliceVarP(&flgs.Runners, "runner", "", []string{}, flgs.Usage("Runners"))
+	postmanCmd.Flags().StringSliceVarP(&flgs.Overlays, "overlay", "", []string{}, flgs.Usage("Overlays"))
+	postmanCmd.Flags().StringSliceVarP(&flgs.Underlays, "underlay", "", []string{}, flgs.Usage("Underlays"))
+	postmanCmd.Flags().StringVarP(&flgs.RunMatch, "run", "", "", flgs.Usage("RunMatch"))
+	postmanCmd.Flags().StringSliceVarP(&flgs.RunIDs, "id", "", []string{}, flgs.Usage("RunIDs"))
+	postmanCmd.Flags().StringSliceVarP(&flgs.RunLabels, "label", "", []string{}, flgs.Usage("RunLabels"))
+	postmanCmd.Flags().StringSliceVarP(&flgs.EnvFiles, "env-file", "", []string{}, flgs.Usage("EnvFiles"))
+	postmanCmd.Flags().StringVarP(&flgs.CacheDir, "cache-dir", "", "", flgs.Usage("CacheDir"))
+	postmanCmd.Flags().BoolVarP(&flgs.RetainCacheDir, "retain-cache-dir", "", false, flgs.Usage("RetainCacheDir"))
+	setRunbookCompletions(postmanCmd)
+}
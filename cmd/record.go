@@ -0,0 +1,92 @@
+/*
+Copyright © 2022 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/k1LoW/runn"
+	"github.com/spf13/cobra"
+)
+
+// recordCmd represents the record command.
+var recordCmd = &cobra.Command{
+	Use:   "record",
+	Short: "record HTTP requests through the proxy as runbook",
+	Long: `record HTTP requests through the proxy as runbook.
+With --target, it works as the reverse proxy to the target. Otherwise it works as the forward proxy (HTTP only).
+The runbook is written when the proxy is stopped (Ctrl+C).`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		rec, err := runn.NewRecorder(flgs.Desc, flgs.RecordTarget)
+		if err != nil {
+			return err
+		}
+		ln, err := net.Listen("tcp", flgs.RecordListen)
+		if err != nil {
+			return err
+		}
+		srv := &http.Server{Handler: rec, ReadHeaderTimeout: 10 * time.Second}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+		defer stop()
+		go func() {
+			<-ctx.Done()
+			_ = srv.Shutdown(context.Background())
+		}()
+		if flgs.RecordTarget != "" {
+			_, _ = fmt.Fprintf(os.Stderr, "Recording proxy to %s is listening on %s\n", flgs.RecordTarget, ln.Addr())
+		} else {
+			_, _ = fmt.Fprintf(os.Stderr, "Recording forward proxy is listening on %s\n", ln.Addr())
+		}
+		if err := srv.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			return err
+		}
+		_, _ = fmt.Fprintf(os.Stderr, "%d requests recorded\n", rec.NumberOfSteps())
+
+		if flgs.Out == "" {
+			return rec.WriteRunbook(os.Stdout)
+		}
+		f, err := os.Create(filepath.Clean(flgs.Out))
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		return rec.WriteRunbook(f)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(recordCmd)
+	recordCmd.Flags().StringVarP(&flgs.Desc, "desc", "", "", flgs.Usage("Desc"))
+	recordCmd.Flags().StringVarP(&flgs.Out, "out", "", "", flgs.Usage("Out"))
+	recordCmd.Flags().StringVarP(&flgs.RecordListen, "listen", "", "localhost:8080", flgs.Usage("RecordListen"))
+	recordCmd.Flags().StringVarP(&flgs.RecordTarget, "target", "", "", flgs.Usage("RecordTarget"))
+}
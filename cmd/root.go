@@ -49,4 +49,5 @@ func Execute() {
 
 func init() {
 	rootCmd.PersistentFlags().StringSliceVarP(&flgs.Scopes, "scopes", "", []string{}, flgs.Usage("Scopes")) // Plural to support comma-separated input
+	rootCmd.PersistentFlags().StringSliceVarP(&flgs.EnvFiles, "env-file", "", []string{}, flgs.Usage("EnvFiles"))
 }
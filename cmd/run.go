@@ -23,6 +23,7 @@ package cmd
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -60,6 +61,22 @@ var runCmd = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		if flgs.DryRun {
+			planned, err := o.Plan()
+			if err != nil {
+				return err
+			}
+			if flgs.Format == "json" {
+				b, err := json.MarshalIndent(planned, "", "  ")
+				if err != nil {
+					return err
+				}
+				_, _ = fmt.Println(string(b))
+				return nil
+			}
+			printPlan(planned)
+			return nil
+		}
 		if err := o.RunN(ctx); err != nil {
 			return err
 		}
@@ -92,6 +109,38 @@ var runCmd = &cobra.Command{
 			}
 		}
 
+		if flgs.StoreOut != "" {
+			s, err := os.Create(filepath.Clean(flgs.StoreOut))
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := s.Close(); err != nil {
+					_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+					os.Exit(1)
+				}
+			}()
+			if err := o.DumpStore(s); err != nil {
+				return err
+			}
+		}
+
+		if flgs.StepsOut != "" {
+			s, err := os.Create(filepath.Clean(flgs.StepsOut))
+			if err != nil {
+				return err
+			}
+			defer func() {
+				if err := s.Close(); err != nil {
+					_, _ = fmt.Fprintf(os.Stderr, "%s\n", err)
+					os.Exit(1)
+				}
+			}()
+			if err := o.DumpSteps(s); err != nil {
+				return err
+			}
+		}
+
 		if r.HasFailure() {
 			os.Exit(1)
 		}
@@ -105,6 +154,7 @@ func init() {
 	runCmd.Flags().BoolVarP(&flgs.FailFast, "fail-fast", "", false, flgs.Usage("FailFast"))
 	runCmd.Flags().BoolVarP(&flgs.SkipTest, "skip-test", "", false, flgs.Usage("SkipTest"))
 	runCmd.Flags().BoolVarP(&flgs.SkipIncluded, "skip-included", "", false, flgs.Usage("SkipIncluded"))
+	runCmd.Flags().IntVarP(&flgs.IncludeMaxDepth, "include-max-depth", "", 0, flgs.Usage("IncludeMaxDepth"))
 	runCmd.Flags().StringSliceVarP(&flgs.HostRules, "host-rules", "", []string{}, flgs.Usage("HostRules"))
 	runCmd.Flags().StringSliceVarP(&flgs.HTTPOpenApi3s, "http-openapi3", "", []string{}, flgs.Usage("HTTPOpenApi3s"))
 	runCmd.Flags().BoolVarP(&flgs.GRPCNoTLS, "grpc-no-tls", "", false, flgs.Usage("GRPCNoTLS"))
@@ -127,7 +177,54 @@ func init() {
 	runCmd.Flags().StringVarP(&flgs.Format, "format", "", "", flgs.Usage("Format"))
 	runCmd.Flags().BoolVarP(&flgs.Profile, "profile", "", false, flgs.Usage("Profile"))
 	runCmd.Flags().StringVarP(&flgs.ProfileOut, "profile-out", "", "runn.prof", flgs.Usage("ProfileOut"))
+	runCmd.Flags().BoolVarP(&flgs.UpdateSnapshots, "update-snapshots", "", false, flgs.Usage("UpdateSnapshots"))
+	runCmd.Flags().BoolVarP(&flgs.SoftAssertions, "soft-assertions", "", false, flgs.Usage("SoftAssertions"))
+	runCmd.Flags().BoolVarP(&flgs.SharedStore, "shared-store", "", false, flgs.Usage("SharedStore"))
+	runCmd.Flags().StringVarP(&flgs.StoreIn, "store-in", "", "", flgs.Usage("StoreIn"))
+	runCmd.Flags().StringVarP(&flgs.StoreOut, "store-out", "", "", flgs.Usage("StoreOut"))
+	runCmd.Flags().StringVarP(&flgs.StartStep, "start-step", "", "", flgs.Usage("StartStep"))
+	runCmd.Flags().StringVarP(&flgs.EndStep, "end-step", "", "", flgs.Usage("EndStep"))
+	runCmd.Flags().StringSliceVarP(&flgs.Steps, "step", "", []string{}, flgs.Usage("Steps"))
+	runCmd.Flags().StringVarP(&flgs.Replay, "replay", "", "", flgs.Usage("Replay"))
+	runCmd.Flags().StringVarP(&flgs.StepsOut, "steps-out", "", "", flgs.Usage("StepsOut"))
+	runCmd.Flags().BoolVarP(&flgs.DryRun, "dry-run", "", false, flgs.Usage("DryRun"))
 	runCmd.Flags().StringVarP(&flgs.CacheDir, "cache-dir", "", "", flgs.Usage("CacheDir"))
 	runCmd.Flags().BoolVarP(&flgs.RetainCacheDir, "retain-cache-dir", "", false, flgs.Usage("RetainCacheDir"))
 	runCmd.Flags().BoolVarP(&flgs.Verbose, "verbose", "", false, flgs.Usage("Verbose"))
+	setRunbookCompletions(runCmd)
+}
+
+func printPlan(planned []*runn.PlannedStep) {
+	var bookPath string
+	for _, p := range planned {
+		if p.BookPath != bookPath {
+			if bookPath != "" {
+				_, _ = fmt.Fprintln(os.Stdout)
+			}
+			bookPath = p.BookPath
+			_, _ = fmt.Fprintln(os.Stdout, bookPath)
+		}
+		line := fmt.Sprintf("  %s", p.Step)
+		if p.RunnerType != "" {
+			line = fmt.Sprintf("%s [%s]", line, p.RunnerType)
+		}
+		switch {
+		case p.URL != "":
+			line = fmt.Sprintf("%s %s %s", line, p.Method, p.URL)
+		case p.Method != "":
+			line = fmt.Sprintf("%s %s", line, p.Method)
+		case p.Detail != "":
+			line = fmt.Sprintf("%s %s", line, p.Detail)
+		}
+		if p.Desc != "" {
+			line = fmt.Sprintf("%s # %s", line, p.Desc)
+		}
+		if p.If != "" {
+			line = fmt.Sprintf("%s (if: %s)", line, p.If)
+		}
+		if p.Skip {
+			line = fmt.Sprintf("%s (skip)", line)
+		}
+		_, _ = fmt.Fprintln(os.Stdout, line)
+	}
 }
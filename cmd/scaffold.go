@@ -0,0 +1,97 @@
+/*
+Copyright © 2022 Ken'ichiro Oyama <k1lowxb@gmail.com>
+
+Permission is hereby granted, free of charge, to any person obtaining a copy
+of this software and associated documentation files (the "Software"), to deal
+in the Software without restriction, including without limitation the rights
+to use, copy, modify, merge, publish, distribute, sublicense, and/or sell
+copies of the Software, and to permit persons to whom the Software is
+furnished to do so, subject to the following conditions:
+
+The above copyright notice and this permission notice shall be included in
+all copies or substantial portions of the Software.
+
+THE SOFTWARE IS PROVIDED "AS IS", WITHOUT WARRANTY OF ANY KIND, EXPRESS OR
+IMPLIED, INCLUDING BUT NOT LIMITED TO THE WARRANTIES OF MERCHANTABILITY,
+FITNESS FOR A PARTICULAR PURPOSE AND NONINFRINGEMENT. IN NO EVENT SHALL THE
+AUTHORS OR COPYRIGHT HOLDERS BE LIABLE FOR ANY CLAIM, DAMAGES OR OTHER
+LIABILITY, WHETHER IN AN ACTION OF CONTRACT, TORT OR OTHERWISE, ARISING FROM,
+OUT OF OR IN CONNECTION WITH THE SOFTWARE OR THE USE OR OTHER DEALINGS IN
+THE SOFTWARE.
+*/
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/k1LoW/runn"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v2"
+)
+
+// scaffoldCmd represents the scaffold command.
+var scaffoldCmd = &cobra.Command{
+	Use:   "scaffold [OPENAPI3_PATH]",
+	Short: "scaffold runbooks from OpenAPI document",
+	Long:  `scaffold skeleton runbooks from OpenAPI document (one runbook per operation or per tag) with example values from the schema.`,
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		l := args[0]
+		dir := filepath.Clean(flgs.OutDir)
+		ref := l
+		if !strings.HasPrefix(l, "https://") && !strings.HasPrefix(l, "http://") {
+			// The runbooks refer to the document by the relative path from the output directory
+			abs, err := filepath.Abs(l)
+			if err != nil {
+				return err
+			}
+			absDir, err := filepath.Abs(dir)
+			if err != nil {
+				return err
+			}
+			ref, err = filepath.Rel(absDir, abs)
+			if err != nil {
+				return err
+			}
+			ref = filepath.ToSlash(ref)
+		}
+		rbs, err := runn.ScaffoldRunbooks(l, ref, flgs.ScaffoldBy)
+		if err != nil {
+			return err
+		}
+		if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+			return err
+		}
+		names := make([]string, 0, len(rbs))
+		for n := range rbs {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		for _, n := range names {
+			p := filepath.Join(dir, n)
+			if _, err := os.Stat(p); err == nil {
+				_, _ = fmt.Fprintf(os.Stderr, "Skip %s because it already exists\n", p)
+				continue
+			}
+			b, err := yaml.Marshal(rbs[n])
+			if err != nil {
+				return err
+			}
+			if err := os.WriteFile(p, b, 0o600); err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintln(os.Stdout, p)
+		}
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(scaffoldCmd)
+	scaffoldCmd.Flags().StringVarP(&flgs.OutDir, "out-dir", "", ".", flgs.Usage("OutDir"))
+	scaffoldCmd.Flags().StringVarP(&flgs.ScaffoldBy, "by", "", runn.ScaffoldByOperation, flgs.Usage("ScaffoldBy"))
+}
@@ -0,0 +1,67 @@
+package runn
+
+import (
+	"fmt"
+	"sort"
+)
+
+// parseConsts evaluates `consts:` only once when the runbook is loaded.
+func (bk *book) parseConsts(store map[string]any) error {
+	if len(bk.consts) == 0 {
+		return nil
+	}
+	if store != nil {
+		v, err := EvalExpand(bk.consts, store)
+		if err != nil {
+			return fmt.Errorf("invalid consts: %w", err)
+		}
+		var ok bool
+		bk.consts, ok = v.(map[string]any)
+		if !ok {
+			return fmt.Errorf("invalid consts: %v", v)
+		}
+	}
+	root, err := bk.generateOperatorRoot()
+	if err != nil {
+		return err
+	}
+	for k, v := range bk.consts {
+		ev, err := evaluateSchema(v, root, store)
+		if err != nil {
+			return fmt.Errorf("invalid consts: %w", err)
+		}
+		bk.consts[k] = ev
+	}
+	return nil
+}
+
+// bindConsts binds `consts:` into vars.
+// It is an error if the same key is assigned by `vars:` or `--var`.
+func (bk *book) bindConsts() error {
+	if err := checkConsts(bk.consts, bk.vars); err != nil {
+		return err
+	}
+	for k, v := range bk.consts {
+		bk.vars[k] = v
+	}
+	return nil
+}
+
+// checkConsts returns an error if vars assign to the consts of the runbook ( e.g. the vars of `include:` ).
+func (o *operator) checkConsts(vars map[string]any) error {
+	return checkConsts(o.consts, vars)
+}
+
+func checkConsts(consts, vars map[string]any) error {
+	var keys []string
+	for k := range consts {
+		if _, ok := vars[k]; ok {
+			keys = append(keys, k)
+		}
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		return fmt.Errorf("invalid consts: cannot assign to consts: %v", keys)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package runn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConsts(t *testing.T) {
+	tests := []struct {
+		book    string
+		opts    []Option
+		wantErr bool
+	}{
+		{"testdata/book/consts.yml", nil, false},
+		{"testdata/book/consts.yml", []Option{Var("env", "staging")}, true},
+	}
+	for _, tt := range tests {
+		o, err := New(append([]Option{Book(tt.book)}, tt.opts...)...)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("%s: got %v", tt.book, err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("%s: want error", tt.book)
+			continue
+		}
+		if err := o.Run(context.Background()); err != nil {
+			t.Error(err)
+		}
+	}
+}
+
+func TestConstsOverriddenByVars(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	p := filepath.Join(t.TempDir(), "consts.yml")
+	rb := `desc: Consts overridden by vars
+consts:
+  env: production
+vars:
+  env: staging
+steps:
+  -
+    test: 'true'
+`
+	if err := os.WriteFile(p, []byte(rb), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := New(Scopes(ScopeAllowReadParent), Book(p)); err == nil || !strings.Contains(err.Error(), "cannot assign to consts") {
+		t.Errorf("got %v", err)
+	}
+}
+
+func TestIncludeConsts(t *testing.T) {
+	o, err := New(Book("testdata/book/consts_include.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = o.Run(context.Background())
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if !strings.Contains(err.Error(), "cannot assign to consts") {
+		t.Errorf("got %v", err)
+	}
+}
@@ -0,0 +1,256 @@
+package runn
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// curlSafeArgRe - Argument of the curl command that does not need to be quoted.
+var curlSafeArgRe = regexp.MustCompile(`^[a-zA-Z0-9_\-./:=@%+,]+$`)
+
+// CurlCommand is a curl command equivalent to the HTTP step of the runbook.
+type CurlCommand struct {
+	// BookPath - Path of the runbook
+	BookPath string `json:"book_path"`
+	// Step - Reference to the step ( e.g. steps[0], steps.login )
+	Step string `json:"step"`
+	// Desc - `desc:` of the step
+	Desc string `json:"desc,omitempty"`
+	// Command - curl command line
+	Command string `json:"command"`
+}
+
+// CurlCommands renders the HTTP steps of the runbooks as the equivalent curl commands.
+// If resolve is true, the variables that can be evaluated before running ( e.g. vars ) are substituted.
+// The other expressions ( e.g. the references to the results of the previous steps ) remain as they are.
+func (ops *operators) CurlCommands(resolve bool) ([]*CurlCommand, error) {
+	var cmds []*CurlCommand
+	for _, o := range ops.ops {
+		c, err := o.curlCommands(resolve)
+		if err != nil {
+			return nil, err
+		}
+		cmds = append(cmds, c...)
+	}
+	return cmds, nil
+}
+
+func (o *operator) curlCommands(resolve bool) ([]*CurlCommand, error) {
+	var cmds []*CurlCommand
+	for _, s := range o.steps {
+		if s.httpRunner == nil || s.httpRequest == nil {
+			continue
+		}
+		ref := fmt.Sprintf("steps[%d]", s.idx)
+		if o.useMap {
+			ref = fmt.Sprintf("steps.%s", s.key)
+		}
+		if s.httpRunner.endpoint == nil {
+			o.Debugf("%s does not have the endpoint (%s)\n", ref, o.bookPath)
+			continue
+		}
+		var in any = s.httpRequest
+		if resolve {
+			store := o.store.toMap()
+			store[storeRootKeyIncluded] = o.included
+			in = expandResolvable(in, store)
+		}
+		r, ok := in.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid http request: %v", in)
+		}
+		req, err := parseHTTPRequest(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid http request (%s %s): %w", o.bookPath, ref, err)
+		}
+		req.root = o.root
+		args, err := req.curlArgs(s.httpRunner)
+		if err != nil {
+			return nil, fmt.Errorf("failed to render curl command (%s %s): %w", o.bookPath, ref, err)
+		}
+		cmds = append(cmds, &CurlCommand{
+			BookPath: o.bookPath,
+			Step:     ref,
+			Desc:     s.desc,
+			Command:  curlJoin(args),
+		})
+	}
+	return cmds, nil
+}
+
+func (r *httpRequest) curlArgs(rnr *httpRunner) ([]string, error) {
+	var u string
+	if strings.Contains(r.path, delimStart) {
+		// The URL is not parsed to keep the unresolved expressions as they are
+		u = strings.TrimSuffix(rnr.endpoint.String(), "/") + r.path
+	} else {
+		m, err := mergeURL(rnr.endpoint, r.path)
+		if err != nil {
+			return nil, err
+		}
+		u = m.String()
+	}
+	args := []string{"curl"}
+	if r.method != http.MethodGet {
+		args = append(args, "-X", r.method)
+	}
+	args = append(args, u)
+
+	keys := make([]string, 0, len(r.headers))
+	for k := range r.headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range r.headers[k] {
+			args = append(args, "-H", fmt.Sprintf("%s: %s", k, v))
+		}
+	}
+	if r.body == nil {
+		return args, nil
+	}
+
+	if r.isMultipartFormDataMediaType() {
+		fields, err := r.multipartFields()
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range fields {
+			if f.file {
+				args = append(args, "-F", fmt.Sprintf("%s=@%s", f.key, f.value))
+				continue
+			}
+			args = append(args, "-F", fmt.Sprintf("%s=%s", f.key, f.value))
+		}
+		return args, nil
+	}
+	if r.mediaType != "" && r.headers.Get("Content-Type") == "" {
+		args = append(args, "-H", fmt.Sprintf("Content-Type: %s", r.mediaType))
+	}
+	if m, ok := r.body.(map[string]any); ok && r.mediaType == MediaTypeApplicationOctetStream {
+		if fn, ok := m["filename"].(string); ok {
+			return append(args, "--data-binary", "@"+filepath.Join(r.root, fn)), nil
+		}
+	}
+	b, err := r.encodeBody()
+	if err != nil {
+		return nil, err
+	}
+	bb, err := io.ReadAll(b)
+	if err != nil {
+		return nil, err
+	}
+	return append(args, "--data-raw", string(bb)), nil
+}
+
+// multipartField - Field of the multipart/form-data body.
+type multipartField struct {
+	key   string
+	value string
+	// file - The value is the path of the file
+	file bool
+}
+
+// multipartFields returns the fields of the multipart/form-data body.
+// The values that are the paths of the existing files are the files in the same way as the HTTP runner.
+func (r *httpRequest) multipartFields() ([]*multipartField, error) {
+	var kvs [][2]any
+	switch v := r.body.(type) {
+	case []any:
+		for _, vv := range v {
+			m, ok := vv.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid body: %v", r.body)
+			}
+			for k, vvv := range m {
+				kvs = append(kvs, [2]any{k, vvv})
+			}
+		}
+	case map[string]any:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if vs, ok := v[k].([]any); ok {
+				for _, vv := range vs {
+					kvs = append(kvs, [2]any{k, vv})
+				}
+				continue
+			}
+			kvs = append(kvs, [2]any{k, v[k]})
+		}
+	default:
+		return nil, fmt.Errorf("invalid body: %v", r.body)
+	}
+	var fields []*multipartField
+	for _, kv := range kvs {
+		k, v := kv[0].(string), fmt.Sprintf("%v", kv[1])
+		p := filepath.Join(r.root, v)
+		if fi, err := os.Stat(p); err == nil && !fi.IsDir() {
+			fields = append(fields, &multipartField{key: k, value: p, file: true})
+			continue
+		}
+		fields = append(fields, &multipartField{key: k, value: v})
+	}
+	return fields, nil
+}
+
+// curlJoin joins the arguments quoting them with single quotes if needed.
+func curlJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		if curlSafeArgRe.MatchString(a) {
+			quoted[i] = a
+			continue
+		}
+		quoted[i] = "'" + strings.ReplaceAll(a, "'", `'\''`) + "'"
+	}
+	return strings.Join(quoted, " ")
+}
+
+// expandResolvable expands the expressions that can be evaluated with the store.
+// The expressions that cannot be evaluated ( or are evaluated as nil ) remain as they are.
+func expandResolvable(in any, store map[string]any) any {
+	switch v := in.(type) {
+	case map[string]any:
+		m := map[string]any{}
+		for k, vv := range v {
+			m[fmt.Sprintf("%v", expandResolvable(k, store))] = expandResolvable(vv, store)
+		}
+		return m
+	case []any:
+		s := make([]any, len(v))
+		for i, vv := range v {
+			s[i] = expandResolvable(vv, store)
+		}
+		return s
+	case string:
+		if !strings.Contains(v, delimStart) {
+			return v
+		}
+		if loc := varRep.FindStringIndex(v); loc != nil && loc[0] == 0 && loc[1] == len(v) {
+			// Single value keeps the type ( e.g. {{ vars.id }} -> 1 )
+			if e, err := EvalExpand(v, store); err == nil && e != nil {
+				return e
+			}
+			return v
+		}
+		return varRep.ReplaceAllStringFunc(v, func(t string) string {
+			e, err := EvalExpand(t, store)
+			if err != nil || e == nil {
+				return t
+			}
+			return fmt.Sprintf("%v", e)
+		})
+	default:
+		return v
+	}
+}
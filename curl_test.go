@@ -0,0 +1,67 @@
+package runn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestCurlCommands(t *testing.T) {
+	tests := []struct {
+		resolve bool
+		want    []string
+	}{
+		{
+			false,
+			[]string{
+				`curl -X POST https://example.com/api/login -H 'Content-Type: application/json' --data-raw '{"password":"passw0rd","username":"{{ vars.username }}"}'`,
+				`curl 'https://example.com/api/users/{{ vars.userID }}?verbose=true' -H 'Authorization: Bearer {{ steps.login.res.body.token }}'`,
+				`curl -X POST https://example.com/api/upload -F file=@testdata/dummy.png -F 'name={{ vars.username }}'`,
+			},
+		},
+		{
+			true,
+			[]string{
+				`curl -X POST https://example.com/api/login -H 'Content-Type: application/json' --data-raw '{"password":"passw0rd","username":"alice"}'`,
+				`curl 'https://example.com/api/users/1?verbose=true' -H 'Authorization: Bearer {{ steps.login.res.body.token }}'`,
+				`curl -X POST https://example.com/api/upload -F file=@testdata/dummy.png -F name=alice`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		o, err := New(Book("testdata/book/curl_export.yml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		cmds, err := o.curlCommands(tt.resolve)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []string
+		for _, c := range cmds {
+			got = append(got, c.Command)
+		}
+		if diff := cmp.Diff(tt.want, got); diff != "" {
+			t.Error(diff)
+		}
+		if want := "steps.getUser"; cmds[1].Step != want {
+			t.Errorf("got %v want %v", cmds[1].Step, want)
+		}
+	}
+}
+
+func TestCurlJoin(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want string
+	}{
+		{[]string{"curl", "https://example.com/path"}, "curl https://example.com/path"},
+		{[]string{"curl", "-H", "X-Token: abc"}, "curl -H 'X-Token: abc'"},
+		{[]string{"curl", "--data-raw", "it's"}, `curl --data-raw 'it'\''s'`},
+	}
+	for _, tt := range tests {
+		if got := curlJoin(tt.in); got != tt.want {
+			t.Errorf("got %v want %v", got, tt.want)
+		}
+	}
+}
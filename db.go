@@ -1,23 +1,34 @@
 package runn
 
 import (
+	"bytes"
 	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
 	"database/sql"
+	"encoding/csv"
+	"encoding/hex"
 	"errors"
 	"fmt"
+	"net/url"
 	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 	"sync/atomic"
+	"time"
 	"unsafe"
 
 	"github.com/araddon/dateparse"
-	_ "github.com/go-sql-driver/mysql"
+	"github.com/go-sql-driver/mysql"
 	"github.com/goccy/go-json"
 	"github.com/golang-sql/sqlexp"
 	"github.com/golang-sql/sqlexp/nest"
 	_ "github.com/googleapis/go-sql-spanner"
 	_ "github.com/lib/pq"
+	"github.com/rs/xid"
 	"github.com/xo/dburl"
 	"modernc.org/sqlite"
 )
@@ -26,8 +37,13 @@ const (
 	dbStoreLastInsertIDKey = "last_insert_id"
 	dbStoreRowsAffectedKey = "rows_affected"
 	dbStoreRowsKey         = "rows"
+	dbStoreCountKey        = "count"
+	dbStoreChecksumKey     = "checksum"
+	dbStoreElapsedMsKey    = "elapsed_ms"
 )
 
+const dbStreamRowKey = "row"
+
 type Querier interface {
 	sqlexp.Querier
 }
@@ -43,11 +59,64 @@ type dbRunner struct {
 	client    TxQuerier
 	hostRules hostRules
 	trace     *bool
+	// memoryNS - Namespace to isolate named SQLite in-memory databases for each runner
+	memoryNS string
+	pool     *dbConnPool
+	// snapshot - Take the snapshot of the database on the first connection, and restore it on subsequent connections
+	snapshot bool
+	// snapshotDSN - DSN of the database locked by the snapshot while connected
+	snapshotDSN string
+	tls         *dbTLS
+	// mu - Guard the lazy connection shared by the steps run concurrently ( e.g. parallel: )
+	mu sync.Mutex
+}
+
+// dbTLS - TLS settings of the connection that do not fit in the DSN.
+type dbTLS struct {
+	// sslMode - disable, require, verify-ca or verify-full
+	sslMode string
+	cacert  string
+	cert    string
+	key     string
+	// mysqlTLSNames - Names of *tls.Config registered for MySQL by host. Each config is registered only once per runner
+	mysqlTLSNames map[string]string
+	mu            sync.Mutex
+}
+
+// dbConnPool - Settings of the connection pool of *sql.DB.
+type dbConnPool struct {
+	maxOpenConns    *int
+	maxIdleConns    *int
+	connMaxLifetime *time.Duration
 }
 
 type dbQuery struct {
-	stmt  string
-	trace *bool
+	stmt   string
+	insert *dbInsert
+	stream *dbStream
+	expect *dbExpect
+	trace  *bool
+	// timeout - timeout of each statement
+	timeout time.Duration
+}
+
+// dbExpect - Expected table of the selected rows.
+type dbExpect struct {
+	rows []map[string]any
+	// path - path of the CSV file of the expected table
+	path string
+}
+
+// dbInsert - Bulk insert rows into the table.
+type dbInsert struct {
+	table string
+	rows  []map[string]any
+}
+
+// dbStream - Consume the rows of SELECT one by one instead of recording all rows.
+type dbStream struct {
+	// each - condition evaluated for each row
+	each string
 }
 
 type DBResponse struct {
@@ -58,9 +127,10 @@ type DBResponse struct {
 }
 
 func newDBRunner(name, dsn string) (*dbRunner, error) {
-	_, err := dburl.Parse(dsn)
-	if err != nil {
-		return nil, err
+	if !strings.HasPrefix(dsn, duckdbDSNPrefix) {
+		if _, err := dburl.Parse(dsn); err != nil {
+			return nil, err
+		}
 	}
 	return &dbRunner{
 		name: name,
@@ -71,6 +141,36 @@ func newDBRunner(name, dsn string) (*dbRunner, error) {
 var dsnRep = strings.NewReplacer("sqlite://", "moderncsqlite://", "sqlite3://", "moderncsqlite://", "sq://", "moderncsqlite://")
 var spannerInvalidatonKeyCounter uint64 = 0
 
+// duckdbDSNPrefix - Prefix of the DSN for DuckDB. `duckdb://` means in-memory database.
+const duckdbDSNPrefix = "duckdb://"
+
+// sqliteMemoryDSNPrefix - Prefix of the internal DSN for named SQLite in-memory databases.
+const sqliteMemoryDSNPrefix = "runn+sqlitememory://"
+
+// sqliteMemoryDSN converts `sqlite://:memory:?name=x` to the DSN of the shared cache in-memory database named with ns.
+// Steps share the database through the shared cache, and runners with different ns do not.
+func sqliteMemoryDSN(dsn, ns string) (string, bool) {
+	u, err := dburl.Parse(dsn)
+	if err != nil {
+		return "", false
+	}
+	if u.Driver != "sqlite3" || u.Opaque != ":memory:" {
+		return "", false
+	}
+	name := u.Query().Get("name")
+	if name == "" {
+		return "", false
+	}
+	return fmt.Sprintf("%sfile:%s-%s?mode=memory&cache=shared", sqliteMemoryDSNPrefix, url.PathEscape(name), ns), true
+}
+
+func sqliteDriverName() string {
+	if contains(sql.Drivers(), "sqlite3") { // sqlite3 => github.com/mattn/go-sqlite3
+		return "sqlite3"
+	}
+	return "moderncsqlite"
+}
+
 func normalizeDSN(dsn string) string {
 	if !contains(sql.Drivers(), "sqlite3") { // sqlite3 => github.com/mattn/go-sqlite3
 		return dsnRep.Replace(dsn)
@@ -99,9 +199,15 @@ func (rnr *dbRunner) Run(ctx context.Context, s *step) error {
 }
 
 func (rnr *dbRunner) Close() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
 	if rnr.client == nil {
 		return nil
 	}
+	if rnr.snapshotDSN != "" {
+		dbSnapshots.release(rnr.snapshotDSN)
+		rnr.snapshotDSN = ""
+	}
 	if ndb, ok := rnr.client.(*nest.DB); ok {
 		if db := ndb.DB(); db != nil {
 			rnr.client = nil
@@ -121,21 +227,77 @@ func (rnr *dbRunner) Renew() error {
 	return nil
 }
 
+// connectionDSN returns the DSN to connect with the host rules and the TLS settings applied.
+func (rnr *dbRunner) connectionDSN() (string, error) {
+	if len(rnr.hostRules) > 0 {
+		rnr.dsn = rnr.hostRules.replaceDSN(rnr.dsn)
+	}
+	dsn := rnr.dsn
+	if rnr.memoryNS == "" {
+		rnr.memoryNS = xid.New().String()
+	}
+	if m, ok := sqliteMemoryDSN(dsn, rnr.memoryNS); ok {
+		dsn = m
+	}
+	if rnr.tls != nil {
+		return rnr.tls.apply(dsn)
+	}
+	return dsn, nil
+}
+
+// connect connects to the database only once, and returns the client.
+func (rnr *dbRunner) connect(ctx context.Context) (TxQuerier, error) {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.client != nil {
+		return rnr.client, nil
+	}
+	dsn, err := rnr.connectionDSN()
+	if err != nil {
+		return nil, err
+	}
+	if rnr.snapshot {
+		if err := dbSnapshots.takeOrRestore(ctx, dsn); err != nil {
+			return nil, err
+		}
+	}
+	nx, err := connectDB(dsn, rnr.pool)
+	if err != nil {
+		if rnr.snapshot {
+			dbSnapshots.release(dsn)
+		}
+		return nil, err
+	}
+	rnr.client = nx
+	if rnr.snapshot {
+		rnr.snapshotDSN = dsn
+	}
+	return nx, nil
+}
+
 func (rnr *dbRunner) run(ctx context.Context, q *dbQuery, s *step) error {
 	o := s.parent
-	if rnr.client == nil {
-		if len(rnr.hostRules) > 0 {
-			rnr.dsn = rnr.hostRules.replaceDSN(rnr.dsn)
-		}
-		nx, err := connectDB(rnr.dsn)
+	client, err := rnr.connect(ctx)
+	if err != nil {
+		return err
+	}
+	var (
+		stmts []string
+		args  [][]any
+	)
+	if q.insert != nil {
+		stmts, args, err = q.insert.build(rnr.dsn)
 		if err != nil {
 			return err
 		}
-		rnr.client = nx
+	} else {
+		stmts = separateStmt(q.stmt)
 	}
-	stmts := separateStmt(q.stmt)
 	out := map[string]any{}
-	tx, err := rnr.client.BeginTx(ctx, &sql.TxOptions{})
+	if q.insert != nil {
+		out[string(dbStoreRowsAffectedKey)] = int64(0)
+	}
+	tx, err := client.BeginTx(ctx, &sql.TxOptions{})
 	if err != nil {
 		return err
 	}
@@ -150,18 +312,40 @@ func (rnr *dbRunner) run(ctx context.Context, q *dbQuery, s *step) error {
 	if err != nil {
 		return err
 	}
-	for _, stmt := range stmts {
+	start := time.Now()
+	var affected int64
+	for i, stmt := range stmts {
 		stmt = stmt + tc // add trace comment
 		o.capturers.captureDBStatement(rnr.name, stmt)
-		err := func() error {
+		err := func() (err error) {
+			ctx := ctx
+			if q.timeout > 0 {
+				var cancel context.CancelFunc
+				ctx, cancel = context.WithTimeout(ctx, q.timeout)
+				defer cancel()
+				defer func() {
+					if err != nil && errors.Is(ctx.Err(), context.DeadlineExceeded) {
+						err = newDBTimeoutError(q.timeout, err)
+					}
+				}()
+			}
 			if !strings.HasPrefix(strings.ToUpper(stmt), "SELECT") {
 				// exec
-				r, err := tx.ExecContext(ctx, stmt)
+				var bind []any
+				if args != nil {
+					bind = args[i]
+				}
+				r, err := tx.ExecContext(ctx, stmt, bind...)
 				if err != nil {
 					return err
 				}
 				id, _ := r.LastInsertId()
 				a, _ := r.RowsAffected()
+				if q.insert != nil {
+					// rows_affected of bulk insert is the total of all batches
+					affected += a
+					a = affected
+				}
 				out = map[string]any{
 					string(dbStoreLastInsertIDKey): id,
 					string(dbStoreRowsAffectedKey): a,
@@ -176,7 +360,6 @@ func (rnr *dbRunner) run(ctx context.Context, q *dbQuery, s *step) error {
 			}
 
 			// query
-			var rows []map[string]any
 			r, err := tx.QueryContext(ctx, stmt)
 			if err != nil {
 				return err
@@ -191,67 +374,19 @@ func (rnr *dbRunner) run(ctx context.Context, q *dbQuery, s *step) error {
 			if err != nil {
 				return err
 			}
+
+			if q.stream != nil {
+				// stream
+				out, err = q.stream.consume(r, columns, types, s)
+				return err
+			}
+
+			var rows []map[string]any
 			for r.Next() {
-				row := map[string]any{}
-				vals := make([]any, len(columns))
-				valsp := make([]any, len(columns))
-				for i := range columns {
-					valsp[i] = &vals[i]
-				}
-				if err := r.Scan(valsp...); err != nil {
+				row, err := scanRow(r, columns, types)
+				if err != nil {
 					return err
 				}
-				for i, c := range columns {
-					t := strings.ToUpper(types[i].DatabaseTypeName())
-					switch v := vals[i].(type) {
-					case []byte:
-						s := string(v)
-						switch {
-						case strings.Contains(t, "TEXT") || strings.Contains(t, "CHAR") || t == "TIME": // MySQL8: ENUM = CHAR
-							row[c] = s
-						case t == "DECIMAL" || t == "FLOAT" || t == "DOUBLE": // MySQL: NUMERIC = DECIMAL
-							num, err := strconv.ParseFloat(s, 64) //nostyle:repetition
-							if err != nil {
-								return fmt.Errorf("invalid column: evaluated %s, but got %s(%v): %w", c, t, s, err)
-							}
-							row[c] = num
-						case t == "DATE" || t == "TIMESTAMP" || t == "DATETIME": // MySQL(SSH port fowarding)
-							d, err := dateparse.ParseStrict(s)
-							if err != nil {
-								return fmt.Errorf("invalid column: evaluated %s, but got %s(%v): %w", c, t, s, err)
-							}
-							row[c] = d
-						case t == "JSONB": // PostgreSQL JSONB
-							var jsonColumn map[string]any
-							err = json.Unmarshal(v, &jsonColumn)
-							if err != nil {
-								return fmt.Errorf("invalid column: evaluated %s, but got %s(%v): %w", c, t, s, err)
-							}
-							row[c] = jsonColumn
-						default: // MySQL: BOOLEAN = TINYINT
-							num, err := strconv.Atoi(s) //nostyle:repetition
-							if err != nil {
-								return fmt.Errorf("invalid column: evaluated %s, but got %s(%v): %w", c, t, s, err)
-							}
-							row[c] = num
-						}
-					case string:
-						switch {
-						case t == "JSON": // Sqlite JSON
-							var jsonColumn map[string]any
-							err = json.Unmarshal([]byte(v), &jsonColumn)
-							if err != nil {
-								return fmt.Errorf("invalid column: evaluated %s, but got %s(%v): %w", c, t, v, err)
-							}
-							row[c] = jsonColumn
-						default:
-							row[c] = v
-						}
-					default:
-						// MySQL8: DATE, TIMESTAMP, DATETIME
-						row[c] = v
-					}
-				}
 				rows = append(rows, row)
 			}
 			if err := r.Err(); err != nil {
@@ -264,13 +399,14 @@ func (rnr *dbRunner) run(ctx context.Context, q *dbQuery, s *step) error {
 			})
 
 			out = map[string]any{
-				string(dbStoreRowsKey): rows,
+				string(dbStoreRowsKey):  rows,
+				string(dbStoreCountKey): len(rows),
 			}
 			return nil
 		}()
 		if err != nil {
-			if err := tx.Rollback(); err != nil {
-				return err
+			if rerr := tx.Rollback(); rerr != nil {
+				return errors.Join(err, rerr)
 			}
 			return err
 		}
@@ -278,10 +414,320 @@ func (rnr *dbRunner) run(ctx context.Context, q *dbQuery, s *step) error {
 	if err := tx.Commit(); err != nil {
 		return err
 	}
+	out[string(dbStoreElapsedMsKey)] = float64(time.Since(start).Microseconds()) / 1000
 	o.record(out)
+	if q.expect != nil {
+		rows, _ := out[string(dbStoreRowsKey)].([]map[string]any)
+		diffs, err := q.expect.diff(rows, o.root)
+		if err != nil {
+			return err
+		}
+		if len(diffs) > 0 {
+			return fmt.Errorf("selected rows do not match the expected table:\n%s", strings.Join(diffs, "\n"))
+		}
+	}
 	return nil
 }
 
+// dbInsertMaxParams - Maximum number of parameters in a bulk insert statement ( SQLite: SQLITE_MAX_VARIABLE_NUMBER ).
+const dbInsertMaxParams = 32766
+
+// build builds the bulk insert statements and their arguments for the driver of dsn.
+// The columns are the union of keys of all rows, and missing values are inserted as NULL.
+func (in *dbInsert) build(dsn string) ([]string, [][]any, error) {
+	if len(in.rows) == 0 {
+		return nil, nil, nil
+	}
+	driver := "sqlite3"
+	if strings.HasPrefix(dsn, duckdbDSNPrefix) {
+		driver = "duckdb"
+	} else if u, err := dburl.Parse(dsn); err == nil {
+		driver = u.Driver
+	}
+	var columns []string
+	for _, row := range in.rows {
+		for k := range row {
+			if !contains(columns, k) {
+				columns = append(columns, k)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return nil, nil, fmt.Errorf("invalid insert rows: no columns: %v", in.rows)
+	}
+	sort.Strings(columns)
+	quoted := make([]string, len(columns))
+	for i, c := range columns {
+		quoted[i] = quoteDBIdent(driver, c)
+	}
+	batch := dbInsertMaxParams / len(columns)
+	var (
+		stmts []string
+		args  [][]any
+	)
+	for start := 0; start < len(in.rows); start += batch {
+		end := start + batch
+		if end > len(in.rows) {
+			end = len(in.rows)
+		}
+		var (
+			values []string
+			a      []any
+		)
+		for _, row := range in.rows[start:end] {
+			ph := make([]string, len(columns))
+			for i, c := range columns {
+				v, err := dbInsertValue(row[c])
+				if err != nil {
+					return nil, nil, fmt.Errorf("invalid value of %s: %w", c, err)
+				}
+				a = append(a, v)
+				if driver == "postgres" {
+					ph[i] = fmt.Sprintf("$%d", len(a))
+				} else {
+					ph[i] = "?"
+				}
+			}
+			values = append(values, fmt.Sprintf("(%s)", strings.Join(ph, ", ")))
+		}
+		stmts = append(stmts, fmt.Sprintf("INSERT INTO %s (%s) VALUES %s", quoteDBTable(driver, in.table), strings.Join(quoted, ", "), strings.Join(values, ", ")))
+		args = append(args, a)
+	}
+	return stmts, args, nil
+}
+
+// dbInsertValue converts maps and slices (e.g. JSON objects in HTTP responses) to JSON strings.
+func dbInsertValue(v any) (any, error) {
+	switch v.(type) {
+	case map[string]any, []any:
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return string(b), nil
+	default:
+		return v, nil
+	}
+}
+
+// quoteDBTable quotes each part of the table name qualified by the schema ( e.g. `public.users` ).
+func quoteDBTable(driver, s string) string {
+	parts := strings.Split(s, ".")
+	for i, p := range parts {
+		parts[i] = quoteDBIdent(driver, p)
+	}
+	return strings.Join(parts, ".")
+}
+
+func quoteDBIdent(driver, s string) string {
+	switch driver {
+	case "mysql", "spanner":
+		return "`" + strings.ReplaceAll(s, "`", "``") + "`"
+	default:
+		return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+	}
+}
+
+// load returns the columns and rows of the expected table.
+func (e *dbExpect) load(root string) ([]string, []map[string]any, error) {
+	if e.path == "" {
+		var columns []string
+		for _, row := range e.rows {
+			for k := range row {
+				if !contains(columns, k) {
+					columns = append(columns, k)
+				}
+			}
+		}
+		sort.Strings(columns)
+		return columns, e.rows, nil
+	}
+	b, err := readFile(fp(e.path, root))
+	if err != nil {
+		return nil, nil, err
+	}
+	records, err := csv.NewReader(bytes.NewReader(b)).ReadAll()
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid expected table %s: %w", e.path, err)
+	}
+	if len(records) == 0 {
+		return nil, nil, fmt.Errorf("invalid expected table %s: no header", e.path)
+	}
+	columns := records[0]
+	rows := []map[string]any{}
+	for _, r := range records[1:] {
+		row := map[string]any{}
+		for i, c := range columns {
+			row[c] = r[i]
+		}
+		rows = append(rows, row)
+	}
+	return columns, rows, nil
+}
+
+// diff returns the differences between got and the expected table for each cell.
+// The cells are compared in the string representation, so `NULL` in CSV matches NULL.
+func (e *dbExpect) diff(got []map[string]any, root string) ([]string, error) {
+	columns, want, err := e.load(root)
+	if err != nil {
+		return nil, err
+	}
+	var diffs []string
+	if len(got) != len(want) {
+		diffs = append(diffs, fmt.Sprintf("number of rows: got %d, want %d", len(got), len(want)))
+	}
+	for i := 0; i < len(got) && i < len(want); i++ {
+		for _, c := range columns {
+			w, ok := want[i][c]
+			if !ok {
+				continue
+			}
+			g, ok := got[i][c]
+			if !ok {
+				diffs = append(diffs, fmt.Sprintf("rows[%d].%s: got no column, want %q", i, c, dbCellString(w)))
+				continue
+			}
+			if gs, ws := dbCellString(g), dbCellString(w); gs != ws {
+				diffs = append(diffs, fmt.Sprintf("rows[%d].%s: got %q, want %q", i, c, gs, ws))
+			}
+		}
+	}
+	return diffs, nil
+}
+
+func dbCellString(v any) string {
+	switch vv := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return vv
+	case []byte:
+		return string(vv)
+	case time.Time:
+		return vv.Format(time.RFC3339Nano)
+	case float64:
+		return strconv.FormatFloat(vv, 'f', -1, 64)
+	case map[string]any, []any:
+		b, err := json.Marshal(vv)
+		if err != nil {
+			return fmt.Sprint(vv)
+		}
+		return string(b)
+	default:
+		return fmt.Sprint(vv)
+	}
+}
+
+// scanRow scans the current row of r and converts the values of the columns.
+func scanRow(r *sql.Rows, columns []string, types []*sql.ColumnType) (map[string]any, error) {
+	row := map[string]any{}
+	vals := make([]any, len(columns))
+	valsp := make([]any, len(columns))
+	for i := range columns {
+		valsp[i] = &vals[i]
+	}
+	if err := r.Scan(valsp...); err != nil {
+		return nil, err
+	}
+	for i, c := range columns {
+		t := strings.ToUpper(types[i].DatabaseTypeName())
+		switch v := vals[i].(type) {
+		case []byte:
+			s := string(v)
+			switch {
+			case strings.Contains(t, "TEXT") || strings.Contains(t, "CHAR") || t == "TIME": // MySQL8: ENUM = CHAR
+				row[c] = s
+			case t == "DECIMAL" || t == "FLOAT" || t == "DOUBLE": // MySQL: NUMERIC = DECIMAL
+				num, err := strconv.ParseFloat(s, 64) //nostyle:repetition
+				if err != nil {
+					return nil, fmt.Errorf("invalid column: evaluated %s, but got %s(%v): %w", c, t, s, err)
+				}
+				row[c] = num
+			case t == "DATE" || t == "TIMESTAMP" || t == "DATETIME": // MySQL(SSH port fowarding)
+				d, err := dateparse.ParseStrict(s)
+				if err != nil {
+					return nil, fmt.Errorf("invalid column: evaluated %s, but got %s(%v): %w", c, t, s, err)
+				}
+				row[c] = d
+			case t == "JSONB": // PostgreSQL JSONB
+				var jsonColumn map[string]any
+				if err := json.Unmarshal(v, &jsonColumn); err != nil {
+					return nil, fmt.Errorf("invalid column: evaluated %s, but got %s(%v): %w", c, t, s, err)
+				}
+				row[c] = jsonColumn
+			default: // MySQL: BOOLEAN = TINYINT
+				num, err := strconv.Atoi(s) //nostyle:repetition
+				if err != nil {
+					return nil, fmt.Errorf("invalid column: evaluated %s, but got %s(%v): %w", c, t, s, err)
+				}
+				row[c] = num
+			}
+		case string:
+			switch {
+			case t == "JSON": // Sqlite JSON
+				var jsonColumn map[string]any
+				if err := json.Unmarshal([]byte(v), &jsonColumn); err != nil {
+					return nil, fmt.Errorf("invalid column: evaluated %s, but got %s(%v): %w", c, t, v, err)
+				}
+				row[c] = jsonColumn
+			default:
+				row[c] = v
+			}
+		default:
+			// MySQL8: DATE, TIMESTAMP, DATETIME
+			row[c] = v
+		}
+	}
+	return row, nil
+}
+
+// consume reads rows one by one, evaluates `each:` per row and records aggregated values only.
+func (st *dbStream) consume(r *sql.Rows, columns []string, types []*sql.ColumnType, s *step) (map[string]any, error) {
+	o := s.parent
+	var store map[string]any
+	if st.each != "" {
+		store = o.store.toMap()
+		store[storeRootKeyIncluded] = o.included
+		store[storeRootPrevious] = o.store.latest()
+	}
+	h := sha256.New()
+	// count is int as well as the count of rows in the normal mode
+	var c int
+	for r.Next() {
+		row, err := scanRow(r, columns, types)
+		if err != nil {
+			return nil, err
+		}
+		b, err := json.Marshal(row)
+		if err != nil {
+			return nil, err
+		}
+		_, _ = h.Write(append(b, '\n'))
+		if st.each != "" {
+			store[dbStreamRowKey] = row
+			tf, err := EvalCond(st.each, store)
+			if err != nil {
+				return nil, err
+			}
+			if !tf {
+				tree, err := buildTree(st.each, store)
+				if err != nil {
+					return nil, err
+				}
+				return nil, fmt.Errorf("stream failed on rows[%d]: %w", c, newCondFalseError(st.each, tree))
+			}
+		}
+		c++
+	}
+	if err := r.Err(); err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		string(dbStoreCountKey):    c,
+		string(dbStoreChecksumKey): hex.EncodeToString(h.Sum(nil)),
+	}, nil
+}
+
 func (q *dbQuery) generateTraceStmtComment(s *step) (string, error) {
 	if q.trace == nil || !*q.trace {
 		return "", nil
@@ -297,22 +743,31 @@ func (q *dbQuery) generateTraceStmtComment(s *step) (string, error) {
 	return fmt.Sprintf(" /* %s */", string(tj)), nil
 }
 
-func connectDB(dsn string) (TxQuerier, error) {
+func connectDB(dsn string, pool *dbConnPool) (TxQuerier, error) {
 	var (
 		db  *sql.DB
 		err error
 	)
-	if strings.HasPrefix(dsn, "sp://") || strings.HasPrefix(dsn, "spanner://") {
+	switch {
+	case strings.HasPrefix(dsn, sqliteMemoryDSNPrefix):
+		db, err = sql.Open(sqliteDriverName(), strings.TrimPrefix(dsn, sqliteMemoryDSNPrefix))
+	case strings.HasPrefix(dsn, duckdbDSNPrefix):
+		if !contains(sql.Drivers(), "duckdb") {
+			return nil, errors.New("DuckDB driver is not registered. build runn with `-tags duckdb` or import github.com/marcboeker/go-duckdb") //nostyle:errorstrings
+		}
+		db, err = sql.Open("duckdb", strings.TrimPrefix(dsn, duckdbDSNPrefix))
+	case strings.HasPrefix(dsn, "sp://") || strings.HasPrefix(dsn, "spanner://"):
 		// NOTE: go-sql-spanner trys to reuse the connection internally when the same DSN is specified.
 		key := atomic.AddUint64(&spannerInvalidatonKeyCounter, 1)
 		d := strings.Split(strings.Split(dsn, "://")[1], "/")
 		db, err = sql.Open("spanner", fmt.Sprintf(`projects/%s/instances/%s/databases/%s;workaroundConnectionInvalidationKey=%d`, d[0], d[1], d[2], key))
-	} else {
+	default:
 		db, err = dburl.Open(normalizeDSN(dsn))
 	}
 	if err != nil {
 		return nil, err
 	}
+	pool.apply(db)
 	nx, err := nestTx(db)
 	if err != nil {
 		return nil, err
@@ -320,6 +775,129 @@ func connectDB(dsn string) (TxQuerier, error) {
 	return nx, nil
 }
 
+// apply returns dsn with the TLS settings.
+// PostgreSQL ( lib/pq ) reads certificates from the files, and MySQL uses *tls.Config registered with the generated name.
+func (t *dbTLS) apply(dsn string) (string, error) {
+	u, err := dburl.Parse(dsn)
+	if err != nil {
+		return "", err
+	}
+	q := u.Query()
+	switch u.Driver {
+	case "postgres":
+		if t.sslMode != "" {
+			q.Set("sslmode", t.sslMode)
+		}
+		if t.cacert != "" {
+			q.Set("sslrootcert", t.cacert)
+		}
+		if t.cert != "" {
+			q.Set("sslcert", t.cert)
+		}
+		if t.key != "" {
+			q.Set("sslkey", t.key)
+		}
+	case "mysql":
+		if t.sslMode == "disable" {
+			q.Set("tls", "false")
+			break
+		}
+		name, err := t.registerMySQLTLSConfig(u.Hostname())
+		if err != nil {
+			return "", err
+		}
+		q.Set("tls", name)
+	default:
+		return "", fmt.Errorf("TLS settings are not supported for the driver: %s", u.Driver) //nostyle:errorstrings
+	}
+	u.RawQuery = q.Encode()
+	return u.URL.String(), nil
+}
+
+// registerMySQLTLSConfig registers *tls.Config for the host to the MySQL driver only once, and returns the registered name.
+func (t *dbTLS) registerMySQLTLSConfig(host string) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if name, ok := t.mysqlTLSNames[host]; ok {
+		return name, nil
+	}
+	c, err := t.tlsConfig(host)
+	if err != nil {
+		return "", err
+	}
+	name := fmt.Sprintf("runn-%s", xid.New().String())
+	if err := mysql.RegisterTLSConfig(name, c); err != nil {
+		return "", err
+	}
+	if t.mysqlTLSNames == nil {
+		t.mysqlTLSNames = map[string]string{}
+	}
+	t.mysqlTLSNames[host] = name
+	return name, nil
+}
+
+func (t *dbTLS) tlsConfig(host string) (*tls.Config, error) {
+	c := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: host,
+	}
+	if t.cacert != "" {
+		b, err := readFile(t.cacert)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(b) {
+			return nil, fmt.Errorf("failed to append ca certs: %s", t.cacert)
+		}
+		c.RootCAs = pool
+	}
+	if t.cert != "" || t.key != "" {
+		cert, err := tls.LoadX509KeyPair(t.cert, t.key)
+		if err != nil {
+			return nil, err
+		}
+		c.Certificates = []tls.Certificate{cert}
+	}
+	switch t.sslMode {
+	case "require":
+		c.InsecureSkipVerify = true
+	case "verify-ca":
+		// Verify the certificate chain without the host name
+		c.InsecureSkipVerify = true
+		c.VerifyConnection = func(cs tls.ConnectionState) error {
+			opts := x509.VerifyOptions{
+				Roots:         c.RootCAs,
+				Intermediates: x509.NewCertPool(),
+			}
+			for _, cert := range cs.PeerCertificates[1:] {
+				opts.Intermediates.AddCert(cert)
+			}
+			_, err := cs.PeerCertificates[0].Verify(opts)
+			return err
+		}
+	case "", "verify-full":
+	default:
+		return nil, fmt.Errorf("invalid sslMode: %s", t.sslMode)
+	}
+	return c, nil
+}
+
+func (p *dbConnPool) apply(db *sql.DB) {
+	if p == nil {
+		return
+	}
+	if p.maxOpenConns != nil {
+		db.SetMaxOpenConns(*p.maxOpenConns)
+	}
+	if p.maxIdleConns != nil {
+		db.SetMaxIdleConns(*p.maxIdleConns)
+	}
+	if p.connMaxLifetime != nil {
+		db.SetConnMaxLifetime(*p.connMaxLifetime)
+	}
+}
+
 func nestTx(client Querier) (TxQuerier, error) {
 	switch c := client.(type) {
 	case *sql.DB:
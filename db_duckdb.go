@@ -0,0 +1,6 @@
+//go:build duckdb
+
+package runn
+
+// DuckDB driver requires cgo, so it is linked only when building with `-tags duckdb`.
+import _ "github.com/marcboeker/go-duckdb"
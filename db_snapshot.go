@@ -0,0 +1,193 @@
+package runn
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/xo/dburl"
+)
+
+const pgSnapshotDBSuffix = "_runn_snapshot"
+
+// dbSnapshots - Snapshots of databases taken by DB runners with `snapshot: true` in this process. The key is DSN.
+var dbSnapshots = &dbSnapshotStore{
+	snapshots: map[string]*dbSnapshot{},
+	connected: map[string]int{},
+}
+
+type dbSnapshotStore struct {
+	snapshots map[string]*dbSnapshot
+	// connected - Number of the DB runners connected to the database with the snapshot. The key is DSN
+	connected map[string]int
+	mu        sync.Mutex
+}
+
+type dbSnapshot struct {
+	// sqlite - content of the SQLite database file
+	sqlite []byte
+}
+
+// takeOrRestore takes the snapshot of the database on the first call, and restores the database from the snapshot on subsequent calls.
+// The database is locked exclusively by the DB runner until release is called,
+// because restoring the database terminates the sessions and replaces the files of the other DB runners.
+func (st *dbSnapshotStore) takeOrRestore(ctx context.Context, dsn string) error {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.connected[dsn] > 0 {
+		return fmt.Errorf("failed to take or restore snapshot of %s: the database is in use by another DB runner ( e.g. in concurrent runbooks )", dsn)
+	}
+	if err := st.takeOrRestoreLocked(ctx, dsn); err != nil {
+		return err
+	}
+	st.connected[dsn]++
+	return nil
+}
+
+// release releases the database locked by takeOrRestore.
+func (st *dbSnapshotStore) release(dsn string) {
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.connected[dsn] > 0 {
+		st.connected[dsn]--
+	}
+}
+
+func (st *dbSnapshotStore) takeOrRestoreLocked(ctx context.Context, dsn string) error {
+	u, err := dburl.Parse(dsn)
+	if err != nil {
+		return err
+	}
+	ss, ok := st.snapshots[dsn]
+	switch u.Driver {
+	case "sqlite3":
+		if !ok {
+			ss, err = takeSQLiteSnapshot(ctx, u)
+			if err != nil {
+				return fmt.Errorf("failed to take snapshot of %s: %w", dsn, err)
+			}
+			st.snapshots[dsn] = ss
+			return nil
+		}
+		if err := restoreSQLiteSnapshot(u, ss); err != nil {
+			return fmt.Errorf("failed to restore snapshot of %s: %w", dsn, err)
+		}
+	case "postgres":
+		if !ok {
+			if err := takePostgresSnapshot(ctx, u); err != nil {
+				return fmt.Errorf("failed to take snapshot of %s: %w", dsn, err)
+			}
+			st.snapshots[dsn] = &dbSnapshot{}
+			return nil
+		}
+		if err := restorePostgresSnapshot(ctx, u); err != nil {
+			return fmt.Errorf("failed to restore snapshot of %s: %w", dsn, err)
+		}
+	default:
+		return fmt.Errorf("snapshot is not supported for the driver: %s", u.Driver)
+	}
+	return nil
+}
+
+func sqliteDBPath(u *dburl.URL) (string, error) {
+	p := u.DSN
+	if i := strings.Index(p, "?"); i >= 0 {
+		p = p[:i]
+	}
+	p = strings.TrimPrefix(p, "file:")
+	if p == "" || strings.HasPrefix(p, ":memory:") {
+		return "", fmt.Errorf("snapshot is not supported for the in-memory database: %s", u.String())
+	}
+	return p, nil
+}
+
+func takeSQLiteSnapshot(ctx context.Context, u *dburl.URL) (*dbSnapshot, error) {
+	p, err := sqliteDBPath(u)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := os.MkdirTemp("", "runn")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	db, err := sql.Open(sqliteDriverName(), p)
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	tmp := filepath.Join(dir, "snapshot.db")
+	// VACUUM INTO writes a consistent copy of the database, including the contents of WAL.
+	if _, err := db.ExecContext(ctx, "VACUUM INTO ?", tmp); err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(tmp)
+	if err != nil {
+		return nil, err
+	}
+	return &dbSnapshot{sqlite: b}, nil
+}
+
+func restoreSQLiteSnapshot(u *dburl.URL, ss *dbSnapshot) error {
+	p, err := sqliteDBPath(u)
+	if err != nil {
+		return err
+	}
+	for _, suffix := range []string{"-wal", "-shm", "-journal"} {
+		if err := os.Remove(p + suffix); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.WriteFile(p, ss.sqlite, 0600)
+}
+
+func takePostgresSnapshot(ctx context.Context, u *dburl.URL) error {
+	name := strings.TrimPrefix(u.Path, "/")
+	snapshot := name + pgSnapshotDBSuffix
+	return execPostgresMaintenance(ctx, u, name,
+		fmt.Sprintf("DROP DATABASE IF EXISTS %s", quotePostgresIdent(snapshot)),
+		fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", quotePostgresIdent(snapshot), quotePostgresIdent(name)),
+	)
+}
+
+func restorePostgresSnapshot(ctx context.Context, u *dburl.URL) error {
+	name := strings.TrimPrefix(u.Path, "/")
+	snapshot := name + pgSnapshotDBSuffix
+	return execPostgresMaintenance(ctx, u, name,
+		fmt.Sprintf("DROP DATABASE IF EXISTS %s", quotePostgresIdent(name)),
+		fmt.Sprintf("CREATE DATABASE %s TEMPLATE %s", quotePostgresIdent(name), quotePostgresIdent(snapshot)),
+	)
+}
+
+// execPostgresMaintenance executes stmts on the `postgres` database after terminating the connections to the database name.
+// Both CREATE DATABASE ... TEMPLATE and DROP DATABASE require that no other sessions are connected.
+func execPostgresMaintenance(ctx context.Context, u *dburl.URL, name string, stmts ...string) error {
+	if name == "" {
+		return fmt.Errorf("database name is not specified: %s", u.String())
+	}
+	mu := u.URL
+	mu.Scheme = "postgres"
+	mu.Path = "/postgres"
+	mdb, err := sql.Open("postgres", mu.String())
+	if err != nil {
+		return err
+	}
+	defer mdb.Close()
+	if _, err := mdb.ExecContext(ctx, "SELECT pg_terminate_backend(pid) FROM pg_stat_activity WHERE datname = $1 AND pid <> pg_backend_pid()", name); err != nil {
+		return err
+	}
+	for _, stmt := range stmts {
+		if _, err := mdb.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func quotePostgresIdent(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, `""`) + `"`
+}
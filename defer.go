@@ -0,0 +1,43 @@
+package runn
+
+import (
+	"context"
+	"errors"
+)
+
+const deferSectionKey = "defer"
+
+// hasDeferred returns true if any step is deferred.
+func (o *operator) hasDeferred() bool {
+	for _, s := range o.steps {
+		if s.deferred {
+			return true
+		}
+	}
+	return false
+}
+
+// runDeferredStep runs the deferred step at the end of the runbook.
+// Like a deferred function call in Go, the values of the steps after the deferred step are not visible while running it.
+func (o *operator) runDeferredStep(ctx context.Context, i int, s *step) error {
+	restore := o.store.rewind(i)
+	defer restore()
+	err := o.runStep(ctx, i, s)
+	s.setResult(err)
+	switch {
+	case errors.Is(errStepSkiped, err):
+		o.recordNotRun(i)
+		return o.recordToLatest(storeStepKeyOutcome, resultSkipped)
+	case err != nil:
+		o.recordNotRun(i)
+		if rerr := o.recordToLatest(storeStepKeyOutcome, resultFailure); rerr != nil {
+			return errors.Join(err, rerr)
+		}
+		if s.force {
+			return nil
+		}
+		return err
+	default:
+		return o.recordToLatest(storeStepKeyOutcome, resultSuccess)
+	}
+}
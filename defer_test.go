@@ -0,0 +1,126 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestDefer(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/defer.yml"), Var("logFile", filepath.Join(t.TempDir(), "log.txt")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// Deferred steps run in the reverse order
+	want := "flush alice\ncleanup alice\n"
+	if got := o.store.stepMap["cleanup"]["stdout"]; got != want {
+		t.Errorf("got %q\nwant %q", got, want)
+	}
+	if got := o.store.stepMapKeys; fmt.Sprint(got) != "[create cleanup flush check]" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestDeferAfterFailure(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []map[string]any{
+		{"exec": map[string]any{"command": "printf alice"}},
+		{"defer": true, "exec": map[string]any{"command": "echo \"cleanup {{ steps[0].stdout }}\""}},
+		{"exec": map[string]any{"command": "exit 1"}, "test": "current.exit_code == 0"},
+		{"defer": true, "exec": map[string]any{"command": "echo flush"}},
+	}
+	for i, s := range steps {
+		if err := o.AppendStep(i, fmt.Sprintf("%d", i), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.Run(ctx); err == nil {
+		t.Error("want error")
+	}
+	if got := o.store.steps[1]["stdout"]; got != "cleanup alice\n" {
+		t.Errorf("got %v\nwant %v", got, "cleanup alice\n")
+	}
+	// The deferred step declared after the failed step also runs
+	want := []result{resultSuccess, resultSuccess, resultFailure, resultSuccess}
+	for i, w := range want {
+		if got := o.store.steps[i][storeStepKeyOutcome]; got != w {
+			t.Errorf("steps[%d]: got %v\nwant %v", i, got, w)
+		}
+	}
+}
+
+func TestDeferDeclaredAfterFailure(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []map[string]any{
+		{"exec": map[string]any{"command": "exit 1"}, "test": "current.exit_code == 0"},
+		{"exec": map[string]any{"command": "echo ng"}},
+		{"defer": true, "exec": map[string]any{"command": "echo cleanup"}},
+	}
+	for i, s := range steps {
+		if err := o.AppendStep(i, fmt.Sprintf("%d", i), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.Run(ctx); err == nil {
+		t.Error("want error")
+	}
+	if got := o.store.steps[2]["stdout"]; got != "cleanup\n" {
+		t.Errorf("got %v\nwant %v", got, "cleanup\n")
+	}
+	want := []result{resultFailure, resultSkipped, resultSuccess}
+	for i, w := range want {
+		if got := o.store.steps[i][storeStepKeyOutcome]; got != w {
+			t.Errorf("steps[%d]: got %v\nwant %v", i, got, w)
+		}
+	}
+}
+
+func TestDeferWithNeeds(t *testing.T) {
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.AppendStep(0, "0", map[string]any{"defer": true, "test": "true"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.AppendStep(1, "1", map[string]any{"needs": []any{}, "test": "true"}); err == nil {
+		t.Error("want error")
+	}
+}
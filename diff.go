@@ -0,0 +1,281 @@
+package runn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+
+	"github.com/expr-lang/expr"
+)
+
+// Kinds of the result files compared by DiffResults.
+const (
+	// ResultKindRun - Results of the runbooks printed by `runn run --format json`
+	ResultKindRun = "run"
+	// ResultKindSteps - Results of the steps dumped by `runn run --steps-out` ( or DumpSteps )
+	ResultKindSteps = "steps"
+)
+
+// Fields compared by DiffResults.
+const (
+	diffFieldResult  = "result"
+	diffFieldOutcome = storeStepKeyOutcome
+)
+
+// diffStatusFields - Fields of the statuses of the steps compared by default
+var diffStatusFields = []string{
+	"res." + httpStoreStatusKey,
+	execStoreExitCodeKey,
+}
+
+// ResultDiff is the difference between two results of the same runbooks ( e.g. runs against staging and production ).
+type ResultDiff struct {
+	// Runbook - Path ( or ID ) of the runbook
+	Runbook string `json:"runbook"`
+	// Step - Step of the runbook ( e.g. steps.login ). Empty for the result of the runbook itself
+	Step string `json:"step,omitempty"`
+	// Field - Compared field ( e.g. result, outcome, res.status, res.body.id )
+	Field string `json:"field"`
+	// A - Value of the first result ( nil if it does not exist )
+	A any `json:"a"`
+	// B - Value of the second result ( nil if it does not exist )
+	B any `json:"b"`
+}
+
+// diffTarget - Comparable values of the runbooks of a result file
+type diffTarget struct {
+	// books - Runbook paths ( or IDs ) in order
+	books []string
+	// steps - Steps of each runbook in order
+	steps map[string][]string
+	// values - Values of the fields of each step of each runbook
+	values map[string]map[string]map[string]any
+}
+
+// DiffResults compares two result files of the same runbooks and returns the differences
+// of the outcomes and the statuses of the steps.
+// The result files are the results printed by `runn run --format json` or the results of the steps dumped by `runn run --steps-out`.
+// fields are the expressions evaluated with each step result ( e.g. res.body.id ) to be compared additionally,
+// and they can be used only with the results dumped by `--steps-out`.
+func DiffResults(a, b []byte, fields ...string) ([]*ResultDiff, error) {
+	for _, f := range fields {
+		if _, err := expr.Compile(f); err != nil {
+			return nil, fmt.Errorf("invalid field %q: %w", f, err)
+		}
+	}
+	ka, err := resultKind(a)
+	if err != nil {
+		return nil, err
+	}
+	kb, err := resultKind(b)
+	if err != nil {
+		return nil, err
+	}
+	if ka != kb {
+		return nil, fmt.Errorf("cannot compare different kinds of results: %s and %s", ka, kb)
+	}
+	if ka == ResultKindRun && len(fields) > 0 {
+		return nil, errors.New("fields can be compared only in the results of the steps dumped by --steps-out")
+	}
+	ta, err := newDiffTarget(ka, a, fields)
+	if err != nil {
+		return nil, err
+	}
+	tb, err := newDiffTarget(kb, b, fields)
+	if err != nil {
+		return nil, err
+	}
+	return ta.diff(tb), nil
+}
+
+// resultKind detects the kind of the result file.
+func resultKind(b []byte) (string, error) {
+	m := map[string]any{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return "", fmt.Errorf("invalid result: %w", err)
+	}
+	if _, ok := m["results"]; ok {
+		return ResultKindRun, nil
+	}
+	for id, v := range m {
+		vv, ok := v.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("invalid result: %s", id)
+		}
+		if _, ok := vv[replayStepsKey]; !ok {
+			return "", fmt.Errorf("invalid result: %s does not have %q", id, replayStepsKey)
+		}
+	}
+	return ResultKindSteps, nil
+}
+
+func newDiffTarget(kind string, b []byte, fields []string) (*diffTarget, error) {
+	t := &diffTarget{
+		steps:  map[string][]string{},
+		values: map[string]map[string]map[string]any{},
+	}
+	switch kind {
+	case ResultKindRun:
+		s := runNResultSimplified{}
+		if err := json.Unmarshal(b, &s); err != nil {
+			return nil, fmt.Errorf("invalid result: %w", err)
+		}
+		for _, rr := range s.Results {
+			book := rr.Path
+			if book == "" {
+				book = rr.ID
+			}
+			t.add(book, "", diffFieldResult, string(rr.Result))
+			t.addStepResults(book, "", rr.Steps)
+		}
+	case ResultKindSteps:
+		m := map[string]map[string]any{}
+		if err := json.Unmarshal(b, &m); err != nil {
+			return nil, fmt.Errorf("invalid result: %w", err)
+		}
+		ids := make([]string, 0, len(m))
+		for id := range m {
+			ids = append(ids, id)
+		}
+		sort.Strings(ids)
+		for _, id := range ids {
+			var keys []string
+			values := map[string]any{}
+			switch steps := m[id][replayStepsKey].(type) {
+			case []any:
+				for i, v := range steps {
+					k := strconv.Itoa(i)
+					keys = append(keys, k)
+					values[k] = v
+				}
+			case map[string]any:
+				for k, v := range steps {
+					keys = append(keys, k)
+					values[k] = v
+				}
+				sort.Strings(keys)
+			default:
+				return nil, fmt.Errorf("invalid result: %s", id)
+			}
+			t.books = appendUniq(t.books, id)
+			for _, k := range keys {
+				sv, ok := values[k].(map[string]any)
+				if !ok {
+					// The step is not run
+					continue
+				}
+				step := fmt.Sprintf("%s.%s", replayStepsKey, k)
+				t.add(id, step, diffFieldOutcome, sv[diffFieldOutcome])
+				for _, f := range diffStatusFields {
+					t.add(id, step, f, diffEval(f, sv))
+				}
+				for _, f := range fields {
+					t.add(id, step, f, diffEval(f, sv))
+				}
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid kind of result: %s", kind)
+	}
+	return t, nil
+}
+
+func (t *diffTarget) addStepResults(book, prefix string, srs []*stepResultSimplified) {
+	for _, sr := range srs {
+		step := fmt.Sprintf("%s%s.%s", prefix, replayStepsKey, sr.Key)
+		t.add(book, step, diffFieldResult, string(sr.Result))
+		if sr.IncludedRunResult != nil {
+			t.addStepResults(book, step+" > ", sr.IncludedRunResult.Steps)
+		}
+	}
+}
+
+func (t *diffTarget) add(book, step, field string, v any) {
+	if _, ok := t.values[book]; !ok {
+		t.books = appendUniq(t.books, book)
+		t.values[book] = map[string]map[string]any{}
+	}
+	if _, ok := t.values[book][step]; !ok {
+		t.steps[book] = append(t.steps[book], step)
+		t.values[book][step] = map[string]any{}
+	}
+	t.values[book][step][field] = v
+}
+
+// diff returns the differences from the other result in the order of the runbooks and the steps of t ( and then other ).
+func (t *diffTarget) diff(other *diffTarget) []*ResultDiff {
+	var diffs []*ResultDiff
+	books := t.books
+	for _, book := range other.books {
+		books = appendUniq(books, book)
+	}
+	for _, book := range books {
+		steps := t.steps[book]
+		for _, step := range other.steps[book] {
+			steps = appendUniq(steps, step)
+		}
+		for _, step := range steps {
+			va := t.values[book][step]
+			vb := other.values[book][step]
+			var fs []string
+			for f := range va {
+				fs = append(fs, f)
+			}
+			for f := range vb {
+				fs = appendUniq(fs, f)
+			}
+			sort.Slice(fs, func(i, j int) bool {
+				return diffFieldOrder(fs[i]) < diffFieldOrder(fs[j]) ||
+					(diffFieldOrder(fs[i]) == diffFieldOrder(fs[j]) && fs[i] < fs[j])
+			})
+			for _, f := range fs {
+				if reflect.DeepEqual(va[f], vb[f]) {
+					continue
+				}
+				diffs = append(diffs, &ResultDiff{
+					Runbook: book,
+					Step:    step,
+					Field:   f,
+					A:       va[f],
+					B:       vb[f],
+				})
+			}
+		}
+	}
+	return diffs
+}
+
+// diffFieldOrder - The outcomes first, then the statuses and the selected fields
+func diffFieldOrder(f string) int {
+	switch f {
+	case diffFieldResult, diffFieldOutcome:
+		return 0
+	}
+	for _, sf := range diffStatusFields {
+		if f == sf {
+			return 1
+		}
+	}
+	return 2
+}
+
+// diffEval evaluates the field with the step result. It returns nil if the field does not exist in the step result.
+func diffEval(f string, sv map[string]any) any {
+	v, err := expr.Eval(f, sv)
+	if err != nil {
+		return nil
+	}
+	return v
+}
+
+func appendUniq(s []string, v string) []string {
+	for _, vv := range s {
+		if vv == v {
+			return s
+		}
+	}
+	return append(s, v)
+}
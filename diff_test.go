@@ -0,0 +1,142 @@
+package runn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDiffResults(t *testing.T) {
+	runA := `{
+  "total": 2, "success": 2, "failure": 0, "skipped": 0,
+  "results": [
+    {"id": "a", "path": "testdata/book/a.yml", "result": "success", "steps": [
+      {"id": "a?step=0", "key": "0", "result": "success"},
+      {"id": "a?step=1", "key": "1", "result": "success", "included_run_result": {"id": "c", "path": "testdata/book/c.yml", "result": "success", "steps": [
+        {"id": "c?step=0", "key": "0", "result": "success"}
+      ]}}
+    ]},
+    {"id": "b", "path": "testdata/book/b.yml", "result": "success", "steps": [
+      {"id": "b?step=0", "key": "login", "result": "success"}
+    ]}
+  ]
+}`
+	runB := `{
+  "total": 2, "success": 1, "failure": 1, "skipped": 0,
+  "results": [
+    {"id": "a", "path": "testdata/book/a.yml", "result": "failure", "steps": [
+      {"id": "a?step=0", "key": "0", "result": "success"},
+      {"id": "a?step=1", "key": "1", "result": "failure", "included_run_result": {"id": "c", "path": "testdata/book/c.yml", "result": "failure", "steps": [
+        {"id": "c?step=0", "key": "0", "result": "failure"}
+      ]}}
+    ]},
+    {"id": "d", "path": "testdata/book/d.yml", "result": "skipped", "steps": []}
+  ]
+}`
+	stepsA := `{
+  "a": {"steps": [
+    {"run": true, "outcome": "success", "res": {"status": 200, "body": {"id": 1, "name": "alice"}}},
+    {"run": true, "outcome": "success", "exit_code": 0, "stdout": "hello\n"},
+    null
+  ]},
+  "b": {"steps": {
+    "login": {"run": true, "outcome": "success", "res": {"status": 200, "body": {"token": "xxx"}}}
+  }}
+}`
+	stepsB := `{
+  "a": {"steps": [
+    {"run": true, "outcome": "failure", "res": {"status": 500, "body": {"error": "internal"}}},
+    {"run": true, "outcome": "success", "exit_code": 0, "stdout": "hello\n"},
+    null
+  ]},
+  "b": {"steps": {
+    "login": {"run": true, "outcome": "success", "res": {"status": 200, "body": {"token": "yyy"}}}
+  }}
+}`
+	tests := []struct {
+		name   string
+		a      string
+		b      string
+		fields []string
+		want   []*ResultDiff
+	}{
+		{
+			"results of runbooks",
+			runA,
+			runB,
+			nil,
+			[]*ResultDiff{
+				{Runbook: "testdata/book/a.yml", Field: "result", A: "success", B: "failure"},
+				{Runbook: "testdata/book/a.yml", Step: "steps.1", Field: "result", A: "success", B: "failure"},
+				{Runbook: "testdata/book/a.yml", Step: "steps.1 > steps.0", Field: "result", A: "success", B: "failure"},
+				{Runbook: "testdata/book/b.yml", Field: "result", A: "success", B: nil},
+				{Runbook: "testdata/book/b.yml", Step: "steps.login", Field: "result", A: "success", B: nil},
+				{Runbook: "testdata/book/d.yml", Field: "result", A: nil, B: "skipped"},
+			},
+		},
+		{
+			"same results",
+			runA,
+			runA,
+			nil,
+			nil,
+		},
+		{
+			"results of steps",
+			stepsA,
+			stepsB,
+			nil,
+			[]*ResultDiff{
+				{Runbook: "a", Step: "steps.0", Field: "outcome", A: "success", B: "failure"},
+				{Runbook: "a", Step: "steps.0", Field: "res.status", A: float64(200), B: float64(500)},
+			},
+		},
+		{
+			"results of steps with fields",
+			stepsA,
+			stepsB,
+			[]string{"res.body.id", "res.body.token", "stdout"},
+			[]*ResultDiff{
+				{Runbook: "a", Step: "steps.0", Field: "outcome", A: "success", B: "failure"},
+				{Runbook: "a", Step: "steps.0", Field: "res.status", A: float64(200), B: float64(500)},
+				{Runbook: "a", Step: "steps.0", Field: "res.body.id", A: float64(1), B: nil},
+				{Runbook: "b", Step: "steps.login", Field: "res.body.token", A: "xxx", B: "yyy"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffResults([]byte(tt.a), []byte(tt.b), tt.fields...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestDiffResultsError(t *testing.T) {
+	run := `{"total": 0, "results": []}`
+	steps := `{"a": {"steps": []}}`
+	tests := []struct {
+		name   string
+		a      string
+		b      string
+		fields []string
+	}{
+		{"different kinds", run, steps, nil},
+		{"fields of results of runbooks", run, run, []string{"res.body.id"}},
+		{"invalid field", steps, steps, []string{"res.body["}},
+		{"invalid json", "{", steps, nil},
+		{"unknown result", `{"a": {"foo": []}}`, steps, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := DiffResults([]byte(tt.a), []byte(tt.b), tt.fields...); err == nil {
+				t.Error("want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,268 @@
+package runn
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/golang-sql/sqlexp/nest"
+)
+
+// doctorTimeout - Timeout of each check of the runners
+const doctorTimeout = 10 * time.Second
+
+// Checks of the runners by Diagnose.
+const (
+	DoctorCheckTCP        = "tcp"
+	DoctorCheckTLS        = "tls"
+	DoctorCheckPing       = "ping"
+	DoctorCheckReflection = "reflection"
+	DoctorCheckProtos     = "protos"
+	DoctorCheckSSH        = "ssh"
+)
+
+// errDoctorNotChecked - The runner cannot be checked before running ( e.g. the in-process HTTP handler, CDP )
+var errDoctorNotChecked = errors.New("not checked")
+
+// RunnerDiagnosis is the result of the preflight check of the connectivity of the runner.
+type RunnerDiagnosis struct {
+	// BookPath - Path of the runbook
+	BookPath string
+	// RunnerKey - Key of the runner
+	RunnerKey string
+	// RunnerType - Type of the runner
+	RunnerType RunnerType
+	// Target - Endpoint of the runner ( the password is redacted )
+	Target string
+	// Check - What is checked ( e.g. tcp, tls, ping, reflection, ssh )
+	Check string
+	// Skipped - The runner is not checked
+	Skipped bool
+	// Err - Error of the check
+	Err error
+	// Elapsed - Elapsed time of the check
+	Elapsed time.Duration
+}
+
+// Diagnose checks the connectivity of the runners of the selected runbooks without running the steps.
+// It checks the TCP/TLS handshake of HTTP runners, the ping of DB runners, the reflection ( or the protos ) of gRPC runners,
+// and the connection and the authentication of SSH runners.
+func (ops *operators) Diagnose(ctx context.Context) ([]*RunnerDiagnosis, error) {
+	sops, err := ops.SelectedOperators()
+	if err != nil {
+		return nil, err
+	}
+	// Runners of the same target are checked only once
+	checked := map[string]*RunnerDiagnosis{}
+	var ds []*RunnerDiagnosis
+	for _, o := range sops {
+		ds = append(ds, o.diagnose(ctx, checked)...)
+	}
+	return ds, nil
+}
+
+func (o *operator) diagnose(ctx context.Context, checked map[string]*RunnerDiagnosis) []*RunnerDiagnosis {
+	type runner struct {
+		key    string
+		typ    RunnerType
+		target string
+		check  func(ctx context.Context) (string, error)
+	}
+	var rnrs []runner
+	for k, r := range o.httpRunners {
+		r := r
+		target := "(handler)"
+		if r.endpoint != nil {
+			target = r.endpoint.String()
+		}
+		rnrs = append(rnrs, runner{k, RunnerTypeHTTP, target, r.diagnose})
+	}
+	for k, r := range o.dbRunners {
+		r := r
+		rnrs = append(rnrs, runner{k, RunnerTypeDB, redactDSN(r.dsn), r.diagnose})
+	}
+	for k, r := range o.grpcRunners {
+		r := r
+		rnrs = append(rnrs, runner{k, RunnerTypeGRPC, r.target, r.diagnose})
+	}
+	for k, r := range o.sshRunners {
+		r := r
+		rnrs = append(rnrs, runner{k, RunnerTypeSSH, r.addr, r.diagnose})
+	}
+	for k := range o.cdpRunners {
+		rnrs = append(rnrs, runner{k, RunnerTypeCDP, "(chrome)", func(ctx context.Context) (string, error) {
+			return "", errDoctorNotChecked
+		}})
+	}
+	sort.SliceStable(rnrs, func(i, j int) bool {
+		return rnrs[i].key < rnrs[j].key
+	})
+	var ds []*RunnerDiagnosis
+	for _, r := range rnrs {
+		id := string(r.typ) + " " + r.target
+		d := &RunnerDiagnosis{
+			BookPath:   o.bookPath,
+			RunnerKey:  r.key,
+			RunnerType: r.typ,
+			Target:     r.target,
+		}
+		if c, ok := checked[id]; ok && !c.Skipped {
+			d.Check, d.Skipped, d.Err, d.Elapsed = c.Check, c.Skipped, c.Err, c.Elapsed
+			ds = append(ds, d)
+			continue
+		}
+		o.Debugf("Check the %s runner %q (%s)\n", r.typ, r.key, r.target)
+		start := time.Now()
+		cctx, cancel := context.WithTimeout(ctx, doctorTimeout)
+		d.Check, d.Err = r.check(cctx)
+		cancel()
+		d.Elapsed = time.Since(start)
+		if errors.Is(d.Err, errDoctorNotChecked) {
+			d.Skipped = true
+			d.Err = nil
+		}
+		checked[id] = d
+		ds = append(ds, d)
+	}
+	return ds
+}
+
+// diagnose checks the TCP ( and the TLS ) handshake with the endpoint.
+func (rnr *httpRunner) diagnose(ctx context.Context) (string, error) {
+	if rnr.endpoint == nil {
+		return "", errDoctorNotChecked
+	}
+	host := rnr.endpoint.Hostname()
+	port := rnr.endpoint.Port()
+	if port == "" {
+		port = "80"
+		if rnr.endpoint.Scheme == "https" {
+			port = "443"
+		}
+	}
+	addr := net.JoinHostPort(host, port)
+	dial := (&net.Dialer{}).DialContext
+	var tlsc *tls.Config
+	if t, ok := rnr.client.Transport.(*http.Transport); ok {
+		if t.DialContext != nil {
+			dial = t.DialContext
+		}
+		if t.TLSClientConfig != nil {
+			tlsc = t.TLSClientConfig.Clone()
+		}
+		if t.Proxy != nil {
+			if pu, err := t.Proxy(&http.Request{URL: rnr.endpoint}); err == nil && pu != nil {
+				// Only the connection to the proxy can be checked
+				conn, err := dial(ctx, "tcp", proxyAddr(pu))
+				if err != nil {
+					return DoctorCheckTCP, err
+				}
+				return DoctorCheckTCP, conn.Close()
+			}
+		}
+	}
+	conn, err := dial(ctx, "tcp", addr)
+	if err != nil {
+		return DoctorCheckTCP, err
+	}
+	defer conn.Close()
+	if rnr.endpoint.Scheme != "https" {
+		return DoctorCheckTCP, nil
+	}
+	if tlsc == nil {
+		tlsc = &tls.Config{MinVersion: tls.VersionTLS12}
+	}
+	if tlsc.ServerName == "" {
+		tlsc.ServerName = host
+	}
+	tc := tls.Client(conn, tlsc)
+	if err := tc.HandshakeContext(ctx); err != nil {
+		return DoctorCheckTLS, err
+	}
+	return DoctorCheckTLS, nil
+}
+
+func proxyAddr(u *url.URL) string {
+	if u.Port() != "" {
+		return u.Host
+	}
+	if u.Scheme == "https" {
+		return net.JoinHostPort(u.Hostname(), "443")
+	}
+	return net.JoinHostPort(u.Hostname(), "80")
+}
+
+// diagnose connects to the database and pings it.
+func (rnr *dbRunner) diagnose(ctx context.Context) (string, error) {
+	if rnr.client != nil {
+		// The client is given by the runn.DBRunner option
+		return DoctorCheckPing, pingDB(ctx, rnr.client)
+	}
+	dsn, err := rnr.connectionDSN()
+	if err != nil {
+		return DoctorCheckPing, err
+	}
+	client, err := connectDB(dsn, rnr.pool)
+	if err != nil {
+		return DoctorCheckPing, err
+	}
+	defer func() {
+		if ndb, ok := client.(*nest.DB); ok {
+			_ = ndb.DB().Close()
+		}
+	}()
+	return DoctorCheckPing, pingDB(ctx, client)
+}
+
+func pingDB(ctx context.Context, client TxQuerier) error {
+	p, ok := client.(interface {
+		PingContext(ctx context.Context) error
+	})
+	if !ok {
+		return errDoctorNotChecked
+	}
+	return p.PingContext(ctx)
+}
+
+// diagnose connects to the server and resolves the methods using the reflection ( or the protos ).
+func (rnr *grpcRunner) diagnose(ctx context.Context) (string, error) {
+	check := DoctorCheckReflection
+	if len(rnr.importPaths) > 0 || len(rnr.protos) > 0 {
+		check = DoctorCheckProtos
+	}
+	if err := rnr.connectAndResolve(ctx); err != nil {
+		return check, err
+	}
+	return check, nil
+}
+
+// diagnose connects to the host and authenticates.
+func (rnr *sshRunner) diagnose(ctx context.Context) (string, error) {
+	if rnr.client != nil {
+		// Already connected ( keepSession )
+		return DoctorCheckSSH, nil
+	}
+	client, err := connectSSH(rnr.addr, rnr.opts...)
+	if err != nil {
+		return DoctorCheckSSH, err
+	}
+	return DoctorCheckSSH, client.Close()
+}
+
+// redactDSN returns the DSN with the password redacted.
+func redactDSN(dsn string) string {
+	u, err := url.Parse(dsn)
+	if err != nil || u.Scheme == "" {
+		if i := strings.Index(dsn, "://"); i >= 0 {
+			return dsn[:i+3] + "..."
+		}
+		return "..."
+	}
+	return u.Redacted()
+}
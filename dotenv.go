@@ -0,0 +1,137 @@
+package runn
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+// loadEnvFiles loads the dotenv files into envs.
+// The environment variables already loaded are not overridden, so the earlier file takes precedence.
+func loadEnvFiles(envs map[string]string, root string, paths ...string) error {
+	for _, p := range paths {
+		b, err := readFile(fp(p, root))
+		if err != nil {
+			return fmt.Errorf("failed to load env file %s: %w", p, err)
+		}
+		b, err = decryptSOPSIfEncrypted(b, fp(p, root), sopsFormatDotenv)
+		if err != nil {
+			return fmt.Errorf("failed to load env file %s: %w", p, err)
+		}
+		kvs, err := parseDotenv(b)
+		if err != nil {
+			return fmt.Errorf("invalid env file %s: %w", p, err)
+		}
+		for _, kv := range kvs {
+			if _, ok := envs[kv[0]]; ok {
+				continue
+			}
+			envs[kv[0]] = kv[1]
+		}
+	}
+	return nil
+}
+
+// runbookEnvFiles returns the dotenv files of `envFiles:` in the runbook, which are loaded before expanding the environment variables.
+func runbookEnvFiles(b []byte) []string {
+	rb := struct {
+		EnvFiles []string `yaml:"envFiles,omitempty"`
+	}{}
+	if err := yaml.Unmarshal(b, &rb); err != nil {
+		// Errors are reported when parsing the runbook
+		return nil
+	}
+	return rb.EnvFiles
+}
+
+// layeredLookupEnv returns the function to look up the environment variables.
+// The environment variables of the process take precedence, and then the layers are looked up in order.
+// The values of the layers are not set to the environment variables of the process.
+func layeredLookupEnv(lookupEnv func(string) (string, bool), layers ...map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		if v, ok := lookupEnv(k); ok {
+			return v, ok
+		}
+		for _, l := range layers {
+			if v, ok := l[k]; ok {
+				return v, ok
+			}
+		}
+		return "", false
+	}
+}
+
+// lookupEnv looks up the environment variables of the process and then the ones loaded by EnvFile.
+func (bk *book) lookupEnv(k string) (string, bool) {
+	return layeredLookupEnv(os.LookupEnv, bk.dotenv)(k)
+}
+
+// parseDotenv parses the content of the dotenv file and returns the pairs of the key and the value in order.
+func parseDotenv(b []byte) ([][2]string, error) {
+	var envs [][2]string
+	s := bufio.NewScanner(bytes.NewReader(b))
+	n := 0
+	for s.Scan() {
+		n++
+		line := strings.TrimSpace(s.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		k, v, ok := strings.Cut(line, "=")
+		if !ok {
+			return nil, fmt.Errorf("line %d: %q", n, line)
+		}
+		k = strings.TrimSpace(k)
+		if k == "" || strings.ContainsAny(k, " \t") {
+			return nil, fmt.Errorf("line %d: invalid key: %q", n, k)
+		}
+		v = strings.TrimSpace(v)
+		switch {
+		case strings.HasPrefix(v, `"`):
+			i := closingQuote(v)
+			if i < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value: %s", n, v)
+			}
+			uq, err := strconv.Unquote(v[:i+1])
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w: %s", n, err, v)
+			}
+			v = uq
+		case strings.HasPrefix(v, "'"):
+			i := strings.Index(v[1:], "'")
+			if i < 0 {
+				return nil, fmt.Errorf("line %d: unterminated quoted value: %s", n, v)
+			}
+			v = v[1 : i+1]
+		default:
+			// Trailing comment
+			if i := strings.Index(v, " #"); i >= 0 {
+				v = strings.TrimSpace(v[:i])
+			}
+		}
+		envs = append(envs, [2]string{k, v})
+	}
+	if err := s.Err(); err != nil {
+		return nil, err
+	}
+	return envs, nil
+}
+
+// closingQuote returns the index of the closing double quote.
+func closingQuote(v string) int {
+	for i := 1; i < len(v); i++ {
+		switch v[i] {
+		case '\\':
+			i++
+		case '"':
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,145 @@
+package runn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseDotenv(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    [][2]string
+		wantErr bool
+	}{
+		{"", nil, false},
+		{"# comment\n\nFOO=bar\n", [][2]string{{"FOO", "bar"}}, false},
+		{"export FOO=bar # comment", [][2]string{{"FOO", "bar"}}, false},
+		{"FOO = bar\nBAR=", [][2]string{{"FOO", "bar"}, {"BAR", ""}}, false},
+		{`FOO="bar\nbaz" # comment`, [][2]string{{"FOO", "bar\nbaz"}}, false},
+		{`FOO="bar \"baz\""`, [][2]string{{"FOO", `bar "baz"`}}, false},
+		{`FOO='bar\n#baz'`, [][2]string{{"FOO", `bar\n#baz`}}, false},
+		{"FOO", nil, true},
+		{"FOO BAR=baz", nil, true},
+		{`FOO="bar`, nil, true},
+		{`FOO='bar`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseDotenv([]byte(tt.in))
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %s", tt.in)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestEnvFiles(t *testing.T) {
+	keys := []string{"RUNN_TEST_DOTENV_USER", "RUNN_TEST_DOTENV_TOKEN", "RUNN_TEST_DOTENV_QUOTED", "RUNN_TEST_DOTENV_EXTRA"}
+	unsetenv := func() {
+		for _, k := range keys {
+			_ = os.Unsetenv(k)
+		}
+	}
+	tests := []struct {
+		name      string
+		env       string
+		opts      []Option
+		wantUser  string
+		wantExtra string
+	}{
+		{"envFiles of runbook", "", nil, "alice", ""},
+		{"EnvFile takes precedence over envFiles", "", []Option{EnvFile("testdata/env/override.env")}, "bob", "extra"},
+		{"environment variable takes precedence", "carol", []Option{EnvFile("testdata/env/override.env")}, "carol", "extra"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			unsetenv()
+			t.Cleanup(unsetenv)
+			if tt.env != "" {
+				t.Setenv("RUNN_TEST_DOTENV_USER", tt.env)
+			}
+			opts := append(tt.opts, Book("testdata/book/dotenv.yml"))
+			o, err := New(opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := o.store.vars["user"]; got != tt.wantUser {
+				t.Errorf("got %v\nwant %v", got, tt.wantUser)
+			}
+			if got := o.dotenv["RUNN_TEST_DOTENV_EXTRA"]; got != tt.wantExtra {
+				t.Errorf("got %v\nwant %v", got, tt.wantExtra)
+			}
+			// The values of the dotenv files are not set to the environment variables of the process
+			for _, k := range keys {
+				if k == "RUNN_TEST_DOTENV_USER" && tt.env != "" {
+					continue
+				}
+				if v, ok := os.LookupEnv(k); ok {
+					t.Errorf("%s is set: %v", k, v)
+				}
+			}
+			if tt.wantUser != "alice" {
+				return
+			}
+			if err := o.Run(ctx); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
+
+func TestEnvFileIncluded(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	dir := t.TempDir()
+	files := map[string]string{
+		"test.env": "RUNN_TEST_DOTENV_NESTED=nested\n",
+		"parent.yml": `desc: Parent
+steps:
+  child:
+    include:
+      path: child.yml
+  check:
+    test: steps.child.name == "nested"
+`,
+		"child.yml": `desc: Child
+vars:
+  name: ${RUNN_TEST_DOTENV_NESTED}
+steps:
+  -
+    bind:
+      name: vars.name
+`,
+	}
+	for n, c := range files {
+		if err := os.WriteFile(filepath.Join(dir, n), []byte(c), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	o, err := New(Scopes(ScopeAllowReadParent), EnvFile(filepath.Join(dir, "test.env")), Book(filepath.Join(dir, "parent.yml")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Error(err)
+	}
+	if _, ok := os.LookupEnv("RUNN_TEST_DOTENV_NESTED"); ok {
+		t.Error("RUNN_TEST_DOTENV_NESTED should not be set")
+	}
+}
@@ -1,6 +1,9 @@
 package runn
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type BeforeFuncError struct{ err error }
 
@@ -21,3 +24,18 @@ func (e *AfterFuncError) Unwrap() error { return e.err }
 func newAfterFuncError(err error) *AfterFuncError {
 	return &AfterFuncError{err: err}
 }
+
+type DBTimeoutError struct {
+	timeout time.Duration
+	err     error
+}
+
+func (e *DBTimeoutError) Error() string {
+	return fmt.Errorf("db query timed out after %s: %w", e.timeout, e.err).Error()
+}
+
+func (e *DBTimeoutError) Unwrap() error { return e.err }
+
+func newDBTimeoutError(timeout time.Duration, err error) *DBTimeoutError {
+	return &DBTimeoutError{timeout: timeout, err: err}
+}
@@ -25,6 +25,9 @@ const (
 var alphaRe = regexp.MustCompile(`^[a-zA-Z][a-zA-Z0-9]*$`)
 
 func Eval(e string, store any) (any, error) {
+	if err := resolveLazyVars(e, store); err != nil {
+		return nil, fmt.Errorf("eval error: %w", err)
+	}
 	v, err := expr.Eval(trimComment(e), store)
 	if err != nil {
 		return nil, fmt.Errorf("eval error: %w", err)
@@ -108,6 +111,9 @@ func EvalExpand(in, store any) (any, error) {
 			// No need to expand
 			return in, nil
 		}
+		if err := resolveLazyVars(s, store); err != nil {
+			return nil, err
+		}
 		if !strings.Contains(s, ":") {
 			// Single value
 			repFn := expand.ExprRepFn(delimStart, delimEnd, store)
@@ -127,6 +133,11 @@ func EvalExpand(in, store any) (any, error) {
 	if err != nil {
 		return nil, err
 	}
+	if strings.Contains(string(b), delimStart) {
+		if err := resolveLazyVars(string(b), store); err != nil {
+			return nil, err
+		}
+	}
 	e, err := expand.ReplaceYAML(string(b), expand.ExprRepFn(delimStart, delimEnd, store), expand.ReplaceMapKey())
 	if err != nil {
 		return nil, err
@@ -0,0 +1,53 @@
+package runn
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/spf13/cast"
+)
+
+const eventuallySectionKey = "eventually"
+
+const defaultEventuallyTimeout = "30sec"
+
+// parseEventually parses `eventually:` as the retry of the step until the timeout elapses.
+// `eventually: 10sec` is the short syntax of `timeout:`. The other keys are same as `retry:`.
+func parseEventually(v any) (*retryConfig, error) {
+	var timeout any = defaultEventuallyTimeout
+	m := map[string]any{}
+	if vv, ok := v.(map[string]any); ok {
+		for k, vvv := range vv {
+			if k == "timeout" {
+				timeout = vvv
+				continue
+			}
+			m[k] = vvv
+		}
+	} else {
+		// short syntax
+		timeout = v
+	}
+	r, err := parseRetry(m, "")
+	if err != nil {
+		return nil, err
+	}
+	_, hasMax := m["max"]
+	_, hasCount := m["count"]
+	if !hasMax && !hasCount {
+		// Re-run until the timeout elapses
+		r.count = math.MaxInt
+	}
+	s, err := cast.ToStringE(timeout)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %v", timeout)
+	}
+	r.timeout, err = parseDuration(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timeout: %w", err)
+	}
+	if r.timeout <= 0 {
+		return nil, fmt.Errorf("invalid timeout: %v", timeout)
+	}
+	return r, nil
+}
@@ -0,0 +1,149 @@
+package runn
+
+import (
+	"context"
+	"math"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStepEventually(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/eventually.yml"), Var("countFile", filepath.Join(t.TempDir(), "count.txt")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := 2; len(o.store.steps) != want {
+		t.Errorf("got %v\nwant %v", len(o.store.steps), want)
+	}
+}
+
+func TestStepEventuallyFailure(t *testing.T) {
+	tests := []struct {
+		name string
+		step map[string]any
+		want string
+	}{
+		{
+			"timeout",
+			map[string]any{
+				"eventually": map[string]any{"timeout": "50ms", "interval": "10ms"},
+				"test":       "false",
+			},
+			"eventually failed on \"\".steps[0].eventually (timeout: 50ms, interval: 10ms",
+		},
+		{
+			"interval longer than timeout",
+			map[string]any{
+				"eventually": map[string]any{"timeout": "10ms", "interval": "1sec"},
+				"test":       "false",
+			},
+			"attempts: 1)",
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.AppendStep(0, "", tt.step); err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if err == nil {
+				t.Fatal("want error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v\nwant %v", err, tt.want)
+			}
+			if len(o.store.steps) != 1 {
+				t.Errorf("got %v\nwant %v", len(o.store.steps), 1)
+			}
+		})
+	}
+}
+
+func TestStepEventuallyDeadline(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.AppendStep(0, "", map[string]any{
+		"eventually": map[string]any{"timeout": "200ms", "interval": "10ms"},
+		"exec":       map[string]any{"command": "sleep 5"},
+		"test":       "current.exit_code == 0",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	err = o.Run(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "eventually failed") {
+		t.Errorf("got %v\nwant eventually failed error", err)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("the running step should be canceled at the deadline: %v", elapsed)
+	}
+}
+
+func TestParseEventually(t *testing.T) {
+	tests := []struct {
+		in           any
+		wantTimeout  time.Duration
+		wantInterval time.Duration
+		wantCount    int
+		wantErr      bool
+	}{
+		{"10sec", 10 * time.Second, time.Second, math.MaxInt, false},
+		{5, 5 * time.Second, time.Second, math.MaxInt, false},
+		{map[string]any{}, 30 * time.Second, time.Second, math.MaxInt, false},
+		{map[string]any{"timeout": "1min", "interval": "500ms"}, time.Minute, 500 * time.Millisecond, math.MaxInt, false},
+		{map[string]any{"timeout": "1min", "count": 3}, time.Minute, time.Second, 3, false},
+		{map[string]any{"timeout": "invalid"}, 0, 0, 0, true},
+		{map[string]any{"interval": "invalid"}, 0, 0, 0, true},
+		{map[string]any{"timeout": "1min", "max": 3}, time.Minute, time.Second, 3, false},
+		{map[string]any{"max": 3, "count": 3}, 0, 0, 0, true},
+		{0, 0, 0, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseEventually(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: got %v\nwantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.timeout != tt.wantTimeout {
+			t.Errorf("got %v\nwant %v", got.timeout, tt.wantTimeout)
+		}
+		if got.interval != tt.wantInterval {
+			t.Errorf("got %v\nwant %v", got.interval, tt.wantInterval)
+		}
+		if got.count != tt.wantCount {
+			t.Errorf("got %v\nwant %v", got.count, tt.wantCount)
+		}
+	}
+}
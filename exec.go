@@ -5,10 +5,18 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"os"
+	osexec "os/exec"
+	"path/filepath"
+	"sort"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
 	"github.com/cli/safeexec"
 	"github.com/k1LoW/exec"
+	"github.com/kballard/go-shellquote"
 )
 
 const execRunnerKey = "exec"
@@ -17,16 +25,120 @@ const (
 	execStoreStdoutKey   = "stdout"
 	execStoreStderrKey   = "stderr"
 	execStoreExitCodeKey = "exit_code"
+	execStorePIDKey      = "pid"
 )
 
 const execDefaultShell = "sh"
 
-type execRunner struct{}
+// execDefaultOSKey - Key of the command used when there is no command for the platform.
+const execDefaultOSKey = "default"
+
+// execKnownOS - Values of GOOS available as the keys of the commands for each platform.
+var execKnownOS = map[string]bool{
+	"aix":       true,
+	"android":   true,
+	"darwin":    true,
+	"dragonfly": true,
+	"freebsd":   true,
+	"illumos":   true,
+	"ios":       true,
+	"js":        true,
+	"linux":     true,
+	"netbsd":    true,
+	"openbsd":   true,
+	"plan9":     true,
+	"solaris":   true,
+	"wasip1":    true,
+	"windows":   true,
+}
+
+// execStdinFileScheme - Scheme to read stdin from the file ( relative to the runbook ).
+const execStdinFileScheme = "file://"
+
+// execTruncatedMarker - Format of the output truncated by maxStdoutSize or maxStderrSize.
+const execTruncatedMarker = "%s\n... (truncated %d bytes)"
+
+// execDefaultGracePeriod - Time to wait for the process to exit after the signal before killing it.
+const execDefaultGracePeriod = 5 * time.Second
+
+var execDefaultSignal = syscall.SIGTERM
+
+type execRunner struct {
+	// bgs - Processes started in the background
+	bgs []*execProcess
+	mu  sync.Mutex
+}
+
+type execProcess struct {
+	cmd         *osexec.Cmd
+	done        chan struct{}
+	signal      os.Signal
+	gracePeriod time.Duration
+}
 
 type execCommand struct {
 	command string
-	shell   string
-	stdin   string
+	// args - Arguments to execute the command directly without shell
+	args      []string
+	shell     string
+	stdin     string
+	stdinFile string
+	env       map[string]string
+	dir       string
+	// background - Start the command in the background and terminate it at the end of the runbook
+	background bool
+	// timeout - Terminate the command and fail the step when the command does not exit within timeout
+	timeout time.Duration
+	// signal - Signal to terminate the command
+	signal os.Signal
+	// gracePeriod - Time to wait for the command to exit after the signal before killing it
+	gracePeriod time.Duration
+	// waitFor - Wait until a line of the output matches instead of waiting for the command to exit
+	waitFor *execWaitFor
+	// maxStdoutSize - Max size of stdout to record ( 0 means unlimited )
+	maxStdoutSize int64
+	// maxStderrSize - Max size of stderr to record ( 0 means unlimited )
+	maxStderrSize int64
+	// retry - Retry the command until the condition is satisfied
+	retry *retryConfig
+	// tty - Run the command under a pseudo-terminal ( stdout and stderr are recorded together as stdout )
+	tty bool
+}
+
+// execOutputBuffer records the output of the command up to max bytes.
+type execOutputBuffer struct {
+	buf       bytes.Buffer
+	max       int64
+	truncated int64
+}
+
+func newExecOutputBuffer(max int64) *execOutputBuffer {
+	return &execOutputBuffer{max: max}
+}
+
+// Write always consumes p so that the command is not blocked by the truncation.
+func (b *execOutputBuffer) Write(p []byte) (int, error) {
+	if b.max <= 0 {
+		return b.buf.Write(p)
+	}
+	rest := b.max - int64(b.buf.Len())
+	if rest < 0 {
+		rest = 0
+	}
+	if int64(len(p)) > rest {
+		b.truncated += int64(len(p)) - rest
+		_, _ = b.buf.Write(p[:rest])
+		return len(p), nil
+	}
+	return b.buf.Write(p)
+}
+
+// String returns the recorded output with the truncation marker.
+func (b *execOutputBuffer) String() string {
+	if b.truncated == 0 {
+		return b.buf.String()
+	}
+	return fmt.Sprintf(execTruncatedMarker, b.buf.String(), b.truncated)
 }
 
 func newExecRunner() *execRunner {
@@ -57,35 +169,234 @@ func (rnr *execRunner) Run(ctx context.Context, s *step) error {
 }
 
 func (rnr *execRunner) run(ctx context.Context, c *execCommand, s *step) error {
-	o := s.parent
-	stdout := new(bytes.Buffer)
-	stderr := new(bytes.Buffer)
-	if c.shell == "" {
-		c.shell = execDefaultShell
+	if c.retry != nil {
+		return rnr.runWithRetry(ctx, c, s)
+	}
+	v, err := rnr.exec(ctx, c, s)
+	if v != nil {
+		s.parent.record(v)
 	}
-	o.capturers.captureExecCommand(c.command, c.shell)
+	return err
+}
 
-	sh, err := safeexec.LookPath(c.shell)
+// exec executes the command and returns the values to record.
+// The values are nil when the command cannot be executed.
+func (rnr *execRunner) exec(ctx context.Context, c *execCommand, s *step) (map[string]any, error) {
+	o := s.parent
+	stdout := newExecOutputBuffer(c.maxStdoutSize)
+	stderr := newExecOutputBuffer(c.maxStderrSize)
+	var (
+		name string
+		args []string
+	)
+	if len(c.args) > 0 {
+		o.capturers.captureExecCommand(shellquote.Join(c.args...), "")
+		name = c.args[0]
+		args = c.args[1:]
+	} else {
+		if c.shell == "" {
+			c.shell = execDefaultShell
+		}
+		o.capturers.captureExecCommand(c.command, c.shell)
+		name = c.shell
+		args = execShellArgs(c.shell, c.command)
+	}
+	bin, err := safeexec.LookPath(name)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	var cmd *osexec.Cmd
+	if c.background {
+		// Background processes are terminated by terminateBackgrounds instead of ctx
+		cmd = exec.Command(bin, args...)
+	} else {
+		cmd = exec.CommandContext(ctx, bin, args...)
+	}
+	if len(c.env) > 0 {
+		cmd.Env = os.Environ()
+		keys := make([]string, 0, len(c.env))
+		for k := range c.env {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			cmd.Env = append(cmd.Env, fmt.Sprintf("%s=%s", k, c.env[k]))
+		}
+	}
+	if c.dir != "" {
+		cmd.Dir = fp(c.dir, o.root)
+	}
+	if c.stdinFile != "" {
+		b, err := readFile(fp(c.stdinFile, o.root))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read stdin: %w", err)
+		}
+		c.stdin = string(b)
 	}
-	cmd := exec.CommandContext(ctx, sh, "-c", c.command)
 	if strings.Trim(c.stdin, " \n") != "" {
 		cmd.Stdin = strings.NewReader(c.stdin)
 
 		o.capturers.captureExecStdin(c.stdin)
 	}
+	if c.waitFor != nil {
+		return rnr.runWaitFor(cmd, c, s)
+	}
+	if c.background {
+		return rnr.startBackground(cmd, c)
+	}
 	cmd.Stdout = stdout
 	cmd.Stderr = stderr
-	_ = cmd.Run()
+	var timedOut bool
+	if c.timeout > 0 || c.tty {
+		p, err := startProcess(cmd, c)
+		if err != nil {
+			return nil, err
+		}
+		var timeout <-chan time.Time
+		if c.timeout > 0 {
+			timeout = time.After(c.timeout)
+		}
+		select {
+		case <-p.done:
+		case <-timeout:
+			timedOut = true
+			_ = p.terminate()
+		}
+	} else {
+		_ = cmd.Run()
+	}
 
 	o.capturers.captureExecStdout(stdout.String())
 	o.capturers.captureExecStderr(stderr.String())
 
-	o.record(map[string]any{
+	v := map[string]any{
 		string(execStoreStdoutKey):   stdout.String(),
 		string(execStoreStderrKey):   stderr.String(),
 		string(execStoreExitCodeKey): cmd.ProcessState.ExitCode(),
-	})
-	return nil
+	}
+	if timedOut {
+		return v, fmt.Errorf("command timed out after %s", c.timeout)
+	}
+	return v, nil
+}
+
+// execShellArgs returns the arguments to execute command with shell.
+func execShellArgs(shell, command string) []string {
+	switch strings.TrimSuffix(filepath.Base(shell), ".exe") {
+	case "pwsh", "powershell":
+		return []string{"-NoProfile", "-NonInteractive", "-Command", command}
+	case "cmd":
+		return []string{"/c", command}
+	default:
+		return []string{"-c", command}
+	}
+}
+
+func (rnr *execRunner) startBackground(cmd *osexec.Cmd, c *execCommand) (map[string]any, error) {
+	p, err := startProcess(cmd, c)
+	if err != nil {
+		return nil, err
+	}
+	rnr.mu.Lock()
+	rnr.bgs = append(rnr.bgs, p)
+	rnr.mu.Unlock()
+	return map[string]any{
+		string(execStorePIDKey): cmd.Process.Pid,
+	}, nil
+}
+
+// terminateBackgrounds terminates the processes started in the background.
+func (rnr *execRunner) terminateBackgrounds() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	var errs error
+	for _, p := range rnr.bgs {
+		if err := p.terminate(); err != nil {
+			errs = errors.Join(errs, err)
+		}
+	}
+	rnr.bgs = nil
+	return errs
+}
+
+func startProcess(cmd *osexec.Cmd, c *execCommand) (*execProcess, error) {
+	var (
+		pt  *execPTY
+		err error
+	)
+	if c.tty {
+		pt, err = attachPTY(cmd)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := cmd.Start(); err != nil {
+		if pt != nil {
+			_ = pt.release()
+		}
+		return nil, err
+	}
+	if pt != nil {
+		pt.start()
+	}
+	p := &execProcess{
+		cmd:         cmd,
+		done:        make(chan struct{}),
+		signal:      c.signal,
+		gracePeriod: c.gracePeriod,
+	}
+	if p.signal == nil {
+		p.signal = execDefaultSignal
+	}
+	if p.gracePeriod == 0 {
+		p.gracePeriod = execDefaultGracePeriod
+	}
+	go func() {
+		_ = cmd.Wait()
+		if pt != nil {
+			_ = pt.wait()
+		}
+		close(p.done)
+	}()
+	return p, nil
+}
+
+// terminate sends the signal to the process group, and kills it if it does not exit within the grace period.
+func (p *execProcess) terminate() error {
+	select {
+	case <-p.done:
+		return nil
+	default:
+	}
+	var errs error
+	if err := exec.TerminateCommand(p.cmd, p.signal); err != nil {
+		errs = errors.Join(errs, err)
+	}
+	select {
+	case <-p.done:
+	case <-time.After(p.gracePeriod):
+		if err := exec.KillCommand(p.cmd); err != nil {
+			errs = errors.Join(errs, err)
+		}
+		<-p.done
+	}
+	return errs
+}
+
+// parseSignal parses the name of the signal ( e.g. SIGINT, INT ).
+func parseSignal(name string) (os.Signal, error) {
+	switch strings.TrimPrefix(strings.ToUpper(name), "SIG") {
+	case "TERM":
+		return syscall.SIGTERM, nil
+	case "INT":
+		return syscall.SIGINT, nil
+	case "KILL":
+		return syscall.SIGKILL, nil
+	case "HUP":
+		return syscall.SIGHUP, nil
+	case "QUIT":
+		return syscall.SIGQUIT, nil
+	default:
+		return nil, fmt.Errorf("unsupported signal: %s", name)
+	}
 }
@@ -0,0 +1,85 @@
+package runn
+
+import (
+	"fmt"
+	"io"
+	"os"
+	osexec "os/exec"
+
+	"github.com/creack/pty"
+)
+
+// execPTYEOF - EOF ( Ctrl-D ) sent to the terminal after writing stdin.
+const execPTYEOF = 0x04
+
+// execPTY - Pseudo-terminal attached to the command.
+type execPTY struct {
+	ptmx *os.File
+	tty  *os.File
+	in   io.Reader
+	out  io.Writer
+	// copied - Closed when the output of the terminal is copied to out
+	copied chan struct{}
+}
+
+// attachPTY allocates a pseudo-terminal and attaches it to stdin, stdout and stderr of cmd.
+// The output of the terminal ( stdout and stderr of cmd ) is written to cmd.Stdout, and cmd.Stdin is written to the terminal.
+func attachPTY(cmd *osexec.Cmd) (*execPTY, error) {
+	ptmx, tty, err := pty.Open()
+	if err != nil {
+		return nil, fmt.Errorf("failed to allocate pty: %w", err)
+	}
+	p := &execPTY{
+		ptmx:   ptmx,
+		tty:    tty,
+		in:     cmd.Stdin,
+		out:    cmd.Stdout,
+		copied: make(chan struct{}),
+	}
+	if p.out == nil {
+		p.out = io.Discard
+	}
+	if err := setTTYSize(ptmx); err != nil {
+		_ = p.release()
+		return nil, fmt.Errorf("failed to set the size of pty: %w", err)
+	}
+	cmd.Stdin = tty
+	cmd.Stdout = tty
+	cmd.Stderr = tty
+	setControllingTTY(cmd)
+	return p, nil
+}
+
+// start starts copying the input and the output of the terminal after the command is started.
+func (p *execPTY) start() {
+	// The command holds the tty
+	_ = p.tty.Close()
+	if p.in != nil {
+		go func() {
+			b, _ := io.ReadAll(p.in)
+			_, _ = p.ptmx.Write(b)
+			eof := []byte{execPTYEOF}
+			if len(b) > 0 && b[len(b)-1] != '\n' {
+				// The first EOF only flushes the incomplete line
+				eof = append(eof, execPTYEOF)
+			}
+			_, _ = p.ptmx.Write(eof)
+		}()
+	}
+	go func() {
+		// Reading ptmx fails ( EIO ) when all the processes attached to the tty exit
+		_, _ = io.Copy(p.out, p.ptmx)
+		close(p.copied)
+	}()
+}
+
+// wait waits until the output of the terminal is copied, and closes the terminal.
+func (p *execPTY) wait() error {
+	<-p.copied
+	return p.ptmx.Close()
+}
+
+func (p *execPTY) release() error {
+	_ = p.tty.Close()
+	return p.ptmx.Close()
+}
@@ -0,0 +1,33 @@
+//go:build !windows
+
+package runn
+
+import (
+	"os"
+	osexec "os/exec"
+	"syscall"
+
+	"github.com/creack/pty"
+)
+
+const (
+	execPTYRows = 24
+	execPTYCols = 80
+)
+
+// setControllingTTY starts cmd in the new session with the tty ( stdin ) as the controlling terminal.
+// The session leader is also the leader of the process group, so the process group is still terminated as a whole.
+func setControllingTTY(cmd *osexec.Cmd) {
+	if cmd.SysProcAttr == nil {
+		cmd.SysProcAttr = &syscall.SysProcAttr{}
+	}
+	// setpgid fails after setsid
+	cmd.SysProcAttr.Setpgid = false
+	cmd.SysProcAttr.Setsid = true
+	cmd.SysProcAttr.Setctty = true
+	cmd.SysProcAttr.Ctty = 0
+}
+
+func setTTYSize(ptmx *os.File) error {
+	return pty.Setsize(ptmx, &pty.Winsize{Rows: execPTYRows, Cols: execPTYCols})
+}
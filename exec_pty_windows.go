@@ -0,0 +1,15 @@
+//go:build windows
+
+package runn
+
+import (
+	"os"
+	osexec "os/exec"
+)
+
+// setControllingTTY does nothing because pty is not supported on Windows ( attachPTY fails ).
+func setControllingTTY(_ *osexec.Cmd) {}
+
+func setTTYSize(_ *os.File) error {
+	return nil
+}
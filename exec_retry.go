@@ -0,0 +1,59 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// execRetryDefaultUntil - Default condition of `retry:` of exec commands
+const execRetryDefaultUntil = "current.exit_code == 0"
+
+// runWithRetry executes the command until retry.until is satisfied, and records the result of the last attempt.
+func (rnr *execRunner) runWithRetry(ctx context.Context, c *execCommand, s *step) error {
+	o := s.parent
+	r := c.retry
+	var (
+		v   map[string]any
+		err error
+	)
+	for i := 0; i < r.count; i++ {
+		if i > 0 {
+			select {
+			case <-ctx.Done():
+				o.record(v)
+				return ctx.Err()
+			case <-time.After(r.wait(i)):
+			}
+		}
+		v, err = rnr.exec(ctx, c, s)
+		if v == nil {
+			// The command cannot be executed
+			return err
+		}
+		if err != nil {
+			continue
+		}
+		store := o.store.toMap()
+		store[storeRootKeyIncluded] = o.included
+		store[storeRootPrevious] = o.store.latest()
+		store[storeRootKeyCurrent] = v
+		tf, eerr := EvalCond(r.until, store)
+		if eerr != nil {
+			o.record(v)
+			return fmt.Errorf("failed to evaluate retry.until: %w", eerr)
+		}
+		if tf {
+			o.record(v)
+			return nil
+		}
+		bt, berr := buildTree(r.until, store)
+		if berr != nil {
+			o.record(v)
+			return berr
+		}
+		err = fmt.Errorf("(%s) is not true\n%s", r.until, bt)
+	}
+	o.record(v)
+	return fmt.Errorf("retry exec failed (count: %d, interval: %v): %w", r.count, r.interval, err)
+}
@@ -3,8 +3,12 @@ package runn
 import (
 	"context"
 	"fmt"
+	"os"
+	"regexp"
 	"strings"
+	"syscall"
 	"testing"
+	"time"
 
 	"github.com/cli/safeexec"
 	"github.com/google/go-cmp/cmp"
@@ -20,34 +24,41 @@ func TestExecRun(t *testing.T) {
 		}
 	})
 	tests := []struct {
-		command string
-		stdin   string
-		shell   string
-		want    map[string]any
+		command   string
+		stdin     string
+		stdinFile string
+		shell     string
+		want      map[string]any
 	}{
-		{"echo hello!!", "", "", map[string]any{
+		{"echo hello!!", "", "", "", map[string]any{
 			"stdout":    "hello!!\n",
 			"stderr":    "",
 			"exit_code": 0,
 			"run":       true,
 		}},
-		{"cat", "hello!!", "", map[string]any{
+		{"cat", "hello!!", "", "", map[string]any{
 			"stdout":    "hello!!",
 			"stderr":    "",
 			"exit_code": 0,
 			"run":       true,
 		}},
+		{"cat", "", "testdata/exec_stdin.txt", "", map[string]any{
+			"stdout":    "hello from file!!\n",
+			"stderr":    "",
+			"exit_code": 0,
+			"run":       true,
+		}},
 	}
 	ctx := context.Background()
 	for _, tt := range tests {
-		t.Run(tt.command, func(t *testing.T) {
+		t.Run(tt.command+tt.stdinFile, func(t *testing.T) {
 			o, err := New()
 			if err != nil {
 				t.Fatal(err)
 			}
 			r := newExecRunner()
 			s := newStep(0, "stepKey", o)
-			c := &execCommand{command: tt.command, stdin: tt.stdin, shell: tt.shell}
+			c := &execCommand{command: tt.command, stdin: tt.stdin, stdinFile: tt.stdinFile, shell: tt.shell}
 			if err := r.run(ctx, c, s); err != nil {
 				t.Error(err)
 				return
@@ -105,3 +116,400 @@ func TestExecShell(t *testing.T) {
 		})
 	}
 }
+
+func TestExecEnvAndDir(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newExecRunner()
+	s := newStep(0, "stepKey", o)
+	c := &execCommand{
+		command: "echo $FOO $BAR $(basename $(pwd))",
+		env:     map[string]string{"FOO": "foo", "BAR": "1"},
+		dir:     "testdata",
+	}
+	if err := r.run(ctx, c, s); err != nil {
+		t.Fatal(err)
+	}
+	got := o.store.steps[0]["stdout"]
+	if want := "foo 1 testdata\n"; got != want {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
+
+func TestExecBackground(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/exec_background.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	pid, ok := o.store.steps[0]["pid"].(int)
+	if !ok {
+		t.Fatalf("invalid pid: %v", o.store.steps[0]["pid"])
+	}
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := p.Signal(syscall.Signal(0)); err == nil {
+		t.Errorf("the background process (%d) should be terminated at the end of the runbook", pid)
+	}
+}
+
+func TestExecWithoutShell(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := newExecRunner()
+	s := newStep(0, "stepKey", o)
+	c := &execCommand{args: []string{"echo", "$HOME", "it's"}}
+	if err := r.run(ctx, c, s); err != nil {
+		t.Fatal(err)
+	}
+	got := o.store.steps[0]["stdout"]
+	if want := "$HOME it's\n"; got != want {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+}
+
+func TestExecShellArgs(t *testing.T) {
+	tests := []struct {
+		shell string
+		want  []string
+	}{
+		{"sh", []string{"-c", "echo hello"}},
+		{"/bin/bash", []string{"-c", "echo hello"}},
+		{"pwsh", []string{"-NoProfile", "-NonInteractive", "-Command", "echo hello"}},
+		{"powershell.exe", []string{"-NoProfile", "-NonInteractive", "-Command", "echo hello"}},
+		{"cmd", []string{"/c", "echo hello"}},
+	}
+	for _, tt := range tests {
+		got := execShellArgs(tt.shell, "echo hello")
+		if diff := cmp.Diff(got, tt.want, nil); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestExecTimeout(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	tests := []struct {
+		name     string
+		command  string
+		signal   os.Signal
+		wantErr  bool
+		wantOut  string
+		maxSpent time.Duration
+	}{
+		{"exit within timeout", "echo done", nil, false, "done\n", 5 * time.Second},
+		{"timed out", "sleep 10", nil, true, "", 5 * time.Second},
+		{"trap signal", "trap 'echo trapped; exit 1' INT; echo start; while true; do sleep 0.05; done", syscall.SIGINT, true, "start\ntrapped\n", 5 * time.Second},
+		{"kill after grace period", "trap '' TERM; sleep 10", nil, true, "", 5 * time.Second},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := newExecRunner()
+			s := newStep(0, "stepKey", o)
+			c := &execCommand{command: tt.command, timeout: 200 * time.Millisecond, signal: tt.signal, gracePeriod: 500 * time.Millisecond}
+			start := time.Now()
+			err = r.run(ctx, c, s)
+			if spent := time.Since(start); spent > tt.maxSpent {
+				t.Errorf("spent %v", spent)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			}
+			if got := o.store.steps[0]["stdout"]; got != tt.wantOut {
+				t.Errorf("got %q\nwant %q", got, tt.wantOut)
+			}
+		})
+	}
+}
+
+func TestExecWaitFor(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	tests := []struct {
+		name        string
+		command     string
+		waitFor     *execWaitFor
+		background  bool
+		wantErr     bool
+		wantMatched []any
+	}{
+		{
+			"match",
+			"echo start; sleep 0.1; echo 'listening on :8080'; sleep 10",
+			&execWaitFor{match: regexp.MustCompile(`listening on :(\d+)`), timeout: 5 * time.Second},
+			false,
+			false,
+			[]any{"listening on :8080", "8080"},
+		},
+		{
+			"match stderr in background",
+			"echo start; echo 'ready' >&2; sleep 10",
+			&execWaitFor{match: regexp.MustCompile(`^ready$`), timeout: 5 * time.Second},
+			true,
+			false,
+			[]any{"ready"},
+		},
+		{
+			"cond",
+			"echo 1; echo 2; echo 3; sleep 10",
+			&execWaitFor{cond: `int(line) >= 2`, timeout: 5 * time.Second},
+			false,
+			false,
+			[]any{"2"},
+		},
+		{
+			"last line without newline",
+			"printf 'a\nready'",
+			&execWaitFor{match: regexp.MustCompile(`ready`), timeout: 5 * time.Second},
+			false,
+			false,
+			[]any{"ready"},
+		},
+		{
+			"exited before match",
+			"echo start",
+			&execWaitFor{match: regexp.MustCompile(`ready`), timeout: 5 * time.Second},
+			false,
+			true,
+			nil,
+		},
+		{
+			"timed out",
+			"echo start; sleep 10",
+			&execWaitFor{match: regexp.MustCompile(`ready`), timeout: 200 * time.Millisecond},
+			false,
+			true,
+			nil,
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := newExecRunner()
+			s := newStep(0, "stepKey", o)
+			c := &execCommand{command: tt.command, waitFor: tt.waitFor, background: tt.background, gracePeriod: 500 * time.Millisecond}
+			start := time.Now()
+			err = r.run(ctx, c, s)
+			if spent := time.Since(start); spent > 5*time.Second {
+				t.Errorf("spent %v", spent)
+			}
+			t.Cleanup(func() {
+				if err := r.terminateBackgrounds(); err != nil {
+					t.Error(err)
+				}
+			})
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			}
+			got, _ := o.store.steps[0]["matched"].([]any)
+			if diff := cmp.Diff(got, tt.wantMatched); diff != "" {
+				t.Error(diff)
+			}
+			if tt.background {
+				if _, ok := o.store.steps[0]["pid"]; !ok {
+					t.Error("pid is not recorded")
+				}
+				if len(r.bgs) != 1 {
+					t.Errorf("got %d background processes", len(r.bgs))
+				}
+			}
+		})
+	}
+}
+
+func TestExecMaxOutputSize(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	tests := []struct {
+		name          string
+		command       string
+		maxStdoutSize int64
+		maxStderrSize int64
+		wantStdout    string
+		wantStderr    string
+		wantExitCode  int
+	}{
+		{"unlimited", "echo hello; echo world >&2", 0, 0, "hello\n", "world\n", 0},
+		{"within the limit", "echo hello", 6, 0, "hello\n", "", 0},
+		{"truncated", "echo hello; echo world >&2; exit 3", 3, 0, "hel\n... (truncated 3 bytes)", "world\n", 3},
+		{"truncated separately", "seq 1 1000; seq 1 1000 >&2", 0, 2, "", "1\n\n... (truncated 3891 bytes)", 0},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := newExecRunner()
+			s := newStep(0, "stepKey", o)
+			c := &execCommand{command: tt.command, maxStdoutSize: tt.maxStdoutSize, maxStderrSize: tt.maxStderrSize}
+			if err := r.run(ctx, c, s); err != nil {
+				t.Fatal(err)
+			}
+			got := o.store.steps[0]
+			if tt.wantStdout != "" {
+				if got["stdout"] != tt.wantStdout {
+					t.Errorf("got %q\nwant %q", got["stdout"], tt.wantStdout)
+				}
+			}
+			if got["stderr"] != tt.wantStderr {
+				t.Errorf("got %q\nwant %q", got["stderr"], tt.wantStderr)
+			}
+			if got["exit_code"] != tt.wantExitCode {
+				t.Errorf("got %v\nwant %v", got["exit_code"], tt.wantExitCode)
+			}
+		})
+	}
+}
+
+func TestExecTTY(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	tests := []struct {
+		name       string
+		command    string
+		stdin      string
+		tty        bool
+		wantStdout string
+	}{
+		{"without tty", "if [ -t 0 ] && [ -t 1 ] && [ -t 2 ]; then echo tty; else echo notty; fi", "", false, "notty\n"},
+		{"with tty", "if [ -t 0 ] && [ -t 1 ] && [ -t 2 ]; then echo tty; else echo notty; fi", "", true, "tty\r\n"},
+		{"stderr", "echo out; echo err >&2", "", true, "out\r\nerr\r\n"},
+		{"stdin", "read name; echo \"hello $name\"", "alice\n", true, "alice\r\nhello alice\r\n"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := newExecRunner()
+			s := newStep(0, "stepKey", o)
+			c := &execCommand{command: tt.command, stdin: tt.stdin, tty: tt.tty}
+			if err := r.run(ctx, c, s); err != nil {
+				t.Fatal(err)
+			}
+			if got := o.store.steps[0]["stdout"]; got != tt.wantStdout {
+				t.Errorf("got %q\nwant %q", got, tt.wantStdout)
+			}
+			if got := o.store.steps[0]["exit_code"]; got != 0 {
+				t.Errorf("got %v\nwant %v", got, 0)
+			}
+		})
+	}
+}
+
+func TestExecRetry(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// The command counts the attempts with the file, and succeeds at the third attempt
+	const command = `n=$(cat count 2>/dev/null || echo 0); n=$((n+1)); echo $n > count; echo $n; [ $n -ge 3 ]`
+	tests := []struct {
+		name       string
+		retry      *retryConfig
+		wantErr    bool
+		wantStdout string
+	}{
+		{"exit code", &retryConfig{count: 5, interval: 10 * time.Millisecond, until: execRetryDefaultUntil}, false, "3\n"},
+		{"stdout", &retryConfig{count: 5, interval: 10 * time.Millisecond, until: `current.stdout == "2\n"`}, false, "2\n"},
+		{"exhausted", &retryConfig{count: 2, interval: 10 * time.Millisecond, until: execRetryDefaultUntil}, true, "2\n"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := newExecRunner()
+			s := newStep(0, "stepKey", o)
+			c := &execCommand{command: command, dir: t.TempDir(), retry: tt.retry}
+			if err := r.run(ctx, c, s); (err != nil) != tt.wantErr {
+				t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			}
+			if o.store.length() != 1 {
+				t.Errorf("got %d records", o.store.length())
+			}
+			if got := o.store.steps[0]["stdout"]; got != tt.wantStdout {
+				t.Errorf("got %q\nwant %q", got, tt.wantStdout)
+			}
+		})
+	}
+}
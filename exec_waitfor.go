@@ -0,0 +1,217 @@
+package runn
+
+import (
+	"bytes"
+	"fmt"
+	osexec "os/exec"
+	"regexp"
+	"sync"
+	"time"
+)
+
+const execStoreMatchedKey = "matched"
+
+// execWaitForLineKey - Key of the line bound when evaluating the cond of waitFor.
+const execWaitForLineKey = "line"
+
+const execWaitForDefaultTimeout = 60 * time.Second
+
+// execWaitFor - Wait until a line of the output ( stdout or stderr ) of the command matches.
+type execWaitFor struct {
+	// match - regexp to match the line
+	match *regexp.Regexp
+	// cond - condition evaluated for each line
+	cond    string
+	timeout time.Duration
+}
+
+// execLineMatcher watches the output of the command line by line.
+type execLineMatcher struct {
+	w     *execWaitFor
+	store map[string]any
+	// matched - Receives the submatches of the matched line ( or nil when the evaluation of cond fails )
+	matched chan []string
+	once    sync.Once
+	// discard - Stop recording the output after the match ( for background processes )
+	discard   bool
+	discarded bool
+	err       error
+	mu        sync.Mutex
+}
+
+// execLineWriter is io.Writer that records the output and passes the lines to execLineMatcher.
+type execLineWriter struct {
+	m       *execLineMatcher
+	buf     *execOutputBuffer
+	partial []byte
+}
+
+func newExecLineMatcher(w *execWaitFor, s *step, discard bool) *execLineMatcher {
+	m := &execLineMatcher{
+		w:       w,
+		matched: make(chan []string, 1),
+		discard: discard,
+	}
+	if w.cond != "" {
+		o := s.parent
+		m.store = o.store.toMap()
+		m.store[storeRootKeyIncluded] = o.included
+		m.store[storeRootPrevious] = o.store.latest()
+	}
+	return m
+}
+
+func (m *execLineMatcher) writer(max int64) *execLineWriter {
+	return &execLineWriter{m: m, buf: newExecOutputBuffer(max)}
+}
+
+func (m *execLineMatcher) check(line string) {
+	var matched []string
+	switch {
+	case m.w.match != nil:
+		matched = m.w.match.FindStringSubmatch(line)
+	case m.w.cond != "":
+		m.store[execWaitForLineKey] = line
+		tf, err := EvalCond(m.w.cond, m.store)
+		if err != nil {
+			m.err = fmt.Errorf("failed to evaluate waitFor: %w", err)
+			m.once.Do(func() {
+				m.matched <- nil
+			})
+			return
+		}
+		if tf {
+			matched = []string{line}
+		}
+	}
+	if matched == nil {
+		return
+	}
+	m.once.Do(func() {
+		m.discarded = m.discard
+		m.matched <- matched
+	})
+}
+
+// result returns the error of the evaluation of cond.
+func (m *execLineMatcher) result() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.err
+}
+
+func (w *execLineWriter) Write(p []byte) (int, error) {
+	w.m.mu.Lock()
+	defer w.m.mu.Unlock()
+	if w.m.discarded {
+		return len(p), nil
+	}
+	_, _ = w.buf.Write(p)
+	w.partial = append(w.partial, p...)
+	for {
+		i := bytes.IndexByte(w.partial, '\n')
+		if i < 0 {
+			break
+		}
+		line := string(bytes.TrimSuffix(w.partial[:i], []byte("\r")))
+		w.partial = w.partial[i+1:]
+		if w.m.err == nil {
+			w.m.check(line)
+		}
+	}
+	return len(p), nil
+}
+
+// flush checks the last line without a trailing newline.
+func (w *execLineWriter) flush() {
+	w.m.mu.Lock()
+	defer w.m.mu.Unlock()
+	if len(w.partial) == 0 || w.m.err != nil {
+		return
+	}
+	w.m.check(string(w.partial))
+	w.partial = nil
+}
+
+// String returns the recorded output.
+func (w *execLineWriter) String() string {
+	w.m.mu.Lock()
+	defer w.m.mu.Unlock()
+	return w.buf.String()
+}
+
+// runWaitFor starts the command and waits until a line of the output matches.
+// The command is terminated after the match unless it is started in the background.
+func (rnr *execRunner) runWaitFor(cmd *osexec.Cmd, c *execCommand, s *step) (map[string]any, error) {
+	o := s.parent
+	m := newExecLineMatcher(c.waitFor, s, c.background)
+	stdout := m.writer(c.maxStdoutSize)
+	stderr := m.writer(c.maxStderrSize)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	p, err := startProcess(cmd, c)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		matched []string
+		exited  bool
+	)
+	select {
+	case matched = <-m.matched:
+	case <-p.done:
+		exited = true
+		stdout.flush()
+		stderr.flush()
+		select {
+		case matched = <-m.matched:
+		default:
+		}
+	case <-time.After(c.waitFor.timeout):
+	}
+	if err := m.result(); err != nil {
+		_ = p.terminate()
+		return nil, err
+	}
+	if matched != nil && c.background {
+		rnr.mu.Lock()
+		rnr.bgs = append(rnr.bgs, p)
+		rnr.mu.Unlock()
+		o.capturers.captureExecStdout(stdout.String())
+		o.capturers.captureExecStderr(stderr.String())
+		return map[string]any{
+			string(execStorePIDKey):     cmd.Process.Pid,
+			string(execStoreStdoutKey):  stdout.String(),
+			string(execStoreStderrKey):  stderr.String(),
+			string(execStoreMatchedKey): execMatchedValue(matched),
+		}, nil
+	}
+	_ = p.terminate()
+	o.capturers.captureExecStdout(stdout.String())
+	o.capturers.captureExecStderr(stderr.String())
+	v := map[string]any{
+		string(execStoreStdoutKey):   stdout.String(),
+		string(execStoreStderrKey):   stderr.String(),
+		string(execStoreExitCodeKey): cmd.ProcessState.ExitCode(),
+	}
+	if matched != nil {
+		v[string(execStoreMatchedKey)] = execMatchedValue(matched)
+	}
+	switch {
+	case matched != nil:
+		return v, nil
+	case exited:
+		return v, fmt.Errorf("command exited before the output matched waitFor")
+	default:
+		return v, fmt.Errorf("waitFor timed out after %s", c.waitFor.timeout)
+	}
+}
+
+// execMatchedValue returns the matched line and submatches as the value of the store.
+func execMatchedValue(matched []string) []any {
+	v := make([]any, 0, len(matched))
+	for _, m := range matched {
+		v = append(v, m)
+	}
+	return v
+}
@@ -0,0 +1,140 @@
+package runn
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+
+	"github.com/goccy/go-yaml"
+	"google.golang.org/grpc/codes"
+)
+
+const expectErrorSectionKey = "expectError"
+
+var statusClassRe = regexp.MustCompile(`^([1-5])xx$`)
+
+// expectError - Expected failure of the step.
+type expectError struct {
+	// statusClass - Expected class of HTTP status ( e.g. 4 of `4xx` )
+	statusClass int
+	// grpcCode - Expected gRPC status code
+	grpcCode *codes.Code
+	// cond - Expression that the failure should satisfy
+	cond string
+	raw  string
+}
+
+func newExpectError(v any) (*expectError, error) {
+	var raw string
+	switch vv := v.(type) {
+	case string:
+		raw = vv
+	case int, uint64:
+		raw = fmt.Sprintf("%dxx", vv)
+		if !statusClassRe.MatchString(raw) {
+			return nil, fmt.Errorf("invalid status class: %v", v)
+		}
+	default:
+		b, err := yaml.Marshal(v)
+		if err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("invalid type: %s", string(b))
+	}
+	if raw == "" {
+		return nil, fmt.Errorf("empty %s", expectErrorSectionKey)
+	}
+	e := &expectError{raw: raw}
+	if m := statusClassRe.FindStringSubmatch(raw); m != nil {
+		e.statusClass, _ = strconv.Atoi(m[1])
+		return e, nil
+	}
+	if c, ok := parseGRPCCode(raw); ok {
+		e.grpcCode = &c
+		return e, nil
+	}
+	e.cond = raw
+	return e, nil
+}
+
+// parseGRPCCode parses the name of gRPC status code ( e.g. `NotFound` or `NOT_FOUND` ).
+func parseGRPCCode(v string) (codes.Code, bool) {
+	for c := codes.OK; c <= codes.Unauthenticated; c++ {
+		if c.String() == v {
+			return c, true
+		}
+	}
+	var c codes.Code
+	if err := c.UnmarshalJSON([]byte(strconv.Quote(v))); err == nil {
+		return c, true
+	}
+	return 0, false
+}
+
+// checkExpectError checks that the runner of the step failed as expected.
+func (o *operator) checkExpectError(i int, s *step, runErr error) error {
+	e := s.expectError
+	if runErr != nil {
+		if e.cond == "" {
+			return fmt.Errorf("expected error (%s) on %s, but got: %w", e.raw, o.stepName(i), runErr)
+		}
+		if o.store.length() != i+1 {
+			// Record the error so that it can be used as `current.error`
+			o.record(map[string]any{storeStepKeyError: runErr.Error()})
+		}
+	}
+	store := o.store.toMap()
+	store[storeRootKeyIncluded] = o.included
+	store[storeRootPrevious] = o.store.previous()
+	store[storeRootKeyCurrent] = o.store.latest()
+	switch {
+	case e.statusClass > 0:
+		st, ok := currentStatus(store)
+		if !ok {
+			return fmt.Errorf("expected error (%s) on %s, but no HTTP status was recorded", e.raw, o.stepName(i))
+		}
+		if int(st)/100 != e.statusClass {
+			return fmt.Errorf("expected error (%s) on %s, but got status %d", e.raw, o.stepName(i), st)
+		}
+	case e.grpcCode != nil:
+		st, ok := currentStatus(store)
+		if !ok {
+			return fmt.Errorf("expected error (%s) on %s, but no gRPC status was recorded", e.raw, o.stepName(i))
+		}
+		if codes.Code(st) != *e.grpcCode {
+			return fmt.Errorf("expected error (%s) on %s, but got status %s", e.raw, o.stepName(i), codes.Code(st).String())
+		}
+	default:
+		tf, err := EvalCond(e.cond, store)
+		if err != nil {
+			return fmt.Errorf("invalid %s on %s: %w", expectErrorSectionKey, o.stepName(i), err)
+		}
+		if !tf {
+			bt, err := buildTree(e.cond, store)
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("expected error (%s) on %s is not satisfied\n%s", e.raw, o.stepName(i), bt)
+		}
+	}
+	return nil
+}
+
+func currentStatus(store map[string]any) (int64, bool) {
+	cur, ok := store[storeRootKeyCurrent].(map[string]any)
+	if !ok {
+		return 0, false
+	}
+	res, ok := cur[httpStoreResponseKey].(map[string]any)
+	if !ok {
+		return 0, false
+	}
+	switch st := res[httpStoreStatusKey].(type) {
+	case int:
+		return int64(st), true
+	case int64:
+		return st, true
+	default:
+		return 0, false
+	}
+}
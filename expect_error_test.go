@@ -0,0 +1,115 @@
+package runn
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestNewExpectError(t *testing.T) {
+	notFound := codes.NotFound
+	tests := []struct {
+		in      any
+		want    *expectError
+		wantErr bool
+	}{
+		{"4xx", &expectError{statusClass: 4, raw: "4xx"}, false},
+		{uint64(5), &expectError{statusClass: 5, raw: "5xx"}, false},
+		{"NotFound", &expectError{grpcCode: &notFound, raw: "NotFound"}, false},
+		{"NOT_FOUND", &expectError{grpcCode: &notFound, raw: "NOT_FOUND"}, false},
+		{`current.error contains "refused"`, &expectError{cond: `current.error contains "refused"`, raw: `current.error contains "refused"`}, false},
+		{uint64(6), nil, true},
+		{"", nil, true},
+		{true, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := newExpectError(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: got %v\nwantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.statusClass != tt.want.statusClass || got.cond != tt.want.cond || got.raw != tt.want.raw {
+			t.Errorf("%v: got %#v\nwant %#v", tt.in, got, tt.want)
+		}
+		if (got.grpcCode == nil) != (tt.want.grpcCode == nil) || (got.grpcCode != nil && *got.grpcCode != *tt.want.grpcCode) {
+			t.Errorf("%v: got %v\nwant %v", tt.in, got.grpcCode, tt.want.grpcCode)
+		}
+	}
+}
+
+func TestExpectError(t *testing.T) {
+	tests := []struct {
+		name    string
+		step    map[string]any
+		wantErr string
+	}{
+		{
+			"status class matched",
+			map[string]any{"req": map[string]any{"/notfound": map[string]any{"get": map[string]any{"body": nil}}}, "expectError": "4xx"},
+			"",
+		},
+		{
+			"status class and test",
+			map[string]any{"req": map[string]any{"/notfound": map[string]any{"get": map[string]any{"body": nil}}}, "expectError": "4xx", "test": "current.res.status == 404"},
+			"",
+		},
+		{
+			"status class not matched",
+			map[string]any{"req": map[string]any{"/ok": map[string]any{"get": map[string]any{"body": nil}}}, "expectError": "4xx"},
+			"expected error (4xx) on \"\".steps[0], but got status 200",
+		},
+		{
+			"expression matched",
+			map[string]any{"req": map[string]any{"/notfound": map[string]any{"get": map[string]any{"body": nil}}}, "expectError": "current.res.status == 404"},
+			"",
+		},
+		{
+			"runner error matched",
+			map[string]any{"unreachable": map[string]any{"/ok": map[string]any{"get": map[string]any{"body": nil}}}, "expectError": `current.error contains "http request failed"`, "test": `current.error != ""`},
+			"",
+		},
+		{
+			"runner error not matched",
+			map[string]any{"unreachable": map[string]any{"/ok": map[string]any{"get": map[string]any{"body": nil}}}, "expectError": "5xx"},
+			"expected error (5xx) on \"\".steps[0], but got: http request failed",
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mux := http.NewServeMux()
+			mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			})
+			mux.HandleFunc("/notfound", func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			})
+			o, err := New(HTTPRunnerWithHandler("req", mux), Runner("unreachable", "http://127.0.0.1:1"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.AppendStep(0, "", tt.step); err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("want error")
+			}
+			if !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v\nwant %v", err, tt.wantErr)
+			}
+		})
+	}
+}
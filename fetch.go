@@ -0,0 +1,127 @@
+package runn
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// fetchCallRe - Call of fetch() copied by the browser devtools ( e.g. `fetch("https://...", {...});`, `await fetch("https://...", {...});` )
+var fetchCallRe = regexp.MustCompile(`(?:^|[\s;])(?:await\s+)?fetch\s*\(`)
+
+// fetchOptions - Options ( the second argument ) of fetch(). Only the fields required to generate the steps are defined.
+type fetchOptions struct {
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    *string           `json:"body"`
+}
+
+func isFetchSnippet(in ...string) bool {
+	if len(in) != 1 {
+		return false
+	}
+	loc := fetchCallRe.FindStringIndex(strings.TrimSpace(in[0]))
+	return loc != nil && loc[0] == 0
+}
+
+// fetchToSteps appends the HTTP steps converted from the fetch() calls of the snippet ( "Copy as fetch" / "Copy all as fetch" ).
+func (rb *runbook) fetchToSteps(s string) error {
+	reqs, err := newRequestsFromFetch(s)
+	if err != nil {
+		return err
+	}
+	for _, req := range reqs {
+		dsn := fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host)
+		key := rb.setRunner(dsn)
+		step, err := CreateHTTPStepMapSlice(key, req)
+		if err != nil {
+			return err
+		}
+		if rb.useMap {
+			rb.stepKeys = append(rb.stepKeys, fmt.Sprintf("%s%d", strings.ToLower(req.Method), len(rb.stepKeys)))
+		}
+		rb.Steps = append(rb.Steps, step)
+	}
+	return nil
+}
+
+// newRequestsFromFetch creates the *http.Requests from the fetch() calls of the snippet.
+// The arguments of fetch() are parsed as JSON because the browser devtools copy them as JSON compatible literals.
+func newRequestsFromFetch(s string) ([]*http.Request, error) {
+	var reqs []*http.Request
+	for {
+		loc := fetchCallRe.FindStringIndex(s)
+		if loc == nil {
+			break
+		}
+		s = s[loc[1]:]
+		dec := json.NewDecoder(strings.NewReader(s))
+		var (
+			rawURL string
+			opts   fetchOptions
+		)
+		if err := dec.Decode(&rawURL); err != nil {
+			return nil, fmt.Errorf("invalid fetch() resource: %w", err)
+		}
+		rest := strings.TrimLeft(s[dec.InputOffset():], " \t\r\n")
+		if strings.HasPrefix(rest, ",") {
+			rest = strings.TrimLeft(rest[1:], " \t\r\n")
+			if !strings.HasPrefix(rest, ")") {
+				dec = json.NewDecoder(strings.NewReader(rest))
+				if err := dec.Decode(&opts); err != nil {
+					return nil, fmt.Errorf("invalid fetch() options: %w", err)
+				}
+				rest = rest[dec.InputOffset():]
+			}
+		}
+		s = rest
+		req, err := opts.toRequest(rawURL)
+		if err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	if len(reqs) == 0 {
+		return nil, errors.New("no fetch() calls")
+	}
+	return reqs, nil
+}
+
+func (o *fetchOptions) toRequest(rawURL string) (*http.Request, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid url: %s", rawURL)
+	}
+	method := strings.ToUpper(o.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+	var body io.Reader
+	if o.Body != nil {
+		body = strings.NewReader(*o.Body)
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, v := range o.Headers {
+		k = http.CanonicalHeaderKey(k)
+		if contains(harIgnoreHeaders, k) {
+			continue
+		}
+		req.Header.Set(k, v)
+	}
+	if o.Body != nil && req.Header.Get("Content-Type") == "" {
+		// fetch() sends the string body as text/plain by default
+		req.Header.Set("Content-Type", MediaTypeTextPlain)
+	}
+	return req, nil
+}
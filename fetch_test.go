@@ -0,0 +1,52 @@
+package runn
+
+import (
+	"testing"
+)
+
+func TestIsFetchSnippet(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want bool
+	}{
+		{[]string{`fetch("https://example.com/");`}, true},
+		{[]string{`await fetch("https://example.com/", {"method": "GET"});`}, true},
+		{[]string{"fetchmail", "-v"}, false},
+		{[]string{"fetchmail -v"}, false},
+		{[]string{`echo 'fetch("https://example.com/")'`}, false},
+	}
+	for _, tt := range tests {
+		if got := isFetchSnippet(tt.in...); got != tt.want {
+			t.Errorf("%v: got %v want %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestNewRequestsFromFetch(t *testing.T) {
+	tests := []struct {
+		in          string
+		wantMethods []string
+		wantErr     bool
+	}{
+		{`fetch("https://example.com/");`, []string{"GET"}, false},
+		{`await fetch("https://example.com/users", {"method": "post", "body": "hello"}); await fetch("https://example.com/users/1", {"method": "DELETE"});`, []string{"POST", "DELETE"}, false},
+		{`fetch(url);`, nil, true},
+		{`fetch("/relative");`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := newRequestsFromFetch(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v", tt.in, err)
+			continue
+		}
+		if len(got) != len(tt.wantMethods) {
+			t.Errorf("%s: got %d requests want %d", tt.in, len(got), len(tt.wantMethods))
+			continue
+		}
+		for i, req := range got {
+			if req.Method != tt.wantMethods[i] {
+				t.Errorf("%s: got %v want %v", tt.in, req.Method, tt.wantMethods[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,51 @@
+package runn
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/goccy/go-yaml"
+)
+
+const fileFuncName = "file"
+
+// fileFunc - Built-in function `file` that reads the file relative to the root directory of the runbook.
+type fileFunc func(p string, format ...string) (any, error)
+
+// newFileFunc returns the built-in function `file` bound to the root directory.
+// The format is one of `string` ( default ), `bytes`, `json` and `yaml`.
+func newFileFunc(root string) fileFunc {
+	return func(p string, format ...string) (any, error) {
+		if len(format) > 1 {
+			return nil, fmt.Errorf("invalid file format: %v", format)
+		}
+		b, err := readFile(fp(p, root))
+		if err != nil {
+			return nil, err
+		}
+		f := "string"
+		if len(format) == 1 {
+			f = format[0]
+		}
+		switch f {
+		case "string":
+			return string(b), nil
+		case "bytes":
+			return b, nil
+		case "json":
+			var v any
+			if err := json.Unmarshal(b, &v); err != nil {
+				return nil, fmt.Errorf("invalid json file %s: %w", p, err)
+			}
+			return v, nil
+		case "yaml":
+			var v any
+			if err := yaml.Unmarshal(b, &v); err != nil {
+				return nil, fmt.Errorf("invalid yaml file %s: %w", p, err)
+			}
+			return v, nil
+		default:
+			return nil, fmt.Errorf("invalid file format: %s", f)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package runn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFileFunc(t *testing.T) {
+	tests := []struct {
+		p       string
+		format  []string
+		want    any
+		wantErr bool
+	}{
+		{"vars.json", nil, "{\n    \"foo\": \"test\",\n    \"bar\": 1\n}", false},
+		{"vars.json", []string{"json"}, map[string]any{"foo": "test", "bar": float64(1)}, false},
+		{"vars.yml", []string{"yaml"}, map[string]any{"foo": "test", "bar": uint64(1), "baz": 2.5}, false},
+		{"vars.json", []string{"csv"}, nil, true},
+		{"vars.json", []string{"json", "yaml"}, nil, true},
+		{"notexist.json", nil, nil, true},
+	}
+	fn := newFileFunc("testdata")
+	for _, tt := range tests {
+		got, err := fn(tt.p, tt.format...)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %s %v", tt.p, tt.format)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
@@ -0,0 +1,67 @@
+package runn
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+)
+
+const fixtureFuncName = "fixture"
+
+// fixtureFunc - Built-in function `fixture` that loads the expected value from the JSON or YAML file relative to the root directory of the runbook.
+type fixtureFunc func(p string, values ...map[string]any) (any, error)
+
+// newFixtureFunc returns the built-in function `fixture` bound to the root directory.
+// The templated fields ( `{{ }}` ) in the file are expanded with the store returned by storeFn and the values.
+func newFixtureFunc(root string, storeFn func() map[string]any) fixtureFunc {
+	return func(p string, values ...map[string]any) (any, error) {
+		if len(values) > 1 {
+			return nil, fmt.Errorf("invalid fixture values: %v", values)
+		}
+		b, err := readFile(fp(p, root))
+		if err != nil {
+			return nil, err
+		}
+		var v any
+		switch strings.ToLower(filepath.Ext(p)) {
+		case ".json":
+			if err := json.Unmarshal(b, &v); err != nil {
+				return nil, fmt.Errorf("invalid json file %s: %w", p, err)
+			}
+		case ".yml", ".yaml":
+			if err := yaml.Unmarshal(b, &v); err != nil {
+				return nil, fmt.Errorf("invalid yaml file %s: %w", p, err)
+			}
+		default:
+			return nil, fmt.Errorf("unsupported fixture file: %s", p)
+		}
+		if !strings.Contains(string(b), delimStart) {
+			return v, nil
+		}
+		store := map[string]any{}
+		if storeFn != nil {
+			store = storeFn()
+		}
+		if len(values) == 1 {
+			for k, vv := range values[0] {
+				store[k] = vv
+			}
+		}
+		e, err := EvalExpand(v, store)
+		if err != nil {
+			return nil, fmt.Errorf("failed to expand fixture file %s: %w", p, err)
+		}
+		return e, nil
+	}
+}
+
+// fixtureStore returns the function that returns the store to expand the fixture file in the test of the current step.
+// first is same as the argument of testRunner.Run.
+func (o *operator) fixtureStore(first bool) func() map[string]any {
+	return func() map[string]any {
+		return o.testStore(first)
+	}
+}
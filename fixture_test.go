@@ -0,0 +1,80 @@
+package runn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestFixtureFunc(t *testing.T) {
+	store := func() map[string]any {
+		return map[string]any{"vars": map[string]any{"user": map[string]any{"id": 2}}}
+	}
+	tests := []struct {
+		p       string
+		values  []map[string]any
+		want    any
+		wantErr bool
+	}{
+		{"fixture/user.yml", nil, map[string]any{"id": uint64(1), "name": "alice", "roles": []any{"admin", "member"}}, false},
+		{"fixture/user.json", []map[string]any{{"name": "bob"}}, map[string]any{"id": uint64(2), "name": "bob", "roles": []any{"admin", "member"}}, false},
+		{"fixture/user.json", []map[string]any{{"name": "bob"}, {"name": "carol"}}, nil, true},
+		{"fixture/notexist.json", nil, nil, true},
+		{"cases.csv", nil, nil, true},
+	}
+	fn := newFixtureFunc("testdata", store)
+	for _, tt := range tests {
+		got, err := fn(tt.p, tt.values...)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %v", tt.p)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestFixtureStore(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec, ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "greeting.json"), []byte(`{"stdout": "{{ previous.stdout }}"}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	book := `desc: Expand the fixture with the store of the test
+steps:
+  greet:
+    exec:
+      command: echo -n hello
+  check:
+    test: |
+      compare(fixture("greeting.json"), {stdout: "hello"})
+`
+	p := filepath.Join(dir, "fixture.yml")
+	if err := os.WriteFile(p, []byte(book), 0600); err != nil {
+		t.Fatal(err)
+	}
+	o, err := New(Book(p), Scopes(ScopeAllowReadParent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
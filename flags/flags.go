@@ -28,6 +28,7 @@ type Flags struct {
 	FailFast        bool     `usage:"fail fast"`
 	SkipTest        bool     `usage:"skip \"test:\" section"`
 	SkipIncluded    bool     `usage:"skip running the included runbook by itself"`
+	IncludeMaxDepth int      `usage:"max depth of nested includes. 0 means unlimited"`
 	RunMatch        string   `usage:"run all runbooks with a matching file path, treating the value passed to the option as an unanchored regular expression"`
 	RunIDs          []string `usage:"run the matching runbooks in order if there is only one runbook with a forward matching ID"`
 	RunLabels       []string `usage:"run all runbooks matching the label specification"`
@@ -57,13 +58,37 @@ type Flags struct {
 	LoadTMaxRPS     int      `usage:"max RunN per second for load test. 0 means unlimited"`
 	Profile         bool     `usage:"profile runs of runbooks"`
 	ProfileOut      string   `usage:"profile output path"`
+	UpdateSnapshots bool     `usage:"create or update the snapshot files of \"snapshot:\" instead of comparing"`
+	SoftAssertions  bool     `usage:"evaluate all the conditions joined by \"&&\" in \"test:\" and report every failure"`
+	SharedStore     bool     `usage:"enable the store (\"shared.*\") shared across runbooks in one run"`
+	StoreIn         string   `usage:"load the store file dumped by --store-out to seed vars and bound values"`
+	StoreOut        string   `usage:"dump the vars and bound values of the store to the file after running"`
+	StartStep       string   `usage:"run the steps from the specified step (index, key or name of the step)"`
+	EndStep         string   `usage:"run the steps up to the specified step (index, key or name of the step)"`
+	Steps           []string `usage:"run only the specified steps (index, key or name of the step)"`
+	Replay          string   `usage:"replay the results of the steps dumped by --steps-out instead of running the steps before the selected steps"`
+	StepsOut        string   `usage:"dump the results of the steps to the file after running"`
+	DryRun          bool     `usage:"parse the runbooks and print the planned steps without running them"`
+	Diagram         string   `usage:"type of the Mermaid diagram (\"flowchart\" or \"sequence\")"`
+	DiffFields      []string `usage:"expressions evaluated with each step result to be compared additionally (e.g. \"res.body.id\"). Available for the results dumped by --steps-out"`
+	WatchDebounce   string   `usage:"duration to wait for the changes of the runbooks to settle before re-running"`
 	ProfileDepth    int      `usage:"depth of profile"`
 	ProfileUnit     string   `usage:"-"`
 	ProfileSort     string   `usage:"-"`
 	CacheDir        string   `usage:"specify cache directory for remote runbooks"`
 	RetainCacheDir  bool     `usage:"retain cache directory for remote runbooks"`
 	Scopes          []string `usage:"additional scopes for runn"`
+	EnvFiles        []string `usage:"dotenv files to load for expanding environment variables"`
 	HostRules       []string `usage:"host rules for runn. (\"host rule,host rule,...\")"`
+	LintConfig      string   `usage:"config file of the rules of \"runn lint\""`
+	FmtCheck        bool     `usage:"list the unformatted runbooks without writing and exit with status 1 if any"`
+	HARAllowHosts   []string `usage:"hosts of the HAR entries to be converted to steps (wildcard supported). If not set, all the entries are converted"`
+	OutDir          string   `usage:"output directory of the scaffolded runbooks"`
+	ScaffoldBy      string   `usage:"unit of the scaffolded runbooks (\"operation\" or \"tag\")"`
+	ResolveVars     bool     `usage:"substitute the variables that can be evaluated before running (e.g. vars) in the exported commands"`
+	CollectionName  string   `usage:"name of the exported collection"`
+	RecordListen    string   `usage:"address to listen on for the recording proxy"`
+	RecordTarget    string   `usage:"upstream URL of the recording proxy. If not set, it works as the forward proxy (HTTP only)"`
 	Verbose         bool     `usage:"verbose"`
 }
 
@@ -78,14 +103,24 @@ func (f *Flags) ToOpts() ([]runn.Option, error) {
 		runn.Debug(f.Debug),
 		runn.SkipTest(f.SkipTest),
 		runn.SkipIncluded(f.SkipIncluded),
+		runn.IncludeMaxDepth(f.IncludeMaxDepth),
 		runn.HTTPOpenApi3s(f.HTTPOpenApi3s),
 		runn.GRPCNoTLS(f.GRPCNoTLS),
 		runn.GRPCProtos(f.GRPCProtos),
 		runn.GRPCImportPaths(f.GRPCImportPaths),
 		runn.Profile(f.Profile),
 		runn.Scopes(f.Scopes...),
+		runn.EnvFile(f.EnvFiles...),
 		runn.HostRules(f.HostRules...),
 		runn.RunLabel(f.RunLabels...),
+		runn.LoadStore(f.StoreIn),
+		runn.StartStep(f.StartStep),
+		runn.EndStep(f.EndStep),
+		runn.RunStep(f.Steps...),
+		runn.ReplaySteps(f.Replay),
+		runn.SharedStore(f.SharedStore),
+		runn.UpdateSnapshots(f.UpdateSnapshots),
+		runn.SoftAssertions(f.SoftAssertions),
 	}
 
 	// runbook ID
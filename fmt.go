@@ -0,0 +1,450 @@
+package runn
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/goccy/go-yaml/ast"
+	"github.com/goccy/go-yaml/lexer"
+	"github.com/goccy/go-yaml/parser"
+	"github.com/goccy/go-yaml/token"
+	"gopkg.in/yaml.v2"
+)
+
+const fmtIndent = 2
+
+// runbookKeyOrder - Canonical order of the keys of the runbook.
+var runbookKeyOrder = []string{
+	"id", "desc", "labels", "runners", "hostRules", "vars", "consts", "varsSchema", "lazyVars", "secrets", "envFiles",
+	"debug", "interval", "timeout", "if", "skipTest", "force", "trace", "loop", "cases", "concurrency",
+	"templates", "hooks", "steps",
+}
+
+// stepKeyOrder - Canonical order of the keys of the step. The runner keys not listed here are placed between `with` and `dump`.
+var stepKeyOrder = []string{
+	nameSectionKey, descSectionKey, ifSectionKey, skipSectionKey, onlySectionKey, needsSectionKey, deferSectionKey, forceSectionKey,
+	loopSectionKey, retrySectionKey, eventuallySectionKey, maxLatencySectionKey, expectErrorSectionKey, useSectionKey, withSectionKey,
+	"",
+	dumpRunnerKey, bindRunnerKey, testRunnerKey, snapshotRunnerKey, gotoSectionKey,
+}
+
+// Format formats the runbooks matched by pathp and returns the paths of the runbooks that are not formatted.
+// If write is true, the formatted runbooks are written back to the files.
+func Format(pathp string, write bool) ([]string, error) {
+	paths, err := fetchPaths(pathp)
+	if err != nil {
+		return nil, err
+	}
+	var unformatted []string
+	for _, p := range paths {
+		b, err := readFile(p)
+		if err != nil {
+			return nil, err
+		}
+		out, err := FormatRunbook(b)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", p, err)
+		}
+		if bytes.Equal(b, out) {
+			continue
+		}
+		unformatted = append(unformatted, p)
+		if !write {
+			continue
+		}
+		fi, err := os.Stat(p)
+		if err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(p, out, fi.Mode()); err != nil {
+			return nil, err
+		}
+	}
+	return unformatted, nil
+}
+
+// FormatRunbook formats the runbook in the canonical style.
+// It normalizes the order of the keys of the runbook and the steps, the indentation and the quoting, preserving the comments.
+func FormatRunbook(in []byte) ([]byte, error) {
+	f, err := parser.ParseBytes(in, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	blanks := blankLinedKeys(in, f)
+	for _, d := range f.Docs {
+		if d.Body == nil {
+			continue
+		}
+		sortMapping(d.Body, runbookKeyOrder)
+		for _, v := range mappingValues(d.Body) {
+			switch mappingKey(v) {
+			case "steps":
+				sortSteps(v.Value)
+			case "hooks":
+				for _, h := range mappingValues(v.Value) {
+					sortSteps(h.Value)
+				}
+			}
+		}
+		keepTrailingNewlines(d.Body)
+		reindent(d.Body, 1)
+		ast.Walk(quoteNormalizer{}, d.Body)
+	}
+	out, err := breakListSteps([]byte(strings.TrimRight(f.String(), "\n") + "\n"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to format: %w", err)
+	}
+	out, err = insertBlankLines(out, blanks)
+	if err != nil {
+		return nil, fmt.Errorf("failed to format: %w", err)
+	}
+
+	// Make sure that the formatting does not change the runbook
+	// Use the other YAML parser than goccy/go-yaml, which is lenient with the aliases and the chomping of literal blocks
+	var before, after any
+	if err := yaml.Unmarshal(in, &before); err != nil {
+		return nil, err
+	}
+	if err := yaml.Unmarshal(out, &after); err != nil {
+		return nil, fmt.Errorf("failed to format: %w", err)
+	}
+	if !reflect.DeepEqual(before, after) {
+		return nil, errors.New("failed to format: the formatted runbook differs from the original")
+	}
+	if countComments(in) != countComments(out) {
+		return nil, errors.New("failed to format: the comments cannot be preserved")
+	}
+	return out, nil
+}
+
+func mappingValues(n ast.Node) []*ast.MappingValueNode {
+	switch nn := n.(type) {
+	case *ast.MappingNode:
+		return nn.Values
+	case *ast.MappingValueNode:
+		return []*ast.MappingValueNode{nn}
+	}
+	return nil
+}
+
+// mappingKey returns the key of the mapping value without the comment.
+func mappingKey(v *ast.MappingValueNode) string {
+	return v.Key.GetToken().Value
+}
+
+// sortMapping sorts the keys of the mapping in the order. If the order has no place for the other keys ( "" ),
+// the other keys are kept in their original place. The keys are not sorted if an anchor would be moved below its aliases.
+func sortMapping(n ast.Node, order []string) {
+	m, ok := n.(*ast.MappingNode)
+	if !ok || m.IsFlowStyle {
+		return
+	}
+	other := -1
+	for i, o := range order {
+		if o == "" {
+			other = i
+		}
+	}
+	rank := func(k string) int {
+		for i, o := range order {
+			if o == k {
+				return i
+			}
+		}
+		return other
+	}
+	orig := append([]*ast.MappingValueNode(nil), m.Values...)
+	var (
+		idx    []int
+		values []*ast.MappingValueNode
+	)
+	for i, v := range m.Values {
+		if rank(mappingKey(v)) < 0 {
+			continue
+		}
+		idx = append(idx, i)
+		values = append(values, v)
+	}
+	sort.SliceStable(values, func(i, j int) bool {
+		return rank(mappingKey(values[i])) < rank(mappingKey(values[j]))
+	})
+	for i, v := range values {
+		m.Values[idx[i]] = v
+	}
+	if aliasBeforeAnchor(m.Values) {
+		m.Values = orig
+	}
+}
+
+// aliasBeforeAnchor reports whether an alias refers to the anchor defined in the later values.
+func aliasBeforeAnchor(values []*ast.MappingValueNode) bool {
+	anchors := make([]map[string]bool, len(values))
+	aliases := make([][]string, len(values))
+	all := map[string]bool{}
+	for i, v := range values {
+		c := &anchorCollector{anchors: map[string]bool{}}
+		ast.Walk(c, v)
+		anchors[i] = c.anchors
+		aliases[i] = c.aliases
+		for a := range c.anchors {
+			all[a] = true
+		}
+	}
+	defined := map[string]bool{}
+	for i := range values {
+		for _, a := range aliases[i] {
+			if all[a] && !defined[a] && !anchors[i][a] {
+				return true
+			}
+		}
+		for a := range anchors[i] {
+			defined[a] = true
+		}
+	}
+	return false
+}
+
+// anchorCollector collects the names of the anchors and the aliases.
+type anchorCollector struct {
+	anchors map[string]bool
+	aliases []string
+}
+
+func (c *anchorCollector) Visit(n ast.Node) ast.Visitor {
+	switch nn := n.(type) {
+	case *ast.AnchorNode:
+		c.anchors[nn.Name.GetToken().Value] = true
+	case *ast.AliasNode:
+		c.aliases = append(c.aliases, nn.Value.GetToken().Value)
+	}
+	return c
+}
+
+func sortSteps(n ast.Node) {
+	switch nn := n.(type) {
+	case *ast.SequenceNode:
+		for _, s := range nn.Values {
+			sortMapping(s, stepKeyOrder)
+		}
+	default:
+		for _, v := range mappingValues(n) {
+			sortMapping(v.Value, stepKeyOrder)
+		}
+	}
+}
+
+// reindent shifts the block nodes so that the nested block is indented by fmtIndent from the parent.
+func reindent(n ast.Node, col int) {
+	switch nn := n.(type) {
+	case *ast.MappingNode:
+		if nn.IsFlowStyle {
+			return
+		}
+		for _, v := range nn.Values {
+			reindent(v, col)
+		}
+	case *ast.MappingValueNode:
+		nn.AddColumn(col - nn.Key.GetToken().Position.Column)
+		switch v := nn.Value.(type) {
+		case *ast.MappingNode, *ast.MappingValueNode, *ast.SequenceNode:
+			reindent(v, col+fmtIndent)
+		case *ast.LiteralNode:
+			reindentLiteral(v, col+fmtIndent)
+		}
+	case *ast.SequenceNode:
+		if nn.IsFlowStyle {
+			return
+		}
+		nn.AddColumn(col - nn.Start.Position.Column)
+		for _, v := range nn.Values {
+			switch v.(type) {
+			case *ast.MappingNode, *ast.MappingValueNode, *ast.SequenceNode:
+				reindent(v, col+fmtIndent)
+			}
+		}
+	}
+}
+
+func reindentLiteral(n *ast.LiteralNode, col int) {
+	space := strings.Repeat(" ", col-1)
+	lines := strings.Split(n.Value.Value, "\n")
+	for i, l := range lines {
+		if l == "" {
+			continue
+		}
+		lines[i] = space + l
+	}
+	n.Value.Token.Origin = strings.Join(lines, "\n")
+}
+
+// quoteNormalizer replaces the single quotes with the double quotes if the value does not need escaping.
+type quoteNormalizer struct{}
+
+func (q quoteNormalizer) Visit(n ast.Node) ast.Visitor {
+	s, ok := n.(*ast.StringNode)
+	if !ok || s.Token.Type != token.SingleQuoteType {
+		return q
+	}
+	if strings.ContainsAny(s.Value, "\"\\") || strings.ContainsFunc(s.Value, func(r rune) bool { return !unicode.IsPrint(r) }) {
+		return q
+	}
+	s.Token.Type = token.DoubleQuoteType
+	return q
+}
+
+// keepTrailingNewlines replaces the literal blocks of the keep chomping ( `|+` ) ending with the blank lines with the double-quoted strings,
+// because the blank lines at the end of the literal block are dropped when printing.
+func keepTrailingNewlines(n ast.Node) {
+	keep := func(v ast.Node) ast.Node {
+		l, ok := v.(*ast.LiteralNode)
+		// Only the keep chomping ( `|+` ) includes the trailing blank lines in the value
+		if !ok || !strings.Contains(l.Start.Value, "+") || !strings.HasSuffix(l.Value.Value, "\n\n") {
+			keepTrailingNewlines(v)
+			return v
+		}
+		tk := &token.Token{
+			Type:     token.DoubleQuoteType,
+			Value:    l.Value.Value,
+			Origin:   strconv.Quote(l.Value.Value),
+			Position: l.Start.Position,
+		}
+		sn := ast.String(tk)
+		if l.Comment != nil {
+			_ = sn.SetComment(l.Comment)
+		}
+		return sn
+	}
+	switch nn := n.(type) {
+	case *ast.MappingNode:
+		for _, v := range nn.Values {
+			keepTrailingNewlines(v)
+		}
+	case *ast.MappingValueNode:
+		nn.Value = keep(nn.Value)
+	case *ast.SequenceNode:
+		for i, v := range nn.Values {
+			nn.Values[i] = keep(v)
+		}
+	}
+}
+
+// breakListSteps breaks the lines of the list-form steps after `-` ( e.g. `- exec:` -> `-\n    exec:` ), same as the style of the runbooks.
+func breakListSteps(b []byte) ([]byte, error) {
+	f, err := parser.ParseBytes(b, 0)
+	if err != nil {
+		return nil, err
+	}
+	var seqs []*ast.SequenceNode
+	for _, d := range f.Docs {
+		for _, v := range mappingValues(d.Body) {
+			switch mappingKey(v) {
+			case "steps":
+				if sn, ok := v.Value.(*ast.SequenceNode); ok {
+					seqs = append(seqs, sn)
+				}
+			case "hooks":
+				for _, h := range mappingValues(v.Value) {
+					if sn, ok := h.Value.(*ast.SequenceNode); ok {
+						seqs = append(seqs, sn)
+					}
+				}
+			}
+		}
+	}
+	// Positions of the first keys of the steps
+	var keys []*token.Position
+	for _, sn := range seqs {
+		if sn.IsFlowStyle {
+			continue
+		}
+		for _, s := range sn.Values {
+			vs := mappingValues(s)
+			if m, ok := s.(*ast.MappingNode); ok && m.IsFlowStyle || len(vs) == 0 {
+				continue
+			}
+			keys = append(keys, vs[0].Key.GetToken().Position)
+		}
+	}
+	lines := strings.Split(string(b), "\n")
+	// Break the lines from the bottom not to shift the lines to break
+	sort.Slice(keys, func(i, j int) bool { return keys[i].Line > keys[j].Line })
+	for _, k := range keys {
+		l := lines[k.Line-1]
+		col := k.Column - 1
+		if col < 2 || len(l) < col || l[col-2:col] != "- " {
+			// Already broken
+			continue
+		}
+		broken := []string{l[:col-1], strings.Repeat(" ", col) + l[col:]}
+		lines = append(lines[:k.Line-1], append(broken, lines[k.Line:]...)...)
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+// blankLinedKeys returns the top-level keys preceded by the blank lines ( and the head comments ) of each document.
+func blankLinedKeys(in []byte, f *ast.File) []map[string]bool {
+	lines := strings.Split(string(in), "\n")
+	blanks := make([]map[string]bool, len(f.Docs))
+	for i, d := range f.Docs {
+		blanks[i] = map[string]bool{}
+		for _, v := range mappingValues(d.Body) {
+			l := v.Key.GetToken().Position.Line - 2
+			for l >= 0 && strings.HasPrefix(lines[l], "#") {
+				l--
+			}
+			if l >= 0 && strings.TrimSpace(lines[l]) == "" {
+				blanks[i][mappingKey(v)] = true
+			}
+		}
+	}
+	return blanks
+}
+
+// insertBlankLines inserts the blank lines before the top-level keys of blanks ( and the head comments ), which are dropped when printing.
+func insertBlankLines(b []byte, blanks []map[string]bool) ([]byte, error) {
+	f, err := parser.ParseBytes(b, 0)
+	if err != nil {
+		return nil, err
+	}
+	var insert []int
+	for i, d := range f.Docs {
+		if i >= len(blanks) {
+			break
+		}
+		for _, v := range mappingValues(d.Body) {
+			if blanks[i][mappingKey(v)] {
+				insert = append(insert, v.Key.GetToken().Position.Line-1)
+			}
+		}
+	}
+	lines := strings.Split(string(b), "\n")
+	// Insert the lines from the bottom not to shift the lines to insert
+	sort.Sort(sort.Reverse(sort.IntSlice(insert)))
+	for _, l := range insert {
+		for l > 0 && strings.HasPrefix(lines[l-1], "#") {
+			l--
+		}
+		if l == 0 || strings.TrimSpace(lines[l-1]) == "" {
+			continue
+		}
+		lines = append(lines[:l], append([]string{""}, lines[l:]...)...)
+	}
+	return []byte(strings.Join(lines, "\n")), nil
+}
+
+func countComments(b []byte) int {
+	c := 0
+	for _, t := range lexer.Tokenize(string(b)) {
+		if t.Type == token.CommentType {
+			c++
+		}
+	}
+	return c
+}
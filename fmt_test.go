@@ -0,0 +1,174 @@
+package runn
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+)
+
+func TestFormatRunbook(t *testing.T) {
+	in, err := os.ReadFile("testdata/fmt/unformatted.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := os.ReadFile("testdata/fmt/formatted.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := FormatRunbook(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(want), string(got)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestFormatRunbookValues(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			"list-form steps",
+			"desc: x\nsteps:\n- exec:\n    command: echo\n  desc: d\n- test: true\n",
+			"desc: x\nsteps:\n  -\n    desc: d\n    exec:\n      command: echo\n  -\n    test: true\n",
+		},
+		{
+			"steps of hooks",
+			"desc: x\nhooks:\n  beforeEach:\n    - test: true\nsteps:\n  -\n    test: true\n",
+			"desc: x\nhooks:\n  beforeEach:\n    -\n      test: true\nsteps:\n  -\n    test: true\n",
+		},
+		{
+			"not steps",
+			"desc: x\nvars:\n  users:\n    - name: alice\nsteps:\n  -\n    test: true\n",
+			"desc: x\nvars:\n  users:\n    - name: alice\nsteps:\n  -\n    test: true\n",
+		},
+		{
+			"keep unknown keys in place",
+			"steps:\n  -\n    test: true\nmy_aliases:\n  a: 1\ndesc: x\n",
+			"desc: x\nmy_aliases:\n  a: 1\nsteps:\n  -\n    test: true\n",
+		},
+		{
+			"not move anchors below aliases",
+			"desc: x\nsteps:\n  a:\n    test: &t true\nvars:\n  v: *t\n",
+			"desc: x\nsteps:\n  a:\n    test: &t true\nvars:\n  v: *t\n",
+		},
+		{
+			"keep blank lines between top-level keys",
+			"vars:\n  a: 1\n\n# steps\nsteps:\n  -\n    test: |\n      true\n\n  -\n    test: true\n\ndesc: x\n",
+			"desc: x\nvars:\n  a: 1\n\n# steps\nsteps:\n  -\n    test: |\n      true\n  -\n    test: true\n",
+		},
+		{
+			"keep trailing newlines",
+			"desc: x\nsteps:\n  -\n    exec:\n      command: cat\n      stdin: |+\n        a\n\n\n  -\n    test: true\n",
+			"desc: x\nsteps:\n  -\n    exec:\n      command: cat\n      stdin: \"a\\n\\n\\n\"\n  -\n    test: true\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FormatRunbook([]byte(tt.in))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, string(got)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestFormatRunbookAnchors(t *testing.T) {
+	b, err := os.ReadFile("testdata/book/yaml_anchor_alias.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := FormatRunbook(b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The anchors must be defined before the aliases ( strict YAML parsers fail with the undefined aliases )
+	var v any
+	if err := yaml.Unmarshal(got, &v); err != nil {
+		t.Fatal(err)
+	}
+	out := string(got)
+	if strings.Index(out, "\nmy_aliases:") > strings.Index(out, "\nvars:") {
+		t.Errorf("the anchors are moved below the aliases:\n%s", out)
+	}
+	if want := strings.Count(string(b), "test: |\n"); strings.Count(out, "test: |\n") != want {
+		t.Errorf("the literal blocks are not kept:\n%s", out)
+	}
+	for _, k := range []string{"\n\nmy_aliases:", "\n\nvars:", "\n\nsteps:"} {
+		if !strings.Contains(out, k) {
+			t.Errorf("the blank line before %q is not kept:\n%s", strings.TrimSpace(k), out)
+		}
+	}
+}
+
+func TestFormatRunbookIdempotent(t *testing.T) {
+	paths, err := filepath.Glob("testdata/book/*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range paths {
+		t.Run(p, func(t *testing.T) {
+			b, err := os.ReadFile(p)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := FormatRunbook(b)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got2, err := FormatRunbook(got)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(string(got), string(got2)); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestFormat(t *testing.T) {
+	readParent := globalScopes.readParent
+	globalScopes.readParent = true
+	t.Cleanup(func() {
+		globalScopes.readParent = readParent
+	})
+	dir := t.TempDir()
+	b, err := os.ReadFile("testdata/fmt/unformatted.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := filepath.Join(dir, "book.yml")
+	if err := os.WriteFile(p, b, 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := Format(p, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{p}, got); diff != "" {
+		t.Error(diff)
+	}
+
+	if _, err := Format(p, true); err != nil {
+		t.Fatal(err)
+	}
+	got, err = Format(p, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != 0 {
+		t.Errorf("got %v want no unformatted runbooks", got)
+	}
+}
@@ -0,0 +1,3 @@
+package runn
+
+const forceSectionKey = "force"
@@ -0,0 +1,39 @@
+package runn
+
+import (
+	"context"
+	"testing"
+)
+
+func TestStepForce(t *testing.T) {
+	o, err := New(Book("testdata/book/step_force.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rs := o.Result().StepResults
+	if rs[0].Err == nil {
+		t.Error("the failure of the step with force should be reported")
+	}
+	if rs[1].Err != nil || rs[2].Err != nil {
+		t.Errorf("got %v, %v", rs[1].Err, rs[2].Err)
+	}
+}
+
+func TestStepForceDoesNotTolerateOthers(t *testing.T) {
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.AppendStep(0, "0", map[string]any{"force": true, "test": "false"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.AppendStep(1, "1", map[string]any{"test": "false"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err == nil {
+		t.Error("want error")
+	}
+}
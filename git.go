@@ -0,0 +1,218 @@
+package runn
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/cli/safeexec"
+)
+
+// gitMu - Lock for cloning the repositories into the cache directory.
+var gitMu sync.Mutex
+
+// gitFetched - Directories of the repositories fetched in this process. Guarded by gitMu
+var gitFetched = map[string]struct{}{}
+
+// gitProtocolConfig - Config to limit the transports used to fetch the repositories.
+var gitProtocolConfig = []string{
+	"-c", "protocol.allow=never",
+	"-c", "protocol.https.allow=always",
+	"-c", "protocol.ssh.allow=always",
+	"-c", "protocol.file.allow=always",
+}
+
+// commitHashRe - Full commit hash ( SHA-1 or SHA-256 ). The commit checked out by it is never updated.
+var commitHashRe = regexp.MustCompile(`^([0-9a-f]{40}|[0-9a-f]{64})$`)
+
+// gitSource - Source of the files in the git repository ( like `git::https://github.com/owner/repo.git//path/to/book.yml?ref=v1.0.0` ).
+type gitSource struct {
+	// repo - URL of the repository
+	repo string
+	// path - Path ( or glob pattern ) of the files in the repository
+	path string
+	// ref - Branch, tag or commit to check out. Empty means the default branch
+	ref string
+}
+
+// parseGitSource parses the path of git:: .
+func parseGitSource(p string) (*gitSource, error) {
+	src := strings.TrimPrefix(p, prefixGit)
+	g := &gitSource{}
+	if i := strings.LastIndex(src, "?"); i >= 0 {
+		q, err := url.ParseQuery(src[i+1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid git path: %w: %s", err, p)
+		}
+		for k := range q {
+			if k != "ref" {
+				return nil, fmt.Errorf("invalid git path: invalid query: %s: %s", k, p)
+			}
+		}
+		g.ref = q.Get("ref")
+		src = src[:i]
+	}
+	start := 0
+	if i := strings.Index(src, "://"); i >= 0 {
+		start = i + len("://")
+	}
+	i := strings.Index(src[start:], "//")
+	if i < 0 {
+		return nil, fmt.Errorf("invalid git path: the path in the repository is required ( like git::https://github.com/owner/repo.git//path/to/book.yml ): %s", p)
+	}
+	g.repo = src[:start+i]
+	g.path = src[start+i+len("//"):]
+	if g.repo == "" || g.path == "" {
+		return nil, fmt.Errorf("invalid git path: %s", p)
+	}
+	// Prevent the repository and the ref from being interpreted as options of git
+	if strings.HasPrefix(g.repo, "-") {
+		return nil, fmt.Errorf("invalid git path: the repository must not start with '-': %s", p)
+	}
+	if strings.HasPrefix(g.ref, "-") {
+		return nil, fmt.Errorf("invalid git path: the ref must not start with '-': %s", p)
+	}
+	// Prevent the path from pointing outside the cloned repository
+	if strings.HasPrefix(g.path, "/") || slices.Contains(strings.Split(g.path, "/"), "..") {
+		return nil, fmt.Errorf("invalid git path: the path in the repository must not be absolute or contain '..': %s", p)
+	}
+	return g, nil
+}
+
+// fetchPathsViaGit clones the repository into the cache directory and returns the paths of the files.
+// The cloned repository is reused while the cache directory exists.
+func fetchPathsViaGit(p string) ([]string, error) {
+	g, err := parseGitSource(p)
+	if err != nil {
+		return nil, err
+	}
+	dir, err := g.clone()
+	if err != nil {
+		return nil, err
+	}
+	if !strings.Contains(g.path, "*") {
+		fp := filepath.Join(dir, filepath.FromSlash(g.path))
+		if _, err := os.Stat(fp); err != nil {
+			return nil, fmt.Errorf("invalid git path: %w: %s", err, p)
+		}
+		if err := validateGitPath(dir, fp); err != nil {
+			return nil, fmt.Errorf("invalid git path: %w: %s", err, p)
+		}
+		return []string{fp}, nil
+	}
+	var paths []string
+	if err := doublestar.GlobWalk(os.DirFS(dir), g.path, func(pp string, d fs.DirEntry) error {
+		if d.IsDir() {
+			return nil
+		}
+		fp := filepath.Join(dir, filepath.FromSlash(pp))
+		if err := validateGitPath(dir, fp); err != nil {
+			return fmt.Errorf("invalid git path: %w: %s", err, p)
+		}
+		paths = append(paths, fp)
+		return nil
+	}); err != nil {
+		return nil, err
+	}
+	return paths, nil
+}
+
+// validateGitPath validates that the file resolves inside the cloned repository ( e.g. not via a symbolic link to the outside ).
+func validateGitPath(dir, fp string) error {
+	rdir, err := filepath.EvalSymlinks(dir)
+	if err != nil {
+		return err
+	}
+	rfp, err := filepath.EvalSymlinks(fp)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(rdir, rfp)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("%s resolves outside of the repository", fp)
+	}
+	return nil
+}
+
+// clone clones the repository at the ref into the cache directory and returns the directory.
+// The cached repository is fetched again once per process unless the ref is a commit hash, because the branch ( or tag ) may be updated.
+func (g *gitSource) clone() (string, error) {
+	gitMu.Lock()
+	defer gitMu.Unlock()
+	cd, err := cacheDir()
+	if err != nil {
+		return "", err
+	}
+	dir := filepath.Join(cd, schemeGit, fmt.Sprintf("%x", sha256.Sum256([]byte(g.repo+"@"+g.ref))))
+	if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+		if _, ok := gitFetched[dir]; ok || commitHashRe.MatchString(g.ref) {
+			return dir, nil
+		}
+		if err := g.fetch(dir); err != nil {
+			return "", err
+		}
+		gitFetched[dir] = struct{}{}
+		return dir, nil
+	}
+	if err := os.MkdirAll(dir, os.ModePerm); err != nil {
+		return "", err
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "--", "origin", g.repo},
+	} {
+		if err := runGit(dir, args...); err != nil {
+			_ = os.RemoveAll(dir)
+			return "", fmt.Errorf("failed to clone %s: %w", g.repo, err)
+		}
+	}
+	if err := g.fetch(dir); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", err
+	}
+	gitFetched[dir] = struct{}{}
+	return dir, nil
+}
+
+// fetch fetches the ref of the repository and checks it out.
+func (g *gitSource) fetch(dir string) error {
+	ref := g.ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	// Fetch only the ref so that the commit hash can also be specified
+	for _, args := range [][]string{
+		append(append([]string{}, gitProtocolConfig...), "fetch", "--quiet", "--depth", "1", "--", "origin", ref),
+		{"checkout", "--quiet", "--force", "--detach", "FETCH_HEAD"},
+	} {
+		if err := runGit(dir, args...); err != nil {
+			return fmt.Errorf("failed to clone %s: %w", g.repo, err)
+		}
+	}
+	return nil
+}
+
+func runGit(dir string, args ...string) error {
+	bin, err := safeexec.LookPath("git")
+	if err != nil {
+		return err
+	}
+	stderr := new(bytes.Buffer)
+	cmd := exec.Command(bin, args...)
+	cmd.Dir = dir
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("git %s: %w: %s", strings.Join(args, " "), err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
@@ -0,0 +1,248 @@
+package runn
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/cli/safeexec"
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseGitSource(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *gitSource
+		wantErr bool
+	}{
+		{
+			"git::https://github.com/k1LoW/runn.git//testdata/book/book.yml",
+			&gitSource{repo: "https://github.com/k1LoW/runn.git", path: "testdata/book/book.yml"},
+			false,
+		},
+		{
+			"git::https://github.com/k1LoW/runn.git//testdata/book/runn_*.yml?ref=v0.90.0",
+			&gitSource{repo: "https://github.com/k1LoW/runn.git", path: "testdata/book/runn_*.yml", ref: "v0.90.0"},
+			false,
+		},
+		{
+			"git::file:///path/to/repo//book.yml?ref=main",
+			&gitSource{repo: "file:///path/to/repo", path: "book.yml", ref: "main"},
+			false,
+		},
+		{"git::https://github.com/k1LoW/runn.git", nil, true},
+		{"git::https://github.com/k1LoW/runn.git//", nil, true},
+		{"git::https://github.com/k1LoW/runn.git//book.yml?tag=v0.90.0", nil, true},
+		{"git::--upload-pack=touch /tmp/pwned//book.yml", nil, true},
+		{"git::https://github.com/k1LoW/runn.git//book.yml?ref=--upload-pack=touch", nil, true},
+		{"git::https://github.com/k1LoW/runn.git//../../etc/passwd", nil, true},
+		{"git::https://github.com/k1LoW/runn.git//testdata/../../book.yml", nil, true},
+		{"git::https://github.com/k1LoW/runn.git///etc/passwd", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := parseGitSource(tt.in)
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+			if diff := cmp.Diff(got, tt.want, cmp.AllowUnexported(gitSource{})); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestIncludeGit(t *testing.T) {
+	if _, err := safeexec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent, ScopeDenyReadRemote); err != nil {
+			t.Fatal(err)
+		}
+		if err := RemoveCacheDir(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	repo := t.TempDir()
+	included := `desc: Shared setup
+steps:
+  -
+    bind:
+      token: '"shared"'
+`
+	if err := os.WriteFile(filepath.Join(repo, "setup.yml"), []byte(included), 0600); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "setup.yml"},
+		{"-c", "user.name=runn", "-c", "user.email=runn@example.com", "commit", "--quiet", "-m", "Add setup"},
+		{"tag", "v1.0.0"},
+	} {
+		if err := runGit(repo, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	sum := fmt.Sprintf("sha256:%x", sha256.Sum256([]byte(included)))
+	src := fmt.Sprintf("git::file://%s//setup.yml?ref=v1.0.0", filepath.ToSlash(repo))
+
+	tests := []struct {
+		checksum string
+		wantErr  string
+	}{
+		{"", ""},
+		{sum, ""},
+		{"sha256:0000", "checksum mismatch"},
+		{"md5:0000", "unsupported algorithm"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.checksum, func(t *testing.T) {
+			rb := fmt.Sprintf(`desc: Include from git
+steps:
+  setup:
+    include:
+      path: '%s'
+      checksum: '%s'
+  check:
+    test: steps.setup.token == "shared"
+`, src, tt.checksum)
+			p := filepath.Join(t.TempDir(), "include_git.yml")
+			if err := os.WriteFile(p, []byte(rb), 0600); err != nil {
+				t.Fatal(err)
+			}
+			o, err := New(Scopes(ScopeAllowReadParent, ScopeAllowReadRemote), Book(p))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v\nwant %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGitSourceCloneRefresh(t *testing.T) {
+	if _, err := safeexec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Cleanup(func() {
+		if err := RemoveCacheDir(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	repo := t.TempDir()
+	commit := func(content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(repo, "book.yml"), []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+		for _, args := range [][]string{
+			{"add", "book.yml"},
+			{"-c", "user.name=runn", "-c", "user.email=runn@example.com", "commit", "--quiet", "-m", content},
+		} {
+			if err := runGit(repo, args...); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	if err := runGit(repo, "init", "--quiet", "--initial-branch", "main"); err != nil {
+		t.Fatal(err)
+	}
+	commit("v1")
+	g := &gitSource{repo: fmt.Sprintf("file://%s", filepath.ToSlash(repo)), path: "book.yml", ref: "main"}
+	read := func() string {
+		t.Helper()
+		dir, err := g.clone()
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := os.ReadFile(filepath.Join(dir, "book.yml"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return string(b)
+	}
+	if got := read(); got != "v1" {
+		t.Errorf("got %v\nwant %v", got, "v1")
+	}
+	commit("v2")
+	// The repository is fetched once per process
+	if got := read(); got != "v1" {
+		t.Errorf("got %v\nwant %v", got, "v1")
+	}
+	// The branch of the repository cached by the previous process is updated
+	gitMu.Lock()
+	clear(gitFetched)
+	gitMu.Unlock()
+	if got := read(); got != "v2" {
+		t.Errorf("got %v\nwant %v", got, "v2")
+	}
+}
+
+func TestFetchPathsViaGitSymlinkOutside(t *testing.T) {
+	if _, err := safeexec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+	t.Cleanup(func() {
+		if err := RemoveCacheDir(); err != nil {
+			t.Fatal(err)
+		}
+	})
+	outside := filepath.Join(t.TempDir(), "secret.yml")
+	if err := os.WriteFile(outside, []byte("desc: secret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	repo := t.TempDir()
+	if err := os.WriteFile(filepath.Join(repo, "book.yml"), []byte("desc: book\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(outside, filepath.Join(repo, "link.yml")); err != nil {
+		t.Fatal(err)
+	}
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"add", "book.yml", "link.yml"},
+		{"-c", "user.name=runn", "-c", "user.email=runn@example.com", "commit", "--quiet", "-m", "Add books"},
+	} {
+		if err := runGit(repo, args...); err != nil {
+			t.Fatal(err)
+		}
+	}
+	src := fmt.Sprintf("git::file://%s", filepath.ToSlash(repo))
+
+	tests := []struct {
+		path    string
+		wantErr bool
+	}{
+		{"book.yml", false},
+		{"link.yml", true},
+		{"*.yml", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			_, err := fetchPathsViaGit(src + "//" + tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
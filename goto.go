@@ -0,0 +1,130 @@
+package runn
+
+import (
+	"fmt"
+)
+
+const gotoSectionKey = "goto"
+
+// stepGoto - Jump to the following step when the condition is met.
+type stepGoto struct {
+	// to - Key of the step to jump to
+	to string
+	// cond - Condition to jump. Empty means always
+	cond string
+}
+
+// parseGoto parses `goto:`. The value is the key of the step, the map of `to:` and `if:`, or the list of them.
+func parseGoto(v any) ([]stepGoto, error) {
+	var gotos []stepGoto
+	switch vv := v.(type) {
+	case string:
+		gotos = append(gotos, stepGoto{to: vv})
+	case map[string]any:
+		g, err := parseGotoMap(vv)
+		if err != nil {
+			return nil, err
+		}
+		gotos = append(gotos, g)
+	case []any:
+		for _, vvv := range vv {
+			m, ok := vvv.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid goto: %v", v)
+			}
+			g, err := parseGotoMap(m)
+			if err != nil {
+				return nil, err
+			}
+			gotos = append(gotos, g)
+		}
+	default:
+		return nil, fmt.Errorf("invalid goto: %v", v)
+	}
+	for _, g := range gotos {
+		if g.to == "" {
+			return nil, fmt.Errorf("invalid goto: the key of the step to jump to is empty: %v", v)
+		}
+	}
+	return gotos, nil
+}
+
+func parseGotoMap(m map[string]any) (stepGoto, error) {
+	g := stepGoto{}
+	for k, v := range m {
+		s, ok := v.(string)
+		if !ok {
+			return g, fmt.Errorf("invalid goto: %v", m)
+		}
+		switch k {
+		case "to":
+			g.to = s
+		case "if":
+			g.cond = s
+		default:
+			return g, fmt.Errorf("invalid goto: invalid key: %s", k)
+		}
+	}
+	return g, nil
+}
+
+// hasGotos returns true if any step has `goto:`.
+func (o *operator) hasGotos() bool {
+	for _, s := range o.steps {
+		if s.gotos != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// gotoIndex returns the index of the step to jump to from the step i.
+func (o *operator) gotoIndex(i int, to string) (int, error) {
+	for j := i + 1; j < len(o.steps); j++ {
+		if o.steps[j].key == to {
+			return j, nil
+		}
+	}
+	return 0, fmt.Errorf("invalid goto: %s is not a following step of %s", to, o.steps[i].key)
+}
+
+// validateGotos checks that all the steps to jump to exist.
+func (o *operator) validateGotos() error {
+	for i, s := range o.steps {
+		for _, g := range s.gotos {
+			if _, err := o.gotoIndex(i, g.to); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// evalGoto evaluates `goto:` of the step i after it runs, and returns the index of the step to jump to ( 0 means no jump ).
+func (o *operator) evalGoto(i int, s *step) (int, error) {
+	if len(s.gotos) == 0 {
+		return 0, nil
+	}
+	store := o.store.toMap()
+	store[storeRootKeyIncluded] = o.included
+	store[storeRootPrevious] = o.store.previous()
+	store[storeRootKeyCurrent] = o.store.latest()
+	for _, g := range s.gotos {
+		if g.cond != "" {
+			tf, err := EvalCond(g.cond, store)
+			if err != nil {
+				return 0, fmt.Errorf("goto failed on %s: %w", o.stepName(i), err)
+			}
+			if !tf {
+				continue
+			}
+		}
+		j, err := o.gotoIndex(i, g.to)
+		if err != nil {
+			return 0, err
+		}
+		o.Debugf(cyan("Jump from %s to %s\n"), o.stepName(i), o.stepName(j))
+		return j, nil
+	}
+	return 0, nil
+}
@@ -0,0 +1,138 @@
+package runn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestGoto(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	tests := []struct {
+		status  int
+		wantErr bool
+	}{
+		{200, false},
+		{202, true},
+		{500, true},
+	}
+	for _, tt := range tests {
+		o, err := New(Book("testdata/book/goto.yml"), Var("status", tt.status))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(context.Background()); (err != nil) != tt.wantErr {
+			t.Errorf("status %d: got %v, want error %v", tt.status, err, tt.wantErr)
+		}
+	}
+}
+
+func TestGotoNotFollowingStep(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	const book = `desc: Goto to the preceding step
+steps:
+  first:
+    test: true
+  second:
+    test: true
+    goto: first
+`
+	bp := filepath.Join(t.TempDir(), "book.yml")
+	if err := os.WriteFile(bp, []byte(book), 0600); err != nil {
+		t.Fatal(err)
+	}
+	_, err := New(Scopes(ScopeAllowReadParent), Book(bp))
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if want := "failed to validate goto"; !strings.Contains(err.Error(), want) {
+		t.Errorf("got %v\nwant %v", err, want)
+	}
+	if want := "invalid goto: first is not a following step of second"; !strings.Contains(err.Error(), want) {
+		t.Errorf("got %v\nwant %v", err, want)
+	}
+}
+
+func TestGotoDefer(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	const book = `desc: Goto over the deferred step
+steps:
+  first:
+    test: true
+    goto: last
+  cleanup:
+    defer: true
+    bind:
+      cleaned: true
+  skipped:
+    bind:
+      notrun: true
+  last:
+    test: true
+`
+	bp := filepath.Join(t.TempDir(), "book.yml")
+	if err := os.WriteFile(bp, []byte(book), 0600); err != nil {
+		t.Fatal(err)
+	}
+	o, err := New(Scopes(ScopeAllowReadParent), Book(bp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := o.store.bindVars["cleaned"]; got != true {
+		t.Errorf("the deferred step jumped over by goto should run: got %v", got)
+	}
+	if _, ok := o.store.bindVars["notrun"]; ok {
+		t.Error("the step jumped over by goto should not run")
+	}
+}
+
+func TestParseGoto(t *testing.T) {
+	tests := []struct {
+		in      any
+		want    []stepGoto
+		wantErr bool
+	}{
+		{"done", []stepGoto{{to: "done"}}, false},
+		{map[string]any{"to": "done", "if": "true"}, []stepGoto{{to: "done", cond: "true"}}, false},
+		{[]any{map[string]any{"to": "a", "if": "false"}, map[string]any{"to": "b"}}, []stepGoto{{to: "a", cond: "false"}, {to: "b"}}, false},
+		{map[string]any{"if": "true"}, nil, true},
+		{map[string]any{"to": "done", "unless": "true"}, nil, true},
+		{3, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseGoto(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: got %v, want error %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%v: got %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%v: got %v, want %v", tt.in, got[i], tt.want[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,518 @@
+package runn
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Diagrams of the runbooks rendered by Mermaid.
+const (
+	MermaidFlowchart = "flowchart"
+	MermaidSequence  = "sequence"
+)
+
+// mermaidLabelMaxLen - Max length of each line of the labels
+const mermaidLabelMaxLen = 60
+
+// mermaidClient - Participant of the sequence diagram that runs the steps
+const mermaidClient = "runn"
+
+// RunbookDiagram is the diagram of the runbook.
+type RunbookDiagram struct {
+	// BookPath - Path of the runbook
+	BookPath string `json:"book_path"`
+	// Diagram - Mermaid diagram of the runbook
+	Diagram string `json:"diagram"`
+}
+
+// Mermaid renders the selected runbooks ( and the runbooks included by them ) as Mermaid diagrams ( flowchart or sequence ).
+func (ops *operators) Mermaid(diagram string) ([]*RunbookDiagram, error) {
+	if diagram != MermaidFlowchart && diagram != MermaidSequence {
+		return nil, fmt.Errorf("invalid diagram: %s (%s or %s)", diagram, MermaidFlowchart, MermaidSequence)
+	}
+	sops, err := ops.SelectedOperators()
+	if err != nil {
+		return nil, err
+	}
+	var ds []*RunbookDiagram
+	for _, o := range sops {
+		var d string
+		switch diagram {
+		case MermaidFlowchart:
+			d, err = o.mermaidFlowchart()
+		case MermaidSequence:
+			d, err = o.mermaidSequence()
+		}
+		if err != nil {
+			return nil, err
+		}
+		ds = append(ds, &RunbookDiagram{BookPath: o.bookPath, Diagram: d})
+	}
+	return ds, nil
+}
+
+// mermaidEdge - Edge from the node that is connected to the next node.
+type mermaidEdge struct {
+	from  string
+	label string
+}
+
+type mermaidFlowchartBuilder struct {
+	lines []string
+}
+
+func (g *mermaidFlowchartBuilder) printf(depth int, format string, a ...any) {
+	g.lines = append(g.lines, strings.Repeat("  ", depth+1)+fmt.Sprintf(format, a...))
+}
+
+func (g *mermaidFlowchartBuilder) connect(depth int, pending []mermaidEdge, to string) {
+	for _, e := range pending {
+		if e.label == "" {
+			g.printf(depth, "%s --> %s", e.from, to)
+			continue
+		}
+		g.printf(depth, "%s -->|%s| %s", e.from, mermaidEscape(e.label), to)
+	}
+}
+
+// mermaidFlowchart renders the runbook as the flowchart.
+func (o *operator) mermaidFlowchart() (string, error) {
+	g := &mermaidFlowchartBuilder{}
+	desc := o.desc
+	if desc == "" {
+		desc = ShortenPath(o.bookPath)
+	}
+	g.printf(0, `start(["%s"])`, mermaidLabel(desc))
+	pending := []mermaidEdge{{from: "start"}}
+	var skipped *mermaidEdge
+	if o.ifCond != "" {
+		g.printf(0, `runbook_if{"%s"}`, mermaidLabel("if: "+o.ifCond))
+		g.connect(0, pending, "runbook_if")
+		pending = []mermaidEdge{{from: "runbook_if", label: "true"}}
+		skipped = &mermaidEdge{from: "runbook_if", label: "false"}
+	}
+	pending, err := g.steps(o, "s", pending, 0, map[string]struct{}{o.bookPath: {}})
+	if err != nil {
+		return "", err
+	}
+	if skipped != nil {
+		pending = append(pending, *skipped)
+	}
+	g.printf(0, `fin(["end"])`)
+	g.connect(0, pending, "fin")
+	return "flowchart TD\n" + strings.Join(g.lines, "\n") + "\n", nil
+}
+
+// steps renders the steps of the runbook and returns the edges to be connected to the next node.
+func (g *mermaidFlowchartBuilder) steps(o *operator, prefix string, pending []mermaidEdge, depth int, trail map[string]struct{}) ([]mermaidEdge, error) {
+	planned, err := o.plan()
+	if err != nil {
+		return nil, err
+	}
+	keys := map[string]string{}
+	for i, s := range o.steps {
+		keys[s.key] = prefix + strconv.Itoa(i)
+	}
+	for i, s := range o.steps {
+		id := prefix + strconv.Itoa(i)
+		var skipped *mermaidEdge
+		if s.ifCond != "" {
+			cid := id + "_if"
+			g.printf(depth, `%s{"%s"}`, cid, mermaidLabel("if: "+s.ifCond))
+			g.connect(depth, pending, cid)
+			pending = []mermaidEdge{{from: cid, label: "true"}}
+			skipped = &mermaidEdge{from: cid, label: "false"}
+		}
+		label := mermaidStepLabel(o, s, planned[i])
+		switch {
+		case s.includeConfig != nil:
+			oo := mermaidIncludedOperator(o, s, trail)
+			if oo == nil {
+				g.printf(depth, `%s[["%s"]]`, id, label)
+				break
+			}
+			g.printf(depth, `subgraph %s ["%s"]`, id, label)
+			g.printf(depth+1, "direction TB")
+			trail[oo.bookPath] = struct{}{}
+			if _, err := g.steps(oo, id+"_", nil, depth+1, trail); err != nil {
+				return nil, err
+			}
+			delete(trail, oo.bookPath)
+			g.printf(depth, "end")
+		case s.parallelConfig != nil || s.groupConfig != nil:
+			c := s.parallelConfig
+			if s.groupConfig != nil {
+				c = s.groupConfig.parallelConfig
+			}
+			g.printf(depth, `subgraph %s ["%s"]`, id, label)
+			g.printf(depth+1, "direction TB")
+			for j, cs := range c.steps {
+				cid := fmt.Sprintf("%s_%d", id, j)
+				g.printf(depth+1, `%s["%s"]`, cid, mermaidChildStepLabel(c.keys[j], cs))
+				if s.groupConfig != nil && j > 0 {
+					g.printf(depth+1, "%s_%d --> %s", id, j-1, cid)
+				}
+			}
+			g.printf(depth, "end")
+		default:
+			g.printf(depth, `%s["%s"]`, id, label)
+		}
+		g.connect(depth, pending, id)
+		if s.loop != nil {
+			g.printf(depth, "%s -->|%s| %s", id, mermaidEscape(mermaidLoopLabel(s.loop)), id)
+		}
+		if s.retry != nil {
+			g.printf(depth, "%s -->|%s| %s", id, mermaidEscape(fmt.Sprintf("retry: count %d", s.retry.count)), id)
+		}
+		for _, gt := range s.gotos {
+			to, ok := keys[gt.to]
+			if !ok {
+				continue
+			}
+			l := "goto"
+			if gt.cond != "" {
+				l = "goto if " + gt.cond
+			}
+			g.printf(depth, "%s -.->|%s| %s", id, mermaidEscape(mermaidTruncate(l, mermaidLabelMaxLen)), to)
+		}
+		pending = []mermaidEdge{{from: id}}
+		if skipped != nil {
+			pending = append(pending, *skipped)
+		}
+	}
+	return pending, nil
+}
+
+// mermaidSequence renders the runbook as the sequence diagram.
+func (o *operator) mermaidSequence() (string, error) {
+	g := &mermaidSequenceBuilder{participants: map[string]string{}}
+	if err := g.steps(o, 0, map[string]struct{}{o.bookPath: {}}); err != nil {
+		return "", err
+	}
+	lines := []string{"sequenceDiagram"}
+	desc := o.desc
+	if desc == "" {
+		desc = ShortenPath(o.bookPath)
+	}
+	lines = append(lines, fmt.Sprintf("  title %s", mermaidEscape(mermaidTruncate(desc, mermaidLabelMaxLen))))
+	lines = append(lines, fmt.Sprintf("  participant %s", mermaidClient))
+	for _, k := range g.order {
+		lines = append(lines, fmt.Sprintf("  participant %s as %s", mermaidID(k), mermaidEscape(g.participants[k])))
+	}
+	body := g.lines
+	if o.ifCond != "" {
+		body = append([]string{fmt.Sprintf("  opt if: %s", mermaidEscape(mermaidTruncate(o.ifCond, mermaidLabelMaxLen)))}, indentLines(body)...)
+		body = append(body, "  end")
+	}
+	lines = append(lines, body...)
+	return strings.Join(lines, "\n") + "\n", nil
+}
+
+type mermaidSequenceBuilder struct {
+	lines []string
+	// participants - Labels of the participants keyed by the runner keys
+	participants map[string]string
+	order        []string
+}
+
+func (g *mermaidSequenceBuilder) printf(depth int, format string, a ...any) {
+	g.lines = append(g.lines, strings.Repeat("  ", depth+1)+fmt.Sprintf(format, a...))
+}
+
+func (g *mermaidSequenceBuilder) participant(key, target string) string {
+	if _, ok := g.participants[key]; !ok {
+		label := key
+		if target != "" {
+			label = fmt.Sprintf("%s (%s)", key, target)
+		}
+		g.participants[key] = label
+		g.order = append(g.order, key)
+	}
+	return mermaidID(key)
+}
+
+func (g *mermaidSequenceBuilder) steps(o *operator, depth int, trail map[string]struct{}) error {
+	planned, err := o.plan()
+	if err != nil {
+		return err
+	}
+	for i, s := range o.steps {
+		p := planned[i]
+		d := depth
+		var closes int
+		if s.ifCond != "" {
+			g.printf(d, "opt if: %s", mermaidEscape(mermaidTruncate(s.ifCond, mermaidLabelMaxLen)))
+			d++
+			closes++
+		}
+		if s.loop != nil {
+			g.printf(d, "loop %s", mermaidEscape(mermaidLoopLabel(s.loop)))
+			d++
+			closes++
+		} else if s.retry != nil {
+			g.printf(d, "loop %s", mermaidEscape(fmt.Sprintf("retry: count %d", s.retry.count)))
+			d++
+			closes++
+		}
+		ref := mermaidStepRef(o, s)
+		if s.desc != "" {
+			g.printf(d, "Note over %s: %s", mermaidClient, mermaidEscape(mermaidTruncate(ref+": "+s.desc, mermaidLabelMaxLen)))
+		}
+		switch p.RunnerType {
+		case RunnerTypeHTTP, RunnerTypeGRPC, RunnerTypeDB, RunnerTypeCDP, RunnerTypeSSH:
+			to := g.participant(s.runnerKey, mermaidRunnerTarget(s))
+			g.printf(d, "%s->>%s: %s", mermaidClient, to, mermaidEscape(mermaidTruncate(mermaidRequest(p), mermaidLabelMaxLen)))
+			g.printf(d, "%s-->>%s: %s", to, mermaidClient, "response")
+		case RunnerTypeExec:
+			to := g.participant(execRunnerKey, "")
+			g.printf(d, "%s->>%s: %s", mermaidClient, to, mermaidEscape(mermaidTruncate(p.Detail, mermaidLabelMaxLen)))
+			g.printf(d, "%s-->>%s: %s", to, mermaidClient, "stdout")
+		case RunnerTypeInclude:
+			g.printf(d, "rect rgba(128, 128, 128, 0.1)")
+			g.printf(d+1, "Note over %s: %s", mermaidClient, mermaidEscape(mermaidTruncate("include: "+p.Detail, mermaidLabelMaxLen)))
+			if oo := mermaidIncludedOperator(o, s, trail); oo != nil {
+				trail[oo.bookPath] = struct{}{}
+				if err := g.steps(oo, d+1, trail); err != nil {
+					return err
+				}
+				delete(trail, oo.bookPath)
+			}
+			g.printf(d, "end")
+		case RunnerTypeParallel, RunnerTypeGroup:
+			c := s.parallelConfig
+			block := "par"
+			if s.groupConfig != nil {
+				c = s.groupConfig.parallelConfig
+				block = "rect rgba(128, 128, 128, 0.1)"
+			}
+			for j, cs := range c.steps {
+				switch {
+				case j == 0:
+					g.printf(d, "%s", block)
+				case s.parallelConfig != nil:
+					g.printf(d, "and")
+				}
+				g.printf(d+1, "Note over %s: %s", mermaidClient, mermaidEscape(mermaidTruncate(mermaidChildStepSummary(c.keys[j], cs), mermaidLabelMaxLen)))
+			}
+			g.printf(d, "end")
+		}
+		if s.bindRunner != nil {
+			g.printf(d, "Note over %s: %s", mermaidClient, "bind")
+		}
+		if s.dumpRunner != nil {
+			g.printf(d, "Note over %s: %s", mermaidClient, mermaidEscape(mermaidTruncate("dump: "+s.dumpRequest.expr, mermaidLabelMaxLen)))
+		}
+		if s.testRunner != nil && s.testCond != "" {
+			g.printf(d, "Note over %s: %s", mermaidClient, mermaidEscape(mermaidTruncate("test: "+planDetail(s.testCond), mermaidLabelMaxLen)))
+		}
+		for ; closes > 0; closes-- {
+			d--
+			g.printf(d, "end")
+		}
+	}
+	return nil
+}
+
+// mermaidIncludedOperator returns the operator of the included runbook to render.
+// It returns nil if the runbook cannot be rendered ( e.g. the remote runbook, the path including the expression or the circular include ).
+func mermaidIncludedOperator(o *operator, s *step, trail map[string]struct{}) *operator {
+	p := s.includeConfig.path
+	if hasRemotePrefix(p) || strings.Contains(p, delimStart) {
+		return nil
+	}
+	paths, err := s.includeConfig.bookPaths(o.root)
+	if err != nil || len(paths) != 1 {
+		return nil
+	}
+	if _, ok := trail[paths[0]]; ok {
+		return nil
+	}
+	oo, err := o.newNestedOperator(s, Book(paths[0]), LoadOnly())
+	if err != nil {
+		return nil
+	}
+	return oo
+}
+
+func mermaidStepRef(o *operator, s *step) string {
+	if o.useMap {
+		return s.key
+	}
+	if s.name != "" {
+		return s.name
+	}
+	return fmt.Sprintf("steps[%d]", s.idx)
+}
+
+func mermaidStepLabel(o *operator, s *step, p *PlannedStep) string {
+	head := mermaidStepRef(o, s)
+	if s.desc != "" {
+		head = fmt.Sprintf("%s: %s", head, s.desc)
+	}
+	if s.deferred {
+		head += " (defer)"
+	}
+	if p.Skip {
+		head += " (skip)"
+	}
+	lines := []string{head}
+	switch p.RunnerType {
+	case RunnerTypeHTTP, RunnerTypeGRPC, RunnerTypeDB, RunnerTypeCDP, RunnerTypeSSH:
+		lines = append(lines, fmt.Sprintf("%s: %s", s.runnerKey, mermaidRequest(p)))
+	case RunnerTypeExec, RunnerTypeInclude:
+		lines = append(lines, fmt.Sprintf("%s: %s", p.RunnerType, p.Detail))
+	case RunnerTypeParallel, RunnerTypeGroup:
+		lines = append(lines, string(p.RunnerType))
+	}
+	if s.bindRunner != nil {
+		lines = append(lines, "bind")
+	}
+	if s.dumpRunner != nil {
+		lines = append(lines, "dump: "+s.dumpRequest.expr)
+	}
+	if s.testRunner != nil && s.testCond != "" {
+		lines = append(lines, "test: "+planDetail(s.testCond))
+	}
+	return mermaidLabel(lines...)
+}
+
+// mermaidChildStepSummary returns the summary of the child step of the parallel/group runner.
+func mermaidChildStepSummary(key string, s map[string]any) string {
+	var keys []string
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var runners []string
+	for _, k := range keys {
+		if validateRunnerKey(k) == nil || k == execRunnerKey || k == includeRunnerKey || k == testRunnerKey {
+			runners = append(runners, k)
+		}
+	}
+	summary := key
+	if d, ok := s[descSectionKey].(string); ok && d != "" {
+		summary = fmt.Sprintf("%s: %s", summary, d)
+	}
+	if len(runners) > 0 {
+		summary = fmt.Sprintf("%s [%s]", summary, strings.Join(runners, ", "))
+	}
+	return summary
+}
+
+func mermaidChildStepLabel(key string, s map[string]any) string {
+	return mermaidLabel(mermaidChildStepSummary(key, s))
+}
+
+func mermaidLoopLabel(l *Loop) string {
+	var label string
+	switch {
+	case l.itemsOnly:
+		label = "loop: items"
+	case l.Items != nil:
+		label = fmt.Sprintf("loop: items ( count %s )", l.Count)
+	default:
+		label = fmt.Sprintf("loop: count %s", l.Count)
+	}
+	if l.Until != "" {
+		label = fmt.Sprintf("%s until %s", label, l.Until)
+	}
+	if l.While != "" {
+		label = fmt.Sprintf("%s while %s", label, l.While)
+	}
+	return mermaidTruncate(planDetail(label), mermaidLabelMaxLen)
+}
+
+// mermaidRequest returns the summary of the request of the runner.
+func mermaidRequest(p *PlannedStep) string {
+	switch p.RunnerType {
+	case RunnerTypeHTTP:
+		path := p.URL
+		if u, err := url.Parse(p.URL); err == nil && u.Host != "" {
+			path = u.RequestURI()
+		}
+		return fmt.Sprintf("%s %s", strings.ToUpper(p.Method), path)
+	case RunnerTypeGRPC:
+		return p.Method
+	case RunnerTypeDB, RunnerTypeSSH:
+		return p.Detail
+	case RunnerTypeCDP:
+		return "actions"
+	default:
+		return ""
+	}
+}
+
+// mermaidRunnerTarget returns the endpoint of the runner of the step.
+func mermaidRunnerTarget(s *step) string {
+	switch {
+	case s.httpRunner != nil && s.httpRunner.endpoint != nil:
+		return s.httpRunner.endpoint.String()
+	case s.dbRunner != nil && s.dbRunner.dsn != "":
+		return redactDSN(s.dbRunner.dsn)
+	case s.grpcRunner != nil:
+		return s.grpcRunner.target
+	case s.sshRunner != nil:
+		return s.sshRunner.addr
+	default:
+		return ""
+	}
+}
+
+// mermaidLabel returns the quoted label of the node with the lines.
+func mermaidLabel(lines ...string) string {
+	var escaped []string
+	for _, l := range lines {
+		escaped = append(escaped, mermaidEscape(mermaidTruncate(planDetail(l), mermaidLabelMaxLen)))
+	}
+	return strings.Join(escaped, "<br/>")
+}
+
+// mermaidEscape escapes the characters that have special meanings in Mermaid.
+func mermaidEscape(s string) string {
+	return strings.NewReplacer(
+		"#", "#35;",
+		`"`, "#quot;",
+		"<", "#lt;",
+		">", "#gt;",
+		"|", "#124;",
+		";", "#59;",
+		"\n", " ",
+	).Replace(s)
+}
+
+// mermaidID returns the ID of the participant ( the characters other than alphanumeric are replaced ).
+func mermaidID(key string) string {
+	var b strings.Builder
+	for _, r := range key {
+		if ('a' <= r && r <= 'z') || ('A' <= r && r <= 'Z') || ('0' <= r && r <= '9') || r == '_' {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteRune('_')
+	}
+	if key == mermaidClient {
+		// Do not conflict with the participant of runn itself
+		b.WriteString("_runner")
+	}
+	return b.String()
+}
+
+// mermaidTruncate truncates the string to n runes.
+func mermaidTruncate(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-3]) + "..."
+}
+
+func indentLines(lines []string) []string {
+	indented := make([]string, len(lines))
+	for i, l := range lines {
+		indented[i] = "  " + l
+	}
+	return indented
+}
@@ -0,0 +1,61 @@
+package runn
+
+import (
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/tenntenn/golden"
+)
+
+func TestMermaid(t *testing.T) {
+	for _, diagram := range []string{MermaidFlowchart, MermaidSequence} {
+		t.Run(diagram, func(t *testing.T) {
+			ops, err := Load("testdata/book/graph.yml", LoadOnly())
+			if err != nil {
+				t.Fatal(err)
+			}
+			ds, err := ops.Mermaid(diagram)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(ds) != 1 {
+				t.Fatalf("got %v", ds)
+			}
+			got := ds[0].Diagram
+			f := fmt.Sprintf("graph_%s.mmd", diagram)
+			if os.Getenv("UPDATE_GOLDEN") != "" {
+				golden.Update(t, "testdata", f, got)
+				return
+			}
+			if diff := golden.Diff(t, "testdata", f, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestMermaidInvalidDiagram(t *testing.T) {
+	ops, err := Load("testdata/book/graph.yml", LoadOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ops.Mermaid("class"); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestMermaidEscape(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{`current.res.body.name == "alice"`, "current.res.body.name == #quot;alice#quot;"},
+		{"a < b; b > c | #", "a #lt; b#59; b #gt; c #124; #35;"},
+	}
+	for _, tt := range tests {
+		if got := mermaidEscape(tt.in); got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
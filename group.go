@@ -0,0 +1,174 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+)
+
+const groupRunnerKey = "group"
+
+type groupRunner struct {
+	// operator - Operator of the child steps of the last run
+	operator *operator
+	mu       sync.Mutex
+}
+
+type groupConfig struct {
+	*parallelConfig
+	// interval - Interval between the child steps. If nil, the interval of the runbook is used
+	interval *time.Duration
+	// labels - Labels of the group. The group is skipped if the labels do not match the label condition ( --label )
+	labels []string
+	// runners - Runners used by the child steps instead of the runners of the runbook. The value is the runner config or the name of the runner of the runbook
+	runners map[string]any
+}
+
+func newGroupRunner() *groupRunner {
+	return &groupRunner{}
+}
+
+func parseGroupConfig(v any) (*groupConfig, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid group: %v", v)
+	}
+	c := &groupConfig{}
+	for k, vv := range m {
+		switch k {
+		case "steps":
+			// The child steps must be specified as list because the order of the keys of the map is not preserved
+			if _, ok := vv.([]any); !ok {
+				return nil, fmt.Errorf("invalid group steps: steps must be list: %v", vv)
+			}
+			pc, err := parseChildSteps(groupRunnerKey, vv)
+			if err != nil {
+				return nil, err
+			}
+			c.parallelConfig = pc
+		case "interval":
+			d, err := parseDuration(fmt.Sprintf("%v", vv))
+			if err != nil {
+				return nil, fmt.Errorf("invalid group interval: %w", err)
+			}
+			c.interval = &d
+		case "labels":
+			l, ok := vv.([]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid group labels: labels must be list: %v", vv)
+			}
+			for _, ll := range l {
+				c.labels = append(c.labels, fmt.Sprintf("%v", ll))
+			}
+			if err := validateLabels(c.labels); err != nil {
+				return nil, fmt.Errorf("invalid group labels: %w", err)
+			}
+		case "runners":
+			r, ok := vv.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid group runners: runners must be map: %v", vv)
+			}
+			for rk := range r {
+				if err := validateRunnerKey(rk); err != nil {
+					return nil, fmt.Errorf("invalid group runners: %w", err)
+				}
+			}
+			c.runners = r
+		default:
+			return nil, fmt.Errorf("invalid group: invalid key: %s", k)
+		}
+	}
+	if c.parallelConfig == nil {
+		return nil, errors.New("invalid group steps: no steps")
+	}
+	return c, nil
+}
+
+// Run runs the child steps in order and records the results of them as `steps`.
+// The child steps can refer to the values of the steps before the group and the preceding child steps.
+func (rnr *groupRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	c := s.groupConfig
+	ropts, err := o.runnerOverrideOptions(groupRunnerKey, c.runners)
+	if err != nil {
+		return err
+	}
+	oo, err := o.newConcurrentOperator(s, o.capturers, ropts...)
+	if err != nil {
+		return err
+	}
+	oo.store = o.store.copyUntil(s.idx)
+	oo.steps = append(make([]*step, 0, s.idx+len(c.steps)), o.steps[:s.idx]...)
+	if c.interval != nil {
+		oo.interval = *c.interval
+	}
+	// The child groups are labeled with the labels of the parent group as well
+	oo.labels = joinLabels(o.labels, c.labels)
+	for i, sm := range c.steps {
+		// AppendStep deletes the sections from the map
+		cp := make(map[string]any, len(sm))
+		for k, v := range sm {
+			cp[k] = v
+		}
+		if err := oo.AppendStep(s.idx+i, c.keys[i], cp); err != nil {
+			return fmt.Errorf("invalid group %s: %w", c.stepName(i), err)
+		}
+	}
+	rnr.mu.Lock()
+	rnr.operator = oo
+	rnr.mu.Unlock()
+
+	var (
+		rerr    error
+		results = make([]map[string]any, len(c.steps))
+	)
+	for i := range c.steps {
+		idx := s.idx + i
+		if rerr != nil && !oo.force {
+			cs := oo.steps[idx]
+			cs.setResult(errStepSkiped)
+			oo.recordNotRun(idx)
+			if err := oo.recordToLatest(storeStepKeyOutcome, resultSkipped); err != nil {
+				return err
+			}
+			results[i] = oo.store.latest()
+			continue
+		}
+		v, err := oo.runChildStep(ctx, idx, oo.steps[idx])
+		results[i] = v
+		if err != nil {
+			rerr = errors.Join(rerr, fmt.Errorf("%s: %w", c.stepName(i), err))
+		}
+	}
+
+	// Pass the values bound by the child steps and the received cookies to the following steps
+	lks := o.store.stepScopedKeys()
+	for k, v := range oo.store.bindVars {
+		if lo.Contains(lks, k) {
+			continue
+		}
+		o.store.bindVars[k] = v
+	}
+	o.store.cookies = oo.store.cookies
+
+	steps := make([]any, 0, len(results))
+	for _, r := range results {
+		steps = append(steps, r)
+	}
+	o.record(map[string]any{storeRootKeySteps: steps})
+	return rerr
+}
+
+// terminateBackgrounds terminates the processes started in the background by the child steps.
+func (rnr *groupRunner) terminateBackgrounds() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	if rnr.operator == nil {
+		return nil
+	}
+	return rnr.operator.terminateBackgrounds()
+}
@@ -0,0 +1,177 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestGroup(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	o, err := New(Book("testdata/book/group.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	rs := o.Result().StepResults
+	if !rs[2].Skipped {
+		t.Error("the group should be skipped")
+	}
+}
+
+func TestGroupFailure(t *testing.T) {
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	g := map[string]any{
+		"steps": []any{
+			map[string]any{"test": "false"},
+			map[string]any{"test": "true"},
+		},
+	}
+	if err := o.AppendStep(0, "0", map[string]any{"group": g}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestGroupRunners(t *testing.T) {
+	ctx := context.Background()
+	_, dsn := testutil.SQLite(t)
+	odb, odsn := testutil.SQLite(t)
+	if _, err := odb.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, username TEXT NOT NULL);"); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		runners map[string]any
+		wantErr bool
+	}{
+		{"runner of the runbook", map[string]any{"db": "other"}, false},
+		{"runner config", map[string]any{"db": odsn}, false},
+		{"no runners", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New(Runner("db", dsn), Runner("other", odsn))
+			if err != nil {
+				t.Fatal(err)
+			}
+			g := map[string]any{
+				"steps": []any{
+					map[string]any{
+						"db":   map[string]any{"query": "SELECT COUNT(*) AS c FROM users;"},
+						"test": "current.rows[0].c == 0",
+					},
+				},
+			}
+			if tt.runners != nil {
+				g["runners"] = tt.runners
+			}
+			if err := o.AppendStep(0, "0", map[string]any{"group": g}); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); (err != nil) != tt.wantErr {
+				t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestGroupLabels(t *testing.T) {
+	tests := []struct {
+		runLabels   []string
+		wantSkipped []bool
+	}{
+		{nil, []bool{false, false, false}},
+		{[]string{"smoke"}, []bool{false, false, false}},
+		{[]string{"smoke && !slow"}, []bool{false, true, false}},
+		{[]string{"slow"}, []bool{true, false, false}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.runLabels), func(t *testing.T) {
+			o, err := New(Book("testdata/book/group_labels.yml"), RunLabel(tt.runLabels...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			var got []bool
+			for _, sr := range o.Result().StepResults {
+				got = append(got, sr.Skipped)
+			}
+			if diff := cmp.Diff(tt.wantSkipped, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestGroupLabelsSelectRunbook(t *testing.T) {
+	tests := []struct {
+		runLabels []string
+		want      int
+	}{
+		{[]string{"smoke"}, 1},
+		{[]string{"smoke && !slow"}, 1},
+		{[]string{"unknown"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%v", tt.runLabels), func(t *testing.T) {
+			ops, err := Load("testdata/book/group_labels.yml", RunLabel(tt.runLabels...))
+			if err != nil {
+				t.Fatal(err)
+			}
+			selected, err := ops.SelectedOperators()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := len(selected); got != tt.want {
+				t.Errorf("got %v\nwant %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseGroupConfig(t *testing.T) {
+	tests := []struct {
+		in      any
+		wantErr bool
+	}{
+		{map[string]any{"steps": []any{map[string]any{"test": "true"}}}, false},
+		{map[string]any{"steps": []any{map[string]any{"test": "true"}}, "interval": "1s"}, false},
+		{map[string]any{"steps": []any{}}, true},
+		{map[string]any{"interval": "1s"}, true},
+		{map[string]any{"steps": map[string]any{"a": map[string]any{"test": "true"}}}, true},
+		{map[string]any{"steps": []any{map[string]any{"test": "true"}}, "concurrency": 2}, true},
+		{[]any{map[string]any{"test": "true"}}, true},
+		{map[string]any{"steps": []any{map[string]any{"test": "true"}}, "labels": []any{"smoke"}}, false},
+		{map[string]any{"steps": []any{map[string]any{"test": "true"}}, "labels": "smoke"}, true},
+		{map[string]any{"steps": []any{map[string]any{"test": "true"}}, "labels": []any{"smoke test"}}, true},
+		{map[string]any{"steps": []any{map[string]any{"test": "true"}}, "runners": map[string]any{"req": "https://example.com"}}, false},
+		{map[string]any{"steps": []any{map[string]any{"test": "true"}}, "runners": "https://example.com"}, true},
+		{map[string]any{"steps": []any{map[string]any{"test": "true"}}, "runners": map[string]any{"exec": "https://example.com"}}, true},
+	}
+	for _, tt := range tests {
+		_, err := parseGroupConfig(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: got %v, want error %v", tt.in, err, tt.wantErr)
+		}
+	}
+}
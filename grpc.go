@@ -10,6 +10,7 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/bufbuild/protocompile"
@@ -73,6 +74,8 @@ type grpcRunner struct {
 	hostRules       hostRules
 	trace           *bool
 	traceHeaderName string
+	// mu - Serialize the calls shared by the steps run concurrently ( e.g. parallel: ), because the connection is set up lazily and closed after bidirectional streaming
+	mu sync.Mutex
 }
 
 type grpcMessage struct {
@@ -109,6 +112,8 @@ func (rnr *grpcRunner) Renew() error {
 }
 
 func (rnr *grpcRunner) Close() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
 	if rnr.cc == nil {
 		rnr.refc = nil
 		return nil
@@ -131,6 +136,8 @@ func (rnr *grpcRunner) Run(ctx context.Context, s *step) error {
 
 func (rnr *grpcRunner) run(ctx context.Context, r *grpcRequest, s *step) error {
 	o := s.parent
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
 	if err := rnr.connectAndResolve(ctx); err != nil {
 		return err
 	}
@@ -0,0 +1,135 @@
+package runn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/minio/pkg/wildcard"
+)
+
+const harExt = ".har"
+
+// harIgnoreHeaders - Headers of the HAR entries that are not converted because they are set by the HTTP client.
+var harIgnoreHeaders = []string{"Host", "Content-Length", "Connection", "Accept-Encoding"}
+
+// har - HTTP Archive exported by the browsers. Only the fields required to generate the steps are defined.
+// ref: http://www.softwareishard.com/blog/har-12-spec/
+type har struct {
+	Log struct {
+		Entries []*harEntry `json:"entries"`
+	} `json:"log"`
+}
+
+type harEntry struct {
+	Request struct {
+		Method  string `json:"method"`
+		URL     string `json:"url"`
+		Headers []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"headers"`
+		PostData *struct {
+			MimeType string `json:"mimeType"`
+			Text     string `json:"text"`
+		} `json:"postData,omitempty"`
+	} `json:"request"`
+}
+
+// SetHARAllowHosts sets the hosts ( wildcard supported ) of the HAR entries to be converted to steps.
+// If no hosts are set, all the entries are converted.
+func (rb *runbook) SetHARAllowHosts(hosts ...string) {
+	rb.harAllowHosts = hosts
+}
+
+func isHARFile(in ...string) bool {
+	return len(in) == 1 && strings.EqualFold(filepath.Ext(in[0]), harExt)
+}
+
+func (rb *runbook) harToSteps(p string) error {
+	f, err := os.Open(filepath.Clean(p))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return rb.AppendStepsFromHAR(f)
+}
+
+// AppendStepsFromHAR appends the HTTP steps converted from the entries of the HAR.
+// The runners are set per origin of the requests.
+func (rb *runbook) AppendStepsFromHAR(r io.Reader) error {
+	h := &har{}
+	if err := json.NewDecoder(r).Decode(h); err != nil {
+		return fmt.Errorf("invalid har: %w", err)
+	}
+	for i, e := range h.Log.Entries {
+		req, err := e.toRequest()
+		if err != nil {
+			return fmt.Errorf("invalid har entries[%d]: %w", i, err)
+		}
+		if !rb.harAllowed(req.URL.Host) {
+			continue
+		}
+		dsn := fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host)
+		key := rb.setRunner(dsn)
+		step, err := CreateHTTPStepMapSlice(key, req)
+		if err != nil {
+			return fmt.Errorf("invalid har entries[%d]: %w", i, err)
+		}
+		if rb.useMap {
+			rb.stepKeys = append(rb.stepKeys, fmt.Sprintf("%s%d", strings.ToLower(req.Method), len(rb.stepKeys)))
+		}
+		rb.Steps = append(rb.Steps, step)
+	}
+	return nil
+}
+
+func (rb *runbook) harAllowed(host string) bool {
+	if len(rb.harAllowHosts) == 0 {
+		return true
+	}
+	for _, h := range rb.harAllowHosts {
+		if wildcard.MatchSimple(h, host) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *harEntry) toRequest() (*http.Request, error) {
+	u, err := url.Parse(e.Request.URL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid url: %s", e.Request.URL)
+	}
+	var body io.Reader
+	if e.Request.PostData != nil && e.Request.PostData.Text != "" {
+		body = strings.NewReader(e.Request.PostData.Text)
+	}
+	req, err := http.NewRequest(e.Request.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for _, h := range e.Request.Headers {
+		// HTTP/2 pseudo-headers ( e.g. :authority )
+		if strings.HasPrefix(h.Name, ":") {
+			continue
+		}
+		k := http.CanonicalHeaderKey(h.Name)
+		if contains(harIgnoreHeaders, k) {
+			continue
+		}
+		req.Header.Add(k, h.Value)
+	}
+	if req.Header.Get("Content-Type") == "" && e.Request.PostData != nil && e.Request.PostData.MimeType != "" {
+		req.Header.Set("Content-Type", e.Request.PostData.MimeType)
+	}
+	return req, nil
+}
@@ -0,0 +1,54 @@
+package runn
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestAppendStepsFromHAR(t *testing.T) {
+	tests := []struct {
+		allowHosts  []string
+		wantRunners map[string]any
+		wantSteps   int
+	}{
+		{
+			nil,
+			map[string]any{"req": "https://api.example.com", "req2": "https://www.google-analytics.com", "req3": "https://auth.example.com"},
+			4,
+		},
+		{
+			[]string{"*.example.com"},
+			map[string]any{"req": "https://api.example.com", "req2": "https://auth.example.com"},
+			3,
+		},
+		{
+			[]string{"api.example.com"},
+			map[string]any{"req": "https://api.example.com"},
+			2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			f, err := os.Open("testdata/har/example.har")
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() {
+				_ = f.Close()
+			})
+			rb := NewRunbook("")
+			rb.SetHARAllowHosts(tt.allowHosts...)
+			if err := rb.AppendStepsFromHAR(f); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.wantRunners, rb.Runners); diff != "" {
+				t.Error(diff)
+			}
+			if got := len(rb.Steps); got != tt.wantSteps {
+				t.Errorf("got %v\nwant %v", got, tt.wantSteps)
+			}
+		})
+	}
+}
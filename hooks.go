@@ -0,0 +1,96 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+const (
+	beforeEachHookKey = "beforeEach"
+	afterEachHookKey  = "afterEach"
+)
+
+// buildHooks builds the operators running the hook steps.
+// The operators are built once and reused for every step.
+func (o *operator) buildHooks() error {
+	var err error
+	o.beforeEachHook, err = o.newHookOperator(beforeEachHookKey, o.beforeEachSteps)
+	if err != nil {
+		return err
+	}
+	o.afterEachHook, err = o.newHookOperator(afterEachHookKey, o.afterEachSteps)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// newHookOperator returns the operator running the hook steps. It returns nil if there are no hook steps.
+func (o *operator) newHookOperator(key string, steps []map[string]any) (*operator, error) {
+	if len(steps) == 0 {
+		return nil, nil
+	}
+	oo, err := o.newNestedOperator(nil)
+	if err != nil {
+		return nil, err
+	}
+	oo.id = o.id
+	oo.desc = o.desc
+	oo.bookPath = o.bookPath
+	oo.root = o.root
+	for j, sm := range steps {
+		// AppendStep deletes the sections from the map
+		cp := make(map[string]any, len(sm))
+		for k, v := range sm {
+			cp[k] = v
+		}
+		if err := oo.AppendStep(j, strconv.Itoa(j), cp); err != nil {
+			return nil, fmt.Errorf("invalid hooks.%s[%d]: %w", key, j, err)
+		}
+	}
+	return oo, nil
+}
+
+// runHooks runs the hook steps around the step s.
+// Like an included runbook, the hook steps refer to the values of the operator as `parent`.
+// The values bound by the hook steps are passed to the operator.
+func (o *operator) runHooks(ctx context.Context, i int, s *step, key string, oo *operator) error {
+	if oo == nil {
+		return nil
+	}
+	// Store before record
+	store := o.store.toMap()
+	store[storeRootKeyIncluded] = o.included
+	store[storeRootPrevious] = o.store.latest()
+	// The operator may be run with another testing.T or stopwatch after the hooks are built
+	oo.t = o.thisT
+	oo.thisT = o.thisT
+	oo.sw = o.sw
+	oo.capturers = o.capturers
+	oo.parent = s
+	oo.store.clearSteps()
+	oo.store.bindVars = map[string]any{}
+	oo.store.parentVars = store
+	defer func() {
+		if err := oo.terminateBackgrounds(); err != nil {
+			o.Debugf(yellow("Failed to terminate background processes: %v\n"), err)
+		}
+	}()
+	// Run the hook steps without runInternal, which clears the values of `parent`
+	for j, hs := range oo.steps {
+		err := oo.runStep(ctx, j, hs)
+		hs.setResult(err)
+		switch {
+		case errors.Is(errStepSkiped, err):
+			oo.recordNotRun(j)
+		case err != nil:
+			return fmt.Errorf("hooks.%s failed on %s: %w", key, o.stepName(i), err)
+		}
+	}
+	for k, v := range oo.store.bindVars {
+		o.store.bindVars[k] = v
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package runn
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestHooks(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/hooks.yml"), Var("logFile", filepath.Join(t.TempDir(), "log.txt")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	before := o.beforeEachHook
+	if before == nil {
+		t.Fatal("the operator of beforeEach should be built at load time")
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if o.beforeEachHook != before {
+		t.Error("the operator of beforeEach should be reused")
+	}
+	// The values of the hook steps are not recorded as steps
+	if want := 4; len(o.store.steps) != want {
+		t.Errorf("got %v\nwant %v", len(o.store.steps), want)
+	}
+}
+
+func TestHooksFailure(t *testing.T) {
+	tests := []struct {
+		name   string
+		before []map[string]any
+		after  []map[string]any
+		want   string
+	}{
+		{
+			"beforeEach",
+			[]map[string]any{{"test": "false"}},
+			nil,
+			"hooks.beforeEach failed on \"\".steps[0]",
+		},
+		{
+			"afterEach",
+			nil,
+			[]map[string]any{{"test": "parent.previous.ok == false"}},
+			"hooks.afterEach failed on \"\".steps[0]",
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			o.beforeEachSteps = tt.before
+			o.afterEachSteps = tt.after
+			if err := o.buildHooks(); err != nil {
+				t.Fatal(err)
+			}
+			if err := o.AppendStep(0, "0", map[string]any{"bind": map[string]any{"ok": "true"}}); err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if err == nil {
+				t.Fatal("want error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v\nwant %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -513,10 +513,16 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 	o.capturers.captureHTTPResponse(rnr.name, res)
 
 	if err := rnr.validator.ValidateResponse(ctx, req, res); err != nil {
-		var target *UnsupportedError
-		if errors.As(err, &target) {
+		var (
+			target *UnsupportedError
+			drift  *SchemaDriftError
+		)
+		switch {
+		case errors.As(err, &target):
 			o.Debugf("Skip validate response due to unsupported format: %s", err.Error())
-		} else {
+		case errors.As(err, &drift) && !drift.Fail:
+			o.Warnf("Warning: %s\n", err.Error())
+		default:
 			return err
 		}
 	}
@@ -534,6 +540,13 @@ func (rnr *httpRunner) run(ctx context.Context, r *httpRequest, s *step) error {
 			return err
 		}
 		d[httpStoreBodyKey] = b
+	} else if isXMLContentType(res.Header.Get("Content-Type")) && len(resBody) > 0 {
+		b, err := decodeXML(resBody)
+		if err != nil {
+			// Do not fail the step because the XML can still be asserted with rawBody
+			o.Debugf("Skip decoding XML response: %s\n", err.Error())
+		}
+		d[httpStoreBodyKey] = b
 	} else {
 		d[httpStoreBodyKey] = nil
 	}
@@ -0,0 +1,198 @@
+package runn
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+)
+
+const (
+	schemaDriftWarn = "warn"
+	schemaDriftFail = "fail"
+)
+
+// SchemaDriftError is the error that the fields of HTTP response are not described in the OpenAPI document, or vice versa.
+type SchemaDriftError struct {
+	Method       string
+	Path         string
+	Status       int
+	Undocumented []string
+	Missing      []string
+	// Fail - Whether the drift fails the step. If false, the drift is reported as a warning
+	Fail bool
+}
+
+func (e *SchemaDriftError) Error() string {
+	var b strings.Builder
+	_, _ = fmt.Fprintf(&b, "schema drift detected on %s %s (%d)", e.Method, e.Path, e.Status)
+	for _, p := range e.Undocumented {
+		_, _ = fmt.Fprintf(&b, "\n  undocumented field: %s", p)
+	}
+	for _, p := range e.Missing {
+		_, _ = fmt.Fprintf(&b, "\n  missing field: %s", p)
+	}
+	return b.String()
+}
+
+func validateSchemaDriftMode(m string) error {
+	switch m {
+	case "", schemaDriftWarn, schemaDriftFail:
+		return nil
+	default:
+		return fmt.Errorf("invalid schemaDrift: %s (%s or %s)", m, schemaDriftWarn, schemaDriftFail)
+	}
+}
+
+// detectSchemaDrift compares the fields of the JSON response body with the schema of the response in the OpenAPI document.
+func (v *openApi3Validator) detectSchemaDrift(input *openapi3filter.ResponseValidationInput, res *http.Response) error {
+	route := input.RequestValidationInput.Route
+	ref := route.Operation.Responses.Get(res.StatusCode)
+	if ref == nil {
+		ref = route.Operation.Responses.Default()
+	}
+	if ref == nil || ref.Value == nil {
+		return nil
+	}
+	mt, _, err := mime.ParseMediaType(res.Header.Get("Content-Type"))
+	if err != nil || !strings.Contains(mt, "json") {
+		return nil
+	}
+	m := ref.Value.Content.Get(mt)
+	if m == nil || m.Schema == nil || m.Schema.Value == nil {
+		return nil
+	}
+	if res.Body == nil {
+		return nil
+	}
+	b, err := io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	res.Body = io.NopCloser(bytes.NewBuffer(b))
+	if len(b) == 0 {
+		return nil
+	}
+	var body any
+	if err := json.Unmarshal(b, &body); err != nil {
+		return nil
+	}
+	d := newSchemaDrift()
+	d.walk("$", m.Schema.Value, body)
+	if len(d.undocumented) == 0 && len(d.missing) == 0 {
+		return nil
+	}
+	return &SchemaDriftError{
+		Method:       input.RequestValidationInput.Request.Method,
+		Path:         input.RequestValidationInput.Request.URL.Path,
+		Status:       res.StatusCode,
+		Undocumented: d.sorted(d.undocumented),
+		Missing:      d.sorted(d.missing),
+		Fail:         v.schemaDrift == schemaDriftFail,
+	}
+}
+
+type schemaDrift struct {
+	undocumented map[string]struct{}
+	missing      map[string]struct{}
+}
+
+func newSchemaDrift() *schemaDrift {
+	return &schemaDrift{
+		undocumented: map[string]struct{}{},
+		missing:      map[string]struct{}{},
+	}
+}
+
+func (d *schemaDrift) walk(path string, s *openapi3.Schema, v any) {
+	if s == nil {
+		return
+	}
+	switch vv := v.(type) {
+	case map[string]any:
+		documented, expected := schemaProperties(s)
+		if len(documented) == 0 && s.AdditionalProperties.Schema == nil {
+			// free-form object
+			return
+		}
+		for k, e := range vv {
+			p := fmt.Sprintf("%s.%s", path, k)
+			if ps, ok := documented[k]; ok {
+				d.walk(p, ps, e)
+				continue
+			}
+			if ap := s.AdditionalProperties.Schema; ap != nil {
+				d.walk(p, ap.Value, e)
+				continue
+			}
+			if s.AdditionalProperties.Has != nil && *s.AdditionalProperties.Has {
+				continue
+			}
+			d.undocumented[p] = struct{}{}
+		}
+		for k := range expected {
+			if _, ok := vv[k]; !ok {
+				d.missing[fmt.Sprintf("%s.%s", path, k)] = struct{}{}
+			}
+		}
+	case []any:
+		if s.Items == nil {
+			return
+		}
+		for _, e := range vv {
+			d.walk(fmt.Sprintf("%s[*]", path), s.Items.Value, e)
+		}
+	}
+}
+
+func (d *schemaDrift) sorted(m map[string]struct{}) []string {
+	var l []string
+	for k := range m {
+		l = append(l, k)
+	}
+	sort.Strings(l)
+	return l
+}
+
+// schemaProperties returns the properties that can appear in the object ( including oneOf and anyOf ) and the properties that always appear ( properties and allOf ).
+func schemaProperties(s *openapi3.Schema) (documented, expected map[string]*openapi3.Schema) {
+	documented = map[string]*openapi3.Schema{}
+	expected = map[string]*openapi3.Schema{}
+	for k, p := range s.Properties {
+		documented[k] = p.Value
+		expected[k] = p.Value
+	}
+	for _, ref := range s.AllOf {
+		if ref.Value == nil {
+			continue
+		}
+		d, e := schemaProperties(ref.Value)
+		for k, p := range d {
+			documented[k] = p
+		}
+		for k, p := range e {
+			expected[k] = p
+		}
+	}
+	for _, refs := range []openapi3.SchemaRefs{s.OneOf, s.AnyOf} {
+		for _, ref := range refs {
+			if ref.Value == nil {
+				continue
+			}
+			d, _ := schemaProperties(ref.Value)
+			for k, p := range d {
+				if _, ok := documented[k]; !ok {
+					documented[k] = p
+				}
+			}
+		}
+	}
+	return documented, expected
+}
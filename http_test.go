@@ -509,6 +509,67 @@ func TestHTTPRunnerWithHandler(t *testing.T) {
 	}
 }
 
+func TestHTTPRunnerXMLResponse(t *testing.T) {
+	tests := []struct {
+		contentType string
+		body        string
+		want        any
+	}{
+		{
+			"application/xml; charset=utf-8",
+			`<user id="1"><name>alice</name></user>`,
+			map[string]any{"user": map[string]any{"@id": "1", "name": "alice"}},
+		},
+		{
+			"application/xml",
+			`<user>`,
+			nil,
+		},
+		{
+			"text/plain",
+			`<user id="1"><name>alice</name></user>`,
+			nil,
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := http.NewServeMux()
+			s.HandleFunc("/users/1", func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", tt.contentType)
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(tt.body))
+			})
+			r, err := newHTTPRunnerWithHandler(t.Name(), s)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &httpRequest{
+				path:      "/users/1",
+				method:    http.MethodGet,
+				mediaType: MediaTypeApplicationJSON,
+			}
+			if err := r.run(ctx, req, newStep(0, "stepKey", o)); err != nil {
+				t.Fatal(err)
+			}
+			res, ok := o.store.steps[0]["res"].(map[string]any)
+			if !ok {
+				t.Fatalf("invalid steps res: %v", o.store.steps[0]["res"])
+			}
+			if diff := cmp.Diff(res["body"], tt.want); diff != "" {
+				t.Error(diff)
+			}
+			if res["rawBody"] != tt.body {
+				t.Errorf("got %v\nwant %v", res["rawBody"], tt.body)
+			}
+		})
+	}
+}
+
 func TestNotFollowRedirect(t *testing.T) {
 	tests := []struct {
 		req               *httpRequest
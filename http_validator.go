@@ -63,6 +63,7 @@ func newNopValidator() *nopValidator {
 type openApi3Validator struct {
 	skipValidateRequest  bool
 	skipValidateResponse bool
+	schemaDrift          string
 	doc                  *openapi3.T
 }
 
@@ -97,9 +98,13 @@ func newOpenApi3Validator(c *httpRunnerConfig) (*openApi3Validator, error) {
 	if c.openApi3Doc == nil {
 		return nil, errors.New("cannot load openapi3 document")
 	}
+	if err := validateSchemaDriftMode(c.SchemaDrift); err != nil {
+		return nil, err
+	}
 	return &openApi3Validator{
 		skipValidateRequest:  c.SkipValidateRequest,
 		skipValidateResponse: c.SkipValidateResponse,
+		schemaDrift:          c.SchemaDrift,
 		doc:                  c.openApi3Doc,
 	}, nil
 }
@@ -189,13 +194,16 @@ func (v *openApi3Validator) responseInput(req *http.Request, res *http.Response)
 }
 
 func (v *openApi3Validator) ValidateResponse(ctx context.Context, req *http.Request, res *http.Response) error {
-	if v.skipValidateResponse {
+	if v.skipValidateResponse && v.schemaDrift == "" {
 		return nil
 	}
 	input, err := v.responseInput(req, res)
 	if err != nil {
 		return err
 	}
+	if v.skipValidateResponse {
+		return v.detectSchemaDrift(input, res)
+	}
 
 	err = openapi3filter.ValidateResponse(ctx, input)
 
@@ -224,6 +232,9 @@ func (v *openApi3Validator) ValidateResponse(ctx context.Context, req *http.Requ
 		}
 		return fmt.Errorf("openapi3 validation error: %w\n-----START HTTP REQUEST-----\n%s\n-----END HTTP REQUEST-----\n-----START HTTP RESPONSE-----\n%s\n-----END HTTP RESPONSE-----\n", err, string(b), string(b2))
 	}
+	if v.schemaDrift != "" {
+		return v.detectSchemaDrift(input, res)
+	}
 	return nil
 }
 
@@ -2,11 +2,14 @@ package runn
 
 import (
 	"context"
+	"errors"
 	"io"
 	"net/http"
 	"net/url"
 	"strings"
 	"testing"
+
+	"github.com/google/go-cmp/cmp"
 )
 
 const validOpenApi3Spec = `
@@ -230,3 +233,100 @@ func pathToURL(t *testing.T, p string) *url.URL {
 	}
 	return u
 }
+
+func TestOpenApi3ValidatorSchemaDrift(t *testing.T) {
+	tests := []struct {
+		mode             string
+		body             string
+		wantUndocumented []string
+		wantMissing      []string
+		wantFail         bool
+	}{
+		{"warn", `{"data": {"username": "alice", "email": "alice@example.com"}}`, nil, nil, false},
+		{"warn", `{"data": {"username": "alice", "email": "alice@example.com", "age": 20}, "meta": {}}`, []string{"$.data.age", "$.meta"}, nil, false},
+		{"fail", `{"data": {"username": "alice", "email": "alice@example.com", "age": 20}}`, []string{"$.data.age"}, nil, true},
+		{"fail", `{"data": {"username": "alice"}}`, nil, []string{"$.data.email"}, true},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.body, func(t *testing.T) {
+			c := &httpRunnerConfig{}
+			for _, opt := range []httpRunnerOption{OpenApi3FromData([]byte(schemaDriftOpenApi3Spec)), SkipValidateResponse(true), SchemaDrift(tt.mode)} {
+				if err := opt(c); err != nil {
+					t.Fatal(err)
+				}
+			}
+			v, err := newOpenApi3Validator(c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			req := &http.Request{
+				Method: http.MethodGet,
+				URL:    pathToURL(t, "/users/1"),
+			}
+			res := &http.Response{
+				StatusCode: http.StatusOK,
+				Header:     http.Header{"Content-Type": []string{"application/json"}},
+				Body:       io.NopCloser(strings.NewReader(tt.body)),
+			}
+			err = v.ValidateResponse(ctx, req, res)
+			if tt.wantUndocumented == nil && tt.wantMissing == nil {
+				if err != nil {
+					t.Errorf("got error: %v", err)
+				}
+				return
+			}
+			var drift *SchemaDriftError
+			if !errors.As(err, &drift) {
+				t.Fatalf("want SchemaDriftError: %v", err)
+			}
+			if diff := cmp.Diff(drift.Undocumented, tt.wantUndocumented); diff != "" {
+				t.Error(diff)
+			}
+			if diff := cmp.Diff(drift.Missing, tt.wantMissing); diff != "" {
+				t.Error(diff)
+			}
+			if drift.Fail != tt.wantFail {
+				t.Errorf("got %v\nwant %v", drift.Fail, tt.wantFail)
+			}
+			b, err := io.ReadAll(res.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != tt.body {
+				t.Errorf("got %v\nwant %v", string(b), tt.body)
+			}
+		})
+	}
+}
+
+const schemaDriftOpenApi3Spec = `
+openapi: 3.0.3
+info:
+  title: test spec
+  version: 0.0.1
+paths:
+  /users/{id}:
+    get:
+      parameters:
+        - name: id
+          in: path
+          required: true
+          schema:
+            type: string
+      responses:
+        '200':
+          description: OK
+          content:
+            application/json:
+              schema:
+                type: object
+                properties:
+                  data:
+                    type: object
+                    properties:
+                      username:
+                        type: string
+                      email:
+                        type: string
+`
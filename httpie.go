@@ -0,0 +1,273 @@
+package runn
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/textproto"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+)
+
+var httpieMethodRe = regexp.MustCompile(`^[A-Z]+$`)
+
+// httpieItemSeparators - Separators of the request items of HTTPie in order of precedence for the same position.
+var httpieItemSeparators = []string{":=@", "=@", "==", ":=", "=", "@", ":"}
+
+// httpieOptsWithValue - Options of HTTPie that take the value.
+var httpieOptsWithValue = []string{
+	"-a", "--auth", "-A", "--auth-type", "-p", "--print", "-o", "--output", "--session", "--session-read-only",
+	"--verify", "--timeout", "--proxy", "--cert", "--cert-key", "--pretty", "-s", "--style", "--max-redirects",
+	"--boundary", "--raw", "--format-options", "--response-charset", "--response-mime", "--ssl", "--ciphers",
+}
+
+type httpieItem struct {
+	key   string
+	sep   string
+	value string
+}
+
+func isHTTPieCommand(in ...string) bool {
+	if len(in) == 0 {
+		return false
+	}
+	cmd := in[0]
+	if len(in) == 1 {
+		cmd, _, _ = strings.Cut(strings.TrimSpace(in[0]), " ")
+	}
+	return cmd == "http" || cmd == "https"
+}
+
+func (rb *runbook) httpieToStep(in ...string) error {
+	req, err := newRequestFromHTTPie(in...)
+	if err != nil {
+		return err
+	}
+	dsn := fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host)
+	key := rb.setRunner(dsn)
+	step, err := CreateHTTPStepMapSlice(key, req)
+	if err != nil {
+		return err
+	}
+	rb.Steps = append(rb.Steps, step)
+	return nil
+}
+
+// newRequestFromHTTPie creates the *http.Request from the HTTPie command line ( `http [flags] [METHOD] URL [REQUEST_ITEM ...]` ).
+func newRequestFromHTTPie(in ...string) (*http.Request, error) {
+	args := in
+	if len(in) == 1 {
+		var err error
+		args, err = shellwords.Parse(in[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !isHTTPieCommand(args...) {
+		return nil, fmt.Errorf("not httpie command: %v", in)
+	}
+	scheme := args[0]
+	var (
+		form, multi bool
+		auth        string
+		authType    = "basic"
+		positional  []string
+	)
+	for i := 1; i < len(args); i++ {
+		a := args[i]
+		if !strings.HasPrefix(a, "-") || a == "-" {
+			positional = append(positional, a)
+			continue
+		}
+		name, value, hasValue := strings.Cut(a, "=")
+		if contains(httpieOptsWithValue, name) && !hasValue {
+			if i+1 >= len(args) {
+				return nil, fmt.Errorf("no value for %s", name)
+			}
+			i++
+			value = args[i]
+		}
+		switch name {
+		case "-f", "--form":
+			form = true
+		case "--multipart":
+			form = true
+			multi = true
+		case "-j", "--json":
+			form = false
+		case "-a", "--auth":
+			auth = value
+		case "-A", "--auth-type":
+			authType = value
+		}
+	}
+
+	if len(positional) == 0 {
+		return nil, errors.New("no url")
+	}
+	method := ""
+	if httpieMethodRe.MatchString(positional[0]) && len(positional) > 1 {
+		method = positional[0]
+		positional = positional[1:]
+	}
+	u, err := completeURL(scheme, positional[0])
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		headers = http.Header{}
+		query   = u.Query()
+		data    = map[string]any{}
+		keys    []string
+		files   = map[string]string{}
+	)
+	for _, a := range positional[1:] {
+		it, err := parseHTTPieItem(a)
+		if err != nil {
+			return nil, err
+		}
+		switch it.sep {
+		case ":":
+			headers.Add(it.key, it.value)
+		case "==":
+			query.Add(it.key, it.value)
+		case "=", "=@":
+			data[it.key] = it.value
+			keys = append(keys, it.key)
+		case ":=", ":=@":
+			var v any
+			if it.sep == ":=@" {
+				v = it.value
+			} else if err := json.Unmarshal([]byte(it.value), &v); err != nil {
+				return nil, fmt.Errorf("invalid json item %s: %w", a, err)
+			}
+			data[it.key] = v
+			keys = append(keys, it.key)
+		case "@":
+			files[it.key] = it.value
+			multi = true
+			form = true
+		}
+	}
+	u.RawQuery = query.Encode()
+
+	var (
+		body        io.Reader
+		contentType string
+	)
+	switch {
+	case multi:
+		b := new(bytes.Buffer)
+		mw := multipart.NewWriter(b)
+		for _, k := range keys {
+			if err := mw.WriteField(k, fmt.Sprintf("%v", data[k])); err != nil {
+				return nil, err
+			}
+		}
+		for k, f := range files {
+			h := make(textproto.MIMEHeader)
+			h.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, k, f))
+			h.Set("Content-Type", MediaTypeApplicationOctetStream)
+			if _, err := mw.CreatePart(h); err != nil {
+				return nil, err
+			}
+		}
+		if err := mw.Close(); err != nil {
+			return nil, err
+		}
+		body = b
+		contentType = mw.FormDataContentType()
+	case form && len(keys) > 0:
+		vs := url.Values{}
+		for _, k := range keys {
+			vs.Add(k, fmt.Sprintf("%v", data[k]))
+		}
+		body = strings.NewReader(vs.Encode())
+		contentType = MediaTypeApplicationFormUrlencoded
+	case len(keys) > 0:
+		b, err := json.Marshal(data)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(b)
+		contentType = MediaTypeApplicationJSON
+	}
+	if method == "" {
+		// HTTPie uses POST if the request has data
+		method = http.MethodGet
+		if body != nil {
+			method = http.MethodPost
+		}
+	}
+
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range headers {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if contentType != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if auth != "" {
+		switch authType {
+		case "bearer":
+			req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", auth))
+		default:
+			user, pass, _ := strings.Cut(auth, ":")
+			req.SetBasicAuth(user, pass)
+		}
+	}
+	return req, nil
+}
+
+// completeURL completes the URL without the scheme in the same way as HTTPie ( e.g. `:3000/path` -> `http://localhost:3000/path` ).
+func completeURL(scheme, s string) (*url.URL, error) {
+	switch {
+	case strings.HasPrefix(s, "://"):
+		s = scheme + s
+	case strings.HasPrefix(s, ":"):
+		s = scheme + "://localhost" + s
+	case !strings.Contains(s, "://"):
+		s = scheme + "://" + s
+	}
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, err
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("invalid url: %s", s)
+	}
+	return u, nil
+}
+
+// parseHTTPieItem parses the request item. The separator at the earliest position is used.
+func parseHTTPieItem(s string) (*httpieItem, error) {
+	pos := -1
+	sep := ""
+	for _, sp := range httpieItemSeparators {
+		i := strings.Index(s, sp)
+		if i <= 0 {
+			continue
+		}
+		if pos < 0 || i < pos || (i == pos && len(sp) > len(sep)) {
+			pos = i
+			sep = sp
+		}
+	}
+	if pos < 0 {
+		return nil, fmt.Errorf("invalid request item: %s", s)
+	}
+	return &httpieItem{key: s[:pos], sep: sep, value: s[pos+len(sep):]}, nil
+}
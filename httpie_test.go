@@ -0,0 +1,90 @@
+package runn
+
+import (
+	"io"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestParseHTTPieItem(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    *httpieItem
+		wantErr bool
+	}{
+		{"X-Token:abc=def", &httpieItem{key: "X-Token", sep: ":", value: "abc=def"}, false},
+		{"url=http://example.com", &httpieItem{key: "url", sep: "=", value: "http://example.com"}, false},
+		{"age:=20", &httpieItem{key: "age", sep: ":=", value: "20"}, false},
+		{"page==2", &httpieItem{key: "page", sep: "==", value: "2"}, false},
+		{"avatar@/path/to/a.png", &httpieItem{key: "avatar", sep: "@", value: "/path/to/a.png"}, false},
+		{"bio=@/path/to/bio.txt", &httpieItem{key: "bio", sep: "=@", value: "/path/to/bio.txt"}, false},
+		{"meta:=@/path/to/meta.json", &httpieItem{key: "meta", sep: ":=@", value: "/path/to/meta.json"}, false},
+		{"invalid", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseHTTPieItem(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got error %v", tt.in, err)
+			continue
+		}
+		if diff := cmp.Diff(tt.want, got, cmp.AllowUnexported(httpieItem{})); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestNewRequestFromHTTPie(t *testing.T) {
+	tests := []struct {
+		in         []string
+		wantMethod string
+		wantURL    string
+		wantHeader map[string]string
+		wantBody   string
+	}{
+		{
+			[]string{"http", "example.com/users"},
+			"GET", "http://example.com/users", nil, "",
+		},
+		{
+			[]string{"https", ":3000/users", "name=alice"},
+			"POST", "https://localhost:3000/users", map[string]string{"Content-Type": "application/json"}, `{"name":"alice"}`,
+		},
+		{
+			[]string{"http -A bearer -a xxxxx DELETE http://example.com/users/1"},
+			"DELETE", "http://example.com/users/1", map[string]string{"Authorization": "Bearer xxxxx"}, "",
+		},
+		{
+			[]string{"http", "--form", "POST", "example.com/login", "username=alice", "Content-Type:application/x-www-form-urlencoded; charset=utf-8"},
+			"POST", "http://example.com/login", map[string]string{"Content-Type": "application/x-www-form-urlencoded; charset=utf-8"}, "username=alice",
+		},
+	}
+	for _, tt := range tests {
+		req, err := newRequestFromHTTPie(tt.in...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if req.Method != tt.wantMethod {
+			t.Errorf("got %v want %v", req.Method, tt.wantMethod)
+		}
+		if got := req.URL.String(); got != tt.wantURL {
+			t.Errorf("got %v want %v", got, tt.wantURL)
+		}
+		for k, v := range tt.wantHeader {
+			if got := req.Header.Get(k); got != v {
+				t.Errorf("%s: got %v want %v", k, got, v)
+			}
+		}
+		var body string
+		if req.Body != nil {
+			b, err := io.ReadAll(req.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			body = string(b)
+		}
+		if body != tt.wantBody {
+			t.Errorf("got %v want %v", body, tt.wantBody)
+		}
+	}
+}
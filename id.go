@@ -4,6 +4,7 @@ import (
 	"crypto/sha1" //#nosec G505
 	"encoding/hex"
 	"errors"
+	"fmt"
 	"io"
 	"path/filepath"
 	"strings"
@@ -13,8 +14,22 @@ import (
 )
 
 // generateIDsUsingPath generates IDs using path of runbooks.
+// The runbooks with `id:` keep the specified IDs.
 // ref: https://github.com/k1LoW/runn/blob/main/docs/designs/id.md
 func generateIDsUsingPath(ops []*operator) error {
+	var generated []*operator
+	specified := map[string]string{}
+	for _, o := range ops {
+		if !o.idSpecified {
+			generated = append(generated, o)
+			continue
+		}
+		if p, ok := specified[o.id]; ok {
+			return fmt.Errorf("duplicate runbook id: %s (%s and %s)", o.id, p, o.bookPath)
+		}
+		specified[o.id] = o.bookPath
+	}
+	ops = generated
 	if len(ops) == 0 {
 		return nil
 	}
@@ -73,6 +88,15 @@ func generateIDsUsingPath(ops []*operator) error {
 	return errors.New("failed to generate ids")
 }
 
+// ShortID returns the first 7 characters of the generated ID of the runbook. The ID specified by `id:` is returned as it is.
+func (o *operator) ShortID() string {
+	const l = 7
+	if o.idSpecified || len(o.id) <= l {
+		return o.id
+	}
+	return o.id[:l]
+}
+
 func generateID(p string) (string, error) {
 	if p == "" {
 		return generateRandomID()
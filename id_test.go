@@ -1,6 +1,8 @@
 package runn
 
 import (
+	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -47,3 +49,78 @@ func TestGenerateIDsUsingPath(t *testing.T) {
 		}
 	}
 }
+
+func TestGenerateIDsUsingPathWithSpecifiedID(t *testing.T) {
+	ops := []*operator{
+		{bookPath: "path/to/a.yml", id: "login", idSpecified: true},
+		{bookPath: "path/to/b.yml"},
+	}
+	if err := generateIDsUsingPath(ops); err != nil {
+		t.Fatal(err)
+	}
+	if want := "login"; ops[0].id != want {
+		t.Errorf("want %s, got %s", want, ops[0].id)
+	}
+	want, err := generateID("b.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ops[1].id != want {
+		t.Errorf("want %s, got %s", want, ops[1].id)
+	}
+
+	dup := []*operator{
+		{bookPath: "path/to/a.yml", id: "login", idSpecified: true},
+		{bookPath: "path/to/b.yml", id: "login", idSpecified: true},
+	}
+	if err := generateIDsUsingPath(dup); err == nil {
+		t.Error("want error")
+	}
+}
+
+func TestShortID(t *testing.T) {
+	tests := []struct {
+		o    *operator
+		want string
+	}{
+		{&operator{id: "a1b7b02f5d2c3e4f"}, "a1b7b02"},
+		{&operator{id: "login-smoke", idSpecified: true}, "login-smoke"},
+		{&operator{id: "abc"}, "abc"},
+	}
+	for _, tt := range tests {
+		if got := tt.o.ShortID(); got != tt.want {
+			t.Errorf("got %v\nwant %v", got, tt.want)
+		}
+	}
+}
+
+func TestRunByID(t *testing.T) {
+	tests := []struct {
+		id   string
+		want string
+	}{
+		{"login-smoke", "testdata/book/id_specified.yml"},
+		{"login", "testdata/book/id_specified.yml"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.id, func(t *testing.T) {
+			ops, err := Load(strings.Join([]string{"testdata/book/id_specified.yml", "testdata/book/always_success.yml"}, string(filepath.ListSeparator)), RunID(tt.id))
+			if err != nil {
+				t.Fatal(err)
+			}
+			selected, err := ops.SelectedOperators()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(selected) != 1 {
+				t.Fatalf("got %d runbooks", len(selected))
+			}
+			if got := selected[0].BookPath(); got != tt.want {
+				t.Errorf("got %v\nwant %v", got, tt.want)
+			}
+			if got := selected[0].ID(); got != "login-smoke" {
+				t.Errorf("got %v\nwant %v", got, "login-smoke")
+			}
+		})
+	}
+}
@@ -2,12 +2,22 @@ package runn
 
 import (
 	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
 	"errors"
+	"fmt"
+	"hash"
 	"path/filepath"
+	"sort"
+	"strings"
 )
 
 const includeRunnerKey = "include"
 
+// includeStoreBooksKey - Key of the values of the runbooks matched by the glob pattern of include.
+const includeStoreBooksKey = "books"
+
 type includeRunner struct {
 	runResult *RunResult
 }
@@ -17,7 +27,15 @@ type includeConfig struct {
 	vars     map[string]any
 	skipTest bool
 	force    bool
-	step     *step
+	// checksum - Checksum of the included runbook ( like `sha256:<hex>` ) to pin the remote runbook
+	checksum string
+	// isolate - Run the included runbook without inheriting the runners and the store of the parent runbook
+	isolate bool
+	// stepKeys - Keys of the steps to include. Empty means all the steps
+	stepKeys []string
+	// runners - Runners overriding the runners of the included runbook. The value is the runner config or the name of the runner of the parent runbook
+	runners map[string]any
+	step    *step
 }
 
 type includedRunErr struct {
@@ -62,13 +80,51 @@ func (rnr *includeRunner) Run(ctx context.Context, s *step) error {
 	}
 	rnr.runResult = nil
 
-	// c.path must not be variable expanded. Because it will be impossible to identify the step of the included runbook in case of run failure.
-	var ibp string
-	if hasRemotePrefix(c.path) {
-		ibp = c.path
-	} else {
-		ibp = filepath.Join(o.root, c.path)
+	if !c.isGlob() {
+		v, err := rnr.runBook(ctx, s, c.bookPath(o.root))
+		if err != nil {
+			return err
+		}
+		o.record(v)
+		return nil
+	}
+
+	// Run all the matched runbooks in order
+	paths, err := c.bookPaths(o.root)
+	if err != nil {
+		return err
+	}
+	var (
+		rerr     error
+		failedRr *RunResult
+		books    []any
+	)
+	for _, p := range paths {
+		if rerr != nil && !o.force {
+			break
+		}
+		v, err := rnr.runBook(ctx, s, p)
+		if err != nil {
+			if failedRr == nil {
+				failedRr = rnr.runResult
+			}
+			rerr = errors.Join(rerr, err)
+			continue
+		}
+		books = append(books, v)
 	}
+	if failedRr != nil {
+		// The run result of the first failed runbook is reported as the result of the step
+		rnr.runResult = failedRr
+	}
+	o.record(map[string]any{includeStoreBooksKey: books})
+	return rerr
+}
+
+// runBook runs the included runbook and returns the values to record.
+func (rnr *includeRunner) runBook(ctx context.Context, s *step, ibp string) (map[string]any, error) {
+	o := s.parent
+	c := s.includeConfig
 
 	// Store before record
 	store := o.store.toMap()
@@ -77,42 +133,67 @@ func (rnr *includeRunner) Run(ctx context.Context, s *step) error {
 	pstore := map[string]any{
 		storeRootKeyParent: store,
 	}
-	oo, err := o.newNestedOperator(c.step, bookWithStore(ibp, pstore), SkipTest(c.skipTest))
+	if c.isolate {
+		pstore = nil
+	}
+	if err := o.checkIncludeTrail(ibp); err != nil {
+		return nil, err
+	}
+	if c.checksum != "" {
+		if err := verifyChecksum(ibp, c.checksum); err != nil {
+			return nil, err
+		}
+	}
+	ropts, err := o.runnerOverrideOptions(includeRunnerKey, c.runners)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	opts := []Option{bookWithStore(ibp, pstore), SkipTest(c.skipTest)}
+	if len(c.stepKeys) > 0 {
+		opts = append(opts, includeSteps(c.stepKeys))
+	}
+	oo, err := o.newNestedOperator(c.step, append(opts, ropts...)...)
+	if err != nil {
+		return nil, err
 	}
 
 	// Override vars
+	if err := oo.checkConsts(c.vars); err != nil {
+		return nil, err
+	}
 	for k, v := range c.vars {
 		switch ov := v.(type) {
 		case string:
 			var vv any
 			vv, err = o.expandBeforeRecord(ov)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			evv, err := evaluateSchema(vv, oo.root, store)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			oo.store.vars[k] = evv
 		case map[string]any, []any:
 			vv, err := o.expandBeforeRecord(ov)
 			if err != nil {
-				return err
+				return nil, err
 			}
 			oo.store.vars[k] = vv
 		default:
 			oo.store.vars[k] = ov
 		}
 	}
+	if err := validateVars(oo.varsSchema, oo.store.vars); err != nil {
+		return nil, err
+	}
 	if err := oo.run(ctx); err != nil {
+		oo.maskRunResult(oo.runResult)
 		rnr.runResult = oo.runResult
-		return newIncludedRunErr(err)
+		return nil, newIncludedRunErr(oo.maskErr(err))
 	}
 	rnr.runResult = oo.runResult
-	o.record(oo.store.toNormalizedMap())
-	return nil
+	return oo.store.toNormalizedMap(), nil
 }
 
 // newNestedOperator create nested operator.
@@ -120,25 +201,34 @@ func (o *operator) newNestedOperator(parent *step, opts ...Option) (*operator, e
 	var popts []Option
 	popts = append(popts, included(true))
 
+	// Isolated runbook does not inherit the runners and the store of the parent runbook
+	isolate := parent != nil && parent.includeConfig != nil && parent.includeConfig.isolate
+
 	// Set parent runners for re-use
-	for k, r := range o.httpRunners {
-		popts = append(popts, runnHTTPRunner(k, r))
-	}
-	for k, r := range o.dbRunners {
-		popts = append(popts, runnDBRunner(k, r))
-	}
-	for k, r := range o.grpcRunners {
-		popts = append(popts, runnGrpcRunner(k, r))
-	}
-	for k, r := range o.sshRunners {
-		popts = append(popts, runnSSHRunner(k, r))
+	if !isolate {
+		for k, r := range o.httpRunners {
+			popts = append(popts, runnHTTPRunner(k, r))
+		}
+		for k, r := range o.dbRunners {
+			popts = append(popts, runnDBRunner(k, r))
+		}
+		for k, r := range o.grpcRunners {
+			popts = append(popts, runnGrpcRunner(k, r))
+		}
+		for k, r := range o.sshRunners {
+			popts = append(popts, runnSSHRunner(k, r))
+		}
 	}
 
+	popts = append(popts, dotenv(o.dotenv))
 	popts = append(popts, Debug(o.debug))
 	popts = append(popts, Profile(o.profile))
 	popts = append(popts, SkipTest(o.skipTest))
 	popts = append(popts, Force(o.force))
+	popts = append(popts, IncludeMaxDepth(o.includeMaxDepth))
 	popts = append(popts, Trace(o.trace))
+	popts = append(popts, UpdateSnapshots(o.updateSnapshots))
+	popts = append(popts, SoftAssertions(o.softAssertions))
 	for k, f := range o.store.funcs {
 		popts = append(popts, Func(k, f))
 	}
@@ -154,6 +244,159 @@ func (o *operator) newNestedOperator(parent *step, opts ...Option) (*operator, e
 	oo.sw = o.sw
 	oo.capturers = o.capturers
 	oo.parent = parent
-	oo.store.parentVars = o.store.toMap()
+	if len(oo.secrets) > 0 {
+		// Mask the secrets of the nested runbook in addition to the ones of the parent
+		oo.capturers = newMaskCapturers(oo.capturers, oo)
+	}
+	if !isolate {
+		oo.store.parentVars = o.store.toMap()
+	}
+	oo.store.shared = o.store.shared
 	return oo, nil
 }
+
+// includeTrail returns the paths of the runbooks from the root runbook to the operator.
+func (o *operator) includeTrail() []string {
+	var trail []string
+	if o.parent != nil && o.parent.parent != nil {
+		trail = o.parent.parent.includeTrail()
+	}
+	if o.bookPath == "" {
+		return trail
+	}
+	// The operators of parallel and group steps have the same runbook as the parent
+	if len(trail) > 0 && trail[len(trail)-1] == o.bookPath {
+		return trail
+	}
+	return append(trail, o.bookPath)
+}
+
+// checkIncludeTrail checks that including the runbook does not make a cycle or exceed the max depth of include.
+func (o *operator) checkIncludeTrail(ibp string) error {
+	trail := o.includeTrail()
+	for _, p := range trail {
+		if samePath(p, ibp) {
+			return fmt.Errorf("invalid include: include cycle detected: %s -> %s", strings.Join(trail, " -> "), ibp)
+		}
+	}
+	if o.includeMaxDepth > 0 && len(trail) > o.includeMaxDepth {
+		return fmt.Errorf("invalid include: exceeded the max depth of include (%d): %s -> %s", o.includeMaxDepth, strings.Join(trail, " -> "), ibp)
+	}
+	return nil
+}
+
+// samePath returns true if the paths of the runbooks are the same.
+func samePath(a, b string) bool {
+	if a == b {
+		return true
+	}
+	if hasRemotePrefix(a) || hasRemotePrefix(b) {
+		return false
+	}
+	aa, err := filepath.Abs(a)
+	if err != nil {
+		return false
+	}
+	ab, err := filepath.Abs(b)
+	if err != nil {
+		return false
+	}
+	return aa == ab
+}
+
+// runnerOverrideOptions returns the options to override the runners of the nested operator ( e.g. the included runbook ).
+func (o *operator) runnerOverrideOptions(key string, runners map[string]any) ([]Option, error) {
+	var opts []Option
+	for k, v := range runners {
+		if pk, ok := v.(string); ok {
+			// Remap to the runner of the parent runbook
+			switch {
+			case o.httpRunners[pk] != nil:
+				opts = append(opts, removeRunner(k), runnHTTPRunner(k, o.httpRunners[pk]))
+				continue
+			case o.dbRunners[pk] != nil:
+				opts = append(opts, removeRunner(k), runnDBRunner(k, o.dbRunners[pk]))
+				continue
+			case o.grpcRunners[pk] != nil:
+				opts = append(opts, removeRunner(k), runnGrpcRunner(k, o.grpcRunners[pk]))
+				continue
+			case o.sshRunners[pk] != nil:
+				opts = append(opts, removeRunner(k), runnSSHRunner(k, o.sshRunners[pk]))
+				continue
+			}
+		}
+		ev, err := o.expandBeforeRecord(v)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s runners: %w", key, err)
+		}
+		opts = append(opts, removeRunner(k), overrideRunner(k, ev))
+	}
+	return opts, nil
+}
+
+// isGlob returns true if the path of include is a glob pattern.
+func (c *includeConfig) isGlob() bool {
+	return strings.Contains(c.path, "*")
+}
+
+// bookPath returns the path of the included runbook.
+// c.path must not be variable expanded. Because it will be impossible to identify the step of the included runbook in case of run failure.
+func (c *includeConfig) bookPath(root string) string {
+	if hasRemotePrefix(c.path) {
+		return c.path
+	}
+	return filepath.Join(root, c.path)
+}
+
+// bookPaths returns the paths of the included runbooks in order.
+func (c *includeConfig) bookPaths(root string) ([]string, error) {
+	if !c.isGlob() {
+		return []string{c.bookPath(root)}, nil
+	}
+	if hasRemotePrefix(c.path) && !strings.HasPrefix(c.path, prefixGit) {
+		return nil, fmt.Errorf("invalid include path: remote path does not support wildcard: %s", c.path)
+	}
+	if c.checksum != "" {
+		// The checksum pins one runbook, so it cannot be applied to the matched runbooks
+		return nil, fmt.Errorf("invalid include path: checksum cannot be used with glob pattern: %s", c.path)
+	}
+	paths, err := fetchPaths(c.bookPath(root))
+	if err != nil {
+		return nil, err
+	}
+	if len(paths) == 0 {
+		return nil, fmt.Errorf("invalid include path: no runbooks match %s", c.path)
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// verifyChecksum verifies that the checksum of the runbook matches.
+func verifyChecksum(p, checksum string) error {
+	algo, want, ok := strings.Cut(checksum, ":")
+	if !ok {
+		return fmt.Errorf("invalid include checksum: %s", checksum)
+	}
+	var h hash.Hash
+	switch algo {
+	case "sha256":
+		h = sha256.New()
+	case "sha512":
+		h = sha512.New()
+	default:
+		return fmt.Errorf("invalid include checksum: unsupported algorithm: %s", algo)
+	}
+	fp, err := fetchPath(p)
+	if err != nil {
+		return err
+	}
+	b, err := readFile(fp)
+	if err != nil {
+		return err
+	}
+	_, _ = h.Write(b)
+	if got := hex.EncodeToString(h.Sum(nil)); got != strings.ToLower(want) {
+		return fmt.Errorf("checksum mismatch of %s: got %s:%s, want %s", p, algo, got, checksum)
+	}
+	return nil
+}
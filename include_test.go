@@ -4,8 +4,11 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/google/go-cmp/cmp"
+
 	"github.com/k1LoW/runn/testutil"
 )
 
@@ -207,3 +210,240 @@ func TestIncludedRunErr(t *testing.T) {
 		}
 	}
 }
+
+func TestIncludeGlob(t *testing.T) {
+	tests := []struct {
+		book    string
+		wantErr bool
+	}{
+		{"testdata/book/glob_include.yml", false},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			o, err := New(Book(tt.book))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); err != nil {
+				if !tt.wantErr {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestIncludeConfigBookPaths(t *testing.T) {
+	tests := []struct {
+		path     string
+		checksum string
+		want     []string
+		wantErr  bool
+	}{
+		{"include_glob/setup_a.yml", "", []string{"testdata/include_glob/setup_a.yml"}, false},
+		{"include_glob/setup_*.yml", "", []string{"testdata/include_glob/setup_a.yml", "testdata/include_glob/setup_b.yml"}, false},
+		{"include_glob/notexist_*.yml", "", nil, true},
+		{"https://example.com/setup_*.yml", "", nil, true},
+		{"include_glob/setup_a.yml", "sha256:0000", []string{"testdata/include_glob/setup_a.yml"}, false},
+		{"include_glob/setup_*.yml", "sha256:0000", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			c := &includeConfig{path: tt.path, checksum: tt.checksum}
+			got, err := c.bookPaths("testdata")
+			if err != nil {
+				if !tt.wantErr {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParseIncludeConfigChecksum(t *testing.T) {
+	tests := []struct {
+		in      map[string]any
+		wantErr bool
+	}{
+		{map[string]any{"path": "setup.yml", "checksum": "sha256:0000"}, false},
+		{map[string]any{"path": "git::https://github.com/k1LoW/runn.git//testdata/book/book.yml", "checksum": "sha256:0000"}, false},
+		{map[string]any{"path": "setup_*.yml", "checksum": "sha256:0000"}, true},
+		{map[string]any{"path": "git::https://github.com/k1LoW/runn.git//testdata/book/runn_*.yml", "checksum": "sha256:0000"}, true},
+		{map[string]any{"path": "setup.yml", "checksum": 1}, true},
+	}
+	for _, tt := range tests {
+		_, err := parseIncludeConfig(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: got %v\nwantErr %v", tt.in, err, tt.wantErr)
+		}
+	}
+}
+
+func TestIncludeRunnerOverride(t *testing.T) {
+	ts := testutil.HTTPServer(t)
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/runners_override.yml"), Var("url", ts.URL), Runner("api", ts.URL))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestIncludeIf(t *testing.T) {
+	tests := []struct {
+		env     string
+		wantErr bool
+	}{
+		{"ci", false},
+		{"local", true},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			o, err := New(Book("testdata/book/conditional_include.yml"), Var("env", tt.env))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); err != nil {
+				if !tt.wantErr {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestIncludeIsolate(t *testing.T) {
+	ts := testutil.HTTPServer(t)
+	tests := []struct {
+		name    string
+		isolate bool
+		runners map[string]any
+		wantErr bool
+	}{
+		{"Inherit the parent vars", false, nil, true},
+		{"No parent runners", true, nil, true},
+		{"Pass the runners explicitly", true, map[string]any{"req": ts.URL}, false},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New(Var("foo", "bar"), Runner("req", ts.URL))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newIncludeRunner()
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := newStep(0, "stepKey", o)
+			s.includeConfig = &includeConfig{path: "testdata/book/isolated.yml", isolate: tt.isolate, runners: tt.runners, step: s}
+			if err := r.Run(ctx, s); err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+		})
+	}
+}
+
+func TestIncludeTrail(t *testing.T) {
+	tests := []struct {
+		book     string
+		maxDepth int
+		wantErr  string
+	}{
+		{"testdata/book/cycle_a.yml", 0, "include cycle detected: testdata/book/cycle_a.yml -> testdata/book/cycle_b.yml -> testdata/book/cycle_a.yml"},
+		{"testdata/book/nested_include.yml", 0, ""},
+		{"testdata/book/nested_include.yml", 2, ""},
+		{"testdata/book/nested_include.yml", 1, "exceeded the max depth of include (1)"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s_%d", tt.book, tt.maxDepth), func(t *testing.T) {
+			o, err := New(Book(tt.book), IncludeMaxDepth(tt.maxDepth))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v\nwant %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestIncludeSteps(t *testing.T) {
+	tests := []struct {
+		book    string
+		keys    []string
+		wantErr bool
+	}{
+		{"testdata/book/step_include.yml", nil, false},
+		{"testdata/book/shared_steps.yml", []string{"notexist"}, true},
+		{"testdata/book/glob_include.yml", []string{"setup"}, false},
+		{"testdata/book/runn_0_success.yml", []string{"0"}, true},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			if len(tt.keys) == 0 {
+				o, err := New(Book(tt.book))
+				if err != nil {
+					t.Fatal(err)
+				}
+				if err := o.Run(ctx); err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			r, err := newIncludeRunner()
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := newStep(0, "stepKey", o)
+			s.includeConfig = &includeConfig{path: tt.book, stepKeys: tt.keys, step: s}
+			if err := r.Run(ctx, s); err != nil {
+				if !tt.wantErr {
+					t.Error(err)
+				}
+				return
+			}
+			if tt.wantErr {
+				t.Error("want error")
+			}
+		})
+	}
+}
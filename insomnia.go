@@ -0,0 +1,283 @@
+package runn
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	goyaml "github.com/goccy/go-yaml"
+	"gopkg.in/yaml.v2"
+)
+
+const insomniaExportType = "export"
+
+var (
+	// insomniaVarRe - Environment variable of Insomnia ( e.g. {{ _.base_url }}, {{ base_url }} )
+	insomniaVarRe = regexp.MustCompile(`\{\{\s*(?:_\.)?([a-zA-Z_][a-zA-Z0-9_\.\-]*)\s*\}\}`)
+	// insomniaResponseTagRe - Response tag of Insomnia to chain the requests ( e.g. {% response 'body', 'req_xxx', 'b64::JC5pZA==::46b', 'never', 60 %} )
+	insomniaResponseTagRe = regexp.MustCompile(`\{%\s*response\s+['"](body|header|raw)['"]\s*,\s*['"]([^'"]+)['"]\s*(?:,\s*['"]([^'"]*)['"])?[^%]*%\}`)
+)
+
+// insomniaExport - Workspace export of Insomnia ( export format 4 ). Only the fields required to generate the steps are defined.
+type insomniaExport struct {
+	Type      string              `yaml:"_type"`
+	Resources []*insomniaResource `yaml:"resources"`
+}
+
+type insomniaResource struct {
+	ID       string `yaml:"_id"`
+	Type     string `yaml:"_type"`
+	ParentID string `yaml:"parentId"`
+	Name     string `yaml:"name"`
+	// environment
+	Data map[string]any `yaml:"data"`
+	// request_group
+	Environment map[string]any `yaml:"environment"`
+	// request
+	Method     string               `yaml:"method"`
+	URL        string               `yaml:"url"`
+	Headers    []*insomniaNameValue `yaml:"headers"`
+	Parameters []*insomniaNameValue `yaml:"parameters"`
+	Body       struct {
+		MimeType string               `yaml:"mimeType"`
+		Text     string               `yaml:"text"`
+		Params   []*insomniaNameValue `yaml:"params"`
+	} `yaml:"body"`
+	Authentication map[string]any `yaml:"authentication"`
+}
+
+type insomniaNameValue struct {
+	Name     string `yaml:"name"`
+	Value    string `yaml:"value"`
+	Disabled bool   `yaml:"disabled"`
+}
+
+func parseInsomniaExport(in ...string) (*insomniaExport, bool) {
+	if len(in) != 1 {
+		return nil, false
+	}
+	switch strings.ToLower(filepath.Ext(in[0])) {
+	case ".json", ".yml", ".yaml":
+	default:
+		return nil, false
+	}
+	b, err := os.ReadFile(filepath.Clean(in[0]))
+	if err != nil {
+		return nil, false
+	}
+	e := &insomniaExport{}
+	if err := goyaml.Unmarshal(b, e); err != nil || e.Type != insomniaExportType {
+		return nil, false
+	}
+	return e, true
+}
+
+// insomniaToSteps appends the HTTP steps converted from the requests of the Insomnia export.
+// The environments are converted to vars, and the response tags chaining the requests are converted to the references to `steps`.
+func (rb *runbook) insomniaToSteps(e *insomniaExport) error {
+	var reqs []*insomniaResource
+	// References to the steps converted from the requests
+	refs := map[string]string{}
+	for _, r := range e.Resources {
+		switch r.Type {
+		case "request":
+			if rb.useMap {
+				k := fmt.Sprintf("%s%d", strings.ToLower(r.Method), len(rb.stepKeys)+len(reqs))
+				refs[r.ID] = fmt.Sprintf("steps.%s", k)
+			} else {
+				refs[r.ID] = fmt.Sprintf("steps[%d]", len(rb.Steps)+len(reqs))
+			}
+			reqs = append(reqs, r)
+		}
+	}
+	// The base environment is the environment of the workspace, and the environments of the folders override it
+	vars := map[string]any{}
+	for _, r := range e.Resources {
+		switch {
+		case r.Type == "environment" && strings.HasPrefix(r.ParentID, "wrk_"):
+			for k, v := range r.Data {
+				vars[k] = v
+			}
+		case r.Type == "request_group":
+			for k, v := range r.Environment {
+				vars[k] = v
+			}
+		}
+	}
+	if rb.Vars == nil {
+		rb.Vars = map[string]any{}
+	}
+	for k, v := range vars {
+		rb.Vars[k] = v
+	}
+
+	conv := func(s string) string {
+		s = insomniaVarRe.ReplaceAllString(s, "{{ vars.$1 }}")
+		return insomniaResponseTagRe.ReplaceAllStringFunc(s, func(tag string) string {
+			m := insomniaResponseTagRe.FindStringSubmatch(tag)
+			ref, ok := refs[m[2]]
+			if !ok {
+				return tag
+			}
+			arg := m[3]
+			if strings.HasPrefix(arg, "b64::") {
+				// b64::<base64 encoded value>::46b
+				if b, err := base64.StdEncoding.DecodeString(strings.Split(arg, "::")[1]); err == nil {
+					arg = string(b)
+				}
+			}
+			switch m[1] {
+			case "header":
+				return fmt.Sprintf(`{{ %s.res.headers[%q][0] }}`, ref, http.CanonicalHeaderKey(arg))
+			case "raw":
+				return fmt.Sprintf("{{ %s.res.rawBody }}", ref)
+			default:
+				return fmt.Sprintf("{{ %s.res.body%s }}", ref, strings.TrimPrefix(arg, "$"))
+			}
+		})
+	}
+	expand := func(s string) string {
+		return insomniaVarRe.ReplaceAllStringFunc(s, func(v string) string {
+			m := insomniaVarRe.FindStringSubmatch(v)
+			if vv, ok := vars[m[1]]; ok {
+				return fmt.Sprintf("%v", vv)
+			}
+			return v
+		})
+	}
+
+	for _, r := range reqs {
+		step, err := rb.insomniaRequestToStep(r, conv, expand)
+		if err != nil {
+			return fmt.Errorf("invalid insomnia request %q: %w", r.Name, err)
+		}
+		if rb.useMap {
+			rb.stepKeys = append(rb.stepKeys, strings.TrimPrefix(refs[r.ID], "steps."))
+		}
+		rb.Steps = append(rb.Steps, step)
+	}
+	return nil
+}
+
+func (rb *runbook) insomniaRequestToStep(r *insomniaResource, conv, expand func(string) string) (yaml.MapSlice, error) {
+	// The runner is set per origin of the URL expanded with the environments
+	expanded := expand(r.URL)
+	if i := strings.Index(expanded, "://"); i >= 0 {
+		if j := strings.IndexAny(expanded[i+3:], "/?#"); j >= 0 {
+			expanded = expanded[:i+3+j]
+		}
+	}
+	u, err := url.Parse(expanded)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return nil, fmt.Errorf("invalid url: %s", r.URL)
+	}
+	origin := fmt.Sprintf("%s://%s", u.Scheme, u.Host)
+	key := rb.setRunner(origin)
+
+	raw := conv(r.URL)
+	var p string
+	switch {
+	case strings.HasPrefix(raw, origin):
+		p = strings.TrimPrefix(raw, origin)
+	default:
+		// The origin is given by the variable ( e.g. {{ vars.base_url }}/users )
+		loc := insomniaVarRe.FindStringIndex(r.URL)
+		if loc != nil && loc[0] == 0 && strings.HasPrefix(expand(r.URL[:loc[1]]), origin) {
+			p = strings.TrimPrefix(expand(r.URL[:loc[1]]), origin) + conv(r.URL[loc[1]:])
+		} else {
+			p = strings.TrimPrefix(expand(r.URL), origin)
+		}
+	}
+	if p == "" {
+		p = "/"
+	}
+	var q []string
+	for _, pr := range r.Parameters {
+		if pr.Disabled {
+			continue
+		}
+		q = append(q, fmt.Sprintf("%s=%s", conv(pr.Name), conv(pr.Value)))
+	}
+	if len(q) > 0 {
+		sep := "?"
+		if strings.Contains(p, "?") {
+			sep = "&"
+		}
+		p = p + sep + strings.Join(q, "&")
+	}
+
+	req := yaml.MapSlice{}
+	headers := map[string]string{}
+	for _, h := range r.Headers {
+		if h.Disabled || strings.EqualFold(h.Name, "Content-Type") {
+			continue
+		}
+		headers[http.CanonicalHeaderKey(h.Name)] = conv(h.Value)
+	}
+	switch r.Authentication["type"] {
+	case "bearer":
+		headers["Authorization"] = conv(fmt.Sprintf("Bearer %v", r.Authentication["token"]))
+	case "basic":
+		user, pass := fmt.Sprintf("%v", r.Authentication["username"]), fmt.Sprintf("%v", r.Authentication["password"])
+		if strings.Contains(user+pass, "{{") {
+			headers["Authorization"] = fmt.Sprintf("Basic {{ toBase64(%s + ':' + %s) }}", insomniaExpr(user), insomniaExpr(pass))
+		} else {
+			headers["Authorization"] = fmt.Sprintf("Basic %s", base64.StdEncoding.EncodeToString([]byte(user+":"+pass)))
+		}
+	}
+	if len(headers) > 0 {
+		req = append(req, yaml.MapItem{Key: "headers", Value: headers})
+	}
+
+	var body any
+	ct := r.Body.MimeType
+	switch {
+	case ct == "":
+	case len(r.Body.Params) > 0:
+		params := map[string]any{}
+		for _, pr := range r.Body.Params {
+			if pr.Disabled {
+				continue
+			}
+			params[conv(pr.Name)] = conv(pr.Value)
+		}
+		body = yaml.MapSlice{{Key: ct, Value: params}}
+	case strings.Contains(ct, "json"):
+		var v any
+		if err := json.Unmarshal([]byte(conv(r.Body.Text)), &v); err != nil {
+			// The JSON is not valid until the variables are expanded ( e.g. {"id": {{ _.id }}} )
+			v = conv(r.Body.Text)
+		}
+		body = yaml.MapSlice{{Key: ct, Value: v}}
+	default:
+		body = yaml.MapSlice{{Key: ct, Value: conv(r.Body.Text)}}
+	}
+	req = append(req, yaml.MapItem{Key: "body", Value: body})
+
+	return yaml.MapSlice{
+		{Key: descSectionKey, Value: r.Name},
+		{Key: key, Value: yaml.MapSlice{
+			{Key: p, Value: yaml.MapSlice{
+				{Key: strings.ToLower(r.Method), Value: req},
+			}},
+		}},
+	}, nil
+}
+
+// insomniaExpr converts the value to the expression ( the variable or the string literal ).
+func insomniaExpr(v string) string {
+	if m := insomniaVarRe.FindStringSubmatch(v); m != nil && m[0] == v {
+		return fmt.Sprintf("vars.%s", m[1])
+	}
+	return strconv.Quote(v)
+}
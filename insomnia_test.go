@@ -0,0 +1,52 @@
+package runn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+)
+
+func TestInsomniaToStepsUsingMap(t *testing.T) {
+	rb := NewRunbook("")
+	rb.useMap = true
+	if err := rb.AppendStep("testdata/insomnia/export.json"); err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff([]string{"post0", "get1", "get2"}, rb.stepKeys); diff != "" {
+		t.Error(diff)
+	}
+	b, err := yaml.Marshal(rb.Steps[1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `desc: Get user
+req:
+  /v1/users/{{ steps.post0.res.body.user.id }}?fields=name,email:
+    get:
+      headers:
+        Authorization: Bearer {{ steps.post0.res.body.token }}
+        X-Session: '{{ steps.post0.res.headers["X-Session"][0] }}'
+      body: null
+`
+	if diff := cmp.Diff(want, string(b)); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestParseInsomniaExport(t *testing.T) {
+	tests := []struct {
+		in   []string
+		want bool
+	}{
+		{[]string{"testdata/insomnia/export.json"}, true},
+		{[]string{"testdata/vars.json"}, false},
+		{[]string{"testdata/har/example.har"}, false},
+		{[]string{"echo", "hello"}, false},
+	}
+	for _, tt := range tests {
+		if _, got := parseInsomniaExport(tt.in...); got != tt.want {
+			t.Errorf("%v: got %v want %v", tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package runn
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/goccy/go-yaml"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+const jsonschemaFuncName = "jsonschema"
+
+// jsonschemaFunc - Built-in function `jsonschema` that validates the value against the JSON Schema.
+type jsonschemaFunc func(schema, v any) (bool, error)
+
+// newJSONSchemaFunc returns the built-in function `jsonschema` bound to the root directory.
+// The schema is the path of the schema file ( JSON or YAML ) relative to the root directory, the inline JSON string or the map.
+func newJSONSchemaFunc(root string) jsonschemaFunc {
+	return func(schema, v any) (bool, error) {
+		l, err := jsonschemaLoader(schema, root)
+		if err != nil {
+			return false, err
+		}
+		s, err := gojsonschema.NewSchema(l)
+		if err != nil {
+			return false, fmt.Errorf("invalid JSON Schema: %w", err)
+		}
+		r, err := s.Validate(gojsonschema.NewGoLoader(v))
+		if err != nil {
+			return false, fmt.Errorf("failed to validate: %w", err)
+		}
+		return r.Valid(), nil
+	}
+}
+
+func jsonschemaLoader(schema any, root string) (gojsonschema.JSONLoader, error) {
+	switch s := schema.(type) {
+	case string:
+		if strings.HasPrefix(strings.TrimSpace(s), "{") {
+			// Inline JSON
+			return gojsonschema.NewStringLoader(s), nil
+		}
+		b, err := readFile(fp(s, root))
+		if err != nil {
+			return nil, err
+		}
+		switch filepath.Ext(s) {
+		case ".yml", ".yaml":
+			var v any
+			if err := yaml.Unmarshal(b, &v); err != nil {
+				return nil, fmt.Errorf("invalid JSON Schema file %s: %w", s, err)
+			}
+			return gojsonschema.NewGoLoader(v), nil
+		default:
+			return gojsonschema.NewBytesLoader(b), nil
+		}
+	case map[string]any:
+		return gojsonschema.NewGoLoader(s), nil
+	default:
+		return nil, fmt.Errorf("invalid JSON Schema: %v", schema)
+	}
+}
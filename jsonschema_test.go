@@ -0,0 +1,40 @@
+package runn
+
+import (
+	"testing"
+)
+
+func TestJSONSchemaFunc(t *testing.T) {
+	tests := []struct {
+		schema  any
+		v       any
+		want    bool
+		wantErr bool
+	}{
+		{"jsonschema/user.json", map[string]any{"id": 1, "name": "alice"}, true, false},
+		{"jsonschema/user.json", map[string]any{"id": 1}, false, false},
+		{"jsonschema/user.yml", map[string]any{"id": 1, "name": ""}, false, false},
+		{`{"type": "string"}`, "alice", true, false},
+		{map[string]any{"type": "integer"}, 1, true, false},
+		{map[string]any{"type": "unknown"}, 1, false, true},
+		{"jsonschema/notexist.json", 1, false, true},
+		{1, 1, false, true},
+	}
+	fn := newJSONSchemaFunc("testdata")
+	for _, tt := range tests {
+		got, err := fn(tt.schema, tt.v)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %v", tt.schema)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%v %v: got %v\nwant %v", tt.schema, tt.v, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package runn
+
+import (
+	"fmt"
+	"time"
+)
+
+const maxLatencySectionKey = "maxLatency"
+
+func parseMaxLatency(v any) (time.Duration, error) {
+	switch v.(type) {
+	case string, int, uint64, float64:
+	default:
+		return 0, fmt.Errorf("invalid type: %v", v)
+	}
+	d, err := parseDuration(fmt.Sprintf("%v", v))
+	if err != nil {
+		return 0, err
+	}
+	if d <= 0 {
+		return 0, fmt.Errorf("must be positive: %v", v)
+	}
+	return d, nil
+}
+
+// recordLatency records the latency of the runner of the step in milliseconds and checks it against maxLatency.
+func (o *operator) recordLatency(i int, s *step, elapsed time.Duration) error {
+	if o.store.length() == i+1 {
+		if err := o.store.recordToLatest(storeStepKeyLatency, float64(elapsed.Microseconds())/1000); err != nil {
+			return err
+		}
+	}
+	if s.maxLatency == nil || o.skipTest {
+		return nil
+	}
+	if elapsed > *s.maxLatency {
+		return fmt.Errorf("latency exceeded on %s: %v > %s %v", o.stepName(i), elapsed, maxLatencySectionKey, *s.maxLatency)
+	}
+	return nil
+}
@@ -0,0 +1,87 @@
+package runn
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMaxLatency(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/max_latency.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := o.store.steps[0][storeStepKeyLatency].(float64); !ok {
+		t.Errorf("invalid latency: %v", o.store.steps[0][storeStepKeyLatency])
+	}
+	if _, ok := o.store.steps[1][storeStepKeyLatency]; ok {
+		t.Errorf("test only step should not have latency: %v", o.store.steps[1])
+	}
+}
+
+func TestMaxLatencyExceeded(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.AppendStep(0, "", map[string]any{
+		"exec":       map[string]any{"command": "sleep 0.2"},
+		"maxLatency": "50ms",
+	}); err != nil {
+		t.Fatal(err)
+	}
+	err = o.Run(ctx)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if want := "latency exceeded on"; !strings.Contains(err.Error(), want) {
+		t.Errorf("got %v\nwant %v", err, want)
+	}
+}
+
+func TestParseMaxLatency(t *testing.T) {
+	tests := []struct {
+		in      any
+		want    time.Duration
+		wantErr bool
+	}{
+		{"500ms", 500 * time.Millisecond, false},
+		{"1sec", time.Second, false},
+		{uint64(2), 2 * time.Second, false},
+		{"0ms", 0, true},
+		{"invalid", 0, true},
+		{true, 0, true},
+	}
+	for _, tt := range tests {
+		got, err := parseMaxLatency(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: got %v\nwantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%v: got %v\nwant %v", tt.in, got, tt.want)
+		}
+	}
+}
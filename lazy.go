@@ -0,0 +1,70 @@
+package runn
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// lazyVar - Var of `lazyVars:` that is evaluated on first use.
+type lazyVar struct {
+	expr       string
+	evaluating bool
+}
+
+// MarshalJSON returns null because the var has not been evaluated yet.
+func (v *lazyVar) MarshalJSON() ([]byte, error) {
+	return []byte("null"), nil
+}
+
+var wholeVarsRe = regexp.MustCompile(`\bvars\b\s*([^.\[\s]|$)`)
+
+// bindLazyVars binds `lazyVars:` into vars as unevaluated vars.
+// The vars assigned by `vars:` or `--var` take precedence.
+func (bk *book) bindLazyVars() {
+	for k, e := range bk.lazyVars {
+		if _, ok := bk.vars[k]; ok {
+			continue
+		}
+		bk.vars[k] = &lazyVar{expr: e}
+	}
+}
+
+// resolveLazyVars evaluates the lazy vars referenced in `in` and replaces them with the evaluated values.
+func resolveLazyVars(in string, store any) error {
+	m, ok := store.(map[string]any)
+	if !ok {
+		return nil
+	}
+	vars, ok := m[storeRootKeyVars].(map[string]any)
+	if !ok {
+		return nil
+	}
+	whole := wholeVarsRe.MatchString(in)
+	for k, v := range vars {
+		lv, ok := v.(*lazyVar)
+		if !ok {
+			continue
+		}
+		if !whole && !referencesVar(in, k) {
+			continue
+		}
+		if lv.evaluating {
+			return fmt.Errorf("invalid lazyVars.%s: circular reference", k)
+		}
+		lv.evaluating = true
+		ev, err := Eval(lv.expr, store)
+		lv.evaluating = false
+		if err != nil {
+			return fmt.Errorf("invalid lazyVars.%s: %w", k, err)
+		}
+		vars[k] = ev
+	}
+	return nil
+}
+
+// referencesVar reports whether `in` references the var ( `vars.key` or `vars["key"]` ).
+func referencesVar(in, k string) bool {
+	q := regexp.QuoteMeta(k)
+	re := regexp.MustCompile(`\bvars\s*(\.\s*` + q + `\b|\[\s*["']` + q + `["']\s*\])`)
+	return re.MatchString(in)
+}
@@ -0,0 +1,149 @@
+package runn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLazyVars(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	tests := []struct {
+		name    string
+		book    string
+		opts    []Option
+		wantErr string
+	}{
+		{
+			"not evaluated when the runbook is skipped",
+			`desc: Skipped
+if: false
+lazyVars:
+  token: undefinedFunc()
+steps:
+  -
+    test: vars.token != ""
+`,
+			nil,
+			"",
+		},
+		{
+			"not evaluated when not used",
+			`desc: Not used
+lazyVars:
+  token: undefinedFunc()
+steps:
+  -
+    test: vars.name == "alice"
+`,
+			[]Option{Var("name", "alice")},
+			"",
+		},
+		{
+			"evaluated on first use",
+			`desc: Used
+lazyVars:
+  token: undefinedFunc()
+steps:
+  -
+    test: vars.token != ""
+`,
+			nil,
+			"invalid lazyVars.token",
+		},
+		{
+			"overridden by var",
+			`desc: Overridden
+lazyVars:
+  token: undefinedFunc()
+steps:
+  -
+    test: vars.token == "t0ken"
+`,
+			[]Option{Var("token", "t0ken")},
+			"",
+		},
+		{
+			"circular reference",
+			`desc: Circular
+lazyVars:
+  a: vars.b
+  b: vars.a
+steps:
+  -
+    test: vars.a != ""
+`,
+			nil,
+			"circular reference",
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "lazy.yml")
+			if err := os.WriteFile(p, []byte(tt.book), 0600); err != nil {
+				t.Fatal(err)
+			}
+			o, err := New(append([]Option{Scopes(ScopeAllowReadParent), Book(p)}, tt.opts...)...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v\nwant %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestReferencesVar(t *testing.T) {
+	tests := []struct {
+		in   string
+		k    string
+		want bool
+	}{
+		{"vars.token", "token", true},
+		{`vars["token"] != ""`, "token", true},
+		{"vars['token']", "token", true},
+		{"vars.tokens", "token", false},
+		{"vars.name + token", "token", false},
+		{"myvars.token", "token", false},
+		{"parent.vars.token", "token", true},
+	}
+	for _, tt := range tests {
+		if got := referencesVar(tt.in, tt.k); got != tt.want {
+			t.Errorf("%s: got %v\nwant %v", tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestLazyVarsExpand(t *testing.T) {
+	store := map[string]any{
+		storeRootKeyVars: map[string]any{
+			"name":  "alice",
+			"token": &lazyVar{expr: `"t0ken-" + vars.name`},
+		},
+	}
+	got, err := EvalExpand(map[string]any{"Authorization": "Bearer {{ vars.token }}"}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Bearer t0ken-alice"; got.(map[string]any)["Authorization"] != want {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+	if _, ok := store[storeRootKeyVars].(map[string]any)["token"].(string); !ok {
+		t.Error("the lazy var should be replaced with the evaluated value")
+	}
+}
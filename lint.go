@@ -0,0 +1,469 @@
+package runn
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+
+	"github.com/k1LoW/expand"
+	"gopkg.in/yaml.v2"
+)
+
+type LintSeverity string
+
+const (
+	LintSeverityError   LintSeverity = "error"
+	LintSeverityWarning LintSeverity = "warning"
+	LintSeverityOff     LintSeverity = "off"
+)
+
+const (
+	// LintRuleSyntax - The runbook cannot be parsed
+	LintRuleSyntax = "syntax"
+	// LintRuleUnknownRunner - The step uses the runner that is not defined in `runners:`
+	LintRuleUnknownRunner = "unknown-runner"
+	// LintRuleUnusedRunner - The runner defined in `runners:` is not used by any step
+	LintRuleUnusedRunner = "unused-runner"
+	// LintRuleUndefinedVar - The step references the var that is not defined in `vars:` or `lazyVars:`
+	LintRuleUndefinedVar = "undefined-var"
+	// LintRuleDuplicateStepKey - The key ( or `name:` ) of the step is duplicated
+	LintRuleDuplicateStepKey = "duplicate-step-key"
+	// LintRuleSchema - The runbook does not conform to the JSON Schema of the runbook
+	LintRuleSchema = "schema"
+	// LintRuleDeprecated - The runbook uses the deprecated feature
+	LintRuleDeprecated = "deprecated"
+	// LintRuleRequireDesc - The runbook has no `desc:`
+	LintRuleRequireDesc = "require-desc"
+	// LintRuleRequireStepDesc - The step has no `desc:`
+	LintRuleRequireStepDesc = "require-step-desc"
+)
+
+var defaultLintSeverities = map[string]LintSeverity{
+	LintRuleSyntax:           LintSeverityError,
+	LintRuleUnknownRunner:    LintSeverityError,
+	LintRuleUnusedRunner:     LintSeverityWarning,
+	LintRuleUndefinedVar:     LintSeverityError,
+	LintRuleDuplicateStepKey: LintSeverityError,
+	LintRuleSchema:           LintSeverityError,
+	LintRuleDeprecated:       LintSeverityWarning,
+	LintRuleRequireDesc:      LintSeverityOff,
+	LintRuleRequireStepDesc:  LintSeverityOff,
+}
+
+// deprecatedFuncs - Deprecated built-in functions and their replacements.
+var deprecatedFuncs = map[string]string{
+	"base64encode": "toBase64",
+	"base64decode": "fromBase64",
+	"json.Encode":  "toJSON",
+	"json.Decode":  "fromJSON",
+}
+
+var (
+	varsRefRe       = regexp.MustCompile(`(?:^|[^./\w-])vars\.([A-Za-z_]\w*)`)
+	varsIndexRefRe  = regexp.MustCompile(`(?:^|[^./\w-])vars\[\s*["']([^"']+)["']\s*\]`)
+	deprecatedRefRe = regexp.MustCompile(`(?:^|[^.\w])(base64encode|base64decode|json\.Encode|json\.Decode)\s*\(`)
+)
+
+// LintConfig - Config of the rules of `runn lint`.
+type LintConfig struct {
+	// Rules - Severity of each rule ( `error`, `warning` or `off` )
+	Rules map[string]LintSeverity `yaml:"rules,omitempty"`
+	// KnownVars - Vars given from outside the runbook ( e.g. `--var` or `include:` )
+	KnownVars []string `yaml:"knownVars,omitempty"`
+	// KnownRunners - Runners given from outside the runbook ( e.g. `--runner` )
+	KnownRunners []string `yaml:"knownRunners,omitempty"`
+}
+
+// LoadLintConfig loads the config file of `runn lint`.
+func LoadLintConfig(p string) (*LintConfig, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, err
+	}
+	c := &LintConfig{}
+	if err := yaml.Unmarshal(b, c); err != nil {
+		return nil, fmt.Errorf("invalid lint config %s: %w", p, err)
+	}
+	for r, s := range c.Rules {
+		if _, ok := defaultLintSeverities[r]; !ok {
+			return nil, fmt.Errorf("invalid lint config %s: unknown rule %q", p, r)
+		}
+		switch s {
+		case LintSeverityError, LintSeverityWarning, LintSeverityOff:
+		default:
+			return nil, fmt.Errorf("invalid lint config %s: invalid severity %q of %s", p, s, r)
+		}
+	}
+	return c, nil
+}
+
+func (c *LintConfig) severity(rule string) LintSeverity {
+	if c != nil {
+		if s, ok := c.Rules[rule]; ok {
+			return s
+		}
+	}
+	return defaultLintSeverities[rule]
+}
+
+// LintIssue - Issue of the runbook reported by `runn lint`.
+type LintIssue struct {
+	Path     string
+	Location string
+	Rule     string
+	Severity LintSeverity
+	Message  string
+}
+
+func (i *LintIssue) String() string {
+	if i.Location == "" {
+		return fmt.Sprintf("%s: %s: %s (%s)", i.Path, i.Severity, i.Message, i.Rule)
+	}
+	return fmt.Sprintf("%s:%s: %s: %s (%s)", i.Path, i.Location, i.Severity, i.Message, i.Rule)
+}
+
+// Lint validates the runbooks matching the path pattern beyond YAML syntax.
+func Lint(pathp string, cfg *LintConfig) ([]*LintIssue, error) {
+	paths, err := fetchPaths(pathp)
+	if err != nil {
+		return nil, err
+	}
+	var issues []*LintIssue
+	for _, p := range paths {
+		b, err := readFile(p)
+		if err != nil {
+			return nil, err
+		}
+		issues = append(issues, lintRunbook(p, b, cfg)...)
+	}
+	return issues, nil
+}
+
+type lintStep struct {
+	location string
+	step     yaml.MapSlice
+}
+
+type runbookLinter struct {
+	path   string
+	cfg    *LintConfig
+	issues []*LintIssue
+}
+
+func (l *runbookLinter) report(location, rule, format string, a ...any) {
+	s := l.cfg.severity(rule)
+	if s == LintSeverityOff {
+		return
+	}
+	l.issues = append(l.issues, &LintIssue{
+		Path:     l.path,
+		Location: location,
+		Rule:     rule,
+		Severity: s,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+func lintRunbook(p string, b []byte, cfg *LintConfig) []*LintIssue {
+	l := &runbookLinter{path: p, cfg: cfg}
+	repFn := expand.InterpolateRepFn(os.LookupEnv)
+	rep, err := expand.ReplaceYAML(string(b), repFn)
+	if err != nil {
+		l.report("", LintRuleSyntax, "%v", err)
+		return l.issues
+	}
+	flattened, err := flattenYamlAliases([]byte(rep))
+	if err != nil {
+		l.report("", LintRuleSyntax, "%v", err)
+		return l.issues
+	}
+	var steps []lintStep
+	rb := &runbook{}
+	if err := yaml.Unmarshal(flattened, rb); err == nil {
+		names := map[string]struct{}{}
+		for i, s := range rb.Steps {
+			loc := fmt.Sprintf("steps[%d]", i)
+			for _, kv := range s {
+				if kv.Key != nameSectionKey {
+					continue
+				}
+				n, ok := kv.Value.(string)
+				if !ok {
+					continue
+				}
+				if _, ok := names[n]; ok {
+					l.report(loc, LintRuleDuplicateStepKey, "duplicate step name: %s", n)
+				}
+				names[n] = struct{}{}
+			}
+			steps = append(steps, lintStep{location: loc, step: s})
+		}
+	} else {
+		m := &runbookMapped{}
+		if err := yaml.Unmarshal(flattened, m); err != nil {
+			l.report("", LintRuleSyntax, "%v", err)
+			return l.issues
+		}
+		rb = &runbook{Desc: m.Desc, Runners: m.Runners, Vars: m.Vars, Consts: m.Consts, LazyVars: m.LazyVars, If: m.If, Loop: m.Loop, Cases: m.Cases, Hooks: m.Hooks}
+		keys := map[string]struct{}{}
+		for _, kv := range m.Steps {
+			k, ok := kv.Key.(string)
+			if !ok {
+				l.report("steps", LintRuleSyntax, "invalid step key: %v", kv.Key)
+				continue
+			}
+			loc := fmt.Sprintf("steps.%s", k)
+			if _, ok := keys[k]; ok {
+				l.report(loc, LintRuleDuplicateStepKey, "duplicate step key: %s", k)
+			}
+			keys[k] = struct{}{}
+			s, ok := kv.Value.(yaml.MapSlice)
+			if !ok {
+				l.report(loc, LintRuleSyntax, "invalid step: %v", kv.Value)
+				continue
+			}
+			steps = append(steps, lintStep{location: loc, step: s})
+		}
+	}
+	if rb.Hooks != nil {
+		for i, s := range rb.Hooks.BeforeEach {
+			steps = append(steps, lintStep{location: fmt.Sprintf("hooks.%s[%d]", beforeEachHookKey, i), step: s})
+		}
+		for i, s := range rb.Hooks.AfterEach {
+			steps = append(steps, lintStep{location: fmt.Sprintf("hooks.%s[%d]", afterEachHookKey, i), step: s})
+		}
+	}
+
+	for _, err := range Validate(b) {
+		var verr *RunbookValidationError
+		if errors.As(err, &verr) {
+			l.report(verr.Location, LintRuleSchema, "%s", verr.Message)
+			continue
+		}
+		l.report("", LintRuleSchema, "%v", err)
+	}
+
+	if rb.Desc == "" {
+		l.report("", LintRuleRequireDesc, "runbook has no desc")
+	}
+
+	runners := map[string]struct{}{}
+	for k := range rb.Runners {
+		runners[k] = struct{}{}
+	}
+	for _, k := range l.cfgKnownRunners() {
+		runners[k] = struct{}{}
+	}
+	vars := map[string]struct{}{}
+	for k := range rb.Vars {
+		vars[k] = struct{}{}
+	}
+	for k := range rb.Consts {
+		vars[k] = struct{}{}
+	}
+	for k := range rb.LazyVars {
+		vars[k] = struct{}{}
+	}
+	checkVars := true
+	if rb.Cases != nil {
+		cases, ok := rb.Cases.([]any)
+		if !ok {
+			// The vars of the cases in the external file are unknown
+			checkVars = false
+		}
+		for _, c := range cases {
+			var keys []string
+			collectStrings(mapKeys(c), &keys)
+			for _, k := range keys {
+				vars[k] = struct{}{}
+			}
+		}
+	}
+	for _, k := range l.cfgKnownVars() {
+		vars[k] = struct{}{}
+	}
+
+	used := map[string]struct{}{}
+	for _, s := range steps {
+		hasDesc := false
+		for _, kv := range s.step {
+			k, ok := kv.Key.(string)
+			if !ok {
+				continue
+			}
+			if k == descSectionKey {
+				hasDesc = true
+			}
+			if validateRunnerKey(k) != nil {
+				// built-in runner or section
+				continue
+			}
+			if _, ok := runners[k]; !ok {
+				l.report(s.location, LintRuleUnknownRunner, "unknown runner: %s", k)
+			}
+		}
+		if !hasDesc {
+			l.report(s.location, LintRuleRequireStepDesc, "step has no desc")
+		}
+		collectMapKeys(s.step, used)
+		l.lintStrings(s.location, s.step, vars, checkVars)
+	}
+	l.lintStrings("if", rb.If, vars, checkVars)
+	l.lintStrings("loop", rb.Loop, vars, checkVars)
+
+	var unused []string
+	for k, v := range rb.Runners {
+		if c, ok := v.(map[any]any); ok {
+			if _, ok := c["localForward"]; ok {
+				// The SSH runner for port forwarding is used without steps
+				continue
+			}
+		}
+		if _, ok := used[k]; !ok {
+			unused = append(unused, k)
+		}
+	}
+	sort.Strings(unused)
+	for _, k := range unused {
+		l.report(fmt.Sprintf("runners.%s", k), LintRuleUnusedRunner, "unused runner: %s", k)
+	}
+	return l.issues
+}
+
+func (l *runbookLinter) cfgKnownRunners() []string {
+	if l.cfg == nil {
+		return nil
+	}
+	return l.cfg.KnownRunners
+}
+
+func (l *runbookLinter) cfgKnownVars() []string {
+	if l.cfg == nil {
+		return nil
+	}
+	return l.cfg.KnownVars
+}
+
+// lintStrings checks the var references and the deprecated functions in the strings of v.
+func (l *runbookLinter) lintStrings(location string, v any, vars map[string]struct{}, checkVars bool) {
+	var strs []string
+	collectStrings(v, &strs)
+	undefined := map[string]struct{}{}
+	deprecated := map[string]struct{}{}
+	for _, s := range strs {
+		for _, re := range []*regexp.Regexp{varsRefRe, varsIndexRefRe} {
+			if !checkVars {
+				break
+			}
+			for _, m := range re.FindAllStringSubmatch(s, -1) {
+				if _, ok := vars[m[1]]; !ok {
+					undefined[m[1]] = struct{}{}
+				}
+			}
+		}
+		for _, m := range deprecatedRefRe.FindAllStringSubmatch(s, -1) {
+			deprecated[m[1]] = struct{}{}
+		}
+	}
+	for _, k := range sortedKeys(undefined) {
+		l.report(location, LintRuleUndefinedVar, "undefined var: vars.%s", k)
+	}
+	for _, k := range sortedKeys(deprecated) {
+		l.report(location, LintRuleDeprecated, "%s() is deprecated. Use %s() instead", k, deprecatedFuncs[k])
+	}
+}
+
+func collectStrings(v any, strs *[]string) {
+	switch vv := v.(type) {
+	case string:
+		*strs = append(*strs, vv)
+	case yaml.MapSlice:
+		for _, kv := range vv {
+			collectStrings(kv.Key, strs)
+			collectStrings(kv.Value, strs)
+		}
+	case map[string]any:
+		for k, e := range vv {
+			*strs = append(*strs, k)
+			collectStrings(e, strs)
+		}
+	case map[any]any:
+		for k, e := range vv {
+			collectStrings(k, strs)
+			collectStrings(e, strs)
+		}
+	case []any:
+		for _, e := range vv {
+			collectStrings(e, strs)
+		}
+	}
+}
+
+func collectMapKeys(v any, keys map[string]struct{}) {
+	switch vv := v.(type) {
+	case yaml.MapSlice:
+		for _, kv := range vv {
+			if k, ok := kv.Key.(string); ok {
+				keys[k] = struct{}{}
+			}
+			collectMapKeys(kv.Value, keys)
+		}
+	case map[string]any:
+		for k, e := range vv {
+			keys[k] = struct{}{}
+			collectMapKeys(e, keys)
+		}
+	case map[any]any:
+		for k, e := range vv {
+			if kk, ok := k.(string); ok {
+				keys[kk] = struct{}{}
+			}
+			collectMapKeys(e, keys)
+		}
+	case []any:
+		for _, e := range vv {
+			collectMapKeys(e, keys)
+		}
+	}
+}
+
+// mapKeys returns the keys of the map as the list.
+func mapKeys(v any) []any {
+	var keys []any
+	switch vv := v.(type) {
+	case yaml.MapSlice:
+		for _, kv := range vv {
+			keys = append(keys, kv.Key)
+		}
+	case map[any]any:
+		for k := range vv {
+			keys = append(keys, k)
+		}
+	case map[string]any:
+		for k := range vv {
+			keys = append(keys, k)
+		}
+	}
+	return keys
+}
+
+func sortedKeys(m map[string]struct{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// HasLintErrors returns true if the issues contain the error.
+func HasLintErrors(issues []*LintIssue) bool {
+	for _, i := range issues {
+		if i.Severity == LintSeverityError {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,78 @@
+package runn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestLint(t *testing.T) {
+	tests := []struct {
+		path   string
+		config string
+		want   []string
+	}{
+		{"testdata/lint/valid.yml", "", nil},
+		{
+			"testdata/lint/invalid.yml",
+			"",
+			[]string{
+				"testdata/lint/invalid.yml:steps[1]: error: duplicate step name: get (duplicate-step-key)",
+				"testdata/lint/invalid.yml:steps[1]: error: unknown runner: unknown (unknown-runner)",
+				"testdata/lint/invalid.yml:steps[2]: error: undefined var: vars.undefined (undefined-var)",
+				"testdata/lint/invalid.yml:steps[2]: warning: base64encode() is deprecated. Use toBase64() instead (deprecated)",
+				"testdata/lint/invalid.yml:runners.unused: warning: unused runner: unused (unused-runner)",
+			},
+		},
+		{
+			"testdata/lint/invalid.yml",
+			"testdata/lint/config.yml",
+			[]string{
+				"testdata/lint/invalid.yml:steps[1]: error: duplicate step name: get (duplicate-step-key)",
+				"testdata/lint/invalid.yml: warning: runbook has no desc (require-desc)",
+				"testdata/lint/invalid.yml:steps[1]: error: unknown runner: unknown (unknown-runner)",
+				"testdata/lint/invalid.yml:runners.unused: error: unused runner: unused (unused-runner)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path+tt.config, func(t *testing.T) {
+			var cfg *LintConfig
+			if tt.config != "" {
+				c, err := LoadLintConfig(tt.config)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cfg = c
+			}
+			issues, err := Lint(tt.path, cfg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, i := range issues {
+				got = append(got, i.String())
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestLintDuplicateStepKeys(t *testing.T) {
+	in := `
+steps:
+  a:
+    test: true
+  a:
+    test: false
+`
+	issues := lintRunbook("dup.yml", []byte(in), nil)
+	if len(issues) != 1 {
+		t.Fatalf("got %v", issues)
+	}
+	if want := "dup.yml:steps.a: error: duplicate step key: a (duplicate-step-key)"; issues[0].String() != want {
+		t.Errorf("got %v\nwant %v", issues[0].String(), want)
+	}
+}
@@ -2,6 +2,9 @@ package runn
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
@@ -13,6 +16,9 @@ import (
 const (
 	loopSectionKey             = "loop"
 	storeRootKeyLoopCountIndex = "i"
+	storeRootKeyLoopItem       = "item"
+	storeRootKeyLoopItemIndex  = "index"
+	storeRootKeyLoopItemKey    = "key"
 )
 
 var (
@@ -31,11 +37,29 @@ type Loop struct {
 	Jitter      *float64 `yaml:"jitter,omitempty"`
 	Multiplier  *float64 `yaml:"multiplier,omitempty"`
 	Until       string   `yaml:"until"`
-	ctrl        backoff.Controller
+	// BreakIf - Exit the loop successfully when the condition is true
+	BreakIf string `yaml:"breakIf,omitempty"`
+	// ContinueIf - Continue to the next iteration without evaluating until when the condition is true
+	ContinueIf string `yaml:"continueIf,omitempty"`
+	// While - Run the next iteration only while the condition is true ( evaluated before each iteration )
+	While string `yaml:"while,omitempty"`
+	// Items - List or map ( or expression that evaluates to it ) to iterate over
+	Items any `yaml:"items,omitempty"`
+	ctrl  backoff.Controller
 
 	interval    *time.Duration
 	minInterval *time.Duration
 	maxInterval *time.Duration
+	// itemsOnly - The number of iterations is the number of items ( `count:` is not specified )
+	itemsOnly bool
+}
+
+// loopItem - Item of the list or map iterated over by `items:`.
+type loopItem struct {
+	index int
+	// key - Key of the map ( nil for the list )
+	key   any
+	value any
 }
 
 func newLoop(v any) (*Loop, error) {
@@ -44,10 +68,18 @@ func newLoop(v any) (*Loop, error) {
 		return nil, err
 	}
 	l := &Loop{}
-	err = yaml.Unmarshal(b, l)
-	if err != nil {
-		// short syntax
-		l.Count = strings.TrimRight(string(b), "\n\r")
+	if items, ok := v.([]any); ok {
+		// short syntax for items
+		l.Items = items
+	} else {
+		err = yaml.Unmarshal(b, l)
+		if err != nil {
+			// short syntax
+			l.Count = strings.TrimRight(string(b), "\n\r")
+		}
+	}
+	if l.Items != nil && l.Count == "" {
+		l.itemsOnly = true
 	}
 	if l.Count == "" {
 		l.Count = strconv.Itoa(defaultCount)
@@ -120,3 +152,76 @@ func (l *Loop) Loop(ctx context.Context) bool {
 	}
 	return backoff.Continue(l.ctrl)
 }
+
+// hasCond returns true if the loop has any condition evaluated after each iteration.
+func (l *Loop) hasCond() bool {
+	return l.Until != "" || l.BreakIf != "" || l.ContinueIf != ""
+}
+
+// control evaluates `breakIf:` and `continueIf:` after each iteration.
+func (l *Loop) control(store map[string]any) (brk bool, cont bool, err error) {
+	if l.BreakIf != "" {
+		brk, err = EvalCond(l.BreakIf, store)
+		if err != nil {
+			return false, false, err
+		}
+		if brk {
+			return true, false, nil
+		}
+	}
+	if l.ContinueIf != "" {
+		cont, err = EvalCond(l.ContinueIf, store)
+		if err != nil {
+			return false, false, err
+		}
+	}
+	return false, cont, nil
+}
+
+// next evaluates `while:` before each iteration.
+func (l *Loop) next(store map[string]any) (bool, error) {
+	if l.While == "" {
+		return true, nil
+	}
+	return EvalCond(l.While, store)
+}
+
+// bind binds item, index and key ( only for the map ) to the store.
+func (i *loopItem) bind(store map[string]any) {
+	store[storeRootKeyLoopItem] = i.value
+	store[storeRootKeyLoopItemIndex] = i.index
+	if i.key != nil {
+		store[storeRootKeyLoopItemKey] = i.key
+	}
+}
+
+// evalItems evaluates `items:` and returns the items to iterate over.
+func (l *Loop) evalItems(store map[string]any) ([]loopItem, error) {
+	v := l.Items
+	if e, ok := v.(string); ok {
+		var err error
+		v, err = Eval(e, store)
+		if err != nil {
+			return nil, fmt.Errorf("invalid items: %w", err)
+		}
+	}
+	rv := reflect.ValueOf(v)
+	var items []loopItem
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < rv.Len(); i++ {
+			items = append(items, loopItem{index: i, value: rv.Index(i).Interface()})
+		}
+	case reflect.Map:
+		keys := rv.MapKeys()
+		sort.SliceStable(keys, func(i, j int) bool {
+			return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+		})
+		for i, k := range keys {
+			items = append(items, loopItem{index: i, key: k.Interface(), value: rv.MapIndex(k).Interface()})
+		}
+	default:
+		return nil, fmt.Errorf("invalid items: evaluated %v, but got %T(%v)", l.Items, v, v)
+	}
+	return items, nil
+}
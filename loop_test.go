@@ -1,6 +1,7 @@
 package runn
 
 import (
+	"context"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -84,3 +85,87 @@ func TestNewLoop(t *testing.T) {
 		}
 	}
 }
+
+func TestLoopControl(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	o, err := New(Book("testdata/book/loop_control.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoopWhile(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	o, err := New(Book("testdata/book/loop_while.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	want := []bool{false, true, false}
+	for i, r := range o.Result().StepResults[:3] {
+		if got := r.Skipped; got != want[i] {
+			t.Errorf("steps[%d] got skipped %v, want %v", i, got, want[i])
+		}
+	}
+}
+
+func TestLoopItems(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	o, err := New(Book("testdata/book/loop_items.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestLoopControlError(t *testing.T) {
+	tests := []struct {
+		loop map[string]any
+	}{
+		{map[string]any{"count": 3, "breakIf": "invalid("}},
+		{map[string]any{"count": 3, "continueIf": "invalid("}},
+		{map[string]any{"count": 3, "while": "invalid("}},
+		{map[string]any{"items": "invalid("}},
+		{map[string]any{"items": "1"}},
+	}
+	for _, tt := range tests {
+		o, err := New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.AppendStep(0, "0", map[string]any{"loop": tt.loop, "test": "true"}); err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(context.Background()); err == nil {
+			t.Errorf("want error: %v", tt.loop)
+		}
+	}
+}
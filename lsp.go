@@ -0,0 +1,579 @@
+package runn
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	exprbuiltin "github.com/expr-lang/expr/builtin"
+)
+
+// Kinds of the completion items of Language Server Protocol.
+const (
+	lspCompletionKindFunction = 3
+	lspCompletionKindVariable = 6
+	lspCompletionKindModule   = 9
+	lspCompletionKindProperty = 10
+)
+
+// Error codes of JSON-RPC.
+const (
+	lspErrParse          = -32700
+	lspErrInvalidRequest = -32600
+	lspErrMethodNotFound = -32601
+	lspErrInvalidParams  = -32602
+)
+
+var (
+	lspKeyRe     = regexp.MustCompile(`^\s*(?:-\s+)?([^\s#:'"][^:#]*?|'[^']*'|"[^"]*")\s*:(?:\s|$)`)
+	lspIncludeRe = regexp.MustCompile(`^\s*(?:-\s+)?(include|path)\s*:\s*['"]?([^'"#]+?)['"]?\s*(?:#.*)?$`)
+	lspWordRe    = regexp.MustCompile(`[A-Za-z0-9_]*$`)
+)
+
+// LanguageServer is the Language Server of the runbooks that speaks Language Server Protocol over stdio.
+// It provides the completion of the runner keys, the step fields and the expr functions, and the definition of the include paths.
+type LanguageServer struct {
+	in  *bufio.Reader
+	out io.Writer
+	mu  sync.Mutex
+	// docs - Opened documents keyed by URI
+	docs map[string]*lspDocument
+	// funcs - Completion items of the functions and the variables available in the expressions
+	funcs    []lspCompletionItem
+	shutdown bool
+}
+
+type lspDocument struct {
+	text string
+	// areas - Areas of the runbook detected from the last parsable text
+	areas *areas
+}
+
+type lspRequest struct {
+	JSONRPC string           `json:"jsonrpc"`
+	ID      *json.RawMessage `json:"id,omitempty"`
+	Method  string           `json:"method"`
+	Params  json.RawMessage  `json:"params,omitempty"`
+}
+
+type lspError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspTextDocumentPositionParams struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+type lspCompletionItem struct {
+	Label         string `json:"label"`
+	Kind          int    `json:"kind,omitempty"`
+	Detail        string `json:"detail,omitempty"`
+	Documentation string `json:"documentation,omitempty"`
+}
+
+// NewLanguageServer returns the Language Server that reads the requests from in and writes the responses to out.
+func NewLanguageServer(in io.Reader, out io.Writer) *LanguageServer {
+	return &LanguageServer{
+		in:    bufio.NewReader(in),
+		out:   out,
+		docs:  map[string]*lspDocument{},
+		funcs: lspFuncItems(),
+	}
+}
+
+// Serve serves the requests until the exit notification is received or ctx is canceled.
+func (s *LanguageServer) Serve(ctx context.Context) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		default:
+		}
+		b, err := s.read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		req := &lspRequest{}
+		if err := json.Unmarshal(b, req); err != nil {
+			if err := s.reply(nil, nil, &lspError{Code: lspErrParse, Message: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		if req.Method == "exit" {
+			return nil
+		}
+		result, lerr := s.handle(req)
+		if req.ID == nil {
+			// Notification
+			continue
+		}
+		if err := s.reply(req.ID, result, lerr); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *LanguageServer) handle(req *lspRequest) (any, *lspError) {
+	if s.shutdown && req.Method != "exit" {
+		return nil, &lspError{Code: lspErrInvalidRequest, Message: "server is shut down"}
+	}
+	switch req.Method {
+	case "initialize":
+		return map[string]any{
+			"capabilities": map[string]any{
+				// Full sync
+				"textDocumentSync": 1,
+				"completionProvider": map[string]any{
+					"triggerCharacters": []string{".", " ", "("},
+				},
+				"definitionProvider": true,
+			},
+			"serverInfo": map[string]any{
+				"name": "runn",
+			},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		p := struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}{}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &lspError{Code: lspErrInvalidParams, Message: err.Error()}
+		}
+		s.update(p.TextDocument.URI, p.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		p := struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}{}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &lspError{Code: lspErrInvalidParams, Message: err.Error()}
+		}
+		if len(p.ContentChanges) > 0 {
+			s.update(p.TextDocument.URI, p.ContentChanges[len(p.ContentChanges)-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		p := lspTextDocumentPositionParams{}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &lspError{Code: lspErrInvalidParams, Message: err.Error()}
+		}
+		delete(s.docs, p.TextDocument.URI)
+		return nil, nil
+	case "textDocument/completion":
+		p := lspTextDocumentPositionParams{}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &lspError{Code: lspErrInvalidParams, Message: err.Error()}
+		}
+		d, ok := s.docs[p.TextDocument.URI]
+		if !ok {
+			return []lspCompletionItem{}, nil
+		}
+		return s.complete(d, p.Position), nil
+	case "textDocument/definition":
+		p := lspTextDocumentPositionParams{}
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			return nil, &lspError{Code: lspErrInvalidParams, Message: err.Error()}
+		}
+		d, ok := s.docs[p.TextDocument.URI]
+		if !ok {
+			return nil, nil
+		}
+		locs := lspIncludeDefinition(p.TextDocument.URI, d, p.Position)
+		if len(locs) == 0 {
+			return nil, nil
+		}
+		return locs, nil
+	case "initialized", "$/cancelRequest", "$/setTrace", "workspace/didChangeConfiguration", "textDocument/didSave":
+		return nil, nil
+	default:
+		return nil, &lspError{Code: lspErrMethodNotFound, Message: fmt.Sprintf("method not found: %s", req.Method)}
+	}
+}
+
+// update updates the text of the document. The areas are kept if the text cannot be parsed while editing.
+func (s *LanguageServer) update(uri, text string) {
+	d, ok := s.docs[uri]
+	if !ok {
+		d = &lspDocument{areas: &areas{}}
+		s.docs[uri] = d
+	}
+	d.text = text
+	a := detectRunbookAreas(text)
+	if a.Desc != nil || a.Runners != nil || a.Vars != nil || len(a.Steps) > 0 {
+		d.areas = a
+	}
+}
+
+// read reads the content of the message with the base protocol header.
+func (s *LanguageServer) read() ([]byte, error) {
+	h, err := textproto.NewReader(s.in).ReadMIMEHeader()
+	if err != nil {
+		return nil, err
+	}
+	l, err := strconv.Atoi(h.Get("Content-Length"))
+	if err != nil {
+		return nil, fmt.Errorf("invalid Content-Length: %w", err)
+	}
+	b := make([]byte, l)
+	if _, err := io.ReadFull(s.in, b); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+func (s *LanguageServer) reply(id *json.RawMessage, result any, lerr *lspError) error {
+	res := map[string]any{
+		"jsonrpc": "2.0",
+		"id":      id,
+	}
+	if lerr != nil {
+		res["error"] = lerr
+	} else {
+		res["result"] = result
+	}
+	b, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := fmt.Fprintf(s.out, "Content-Length: %d\r\n\r\n", len(b)); err != nil {
+		return err
+	}
+	_, err = s.out.Write(b)
+	return err
+}
+
+// complete returns the completion items at the position.
+func (s *LanguageServer) complete(d *lspDocument, pos lspPosition) []lspCompletionItem {
+	lines := strings.Split(d.text, "\n")
+	if pos.Line >= len(lines) {
+		return []lspCompletionItem{}
+	}
+	line := lines[pos.Line]
+	prefix := line
+	if r := []rune(line); pos.Character < len(r) {
+		prefix = string(r[:pos.Character])
+	}
+	if o := strings.LastIndex(prefix, "{{"); o >= 0 && !strings.Contains(prefix[o:], "}}") {
+		// In the expression of the template
+		return s.funcs
+	}
+	schema := runbookSchema()
+	a := d.areas
+	if lspIsKeyPosition(prefix) {
+		// The text being edited often cannot be parsed ( e.g. the partial key or the empty step )
+		completed := append([]string{}, lines...)
+		completed[pos.Line] = prefix + "x: ~"
+		if ca := detectRunbookAreas(strings.Join(completed, "\n")); len(ca.Steps) > 0 || ca.Runners != nil {
+			a = ca
+		}
+	}
+	start, end, ok := lspStepRange(lines, a, pos.Line+1)
+	if !ok {
+		// Top level of the runbook
+		if lspIsKeyPosition(prefix) && lspIndent(prefix) == 0 {
+			return lspSchemaItems(schema)
+		}
+		return []lspCompletionItem{}
+	}
+	if !lspIsKeyPosition(prefix) {
+		// The values of the steps are the expressions or the requests
+		return s.funcs
+	}
+	stepIndent := lspStepIndent(lines, start, end, pos.Line+1)
+	indent := lspIndent(prefix)
+	stepSchema, _ := schema["definitions"].(map[string]any)["step"].(map[string]any)
+	if indent <= stepIndent {
+		items := lspSchemaItems(stepSchema)
+		for _, k := range lspRunnerKeys(lines, a) {
+			items = append(items, lspCompletionItem{Label: k, Kind: lspCompletionKindModule, Detail: "runner"})
+		}
+		return items
+	}
+	// The fields of the section ( e.g. `loop:` )
+	parents := lspParentKeys(lines, start, pos.Line+1, indent, stepIndent)
+	sc := stepSchema
+	for _, k := range parents {
+		props, ok := sc["properties"].(map[string]any)
+		if !ok {
+			return []lspCompletionItem{}
+		}
+		sc, ok = props[k].(map[string]any)
+		if !ok {
+			return []lspCompletionItem{}
+		}
+	}
+	return lspSchemaItems(sc)
+}
+
+// lspStepRange returns the range of the lines ( 1-based ) of the step at the line.
+// The step continues until the next step or the next top-level key.
+func lspStepRange(lines []string, a *areas, line int) (int, int, bool) {
+	if a == nil || len(a.Steps) == 0 {
+		return 0, 0, false
+	}
+	idx := -1
+	for i, s := range a.Steps {
+		if s.Start.Line <= line {
+			idx = i
+		}
+	}
+	if idx < 0 {
+		return 0, 0, false
+	}
+	start := a.Steps[idx].Start.Line
+	end := len(lines)
+	if idx+1 < len(a.Steps) {
+		end = a.Steps[idx+1].Start.Line - 1
+	}
+	for l := a.Steps[idx].End.Line + 1; l <= end && l <= len(lines); l++ {
+		t := lines[l-1]
+		if strings.TrimSpace(t) == "" || strings.HasPrefix(strings.TrimSpace(t), "#") {
+			continue
+		}
+		if lspIndent(t) == 0 && !strings.HasPrefix(t, "-") {
+			end = l - 1
+			break
+		}
+	}
+	if line > end {
+		return 0, 0, false
+	}
+	return start, end, true
+}
+
+// lspStepIndent returns the indent of the keys of the step.
+func lspStepIndent(lines []string, start, end, cur int) int {
+	indent := -1
+	for l := start; l <= end && l <= len(lines); l++ {
+		if l == cur {
+			continue
+		}
+		t := lines[l-1]
+		tt := strings.TrimSpace(t)
+		if tt == "" || tt == "-" || strings.HasPrefix(tt, "#") {
+			continue
+		}
+		if l == start && !strings.HasPrefix(tt, "-") {
+			// The key of the map-form step
+			continue
+		}
+		if i := lspIndent(t); indent < 0 || i < indent {
+			indent = i
+		}
+	}
+	if indent < 0 {
+		return lspIndent(lines[cur-1])
+	}
+	return indent
+}
+
+// lspParentKeys returns the keys of the sections that contain the line.
+func lspParentKeys(lines []string, start, cur, indent, stepIndent int) []string {
+	var parents []string
+	for l := cur - 1; l >= start && indent > stepIndent; l-- {
+		t := lines[l-1]
+		if tt := strings.TrimSpace(t); tt == "" || tt == "-" || strings.HasPrefix(tt, "#") {
+			continue
+		}
+		i := lspIndent(t)
+		if i >= indent {
+			continue
+		}
+		m := lspKeyRe.FindStringSubmatch(t)
+		if m == nil {
+			return nil
+		}
+		parents = append([]string{strings.Trim(m[1], `'"`)}, parents...)
+		indent = i
+	}
+	return parents
+}
+
+// lspRunnerKeys returns the keys of `runners:` of the runbook.
+func lspRunnerKeys(lines []string, a *areas) []string {
+	if a == nil || a.Runners == nil {
+		return nil
+	}
+	indent := -1
+	var keys []string
+	for l := a.Runners.Start.Line + 1; l <= a.Runners.End.Line && l <= len(lines); l++ {
+		t := lines[l-1]
+		if strings.TrimSpace(t) == "" || strings.HasPrefix(strings.TrimSpace(t), "#") {
+			continue
+		}
+		i := lspIndent(t)
+		if indent < 0 {
+			indent = i
+		}
+		if i != indent {
+			continue
+		}
+		if m := lspKeyRe.FindStringSubmatch(t); m != nil {
+			keys = append(keys, strings.Trim(m[1], `'"`))
+		}
+	}
+	return keys
+}
+
+// lspIsKeyPosition returns true if the text before the cursor is the ( partial ) key of the mapping.
+func lspIsKeyPosition(prefix string) bool {
+	t := strings.TrimLeft(prefix, " ")
+	t = strings.TrimLeft(strings.TrimPrefix(t, "-"), " ")
+	return lspWordRe.FindString(t) == t
+}
+
+// lspIndent returns the indent of the line. The sequence indicator ( `- ` ) is counted as the indent.
+func lspIndent(line string) int {
+	i := len(line) - len(strings.TrimLeft(line, " "))
+	rest := line[i:]
+	for strings.HasPrefix(rest, "-") && (len(rest) == 1 || rest[1] == ' ') {
+		n := len(rest) - len(strings.TrimLeft(rest[1:], " "))
+		i += n
+		rest = rest[n:]
+	}
+	return i
+}
+
+// lspSchemaItems returns the completion items of the properties of the JSON Schema.
+func lspSchemaItems(schema map[string]any) []lspCompletionItem {
+	props, ok := schema["properties"].(map[string]any)
+	if !ok {
+		return []lspCompletionItem{}
+	}
+	var items []lspCompletionItem
+	for k, v := range props {
+		item := lspCompletionItem{Label: k, Kind: lspCompletionKindProperty}
+		if p, ok := v.(map[string]any); ok {
+			item.Documentation, _ = p["description"].(string)
+		}
+		items = append(items, item)
+	}
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Label < items[j].Label
+	})
+	return items
+}
+
+// lspFuncItems returns the completion items of the functions and the variables available in the expressions.
+func lspFuncItems() []lspCompletionItem {
+	var items []lspCompletionItem
+	for _, k := range []string{storeRootKeyVars, storeRootKeySteps, storeRootKeyParent, storeRootKeyIncluded, storeRootKeyCurrent, storeRootPrevious, storeRootKeyEnv, storeRootKeyCookie, storeRootKeyShared, storeRootKeyLoopCountIndex, storeRootKeyWith} {
+		items = append(items, lspCompletionItem{Label: k, Kind: lspCompletionKindVariable})
+	}
+	bk := newBook()
+	for _, opt := range setupBuiltinFunctions() {
+		_ = opt(bk)
+	}
+	var fns []string
+	for k := range bk.funcs {
+		if _, ok := deprecatedFuncs[k]; ok {
+			continue
+		}
+		fns = append(fns, k)
+	}
+	sort.Strings(fns)
+	for _, k := range fns {
+		items = append(items, lspCompletionItem{Label: k, Kind: lspCompletionKindFunction, Detail: "runn built-in function"})
+	}
+	for _, k := range exprbuiltin.Names {
+		if _, ok := bk.funcs[k]; ok {
+			continue
+		}
+		items = append(items, lspCompletionItem{Label: k, Kind: lspCompletionKindFunction, Detail: "expr built-in function"})
+	}
+	return items
+}
+
+// lspIncludeDefinition returns the locations of the runbooks included by `include:` at the position.
+func lspIncludeDefinition(uri string, d *lspDocument, pos lspPosition) []lspLocation {
+	lines := strings.Split(d.text, "\n")
+	if pos.Line >= len(lines) {
+		return nil
+	}
+	m := lspIncludeRe.FindStringSubmatch(lines[pos.Line])
+	if m == nil {
+		return nil
+	}
+	if m[1] == "path" {
+		// `path:` of the detailed include config
+		parents := lspParentKeys(lines, 1, pos.Line+1, lspIndent(lines[pos.Line]), 0)
+		if len(parents) == 0 || parents[len(parents)-1] != includeRunnerKey {
+			return nil
+		}
+	}
+	p := strings.TrimSpace(m[2])
+	if p == "" || hasRemotePrefix(p) || strings.Contains(p, delimStart) {
+		return nil
+	}
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return nil
+	}
+	c := &includeConfig{path: p}
+	paths, err := c.bookPaths(filepath.Dir(filepath.FromSlash(u.Path)))
+	if err != nil {
+		return nil
+	}
+	var locs []lspLocation
+	for _, ip := range paths {
+		abs, err := filepath.Abs(ip)
+		if err != nil {
+			continue
+		}
+		if _, err := os.Stat(abs); err != nil {
+			continue
+		}
+		locs = append(locs, lspLocation{
+			URI: (&url.URL{Scheme: "file", Path: filepath.ToSlash(abs)}).String(),
+		})
+	}
+	return locs
+}
@@ -0,0 +1,204 @@
+package runn
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+var lspTestRunbook = strings.Join([]string{
+	"desc: LSP",
+	"runners:",
+	"  req: https://example.com",
+	"  db: sqlite:///tmp/test.db",
+	"steps:",
+	"  -",
+	"    req:",
+	"      /users:",
+	"        get:",
+	"          body: null",
+	"    test: current.res.status == 200",
+	"  -",
+	"    loop:",
+	"      ",
+	"    include:",
+	"      path: included.yml",
+	"  -",
+	"    ",
+	"    test: true",
+}, "\n")
+
+func TestLanguageServerComplete(t *testing.T) {
+	s := NewLanguageServer(nil, io.Discard)
+	s.update("file:///tmp/book.yml", lspTestRunbook)
+	d := s.docs["file:///tmp/book.yml"]
+	labels := func(items []lspCompletionItem) map[string]struct{} {
+		m := map[string]struct{}{}
+		for _, i := range items {
+			m[i.Label] = struct{}{}
+		}
+		return m
+	}
+	tests := []struct {
+		name    string
+		pos     lspPosition
+		want    []string
+		notWant []string
+	}{
+		{"top-level keys", lspPosition{Line: 0, Character: 0}, []string{"desc", "runners", "steps", "vars"}, []string{"loop:", "req"}},
+		{"runner keys and step fields", lspPosition{Line: 17, Character: 4}, []string{"req", "db", "test", "loop", "include", "desc"}, []string{"count", "vars"}},
+		{"fields of the section", lspPosition{Line: 13, Character: 6}, []string{"count", "until", "interval"}, []string{"req", "test"}},
+		{"expr functions", lspPosition{Line: 10, Character: 10}, []string{"vars", "current", "len", "urlencode", "faker"}, []string{"req", "base64encode"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := labels(s.complete(d, tt.pos))
+			for _, w := range tt.want {
+				if _, ok := got[w]; !ok {
+					t.Errorf("want %q in %v", w, got)
+				}
+			}
+			for _, w := range tt.notWant {
+				if _, ok := got[w]; ok {
+					t.Errorf("do not want %q", w)
+				}
+			}
+		})
+	}
+}
+
+func TestLanguageServerCompleteWhileEditing(t *testing.T) {
+	s := NewLanguageServer(nil, io.Discard)
+	s.update("file:///tmp/book.yml", "runners:\n  req: https://example.com\nsteps:\n  -\n    re")
+	got := map[string]struct{}{}
+	for _, i := range s.complete(s.docs["file:///tmp/book.yml"], lspPosition{Line: 4, Character: 6}) {
+		got[i.Label] = struct{}{}
+	}
+	for _, w := range []string{"req", "test"} {
+		if _, ok := got[w]; !ok {
+			t.Errorf("want %q in %v", w, got)
+		}
+	}
+}
+
+func TestLanguageServerCompleteInTemplate(t *testing.T) {
+	s := NewLanguageServer(nil, io.Discard)
+	s.update("file:///tmp/book.yml", "desc: '{{ ")
+	got := s.complete(s.docs["file:///tmp/book.yml"], lspPosition{Line: 0, Character: 10})
+	if diff := cmp.Diff(got, s.funcs); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestLanguageServer(t *testing.T) {
+	dir := t.TempDir()
+	bp := filepath.Join(dir, "book.yml")
+	ip := filepath.Join(dir, "included.yml")
+	for _, p := range []string{bp, ip} {
+		if err := os.WriteFile(p, []byte(lspTestRunbook), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	uri := (&url.URL{Scheme: "file", Path: filepath.ToSlash(bp)}).String()
+
+	cr, cw := io.Pipe()
+	sr, sw := io.Pipe()
+	s := NewLanguageServer(cr, sw)
+	done := make(chan error)
+	go func() {
+		done <- s.Serve(context.Background())
+	}()
+	res := bufio.NewReader(sr)
+	id := 0
+	call := func(method string, params any, notify bool) map[string]any {
+		t.Helper()
+		req := map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+		if !notify {
+			id++
+			req["id"] = id
+		}
+		b, err := json.Marshal(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, err := fmt.Fprintf(cw, "Content-Length: %d\r\n\r\n%s", len(b), b); err != nil {
+			t.Fatal(err)
+		}
+		if notify {
+			return nil
+		}
+		h, err := textproto.NewReader(res).ReadMIMEHeader()
+		if err != nil {
+			t.Fatal(err)
+		}
+		l, err := strconv.Atoi(h.Get("Content-Length"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		rb := make([]byte, l)
+		if _, err := io.ReadFull(res, rb); err != nil {
+			t.Fatal(err)
+		}
+		got := map[string]any{}
+		if err := json.Unmarshal(rb, &got); err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+
+	init := call("initialize", map[string]any{}, false)
+	if _, ok := init["result"].(map[string]any)["capabilities"]; !ok {
+		t.Errorf("invalid initialize result: %v", init)
+	}
+	call("initialized", map[string]any{}, true)
+	call("textDocument/didOpen", map[string]any{"textDocument": map[string]any{"uri": uri, "languageId": "yaml", "version": 1, "text": lspTestRunbook}}, true)
+
+	t.Run("completion", func(t *testing.T) {
+		got := call("textDocument/completion", map[string]any{"textDocument": map[string]any{"uri": uri}, "position": map[string]any{"line": 17, "character": 4}}, false)
+		items, ok := got["result"].([]any)
+		if !ok || len(items) == 0 {
+			t.Errorf("invalid completion result: %v", got)
+		}
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		got := call("textDocument/definition", map[string]any{"textDocument": map[string]any{"uri": uri}, "position": map[string]any{"line": 15, "character": 14}}, false)
+		want := []any{map[string]any{
+			"uri": (&url.URL{Scheme: "file", Path: filepath.ToSlash(ip)}).String(),
+			"range": map[string]any{
+				"start": map[string]any{"line": float64(0), "character": float64(0)},
+				"end":   map[string]any{"line": float64(0), "character": float64(0)},
+			},
+		}}
+		if diff := cmp.Diff(got["result"], any(want)); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		got := call("textDocument/hover", map[string]any{}, false)
+		if got["error"].(map[string]any)["code"] != float64(lspErrMethodNotFound) {
+			t.Errorf("invalid error: %v", got)
+		}
+	})
+
+	shutdown := call("shutdown", nil, false)
+	if v, ok := shutdown["result"]; !ok || v != nil {
+		t.Errorf("invalid shutdown result: %v", shutdown)
+	}
+	call("exit", nil, true)
+	if err := <-done; err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,60 @@
+package runn
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+const nameSectionKey = "name"
+
+var stepNameRe = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// validateStepNames validates `name:` of list-form steps and returns the indexes of the steps keyed by the names.
+func validateStepNames(rawSteps []map[string]any) (map[string]int, error) {
+	names := map[string]int{}
+	for i, s := range rawSteps {
+		v, ok := s[nameSectionKey]
+		if !ok {
+			continue
+		}
+		name, ok := v.(string)
+		if !ok || !stepNameRe.MatchString(name) {
+			return nil, fmt.Errorf("invalid name of steps[%d]: %v", i, v)
+		}
+		if j, ok := names[name]; ok {
+			return nil, fmt.Errorf("invalid name of steps[%d]: %q is already used by steps[%d]", i, name, j)
+		}
+		names[name] = i
+	}
+	return names, nil
+}
+
+// namedSteps - Recorded values of list-form steps accessible by both the indexes and the names of `name:` ( e.g. `steps[0]` and `steps.login` ).
+type namedSteps map[any]any
+
+func newNamedSteps(steps []map[string]any, names map[string]int) namedSteps {
+	m := make(namedSteps, len(steps)+len(names))
+	for i, v := range steps {
+		m[i] = v
+	}
+	for name, i := range names {
+		if i < len(steps) {
+			m[name] = steps[i]
+		}
+	}
+	return m
+}
+
+// MarshalJSON marshals the recorded values as the list in order of the steps, same as steps without names.
+func (s namedSteps) MarshalJSON() ([]byte, error) {
+	steps := make([]any, 0, len(s))
+	for i := 0; ; i++ {
+		v, ok := s[i]
+		if !ok {
+			break
+		}
+		steps = append(steps, v)
+	}
+	return json.Marshal(steps)
+}
@@ -0,0 +1,142 @@
+package runn
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestValidateStepNames(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawSteps []map[string]any
+		want     map[string]int
+		wantErr  string
+	}{
+		{
+			"no names",
+			[]map[string]any{
+				{"test": "steps.login.res.status == 200"},
+			},
+			map[string]int{},
+			"",
+		},
+		{
+			"names",
+			[]map[string]any{
+				{"name": "login", "test": "true"},
+				{"test": "true"},
+				{"name": "logout", "test": "true"},
+			},
+			map[string]int{"login": 0, "logout": 2},
+			"",
+		},
+		{
+			"duplicate names",
+			[]map[string]any{
+				{"name": "login", "test": "true"},
+				{"name": "login", "test": "true"},
+			},
+			nil,
+			`"login" is already used by steps[0]`,
+		},
+		{
+			"invalid name",
+			[]map[string]any{
+				{"name": "1st", "test": "true"},
+			},
+			nil,
+			"invalid name of steps[0]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := validateStepNames(tt.rawSteps)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v\nwant %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(got, tt.want); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestNamedSteps(t *testing.T) {
+	s := store{
+		steps: []map[string]any{
+			{"stdout": "hello\n"},
+			{"stdout": "world\n"},
+		},
+		stepNames: map[string]int{"greet": 0, "notyet": 2},
+	}
+	m := s.toMap()
+	tests := []struct {
+		expr string
+		want any
+	}{
+		{"steps.greet.stdout", "hello\n"},
+		{`steps["greet"].stdout`, "hello\n"},
+		{"steps[1].stdout", "world\n"},
+		{"steps.notyet", nil},
+		{`"steps.greet.stdout"`, "steps.greet.stdout"},
+	}
+	for _, tt := range tests {
+		got, err := Eval(tt.expr, m)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Errorf("%s: %s", tt.expr, diff)
+		}
+	}
+
+	b, err := json.Marshal(m[storeRootKeySteps])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `[{"stdout":"hello\n"},{"stdout":"world\n"}]`; string(b) != want {
+		t.Errorf("got %s\nwant %s", b, want)
+	}
+}
+
+func TestStepNamesLiteral(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent, ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	p := filepath.Join(t.TempDir(), "book.yml")
+	rb := `desc: Literal references to named steps are not rewritten
+vars:
+  literal: steps.greet.stdout
+steps:
+  -
+    name: greet
+    exec:
+      command: echo 'steps.greet.stdout'
+  -
+    test: steps.greet.stdout == vars.literal + "\n"
+`
+	if err := os.WriteFile(p, []byte(rb), 0600); err != nil {
+		t.Fatal(err)
+	}
+	o, err := New(Book(p), Scopes(ScopeAllowReadParent, ScopeAllowRunExec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
@@ -0,0 +1,210 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"go.uber.org/multierr"
+)
+
+const needsSectionKey = "needs"
+
+// parseNeeds resolves the keys of the steps in `needs:` to the indexes of the preceding steps.
+func (o *operator) parseNeeds(v any) ([]int, error) {
+	var keys []string
+	switch vv := v.(type) {
+	case nil:
+	case []any:
+		for _, k := range vv {
+			keys = append(keys, fmt.Sprint(k))
+		}
+	default:
+		keys = append(keys, fmt.Sprint(vv))
+	}
+	needs := []int{}
+	for _, k := range keys {
+		found := false
+		for j, s := range o.steps {
+			if s.key == k {
+				needs = append(needs, j)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("invalid needs: %s is not a preceding step", k)
+		}
+	}
+	return needs, nil
+}
+
+// hasNeeds returns true if any step has `needs:`.
+func (o *operator) hasNeeds() bool {
+	for _, s := range o.steps {
+		if s.needs != nil {
+			return true
+		}
+	}
+	return false
+}
+
+// runStepsWithNeeds runs each step as soon as the steps it needs finish.
+// Steps without `needs:` need all the preceding steps.
+// The values are recorded in the order of the steps after all the steps finish.
+func (o *operator) runStepsWithNeeds(ctx context.Context) error {
+	n := len(o.steps)
+	var (
+		results = make([]map[string]any, n)
+		errs    = make([]error, n)
+		// broken - The step failed or was skipped because the step it needs was broken
+		broken   = make([]bool, n)
+		done     = make([]chan struct{}, n)
+		bindVars = map[string]any{}
+		cookies  = o.store.cookies
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+	)
+	for k, v := range o.store.bindVars {
+		bindVars[k] = v
+	}
+	for i := range done {
+		done[i] = make(chan struct{})
+	}
+	cs := newSyncCapturers(o.capturers)
+	// Each goroutine runs the copy of the step so that the steps of the operator are not updated while running
+	copied := make([]*step, n)
+	for i, s := range o.steps {
+		c := *s
+		c.result = nil
+		copied[i] = &c
+	}
+	for i, s := range copied {
+		wg.Add(1)
+		go func(i int, s *step) {
+			defer wg.Done()
+			defer close(done[i])
+			needs := s.needs
+			if needs == nil {
+				needs = make([]int, 0, i)
+				for j := 0; j < i; j++ {
+					needs = append(needs, j)
+				}
+			}
+			brk := false
+			for _, j := range needs {
+				<-done[j]
+				mu.Lock()
+				brk = brk || broken[j]
+				mu.Unlock()
+			}
+			mu.Lock()
+			if brk && !o.force {
+				s.setResult(errStepSkiped)
+				results[i] = map[string]any{storeStepKeyRun: false, storeStepKeyOutcome: resultSkipped}
+				broken[i] = true
+				mu.Unlock()
+				return
+			}
+			if v, ok := o.replayedStep(i); ok {
+				// Replay the stored result instead of running the unselected step
+				o.Debugf(yellow("Replay the stored result on %s\n"), o.stepName(i))
+				s.setResult(errStepSkiped)
+				outcome := resultSkipped
+				if r, ok := v[storeStepKeyOutcome].(string); ok {
+					outcome = result(r)
+				}
+				rv := map[string]any{}
+				for k, vv := range v {
+					rv[k] = vv
+				}
+				rv[storeStepKeyRun] = true
+				rv[storeStepKeyOutcome] = outcome
+				results[i] = rv
+				mu.Unlock()
+				return
+			}
+			st := o.store.copyUntil(0)
+			for j := 0; j < i; j++ {
+				v := results[j]
+				if v == nil {
+					// The step is still running
+					v = map[string]any{storeStepKeyRun: false}
+				}
+				if st.useMap {
+					st.stepMapKeys = append(st.stepMapKeys, o.steps[j].key)
+					st.stepMap[o.steps[j].key] = v
+				} else {
+					st.steps = append(st.steps, v)
+				}
+			}
+			for k, v := range bindVars {
+				st.bindVars[k] = v
+			}
+			st.cookies = cookies
+			mu.Unlock()
+
+			oo, err := o.newConcurrentOperator(o.parent, cs)
+			if err != nil {
+				s.setResult(err)
+				mu.Lock()
+				errs[i] = err
+				broken[i] = true
+				mu.Unlock()
+				return
+			}
+			oo.loopIndex = o.loopIndex
+			oo.hasOnly = o.hasOnly
+			oo.stepSelected = o.stepSelected
+			oo.replaySteps = o.replaySteps
+			oo.beforeEachSteps = o.beforeEachSteps
+			oo.afterEachSteps = o.afterEachSteps
+			// The operators of the hooks are not shared by the steps run concurrently
+			if err := oo.buildHooks(); err != nil {
+				s.setResult(err)
+				mu.Lock()
+				errs[i] = err
+				broken[i] = true
+				mu.Unlock()
+				return
+			}
+			oo.store = st
+			oo.steps = append(make([]*step, 0, i+1), o.steps[:i]...)
+			s.parent = oo
+			oo.steps = append(oo.steps, s)
+			v, err := oo.runParallelStep(ctx, i)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[i] = v
+			errs[i] = err
+			broken[i] = err != nil && !s.force
+			// Pass the values bound by the step and the received cookies to the following steps
+			for k, v := range oo.store.bindVars {
+				bindVars[k] = v
+			}
+			cookies = oo.store.cookies
+		}(i, s)
+	}
+	wg.Wait()
+
+	var rerr error
+	for i, s := range o.steps {
+		s.result = copied[i].result
+		v := results[i]
+		if v == nil {
+			v = map[string]any{storeStepKeyRun: false, storeStepKeyOutcome: resultFailure}
+		}
+		if o.useMap {
+			o.store.recordAsMapped(s.key, v)
+		} else {
+			o.store.recordAsListed(v)
+		}
+		if errs[i] != nil && !s.force {
+			rerr = multierr.Append(rerr, errs[i])
+		}
+	}
+	o.store.bindVars = bindVars
+	o.store.cookies = cookies
+	return rerr
+}
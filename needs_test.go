@@ -0,0 +1,219 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestNeeds(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/needs.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// fetchA and fetchB take 0.5 sec each
+	if spent := time.Since(start); spent > 1*time.Second {
+		t.Errorf("the independent steps should run concurrently: spent %v", spent)
+	}
+	if got := len(o.store.stepMapKeys); got != 5 {
+		t.Errorf("got %v\nwant %v", got, 5)
+	}
+}
+
+func TestNeedsFailure(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	steps := []map[string]any{
+		{"exec": map[string]any{"command": "exit 1"}, "test": "current.exit_code == 0"},
+		{"needs": []any{}, "exec": map[string]any{"command": "echo ok"}},
+		{"needs": 0, "exec": map[string]any{"command": "echo ng"}},
+		{"exec": map[string]any{"command": "echo ng"}},
+	}
+	for i, s := range steps {
+		if err := o.AppendStep(i, fmt.Sprintf("%d", i), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.Run(ctx); err == nil {
+		t.Error("want error")
+	}
+	want := []result{resultFailure, resultSuccess, resultSkipped, resultSkipped}
+	for i, w := range want {
+		if got := o.store.steps[i][storeStepKeyOutcome]; got != w {
+			t.Errorf("steps[%d]: got %v\nwant %v", i, got, w)
+		}
+	}
+	if got := o.store.steps[1]["stdout"]; got != "ok\n" {
+		t.Errorf("got %v\nwant %v", got, "ok\n")
+	}
+}
+
+func TestNeedsStepSelection(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent, ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	const book = `desc: Needs with step selection
+steps:
+  hello:
+    exec:
+      command: echo hello
+  world:
+    needs: hello
+    exec:
+      command: echo world
+  check:
+    needs: hello
+    test: steps.hello.stdout == "hello\n"
+`
+	ctx := context.Background()
+	dir := t.TempDir()
+	bp := filepath.Join(dir, "book.yml")
+	if err := os.WriteFile(bp, []byte(book), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := New(Scopes(ScopeAllowReadParent, ScopeAllowRunExec), Book(bp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := o.DumpSteps(buf); err != nil {
+		t.Fatal(err)
+	}
+	sp := filepath.Join(dir, "steps.json")
+	if err := os.WriteFile(sp, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		opts    []Option
+		want    []bool
+		wantErr bool
+	}{
+		{[]Option{RunStep("world")}, []bool{false, true, false}, false},
+		{[]Option{StartStep("world")}, []bool{false, true, true}, true},
+		{[]Option{StartStep("world"), ReplaySteps(sp)}, []bool{false, true, true}, false},
+	}
+	for _, tt := range tests {
+		opts := append([]Option{Scopes(ScopeAllowReadParent, ScopeAllowRunExec), Book(bp)}, tt.opts...)
+		o, err := New(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			if !tt.wantErr {
+				t.Errorf("got error: %v", err)
+			}
+		} else if tt.wantErr {
+			t.Error("want error")
+		}
+		var got []bool
+		for _, sr := range o.Result().StepResults {
+			got = append(got, !sr.Skipped)
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestNeedsDB(t *testing.T) {
+	ctx := context.Background()
+	db, dsn := testutil.SQLite(t)
+	if _, err := db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, username TEXT NOT NULL);"); err != nil {
+		t.Fatal(err)
+	}
+	// The DB runner is shared by the steps run concurrently, and connects lazily in them
+	o, err := New(Runner("db", dsn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 4; i++ {
+		if err := o.AppendStep(i, fmt.Sprintf("%d", i), map[string]any{
+			"needs": []any{},
+			"db":    map[string]any{"query": "SELECT COUNT(*) AS c FROM users;"},
+			"test":  "current.rows[0].c == 0",
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestParseNeeds(t *testing.T) {
+	tests := []struct {
+		needs   any
+		want    []int
+		wantErr bool
+	}{
+		{"a", []int{0}, false},
+		{[]any{"a", "b"}, []int{0, 1}, false},
+		{[]any{}, []int{}, false},
+		{"c", nil, true},
+		{[]any{"a", 0}, nil, true},
+	}
+	for _, tt := range tests {
+		o, err := New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.useMap = true
+		for i, k := range []string{"a", "b"} {
+			if err := o.AppendStep(i, k, map[string]any{"test": "true"}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		got, err := o.parseNeeds(tt.needs)
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got error: %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %v", tt.needs)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
@@ -10,7 +10,6 @@ import (
 	"math/rand"
 	"net/http"
 	"os"
-	"path/filepath"
 	"sort"
 	"strings"
 	"sync"
@@ -31,6 +30,7 @@ var _ otchkiss.Requester = (*operators)(nil)
 
 type operator struct {
 	id          string
+	idSpecified bool // The id is specified by `id:` of the runbook.
 	httpRunners map[string]*httpRunner
 	dbRunners   map[string]*dbRunner
 	grpcRunners map[string]*grpcRunner
@@ -40,14 +40,31 @@ type operator struct {
 	store       store
 	desc        string
 	labels      []string
-	useMap      bool // Use map syntax in `steps:`.
+	runLabels   []string // Label conditions of the runbooks and the groups to be run ( --label ).
+	useMap      bool     // Use map syntax in `steps:`.
 	debug       bool
 	profile     bool
 	interval    time.Duration
-	loop        *Loop
+	// timeout - Timeout of the runbook run
+	timeout time.Duration
+	loop    *Loop
 	// loopIndex - Index of the loop is dynamically recorded at runtime
 	loopIndex   *int
 	concurrency []string
+	// cases - Values of the cases of `cases:`
+	cases []map[string]any
+	// caseIndex - Index of the case to run. If nil, all cases are run in order
+	caseIndex *int
+	// consts - Values of `consts:` bound into vars
+	consts map[string]any
+	// varsSchema - JSON Schema of `varsSchema:` to validate vars
+	varsSchema map[string]any
+	// secrets - Var names or expression paths of `secrets:` whose values are masked in outputs
+	secrets []string
+	// maskReplacer - Replacer to mask the secret values. It is built once by masker until resetMasker is called
+	maskReplacer *strings.Replacer
+	maskBuilt    bool
+	maskMu       sync.Mutex
 	// root - Root directory of runbook ( rubbook path or working directory )
 	root     string
 	t        *testing.T
@@ -57,11 +74,28 @@ type operator struct {
 	trace    bool
 	failFast bool
 	included bool
-	ifCond   string
-	skipTest bool
-	skipped  bool
-	stdout   io.Writer
-	stderr   io.Writer
+	// includeMaxDepth - Max depth of nested includes. 0 means unlimited
+	includeMaxDepth int
+	// updateSnapshots - Update the snapshot files of `snapshot:` instead of comparing
+	updateSnapshots bool
+	softAssertions  bool
+	ifCond          string
+	skipTest        bool
+	// hasOnly - Any step has `only: true`
+	hasOnly bool
+	// startStep, endStep, runSteps - Steps selected to be run
+	startStep string
+	endStep   string
+	runSteps  []string
+	// stepSelected - Whether each step is selected to be run. nil if all the steps are run
+	stepSelected []bool
+	// dotenv - Environment variables loaded by EnvFile, passed to the nested operators
+	dotenv map[string]string
+	// replaySteps - Results of the steps replayed instead of running the unselected steps
+	replaySteps map[string]any
+	skipped     bool
+	stdout      io.Writer
+	stderr      io.Writer
 	// Skip some errors for `runn list`
 	newOnly  bool
 	bookPath string
@@ -69,9 +103,17 @@ type operator struct {
 	numberOfSteps int
 	beforeFuncs   []func(*RunResult) error
 	afterFuncs    []func(*RunResult) error
-	sw            *stopw.Span
-	capturers     capturers
-	runResult     *RunResult
+	// beforeEachSteps/afterEachSteps - Hook steps run around every step
+	beforeEachSteps []map[string]any
+	afterEachSteps  []map[string]any
+	// beforeEachHook/afterEachHook - Operators running the hook steps, built once by buildHooks
+	beforeEachHook *operator
+	afterEachHook  *operator
+	// templates - Step templates used by `use:`
+	templates map[string]map[string]any
+	sw        *stopw.Span
+	capturers capturers
+	runResult *RunResult
 
 	mu sync.Mutex
 }
@@ -101,6 +143,28 @@ func (o *operator) BookPath() string {
 	return o.bookPath
 }
 
+// Labels returns `labels:` of runbook.
+func (o *operator) Labels() []string {
+	return o.labels
+}
+
+// VarKeys returns the keys of `vars:` of runbook. The keys of the nested maps are joined with ".".
+func (o *operator) VarKeys() []string {
+	var keys []string
+	var walk func(prefix string, m map[string]any)
+	walk = func(prefix string, m map[string]any) {
+		for k, v := range m {
+			keys = append(keys, prefix+k)
+			if mm, ok := v.(map[string]any); ok {
+				walk(prefix+k+".", mm)
+			}
+		}
+	}
+	walk("", o.store.vars)
+	sort.Strings(keys)
+	return keys
+}
+
 // NumberOfSteps returns number of steps.
 func (o *operator) NumberOfSteps() int {
 	return o.numberOfSteps
@@ -128,13 +192,39 @@ func (o *operator) Close(force bool) {
 	}
 }
 
-func (o *operator) runStep(ctx context.Context, i int, s *step) error {
+func (o *operator) runStep(ctx context.Context, i int, s *step) (rerr error) {
 	if o.t != nil {
 		o.t.Helper()
 	}
 	trs := s.trails()
 	o.capturers.setCurrentTrails(trs)
+	// The secret values may be changed by the step ( e.g. `bind:` or the results of the step )
+	defer o.resetMasker()
 	defer o.sw.Start(trs.toProfileIDs()...).Stop()
+	if cause := context.Cause(ctx); errors.Is(cause, errRunbookTimeout) {
+		return fmt.Errorf("canceled on %s: %w", o.stepName(i), cause)
+	}
+	if s.skip || (o.hasOnly && !s.only) || o.stepUnselected(i) {
+		o.Debugf(yellow("Skip on %s\n"), o.stepName(i))
+		return errStepSkiped
+	}
+	if s.groupConfig != nil && len(s.groupConfig.labels) > 0 {
+		// RUUN_LABEL, --label
+		cond := labelCond(o.runLabels)
+		tf, err := EvalCond(cond, labelEnv(joinLabels(o.labels, s.groupConfig.labels)))
+		if err != nil {
+			return err
+		}
+		if !tf {
+			o.Debugf(yellow("Skip on %s because it does not match %s\n"), o.stepName(i), cond)
+			return errStepSkiped
+		}
+	}
+	unbind, err := o.bindWith(s)
+	if err != nil {
+		return fmt.Errorf("%s: %w", o.stepName(i), err)
+	}
+	defer unbind()
 	if i != 0 {
 		// interval:
 		time.Sleep(o.interval)
@@ -162,49 +252,73 @@ func (o *operator) runStep(ctx context.Context, i int, s *step) error {
 		o.Debugf(cyan("Run %q on %s\n"), s.runnerKey, o.stepName(i))
 	}
 
-	stepFn := func(t *testing.T) error {
+	stepFn := func(ctx context.Context, t *testing.T) error {
 		s.clearResult()
 		if t != nil {
 			t.Helper()
 		}
 		run := false
+		var runErr error
+		start := time.Now()
 		switch {
 		case s.httpRunner != nil && s.httpRequest != nil:
 			if err := s.httpRunner.Run(ctx, s); err != nil {
-				return fmt.Errorf("http request failed on %s: %w", o.stepName(i), err)
+				runErr = fmt.Errorf("http request failed on %s: %w", o.stepName(i), err)
 			}
 			run = true
 		case s.dbRunner != nil && s.dbQuery != nil:
 			if err := s.dbRunner.Run(ctx, s); err != nil {
-				return fmt.Errorf("db query failed on %s: %w", o.stepName(i), err)
+				runErr = fmt.Errorf("db query failed on %s: %w", o.stepName(i), err)
 			}
 			run = true
 		case s.grpcRunner != nil && s.grpcRequest != nil:
 			if err := s.grpcRunner.Run(ctx, s); err != nil {
-				return fmt.Errorf("gRPC request failed on %s: %w", o.stepName(i), err)
+				runErr = fmt.Errorf("gRPC request failed on %s: %w", o.stepName(i), err)
 			}
 			run = true
 		case s.cdpRunner != nil && s.cdpActions != nil:
 			if err := s.cdpRunner.Run(ctx, s); err != nil {
-				return fmt.Errorf("cdp action failed on %s: %w", o.stepName(i), err)
+				runErr = fmt.Errorf("cdp action failed on %s: %w", o.stepName(i), err)
 			}
 			run = true
 		case s.sshRunner != nil && s.sshCommand != nil:
 			if err := s.sshRunner.Run(ctx, s); err != nil {
-				return fmt.Errorf("ssh command failed on %s: %w", o.stepName(i), err)
+				runErr = fmt.Errorf("ssh command failed on %s: %w", o.stepName(i), err)
 			}
 			run = true
 		case s.execRunner != nil && s.execCommand != nil:
 			if err := s.execRunner.Run(ctx, s); err != nil {
-				return fmt.Errorf("exec command failed on %s: %w", o.stepName(i), err)
+				runErr = fmt.Errorf("exec command failed on %s: %w", o.stepName(i), err)
 			}
 			run = true
 		case s.includeRunner != nil && s.includeConfig != nil:
 			if err := s.includeRunner.Run(ctx, s); err != nil {
-				return fmt.Errorf("include failed on %s: %w", o.stepName(i), err)
+				runErr = fmt.Errorf("include failed on %s: %w", o.stepName(i), err)
+			}
+			run = true
+		case s.parallelRunner != nil && s.parallelConfig != nil:
+			if err := s.parallelRunner.Run(ctx, s); err != nil {
+				runErr = fmt.Errorf("parallel steps failed on %s: %w", o.stepName(i), err)
+			}
+			run = true
+		case s.groupRunner != nil && s.groupConfig != nil:
+			if err := s.groupRunner.Run(ctx, s); err != nil {
+				runErr = fmt.Errorf("group steps failed on %s: %w", o.stepName(i), err)
 			}
 			run = true
 		}
+		if s.expectError != nil && run {
+			if err := o.checkExpectError(i, s, runErr); err != nil {
+				return err
+			}
+		} else if runErr != nil {
+			return runErr
+		}
+		if run {
+			if err := o.recordLatency(i, s, time.Since(start)); err != nil {
+				return err
+			}
+		}
 		// dump runner
 		if s.dumpRunner != nil && s.dumpRequest != nil {
 			o.Debugf(cyan("Run %q on %s\n"), dumpRunnerKey, o.stepName(i))
@@ -240,6 +354,21 @@ func (o *operator) runStep(ctx context.Context, i int, s *step) error {
 			}
 			run = true
 		}
+		// snapshot runner
+		if s.snapshotRunner != nil && s.snapshotConfig != nil {
+			if o.skipTest {
+				o.Debugf(yellow("Skip %q on %s\n"), snapshotRunnerKey, o.stepName(i))
+				if !run {
+					return errStepSkiped
+				}
+				return nil
+			}
+			o.Debugf(cyan("Run %q on %s\n"), snapshotRunnerKey, o.stepName(i))
+			if err := s.snapshotRunner.Run(ctx, s, !run); err != nil {
+				return fmt.Errorf("snapshot failed on %s: %w", o.stepName(i), err)
+			}
+			run = true
+		}
 
 		if !run {
 			return fmt.Errorf("invalid runner: %v", o.stepName(i))
@@ -247,10 +376,21 @@ func (o *operator) runStep(ctx context.Context, i int, s *step) error {
 		return nil
 	}
 
+	// hooks
+	if err := o.runHooks(ctx, i, s, beforeEachHookKey, o.beforeEachHook); err != nil {
+		return err
+	}
+	defer func() {
+		if err := o.runHooks(ctx, i, s, afterEachHookKey, o.afterEachHook); err != nil {
+			rerr = errors.Join(rerr, err)
+		}
+	}()
+
 	// loop
 	if s.loop != nil {
 		defer func() {
 			o.store.loopIndex = nil
+			o.store.loopItem = nil
 			s.loopIndex = nil
 		}()
 		retrySuccess := false
@@ -265,6 +405,26 @@ func (o *operator) runStep(ctx context.Context, i int, s *step) error {
 		if err != nil {
 			return err
 		}
+		var items []loopItem
+		if s.loop.Items != nil {
+			store := o.store.toMap()
+			store[storeRootKeyIncluded] = o.included
+			store[storeRootPrevious] = o.store.latest()
+			items, err = s.loop.evalItems(store)
+			if err != nil {
+				return fmt.Errorf("loop failed on %s: %w", o.stepName(i), err)
+			}
+			if len(items) == 0 {
+				// nothing to iterate over
+				o.Debugf(yellow("Skip %s\n"), o.stepName(i))
+				return errStepSkiped
+			}
+			if s.loop.itemsOnly {
+				c = len(items)
+			} else {
+				c = min(c, len(items))
+			}
+		}
 		for s.loop.Loop(ctx) {
 			if j >= c {
 				break
@@ -272,31 +432,68 @@ func (o *operator) runStep(ctx context.Context, i int, s *step) error {
 			jj := j
 			o.store.loopIndex = &jj
 			s.loopIndex = &jj
+			if items != nil {
+				o.store.loopItem = &items[j]
+			}
+			if s.loop.While != "" {
+				store := o.store.toMap()
+				store[storeRootKeyIncluded] = o.included
+				if j == 0 {
+					store[storeRootPrevious] = o.store.latest()
+					store[storeRootKeyCurrent] = nil
+				} else {
+					store[storeRootPrevious] = o.store.previous()
+					store[storeRootKeyCurrent] = o.store.latest()
+				}
+				tf, err := s.loop.next(store)
+				if err != nil {
+					return fmt.Errorf("loop failed on %s: %w", o.stepName(i), err)
+				}
+				if !tf {
+					if j == 0 {
+						// not run even once
+						o.Debugf(yellow("Skip %s\n"), o.stepName(i))
+						return errStepSkiped
+					}
+					retrySuccess = true
+					break
+				}
+			}
 			trs := s.trails()
 			o.capturers.setCurrentTrails(trs)
 			sw := o.sw.Start(trs.toProfileIDs()...)
-			if err := stepFn(o.thisT); err != nil {
+			if err := stepFn(ctx, o.thisT); err != nil {
 				sw.Stop()
 				return fmt.Errorf("loop failed: %w", err)
 			}
 			sw.Stop()
-			if s.loop.Until != "" {
+			if s.loop.hasCond() {
 				store := o.store.toMap()
 				store[storeRootKeyIncluded] = o.included
 				store[storeRootPrevious] = o.store.previous()
 				store[storeRootKeyCurrent] = o.store.latest()
-				bt, err = buildTree(s.loop.Until, store)
-				if err != nil {
-					return fmt.Errorf("loop failed on %s: %w", o.stepName(i), err)
-				}
-				tf, err := EvalCond(s.loop.Until, store)
+				brk, cont, err := s.loop.control(store)
 				if err != nil {
 					return fmt.Errorf("loop failed on %s: %w", o.stepName(i), err)
 				}
-				if tf {
+				if brk {
 					retrySuccess = true
 					break
 				}
+				if !cont && s.loop.Until != "" {
+					bt, err = buildTree(s.loop.Until, store)
+					if err != nil {
+						return fmt.Errorf("loop failed on %s: %w", o.stepName(i), err)
+					}
+					tf, err := EvalCond(s.loop.Until, store)
+					if err != nil {
+						return fmt.Errorf("loop failed on %s: %w", o.stepName(i), err)
+					}
+					if tf {
+						retrySuccess = true
+						break
+					}
+				}
 			}
 			j++
 		}
@@ -309,8 +506,12 @@ func (o *operator) runStep(ctx context.Context, i int, s *step) error {
 				return fmt.Errorf("retry loop failed on %s.loop (count: %d, minInterval: %v, maxInterval: %v): %w", o.stepName(i), c, *s.loop.minInterval, *s.loop.maxInterval, err)
 			}
 		}
+	} else if s.retry != nil {
+		if err := o.runStepWithRetry(ctx, i, s, stepFn); err != nil {
+			return err
+		}
 	} else {
-		if err := stepFn(o.thisT); err != nil {
+		if err := stepFn(ctx, o.thisT); err != nil {
 			return err
 		}
 	}
@@ -403,12 +604,28 @@ func New(opts ...Option) (*operator, error) {
 	if err := bk.applyOptions(opts...); err != nil {
 		return nil, err
 	}
-	id, err := generateRandomID()
-	if err != nil {
+	if err := bk.bindConsts(); err != nil {
 		return nil, err
 	}
+	bindVars := bk.bindStoreSeed()
+	// The vars of the included runbook are validated after being overridden by `include.vars:`
+	if !bk.included {
+		if err := validateVars(bk.varsSchema, bk.vars); err != nil {
+			return nil, err
+		}
+	}
+	bk.bindLazyVars()
+	id := bk.id
+	if id == "" {
+		rid, err := generateRandomID()
+		if err != nil {
+			return nil, err
+		}
+		id = rid
+	}
 	o := &operator{
 		id:          id,
+		idSpecified: bk.id != "",
 		httpRunners: map[string]*httpRunner{},
 		dbRunners:   map[string]*dbRunner{},
 		grpcRunners: map[string]*grpcRunner{},
@@ -419,39 +636,63 @@ func New(opts ...Option) (*operator, error) {
 			stepMap:  map[string]map[string]any{},
 			vars:     bk.vars,
 			funcs:    bk.funcs,
-			bindVars: map[string]any{},
+			bindVars: bindVars,
 			useMap:   bk.useMap,
 		},
-		useMap:      bk.useMap,
-		desc:        bk.desc,
-		labels:      bk.labels,
-		debug:       bk.debug,
-		profile:     bk.profile,
-		interval:    bk.interval,
-		loop:        bk.loop,
-		concurrency: bk.concurrency,
-		t:           bk.t,
-		thisT:       bk.t,
-		force:       bk.force,
-		trace:       bk.trace,
-		failFast:    bk.failFast,
-		included:    bk.included,
-		ifCond:      bk.ifCond,
-		skipTest:    bk.skipTest,
-		stdout:      bk.stdout,
-		stderr:      bk.stderr,
-		newOnly:     bk.loadOnly,
-		bookPath:    bk.path,
-		beforeFuncs: bk.beforeFuncs,
-		afterFuncs:  bk.afterFuncs,
-		sw:          stopw.New(),
-		capturers:   bk.capturers,
-		runResult:   newRunResult(bk.desc, bk.labels, bk.path),
+		useMap:          bk.useMap,
+		desc:            bk.desc,
+		labels:          bk.labels,
+		runLabels:       bk.runLabels,
+		debug:           bk.debug,
+		profile:         bk.profile,
+		interval:        bk.interval,
+		timeout:         bk.timeout,
+		loop:            bk.loop,
+		concurrency:     bk.concurrency,
+		cases:           bk.cases,
+		caseIndex:       bk.caseIndex,
+		consts:          bk.consts,
+		varsSchema:      bk.varsSchema,
+		secrets:         bk.secrets,
+		t:               bk.t,
+		thisT:           bk.t,
+		force:           bk.force,
+		trace:           bk.trace,
+		updateSnapshots: bk.updateSnapshots,
+		softAssertions:  bk.softAssertions,
+		failFast:        bk.failFast,
+		included:        bk.included,
+		includeMaxDepth: bk.includeMaxDepth,
+		ifCond:          bk.ifCond,
+		skipTest:        bk.skipTest,
+		startStep:       bk.startStep,
+		endStep:         bk.endStep,
+		runSteps:        bk.runSteps,
+		replaySteps:     bk.replaySteps,
+		dotenv:          bk.dotenv,
+		stdout:          bk.stdout,
+		stderr:          bk.stderr,
+		newOnly:         bk.loadOnly,
+		bookPath:        bk.path,
+		beforeFuncs:     bk.beforeFuncs,
+		afterFuncs:      bk.afterFuncs,
+		beforeEachSteps: bk.beforeEachSteps,
+		afterEachSteps:  bk.afterEachSteps,
+		templates:       bk.templates,
+		sw:              stopw.New(),
+		capturers:       bk.capturers,
+		runResult:       newRunResult(bk.desc, bk.labels, bk.path),
+	}
+
+	if len(o.secrets) > 0 {
+		o.stderr = &maskWriter{o: o, w: o.stderr}
 	}
-
 	if o.debug {
 		o.capturers = append(o.capturers, NewDebugger(o.stderr))
 	}
+	if len(o.secrets) > 0 {
+		o.capturers = newMaskCapturers(o.capturers, o)
+	}
 
 	root, err := bk.generateOperatorRoot()
 	if err != nil {
@@ -459,6 +700,17 @@ func New(opts ...Option) (*operator, error) {
 	}
 	o.root = root
 
+	// Bind the built-in functions `file`, `jsonschema` and `fixture` to the root directory of the runbook
+	if _, ok := o.store.funcs[fileFuncName].(fileFunc); ok {
+		o.store.funcs[fileFuncName] = newFileFunc(root)
+	}
+	if _, ok := o.store.funcs[jsonschemaFuncName].(jsonschemaFunc); ok {
+		o.store.funcs[jsonschemaFuncName] = newJSONSchemaFunc(root)
+	}
+	if _, ok := o.store.funcs[fixtureFuncName].(fixtureFunc); ok {
+		o.store.funcs[fixtureFuncName] = newFixtureFunc(root, o.fixtureStore(false))
+	}
+
 	for k, v := range bk.httpRunners {
 		if _, ok := v.validator.(*nopValidator); ok {
 			for _, l := range bk.openApi3DocLocations {
@@ -475,6 +727,7 @@ func New(opts ...Option) (*operator, error) {
 				}
 				c.SkipValidateRequest, _ = runner["skipValidateRequest"].(bool)
 				c.SkipValidateResponse, _ = runner["skipValidateResponse"].(bool)
+				c.SchemaDrift, _ = runner["schemaDrift"].(string)
 
 				val, err := newHttpValidator(c)
 				if err != nil {
@@ -582,6 +835,14 @@ func New(opts ...Option) (*operator, error) {
 
 	o.numberOfSteps = len(bk.rawSteps)
 
+	if !o.useMap {
+		names, err := validateStepNames(bk.rawSteps)
+		if err != nil && !o.newOnly {
+			return nil, fmt.Errorf("failed to validate step names (%s): %w", o.bookPath, err)
+		}
+		o.store.stepNames = names
+	}
+
 	for i, s := range bk.rawSteps {
 		key := fmt.Sprintf("%d", i)
 		if o.useMap {
@@ -594,6 +855,12 @@ func New(opts ...Option) (*operator, error) {
 			return nil, fmt.Errorf("failed to append step (%s): %w", o.bookPath, err)
 		}
 	}
+	if err := o.validateGotos(); err != nil && !o.newOnly {
+		return nil, fmt.Errorf("failed to validate goto (%s): %w", o.bookPath, err)
+	}
+	if err := o.buildHooks(); err != nil && !o.newOnly {
+		return nil, fmt.Errorf("failed to build hooks (%s): %w", o.bookPath, err)
+	}
 
 	return o, nil
 }
@@ -603,7 +870,13 @@ func (o *operator) AppendStep(idx int, key string, s map[string]any) error {
 	if o.t != nil {
 		o.t.Helper()
 	}
+	// use section
+	s, with, err := o.applyTemplate(s)
+	if err != nil {
+		return err
+	}
 	step := newStep(idx, key, o)
+	step.with = with
 	// if section
 	if v, ok := s[ifSectionKey]; ok {
 		step.ifCond, ok = v.(string)
@@ -612,6 +885,17 @@ func (o *operator) AppendStep(idx int, key string, s map[string]any) error {
 		}
 		delete(s, ifSectionKey)
 	}
+	// name section
+	if v, ok := s[nameSectionKey]; ok {
+		if o.useMap {
+			return fmt.Errorf("invalid name: %s is only available in list-form steps", nameSectionKey)
+		}
+		step.name, ok = v.(string)
+		if !ok {
+			return fmt.Errorf("invalid name: %v", v)
+		}
+		delete(s, nameSectionKey)
+	}
 	// desc section
 	if v, ok := s[descSectionKey]; ok {
 		step.desc, ok = v.(string)
@@ -629,6 +913,113 @@ func (o *operator) AppendStep(idx int, key string, s map[string]any) error {
 		step.loop = r
 		delete(s, loopSectionKey)
 	}
+	// retry section
+	if v, ok := s[retrySectionKey]; ok {
+		if step.loop != nil {
+			return fmt.Errorf("invalid retry: %s cannot be used with %s", retrySectionKey, loopSectionKey)
+		}
+		r, err := parseRetry(v, "")
+		if err != nil {
+			return fmt.Errorf("invalid retry: %w\n%v", err, v)
+		}
+		step.retry = r
+		delete(s, retrySectionKey)
+	}
+	// eventually section
+	if v, ok := s[eventuallySectionKey]; ok {
+		if step.loop != nil || step.retry != nil {
+			return fmt.Errorf("invalid eventually: %s cannot be used with %s or %s", eventuallySectionKey, loopSectionKey, retrySectionKey)
+		}
+		r, err := parseEventually(v)
+		if err != nil {
+			return fmt.Errorf("invalid eventually: %w\n%v", err, v)
+		}
+		step.retry = r
+		delete(s, eventuallySectionKey)
+	}
+	// maxLatency section
+	if v, ok := s[maxLatencySectionKey]; ok {
+		d, err := parseMaxLatency(v)
+		if err != nil {
+			return fmt.Errorf("invalid maxLatency: %w", err)
+		}
+		step.maxLatency = &d
+		delete(s, maxLatencySectionKey)
+	}
+	// expectError section
+	if v, ok := s[expectErrorSectionKey]; ok {
+		e, err := newExpectError(v)
+		if err != nil {
+			return fmt.Errorf("invalid expectError: %w", err)
+		}
+		step.expectError = e
+		delete(s, expectErrorSectionKey)
+	}
+	// needs section
+	if v, ok := s[needsSectionKey]; ok {
+		needs, err := o.parseNeeds(v)
+		if err != nil {
+			return err
+		}
+		step.needs = needs
+		delete(s, needsSectionKey)
+	}
+	// skip section
+	if v, ok := s[skipSectionKey]; ok {
+		step.skip, ok = v.(bool)
+		if !ok {
+			return fmt.Errorf("invalid skip: %v", v)
+		}
+		delete(s, skipSectionKey)
+	}
+	// only section
+	if v, ok := s[onlySectionKey]; ok {
+		step.only, ok = v.(bool)
+		if !ok {
+			return fmt.Errorf("invalid only: %v", v)
+		}
+		if step.only {
+			o.hasOnly = true
+		}
+		delete(s, onlySectionKey)
+	}
+	// force section
+	if v, ok := s[forceSectionKey]; ok {
+		step.force, ok = v.(bool)
+		if !ok {
+			return fmt.Errorf("invalid force: %v", v)
+		}
+		delete(s, forceSectionKey)
+	}
+	// defer section
+	if v, ok := s[deferSectionKey]; ok {
+		step.deferred, ok = v.(bool)
+		if !ok {
+			return fmt.Errorf("invalid defer: %v", v)
+		}
+		delete(s, deferSectionKey)
+	}
+	if (step.deferred && (step.needs != nil || o.hasNeeds())) || (step.needs != nil && o.hasDeferred()) {
+		return fmt.Errorf("invalid defer: %s cannot be used with %s", deferSectionKey, needsSectionKey)
+	}
+	// goto section
+	if v, ok := s[gotoSectionKey]; ok {
+		if !o.useMap {
+			return fmt.Errorf("invalid goto: %s can only be used with the steps of map syntax", gotoSectionKey)
+		}
+		if step.deferred {
+			return fmt.Errorf("invalid goto: %s cannot be used with %s", gotoSectionKey, deferSectionKey)
+		}
+		gotos, err := parseGoto(v)
+		if err != nil {
+			return err
+		}
+		step.gotos = gotos
+		delete(s, gotoSectionKey)
+	}
+	if (step.gotos != nil && (step.needs != nil || o.hasNeeds())) || (step.needs != nil && o.hasGotos()) {
+		return fmt.Errorf("invalid goto: %s cannot be used with %s", gotoSectionKey, needsSectionKey)
+	}
 	// test runner
 	if v, ok := s[testRunnerKey]; ok {
 		step.testRunner = newTestRunner()
@@ -641,6 +1032,13 @@ func (o *operator) AppendStep(idx int, key string, s map[string]any) error {
 			}
 		case string:
 			step.testCond = vv
+		case []any:
+			as, err := parseTestAssertions(vv)
+			if err != nil {
+				return err
+			}
+			step.testAssertions = as
+			step.testCond = joinTestAssertions(as)
 		default:
 			return fmt.Errorf("invalid test condition: %v", v)
 		}
@@ -679,6 +1077,16 @@ func (o *operator) AppendStep(idx int, key string, s map[string]any) error {
 		step.bindCond = cond
 		delete(s, bindRunnerKey)
 	}
+	// snapshot runner
+	if v, ok := s[snapshotRunnerKey]; ok {
+		c, err := parseSnapshotConfig(v)
+		if err != nil {
+			return err
+		}
+		step.snapshotRunner = newSnapshotRunner()
+		step.snapshotConfig = c
+		delete(s, snapshotRunnerKey)
+	}
 
 	k, v, ok := pop(s)
 	if ok {
@@ -696,6 +1104,20 @@ func (o *operator) AppendStep(idx int, key string, s map[string]any) error {
 			}
 			c.step = step
 			step.includeConfig = c
+		case k == parallelRunnerKey:
+			c, err := parseParallelConfig(v)
+			if err != nil {
+				return err
+			}
+			step.parallelRunner = newParallelRunner()
+			step.parallelConfig = c
+		case k == groupRunnerKey:
+			c, err := parseGroupConfig(v)
+			if err != nil {
+				return err
+			}
+			step.groupRunner = newGroupRunner()
+			step.groupConfig = c
 		case k == execRunnerKey:
 			step.execRunner = newExecRunner()
 			vv, ok := v.(map[string]any)
@@ -783,7 +1205,7 @@ func (o *operator) Run(ctx context.Context) error {
 	}()
 	o.capturers.captureStart(o.trails(), o.bookPath, o.desc)
 	if err := o.run(cctx); err != nil {
-		return err
+		return o.maskErr(err)
 	}
 	return nil
 }
@@ -811,6 +1233,7 @@ func (o *operator) Result() *RunResult {
 			panic(err)
 		}
 	}
+	o.maskRunResult(o.runResult)
 	return o.runResult
 }
 
@@ -827,6 +1250,8 @@ func (o *operator) run(ctx context.Context) error {
 	if o.newOnly {
 		return errors.New("this runbook is not allowed to run")
 	}
+	ctx, cancel := o.withTimeout(ctx)
+	defer cancel()
 	var err error
 	if o.t != nil {
 		// As test helper
@@ -834,14 +1259,19 @@ func (o *operator) run(ctx context.Context) error {
 		o.t.Run(o.testName(), func(t *testing.T) {
 			t.Helper()
 			o.thisT = t
-			if o.loop != nil {
+			switch {
+			case o.cases != nil && o.caseIndex == nil:
+				err = o.runCases(ctx)
+			case o.loop != nil:
 				err = o.runLoop(ctx)
-			} else {
+			default:
 				err = o.runInternal(ctx)
 			}
+			err = o.checkTimeout(ctx, err)
 			if err != nil {
 				// Skip parent runner t.Error if there is an error in the included runbook
 				if !errors.Is(&includedRunErr{}, err) {
+					o.maskRunResult(o.runResult)
 					paths, indexes, errs := failedRunbookPathsAndErrors(o.runResult)
 					for ii, p := range paths {
 						last := p[len(p)-1]
@@ -874,12 +1304,15 @@ func (o *operator) run(ctx context.Context) error {
 		}
 		return nil
 	}
-	if o.loop != nil {
+	switch {
+	case o.cases != nil && o.caseIndex == nil:
+		err = o.runCases(ctx)
+	case o.loop != nil:
 		err = o.runLoop(ctx)
-	} else {
+	default:
 		err = o.runInternal(ctx)
 	}
-	if err != nil {
+	if err := o.checkTimeout(ctx, err); err != nil {
 		return fmt.Errorf("failed to run %s: %w", o.bookPathOrID(), err)
 	}
 	return nil
@@ -918,6 +1351,22 @@ func (o *operator) runLoop(ctx context.Context) error {
 		}
 		i := j
 		o.loopIndex = &i
+		if o.loop.While != "" {
+			store := o.store.toMap()
+			store[storeStepKeyOutcome] = string(outcome)
+			tf, err := o.loop.next(store)
+			if err != nil {
+				return fmt.Errorf("loop failed on %s: %w", o.bookPathOrID(), err)
+			}
+			if !tf {
+				if j == 0 {
+					// not run even once
+					return o.skip()
+				}
+				retrySuccess = true
+				break
+			}
+		}
 		trs := o.trails()
 		o.capturers.setCurrentTrails(trs)
 		sw := o.sw.Start(trs.toProfileIDs()...)
@@ -934,21 +1383,31 @@ func (o *operator) runLoop(ctx context.Context) error {
 				outcome = resultSuccess
 			}
 		}
-		if o.loop.Until != "" {
+		if o.loop.hasCond() {
 			store := o.store.toMap()
 			store[storeStepKeyOutcome] = string(outcome)
-			bt, err = buildTree(o.loop.Until, store)
+			brk, cont, err := o.loop.control(store)
 			if err != nil {
 				return fmt.Errorf("loop failed on %s: %w", o.bookPathOrID(), err)
 			}
-			tf, err := EvalCond(o.loop.Until, store)
-			if err != nil {
-				return fmt.Errorf("loop failed on %s: %w", o.bookPathOrID(), err)
-			}
-			if tf {
+			if brk {
 				retrySuccess = true
 				break
 			}
+			if !cont && o.loop.Until != "" {
+				bt, err = buildTree(o.loop.Until, store)
+				if err != nil {
+					return fmt.Errorf("loop failed on %s: %w", o.bookPathOrID(), err)
+				}
+				tf, err := EvalCond(o.loop.Until, store)
+				if err != nil {
+					return fmt.Errorf("loop failed on %s: %w", o.bookPathOrID(), err)
+				}
+				if tf {
+					retrySuccess = true
+					break
+				}
+			}
 		}
 		j++
 	}
@@ -977,6 +1436,14 @@ func (o *operator) runInternal(ctx context.Context) (rerr error) {
 	// Clear results for each scenario run (runInternal); results per root loop are not retrievable.
 	o.clearResult()
 	o.store.clearSteps()
+	o.resetMasker()
+
+	defer func() {
+		// Terminate background processes at the end of the runbook
+		if err := o.terminateBackgrounds(); err != nil {
+			o.Debugf(yellow("Failed to terminate background processes: %v\n"), err)
+		}
+	}()
 
 	defer func() {
 		// Set run error and skipped status
@@ -1041,9 +1508,38 @@ func (o *operator) runInternal(ctx context.Context) (rerr error) {
 	}
 
 	// steps
+	selected, err := o.selectSteps()
+	if err != nil {
+		for _, s := range o.steps {
+			s.setResult(errStepSkiped)
+		}
+		return err
+	}
+	o.stepSelected = selected
+	if o.hasNeeds() {
+		rerr = o.runStepsWithNeeds(ctx)
+		return
+	}
 	failed := false
 	force := o.force
+	var deferred []int
+	jumpTo := 0
 	for i, s := range o.steps {
+		if s.deferred {
+			// The deferred step runs at the end of the runbook even if the previous steps failed or it is jumped over by goto
+			deferred = append(deferred, i)
+			o.recordNotRun(i)
+			continue
+		}
+		if i < jumpTo {
+			// jumped over by goto
+			s.setResult(errStepSkiped)
+			o.recordNotRun(i)
+			if err := o.recordToLatest(storeStepKeyOutcome, resultSkipped); err != nil {
+				return err
+			}
+			continue
+		}
 		if failed && !force {
 			s.setResult(errStepSkiped)
 			o.recordNotRun(i)
@@ -1052,6 +1548,20 @@ func (o *operator) runInternal(ctx context.Context) (rerr error) {
 			}
 			continue
 		}
+		if v, ok := o.replayedStep(i); ok {
+			// Replay the stored result instead of running the unselected step
+			o.Debugf(yellow("Replay the stored result on %s\n"), o.stepName(i))
+			s.setResult(errStepSkiped)
+			outcome := resultSkipped
+			if r, ok := v[storeStepKeyOutcome].(string); ok {
+				outcome = result(r)
+			}
+			o.record(v)
+			if err := o.recordToLatest(storeStepKeyOutcome, outcome); err != nil {
+				return err
+			}
+			continue
+		}
 		err := o.runStep(ctx, i, s)
 		s.setResult(err)
 		switch {
@@ -1065,12 +1575,33 @@ func (o *operator) runInternal(ctx context.Context) (rerr error) {
 			if err := o.recordToLatest(storeStepKeyOutcome, resultFailure); err != nil {
 				return err
 			}
+			if s.force {
+				o.Debugf(yellow("Tolerate the failure of %s: %v\n"), o.stepName(i), err)
+				continue
+			}
 			rerr = multierr.Append(rerr, err)
 			failed = true
 		default:
 			if err := o.recordToLatest(storeStepKeyOutcome, resultSuccess); err != nil {
 				return err
 			}
+			j, err := o.evalGoto(i, s)
+			if err != nil {
+				rerr = multierr.Append(rerr, err)
+				failed = true
+				continue
+			}
+			jumpTo = j
+		}
+	}
+
+	// deferred steps run in the reverse order even if the previous steps failed or the run is canceled
+	dctx, cancel := withDeferredTimeout(ctx)
+	defer cancel()
+	for j := len(deferred) - 1; j >= 0; j-- {
+		i := deferred[j]
+		if err := o.runDeferredStep(dctx, i, o.steps[i]); err != nil {
+			rerr = multierr.Append(rerr, err)
 		}
 	}
 
@@ -1230,22 +1761,65 @@ func Load(pathp string, opts ...Option) (*operators, error) {
 		if bk.skipIncluded {
 			for _, s := range o.steps {
 				if s.includeRunner != nil && s.includeConfig != nil {
-					skipPaths = append(skipPaths, filepath.Join(o.root, s.includeConfig.path))
+					ps, err := s.includeConfig.bookPaths(o.root)
+					if err != nil {
+						// The include fails when it runs
+						continue
+					}
+					skipPaths = append(skipPaths, ps...)
 				}
 			}
 		}
+		if len(o.cases) > 0 {
+			// Each case is run as a separate runbook
+			o.Close(true)
+			cops, err := newCaseOperators(b, opts, len(o.cases))
+			if err != nil {
+				return nil, err
+			}
+			for _, co := range cops {
+				om[caseDesc(co.bookPath, *co.caseIndex)] = co
+			}
+			opss = append(opss, cops...)
+			continue
+		}
 		om[o.bookPath] = o
 		opss = append(opss, o)
 	}
 
-	if err := generateIDsUsingPath(opss); err != nil {
+	if ops.concmax > 1 {
+		// Restoring the snapshot of the database breaks the runbooks running concurrently
+		for _, o := range opss {
+			for _, r := range o.dbRunners {
+				if r.snapshot {
+					return nil, fmt.Errorf("snapshot of DB runner cannot be used with concurrent runs: %s ( %s )", r.name, o.bookPath)
+				}
+			}
+		}
+	}
+
+	if bk.sharedStore {
+		shared := newSharedStore()
+		for _, o := range opss {
+			o.store.shared = shared
+		}
+	}
+
+	// The operators of the cases of the same runbook have the same path
+	if err := generateIDsUsingPath(lo.Filter(opss, func(o *operator, _ int) bool {
+		return o.caseIndex == nil || *o.caseIndex == 0
+	})); err != nil {
+		return nil, err
+	}
+	if err := generateCaseIDs(opss); err != nil {
 		return nil, err
 	}
 
 	var idMatched []*operator
 	cond := labelCond(bk.runLabels)
 	indexes := map[string]int{}
-	for p, o := range om {
+	for _, o := range om {
+		p := o.bookPath
 		// RUNN_RUN, --run
 		if !bk.runMatch.MatchString(p) {
 			o.Debugf(yellow("Skip %s because it does not match %s\n"), p, bk.runMatch.String())
@@ -1256,7 +1830,7 @@ func Load(pathp string, opts ...Option) (*operators, error) {
 			continue
 		}
 		// RUUN_LABEL, --label
-		tf, err := EvalCond(cond, labelEnv(o.labels))
+		tf, err := o.matchLabels(cond)
 		if err != nil {
 			return nil, err
 		}
@@ -1498,6 +2072,9 @@ func partOperators(ops []*operator, n, i int) []*operator {
 func sortOperators(ops []*operator) {
 	sort.SliceStable(ops, func(i, j int) bool {
 		if ops[i].bookPath == ops[j].bookPath {
+			if ops[i].caseIndex != nil && ops[j].caseIndex != nil {
+				return *ops[i].caseIndex < *ops[j].caseIndex
+			}
 			return ops[i].desc < ops[j].desc
 		}
 		return ops[i].bookPath < ops[j].bookPath
@@ -1508,7 +2085,11 @@ func copyOperators(ops []*operator, opts []Option) ([]*operator, error) {
 	var c []*operator
 	for _, o := range ops {
 		// FIXME: Need the function to copy the operator as it is heavy to parse the runbook each time
-		oo, err := New(append([]Option{Book(o.bookPath)}, opts...)...)
+		bopts := []Option{Book(o.bookPath)}
+		if o.caseIndex != nil {
+			bopts = append(bopts, runCase(*o.caseIndex))
+		}
+		oo, err := New(append(bopts, opts...)...)
 		if err != nil {
 			return nil, err
 		}
@@ -1639,6 +2220,29 @@ func labelEnv(labels []string) map[string]bool {
 	})
 }
 
+// matchLabels reports whether the labels of the runbook or of any labeled group of the runbook match the label condition.
+// The labels of the group are joined with the labels of the runbook.
+func (o *operator) matchLabels(cond string) (bool, error) {
+	tf, err := EvalCond(cond, labelEnv(o.labels))
+	if err != nil || tf {
+		return tf, err
+	}
+	for _, s := range o.steps {
+		if s.groupConfig == nil || len(s.groupConfig.labels) == 0 {
+			continue
+		}
+		tf, err := EvalCond(cond, labelEnv(joinLabels(o.labels, s.groupConfig.labels)))
+		if err != nil || tf {
+			return tf, err
+		}
+	}
+	return false, nil
+}
+
+func joinLabels(labels ...[]string) []string {
+	return lo.Uniq(lo.Flatten(labels))
+}
+
 func labelCond(labels []string) string {
 	if len(labels) == 0 {
 		return "true"
@@ -198,7 +198,26 @@ func TestRun(t *testing.T) {
 		{"testdata/book/if.yml"},
 		{"testdata/book/previous.yml"},
 		{"testdata/book/faker.yml"},
+		{"testdata/book/crypto.yml"},
+		{"testdata/book/time.yml"},
+		{"testdata/book/file.yml"},
+		{"testdata/book/serialize.yml"},
+		{"testdata/book/regexp.yml"},
+		{"testdata/book/id.yml"},
+		{"testdata/book/encoding.yml"},
+		{"testdata/book/changes.yml"},
+		{"testdata/book/jq.yml"},
+		{"testdata/book/xpath_css.yml"},
+		{"testdata/book/lazy_vars.yml"},
+		{"testdata/book/step_names.yml"},
+		{"testdata/book/jsonschema.yml"},
+		{"testdata/book/subset.yml"},
 		{"testdata/book/env.yml"},
+		{"testdata/book/test_messages.yml"},
+		{"testdata/book/approx.yml"},
+		{"testdata/book/image.yml"},
+		{"testdata/book/proto_equal.yml"},
+		{"testdata/book/fixture.yml"},
 	}
 	ctx := context.Background()
 	t.Setenv("DEBUG", "false")
@@ -290,7 +309,8 @@ func TestLoad(t *testing.T) {
 				if err != nil {
 					t.Fatal(err)
 				}
-				return len(e)
+				// cases.yml and cases_csv.yml are loaded as the operators of 2 and 3 cases
+				return len(e) + 3
 			}(),
 		},
 		{"testdata/book/**/*", "initdb", "", "", 1},
@@ -661,7 +681,10 @@ func TestShard(t *testing.T) {
 				cmpopts.IgnoreFields(stopw.Span{}, "ID"),
 				cmpopts.IgnoreFields(operator{}, "id"),
 				cmpopts.IgnoreFields(operator{}, "concurrency"),
-				cmpopts.IgnoreFields(operator{}, "mu"),
+				cmpopts.IgnoreFields(operator{}, "mu", "maskMu"),
+				cmpopts.IgnoreFields(dbRunner{}, "mu"),
+				cmpopts.IgnoreFields(grpcRunner{}, "mu"),
+				cmpopts.IgnoreFields(sshRunner{}, "mu"),
 				cmpopts.IgnoreFields(cdpRunner{}, "ctx"),
 				cmpopts.IgnoreFields(cdpRunner{}, "cancel"),
 				cmpopts.IgnoreFields(cdpRunner{}, "opts"),
@@ -1255,6 +1278,8 @@ func TestLabelCond(t *testing.T) {
 		{[]string{"http and openapi3"}, []string{"http"}, false},
 		{[]string{"user-login or user-logout"}, []string{"user-login"}, true},
 		{[]string{"user:login or user:logout"}, []string{"user:login"}, true},
+		{[]string{"smoke && !slow"}, []string{"smoke"}, true},
+		{[]string{"smoke && !slow"}, []string{"smoke", "slow"}, false},
 	}
 	for _, tt := range tests {
 		t.Run(fmt.Sprintf("%v", tt.runLabels), func(t *testing.T) {
@@ -1308,3 +1333,15 @@ func TestRunUsingHTTPOpenAPI3(t *testing.T) {
 		})
 	}
 }
+
+func TestVarKeys(t *testing.T) {
+	o, err := New(Var("username", "alice"), Var("user", map[string]any{"name": "alice", "address": map[string]any{"city": "Tokyo"}}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := o.VarKeys()
+	want := []string{"user", "user.address", "user.address.city", "user.name", "username"}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
@@ -16,6 +16,7 @@ import (
 	"github.com/k1LoW/duration"
 	"github.com/k1LoW/runn/builtin"
 	"github.com/k1LoW/sshc/v4"
+	"github.com/samber/lo"
 	"github.com/spf13/cast"
 	"golang.org/x/crypto/ssh"
 	"google.golang.org/grpc"
@@ -32,7 +33,7 @@ func Book(path string) Option {
 		if bk == nil {
 			return ErrNilBook
 		}
-		loaded, err := loadBook(path, nil)
+		loaded, err := loadBook(path, nil, bk.lookupEnv)
 		if err != nil {
 			return err
 		}
@@ -49,7 +50,7 @@ func Overlay(path string) Option {
 		if len(bk.rawSteps) == 0 {
 			return errors.New("overlays are unusable without its base runbook")
 		}
-		loaded, err := loadBook(path, nil)
+		loaded, err := loadBook(path, nil, bk.lookupEnv)
 		if err != nil {
 			return err
 		}
@@ -81,11 +82,22 @@ func Overlay(path string) Option {
 		for k, v := range loaded.vars {
 			bk.vars[k] = v
 		}
+		for k, v := range loaded.consts {
+			bk.consts[k] = v
+		}
+		for k, t := range loaded.templates {
+			if bk.templates == nil {
+				bk.templates = map[string]map[string]any{}
+			}
+			bk.templates[k] = t
+		}
 		for k, e := range loaded.runnerErrs {
 			bk.runnerErrs[k] = e
 		}
 		bk.rawSteps = append(bk.rawSteps, loaded.rawSteps...)
 		bk.stepKeys = append(bk.stepKeys, loaded.stepKeys...)
+		bk.beforeEachSteps = append(bk.beforeEachSteps, loaded.beforeEachSteps...)
+		bk.afterEachSteps = append(bk.afterEachSteps, loaded.afterEachSteps...)
 		bk.debug = loaded.debug
 		bk.skipTest = loaded.skipTest
 		bk.loop = loaded.loop
@@ -105,7 +117,7 @@ func Underlay(path string) Option {
 		if len(bk.rawSteps) == 0 {
 			return errors.New("underlays are unusable without its base runbook")
 		}
-		loaded, err := loadBook(path, nil)
+		loaded, err := loadBook(path, nil, bk.lookupEnv)
 		if err != nil {
 			return err
 		}
@@ -155,11 +167,26 @@ func Underlay(path string) Option {
 				bk.vars[k] = v
 			}
 		}
+		for k, v := range loaded.consts {
+			if _, ok := bk.consts[k]; !ok {
+				bk.consts[k] = v
+			}
+		}
+		for k, t := range loaded.templates {
+			if bk.templates == nil {
+				bk.templates = map[string]map[string]any{}
+			}
+			if _, ok := bk.templates[k]; !ok {
+				bk.templates[k] = t
+			}
+		}
 		for k, e := range loaded.runnerErrs {
 			bk.runnerErrs[k] = e
 		}
 		bk.rawSteps = append(loaded.rawSteps, bk.rawSteps...)
 		bk.stepKeys = append(loaded.stepKeys, bk.stepKeys...)
+		bk.beforeEachSteps = append(loaded.beforeEachSteps, bk.beforeEachSteps...)
+		bk.afterEachSteps = append(loaded.afterEachSteps, bk.afterEachSteps...)
 		if bk.intervalStr == "" {
 			bk.interval = loaded.interval
 		}
@@ -388,6 +415,17 @@ func DBRunnerWithOptions(name, dsn string, opts ...dbRunnerOption) Option {
 				}
 			}
 			r.trace = c.Trace
+			r.snapshot = c.Snapshot
+			r.tls, err = c.dbTLS("")
+			if err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
+			r.pool, err = c.connPool()
+			if err != nil {
+				bk.runnerErrs[name] = err
+				return nil
+			}
 		}
 		bk.dbRunners[name] = r
 		return nil
@@ -630,16 +668,158 @@ func Var(k any, v any) Option {
 }
 
 // Func - Set function to runner.
+// The function can be used in the expressions of `test:`, `if:`, `bind:` and so on.
 func Func(k string, v any) Option {
 	return func(bk *book) error {
 		if bk == nil {
 			return ErrNilBook
 		}
+		if k == "" {
+			return errors.New("invalid func: name is empty")
+		}
+		if lo.Contains(reservedStoreRootKeys, k) {
+			return fmt.Errorf("invalid func: %q is reserved", k)
+		}
 		bk.funcs[k] = v
 		return nil
 	}
 }
 
+// FakerSeed - Set the seed of the built-in function `faker` to generate the same fake data.
+func FakerSeed(seed int64) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.funcs["faker"] = builtin.NewFakerWithSeed(seed)
+		return nil
+	}
+}
+
+// EnvFile - Load dotenv files to expand environment variables ( `${VAR}` ) in runbooks.
+// Environment variables that are already set are not overridden, and the loaded values are not set to the environment variables of the process.
+// EnvFile should be specified before Book.
+func EnvFile(paths ...string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if bk.dotenv == nil {
+			bk.dotenv = map[string]string{}
+		}
+		return loadEnvFiles(bk.dotenv, "", paths...)
+	}
+}
+
+// dotenv - Environment variables loaded by EnvFile of the parent runbook.
+func dotenv(envs map[string]string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if len(envs) == 0 {
+			return nil
+		}
+		if bk.dotenv == nil {
+			bk.dotenv = map[string]string{}
+		}
+		for k, v := range envs {
+			if _, ok := bk.dotenv[k]; ok {
+				continue
+			}
+			bk.dotenv[k] = v
+		}
+		return nil
+	}
+}
+
+// LoadStore - Load the store file dumped by DumpStore to seed the vars and the values bound by `bind:`.
+// The vars assigned by `vars:`, `consts:` or Var take precedence.
+func LoadStore(p string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if p == "" {
+			return nil
+		}
+		m, err := loadStore(p)
+		if err != nil {
+			return err
+		}
+		bk.storeSeed = m
+		return nil
+	}
+}
+
+// StartStep - Run the steps from the specified step ( index, key of map-form steps or `name:` of list-form steps ).
+func StartStep(s string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.startStep = s
+		return nil
+	}
+}
+
+// EndStep - Run the steps up to the specified step ( index, key of map-form steps or `name:` of list-form steps ).
+func EndStep(s string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.endStep = s
+		return nil
+	}
+}
+
+// RunStep - Run only the specified steps ( index, key of map-form steps or `name:` of list-form steps ).
+// It can be used together with StartStep and EndStep.
+func RunStep(steps ...string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		for _, s := range steps {
+			if s == "" {
+				continue
+			}
+			bk.runSteps = append(bk.runSteps, s)
+		}
+		return nil
+	}
+}
+
+// ReplaySteps - Load the results of the steps dumped by DumpSteps and replay them instead of running the steps before the selected steps.
+func ReplaySteps(p string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if p == "" {
+			return nil
+		}
+		m, err := loadReplaySteps(p)
+		if err != nil {
+			return err
+		}
+		bk.replaySteps = m
+		return nil
+	}
+}
+
+// Secrets - Set var names or expression paths whose values are masked in debug output, error messages and captures.
+func Secrets(paths ...string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.secrets = append(bk.secrets, paths...)
+		return nil
+	}
+}
+
 // Debug - Enable debug output.
 func Debug(debug bool) Option {
 	return func(bk *book) error {
@@ -691,6 +871,20 @@ func FailFast(enable bool) Option {
 	}
 }
 
+// IncludeMaxDepth - Set the max depth of nested includes. 0 means unlimited.
+func IncludeMaxDepth(max int) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if max < 0 {
+			return fmt.Errorf("invalid include max depth: %d", max)
+		}
+		bk.includeMaxDepth = max
+		return nil
+	}
+}
+
 // SkipIncluded - Skip running the included step by itself.
 func SkipIncluded(enable bool) Option {
 	return func(bk *book) error {
@@ -741,6 +935,32 @@ func Trace(enable bool) Option {
 	}
 }
 
+// UpdateSnapshots - Create or update the snapshot files of `snapshot:` instead of comparing.
+func UpdateSnapshots(enable bool) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if !bk.updateSnapshots {
+			bk.updateSnapshots = enable
+		}
+		return nil
+	}
+}
+
+// SoftAssertions - Evaluate all the conditions joined by `&&` in `test:` and report every failure instead of stopping at the first one.
+func SoftAssertions(enable bool) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if !bk.softAssertions {
+			bk.softAssertions = enable
+		}
+		return nil
+	}
+}
+
 // HTTPOpenApi3 - Set the path of OpenAPI Document for HTTP runners.
 // Deprecated: Use HTTPOpenApi3s instead.
 func HTTPOpenApi3(l string) Option {
@@ -968,6 +1188,18 @@ func RunConcurrent(enable bool, max int) Option { //nostyle:repetition
 	}
 }
 
+// SharedStore - Enable the store ( `shared.*` ) shared across runbooks in one RunN.
+// The values bound to `shared` by one runbook can be read by later runbooks.
+func SharedStore(enable bool) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.sharedStore = enable
+		return nil
+	}
+}
+
 // RunRandom - Run the specified number of runbooks at random. Sometimes the same runbook is run multiple times.
 func RunRandom(n int) Option { //nostyle:repetition
 	return func(bk *book) error {
@@ -1048,7 +1280,7 @@ func bookWithStore(path string, store map[string]any) Option {
 		if bk == nil {
 			return ErrNilBook
 		}
-		loaded, err := loadBook(path, store)
+		loaded, err := loadBook(path, store, bk.lookupEnv)
 		if err != nil {
 			return err
 		}
@@ -1063,12 +1295,28 @@ func setupBuiltinFunctions(opts ...Option) []Option {
 		// NOTE: Please add here the built-in functions you want to enable.
 		Func("url", func(v string) *url.URL { return builtin.Url(v) }),
 		Func("urlencode", url.QueryEscape),
+		Func("urldecode", url.QueryUnescape),
+		Func("toBase64URL", builtin.ToBase64URL),
+		Func("fromBase64URL", builtin.FromBase64URL),
+		Func("toHex", builtin.ToHex),
+		Func("fromHex", builtin.FromHex),
+		Func("toQuotedPrintable", builtin.ToQuotedPrintable),
+		Func("fromQuotedPrintable", builtin.FromQuotedPrintable),
 		Func("base64encode", func(v any) string { panic("base64encode() is deprecated. Use toBase64() instead.") }),
 		Func("base64decode", func(v any) string { panic("base64decode() is deprecated. Use fromBase64() instead.") }),
 		Func("bool", func(v any) bool { return cast.ToBool(v) }),
 		Func("time", builtin.Time),
+		Func("approx", builtin.Approx),
+		Func("within", builtin.Within),
 		Func("compare", builtin.Compare),
 		Func("diff", builtin.Diff),
+		Func("changes", builtin.Changes),
+		Func("matchObject", builtin.MatchObject),
+		Func("subsetOf", builtin.SubsetOf),
+		Func("containsAll", builtin.ContainsAll),
+		Func("protoEqual", builtin.ProtoEqual),
+		Func("imageDiff", builtin.ImageDiff),
+		Func("matchImage", builtin.MatchImage),
 		Func("intersect", builtin.Intersect),
 		Func("pick", builtin.Pick),
 		Func("omit", builtin.Omit),
@@ -1089,6 +1337,29 @@ func setupBuiltinFunctions(opts ...Option) []Option {
 		Func("basename", filepath.Base),
 		Func("faker", builtin.NewFaker()),
 		Func("json", builtin.NewJSON()),
+		Func("fromYAML", builtin.FromYAML),
+		Func("toYAML", builtin.ToYAML),
+		Func("fromTOML", builtin.FromTOML),
+		Func("toTOML", builtin.ToTOML),
+		Func("re", builtin.NewRegexp()),
+		Func("jq", builtin.JQ),
+		Func("xpath", builtin.XPath),
+		Func("css", builtin.CSS),
+		Func("uuid", builtin.UUID),
+		Func("uuidv7", builtin.UUIDv7),
+		Func("ulid", builtin.ULID),
+		Func("nanoid", builtin.NanoID),
+		Func("md5", builtin.MD5),
+		Func("sha1", builtin.SHA1),
+		Func("sha256", builtin.SHA256),
+		Func("sha512", builtin.SHA512),
+		Func("hmac", builtin.HMAC),
+		Func("sign", builtin.Sign),
+		Func("verify", builtin.Verify),
+		Func("jwt", builtin.NewJWT()),
+		Func(fileFuncName, newFileFunc("")),
+		Func(jsonschemaFuncName, newJSONSchemaFunc("")),
+		Func(fixtureFuncName, newFixtureFunc("", nil)),
 	},
 		opts...,
 	)
@@ -1104,6 +1375,37 @@ func included(included bool) Option {
 	}
 }
 
+// includeSteps - Leave only the steps of the keys in the book.
+func includeSteps(keys []string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		if !bk.useMap {
+			return fmt.Errorf("invalid include step: the steps of %s must be specified as map to include the step", bk.path)
+		}
+		var (
+			rawSteps []map[string]any
+			stepKeys []string
+		)
+		for i, k := range bk.stepKeys {
+			if !lo.Contains(keys, k) {
+				continue
+			}
+			rawSteps = append(rawSteps, bk.rawSteps[i])
+			stepKeys = append(stepKeys, k)
+		}
+		for _, k := range keys {
+			if !lo.Contains(stepKeys, k) {
+				return fmt.Errorf("invalid include step: %s is not found in %s", k, bk.path)
+			}
+		}
+		bk.rawSteps = rawSteps
+		bk.stepKeys = stepKeys
+		return nil
+	}
+}
+
 // Books - Load multiple runbooks.
 func Books(pathp string) ([]Option, error) {
 	paths, err := fetchPaths(pathp)
@@ -1117,6 +1419,37 @@ func Books(pathp string) ([]Option, error) {
 	return opts, nil
 }
 
+// removeRunner removes the runner of the name from the book.
+func removeRunner(name string) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		delete(bk.runners, name)
+		delete(bk.httpRunners, name)
+		delete(bk.dbRunners, name)
+		delete(bk.grpcRunners, name)
+		delete(bk.cdpRunners, name)
+		delete(bk.sshRunners, name)
+		delete(bk.runnerErrs, name)
+		return nil
+	}
+}
+
+// overrideRunner overrides the runner of the name with the runner config.
+func overrideRunner(name string, v any) Option {
+	return func(bk *book) error {
+		if bk == nil {
+			return ErrNilBook
+		}
+		bk.runners[name] = v
+		if err := bk.parseRunner(name, v); err != nil {
+			return fmt.Errorf("invalid runner %s: %w", name, err)
+		}
+		return nil
+	}
+}
+
 func runnHTTPRunner(name string, r *httpRunner) Option {
 	return func(bk *book) error {
 		if bk == nil {
@@ -0,0 +1,435 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc/status"
+)
+
+const parallelRunnerKey = "parallel"
+
+type parallelRunner struct {
+	// operators - Operators of the child steps of the last run
+	operators []*operator
+	mu        sync.Mutex
+}
+
+type parallelConfig struct {
+	// keys - Keys of the child steps ( indexes when the child steps are specified as list )
+	keys  []string
+	steps []map[string]any
+	// useMap - The child steps are specified as map
+	useMap bool
+}
+
+func newParallelRunner() *parallelRunner {
+	return &parallelRunner{}
+}
+
+func parseParallelConfig(v any) (*parallelConfig, error) {
+	return parseChildSteps(parallelRunnerKey, v)
+}
+
+// parseChildSteps parses the child steps of the runner ( e.g. parallel, group ).
+func parseChildSteps(runnerKey string, v any) (*parallelConfig, error) {
+	c := &parallelConfig{}
+	switch vv := v.(type) {
+	case []any:
+		for i, sv := range vv {
+			sm, ok := sv.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s steps[%d]: %v", runnerKey, i, sv)
+			}
+			c.keys = append(c.keys, strconv.Itoa(i))
+			c.steps = append(c.steps, sm)
+		}
+	case map[string]any:
+		c.useMap = true
+		for k := range vv {
+			c.keys = append(c.keys, k)
+		}
+		sort.Strings(c.keys)
+		for _, k := range c.keys {
+			sm, ok := vv[k].(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s steps.%s: %v", runnerKey, k, vv[k])
+			}
+			c.steps = append(c.steps, sm)
+		}
+	default:
+		return nil, fmt.Errorf("invalid %s steps: %v", runnerKey, v)
+	}
+	if len(c.steps) == 0 {
+		return nil, fmt.Errorf("invalid %s steps: no steps", runnerKey)
+	}
+	for i, sm := range c.steps {
+		if err := validateStepKeys(sm); err != nil {
+			return nil, fmt.Errorf("invalid %s %s. %w: %v", runnerKey, c.stepName(i), err, sm)
+		}
+	}
+	return c, nil
+}
+
+func (c *parallelConfig) stepName(i int) string {
+	if c.useMap {
+		return fmt.Sprintf("steps.%s", c.keys[i])
+	}
+	return fmt.Sprintf("steps[%s]", c.keys[i])
+}
+
+// Run runs the child steps concurrently and records the results of them as `steps`.
+func (rnr *parallelRunner) Run(ctx context.Context, s *step) error {
+	o := s.parent
+	c := s.parallelConfig
+	cs := newSyncCapturers(o.capturers)
+	oos := make([]*operator, len(c.steps))
+	for i, sm := range c.steps {
+		oo, err := o.newParallelOperator(s, cs)
+		if err != nil {
+			return err
+		}
+		// AppendStep deletes the sections from the map
+		cp := make(map[string]any, len(sm))
+		for k, v := range sm {
+			cp[k] = v
+		}
+		if err := oo.AppendStep(s.idx, c.keys[i], cp); err != nil {
+			return fmt.Errorf("invalid parallel %s: %w", c.stepName(i), err)
+		}
+		oos[i] = oo
+	}
+	rnr.mu.Lock()
+	rnr.operators = oos
+	rnr.mu.Unlock()
+
+	results := make([]map[string]any, len(oos))
+	errs := make([]error, len(oos))
+	var wg sync.WaitGroup
+	for i, oo := range oos {
+		wg.Add(1)
+		go func(i int, oo *operator) {
+			defer wg.Done()
+			results[i], errs[i] = oo.runParallelStep(ctx, s.idx)
+		}(i, oo)
+	}
+	wg.Wait()
+
+	var rerr error
+	for i, err := range errs {
+		if err != nil {
+			rerr = errors.Join(rerr, fmt.Errorf("%s: %w", c.stepName(i), err))
+		}
+	}
+	if c.useMap {
+		steps := map[string]any{}
+		for i, k := range c.keys {
+			steps[k] = results[i]
+		}
+		o.record(map[string]any{storeRootKeySteps: steps})
+	} else {
+		steps := make([]any, 0, len(results))
+		for _, r := range results {
+			steps = append(steps, r)
+		}
+		o.record(map[string]any{storeRootKeySteps: steps})
+	}
+	return rerr
+}
+
+// terminateBackgrounds terminates the processes started in the background by the child steps.
+func (rnr *parallelRunner) terminateBackgrounds() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
+	var errs error
+	for _, oo := range rnr.operators {
+		errs = errors.Join(errs, oo.terminateBackgrounds())
+	}
+	return errs
+}
+
+// newParallelOperator creates the operator to run a child step of parallel.
+// The operator has the copy of the store recorded until the parent step, so that the child step can refer to the values in the same way as other steps.
+func (o *operator) newParallelOperator(parent *step, cs capturers) (*operator, error) {
+	oo, err := o.newConcurrentOperator(parent, cs)
+	if err != nil {
+		return nil, err
+	}
+	oo.store = o.store.copyUntil(parent.idx)
+	// Steps before the parent step are required to record the result of the child step as mapped
+	oo.steps = append(make([]*step, 0, parent.idx+1), o.steps[:parent.idx]...)
+	return oo, nil
+}
+
+// newConcurrentOperator creates the operator that runs a step of the operator concurrently with other steps.
+func (o *operator) newConcurrentOperator(parent *step, cs capturers, opts ...Option) (*operator, error) {
+	oo, err := o.newNestedOperator(parent, opts...)
+	if err != nil {
+		return nil, err
+	}
+	oo.id = o.id
+	oo.desc = o.desc
+	oo.bookPath = o.bookPath
+	oo.root = o.root
+	oo.included = o.included
+	oo.interval = o.interval
+	oo.useMap = o.useMap
+	oo.templates = o.templates
+	oo.labels = o.labels
+	oo.runLabels = o.runLabels
+	oo.capturers = cs
+	return oo, nil
+}
+
+// runParallelStep runs the child step appended to the operator, and returns the recorded values.
+func (o *operator) runParallelStep(ctx context.Context, idx int) (map[string]any, error) {
+	return o.runChildStep(ctx, idx, o.steps[len(o.steps)-1])
+}
+
+// runChildStep runs the step, and returns the recorded values.
+func (o *operator) runChildStep(ctx context.Context, idx int, s *step) (map[string]any, error) {
+	err := o.runStep(ctx, idx, s)
+	s.setResult(err)
+	switch {
+	case errors.Is(errStepSkiped, err):
+		o.recordNotRun(idx)
+		if err := o.recordToLatest(storeStepKeyOutcome, resultSkipped); err != nil {
+			return nil, err
+		}
+		err = nil
+	case err != nil:
+		o.recordNotRun(idx)
+		if rerr := o.recordToLatest(storeStepKeyOutcome, resultFailure); rerr != nil {
+			return nil, errors.Join(err, rerr)
+		}
+		if s.force {
+			err = nil
+		}
+	default:
+		if err := o.recordToLatest(storeStepKeyOutcome, resultSuccess); err != nil {
+			return nil, err
+		}
+	}
+	return o.store.latest(), err
+}
+
+// terminateBackgrounds terminates the processes started in the background by the steps.
+func (o *operator) terminateBackgrounds() error {
+	var errs error
+	for _, s := range o.steps {
+		if s.parent != o {
+			// Steps of the parent operator ( see newParallelOperator )
+			continue
+		}
+		if s.execRunner != nil {
+			errs = errors.Join(errs, s.execRunner.terminateBackgrounds())
+		}
+		if s.parallelRunner != nil {
+			errs = errors.Join(errs, s.parallelRunner.terminateBackgrounds())
+		}
+		if s.groupRunner != nil {
+			errs = errors.Join(errs, s.groupRunner.terminateBackgrounds())
+		}
+	}
+	return errs
+}
+
+// syncCapturer serializes the calls to the capturer from the child steps of parallel.
+type syncCapturer struct {
+	c  Capturer
+	mu *sync.Mutex
+}
+
+func newSyncCapturers(cs capturers) capturers {
+	mu := &sync.Mutex{}
+	scs := make(capturers, 0, len(cs))
+	for _, c := range cs {
+		scs = append(scs, &syncCapturer{c: c, mu: mu})
+	}
+	return scs
+}
+
+func (c *syncCapturer) CaptureStart(trs Trails, bookPath, desc string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureStart(trs, bookPath, desc)
+}
+
+func (c *syncCapturer) CaptureResult(trs Trails, result *RunResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureResult(trs, result)
+}
+
+func (c *syncCapturer) CaptureEnd(trs Trails, bookPath, desc string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureEnd(trs, bookPath, desc)
+}
+
+func (c *syncCapturer) CaptureResultByStep(trs Trails, result *RunResult) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureResultByStep(trs, result)
+}
+
+func (c *syncCapturer) CaptureHTTPRequest(name string, req *http.Request) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureHTTPRequest(name, req)
+}
+
+func (c *syncCapturer) CaptureHTTPResponse(name string, res *http.Response) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureHTTPResponse(name, res)
+}
+
+func (c *syncCapturer) CaptureGRPCStart(name string, typ GRPCType, service, method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureGRPCStart(name, typ, service, method)
+}
+
+func (c *syncCapturer) CaptureGRPCRequestHeaders(h map[string][]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureGRPCRequestHeaders(h)
+}
+
+func (c *syncCapturer) CaptureGRPCRequestMessage(m map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureGRPCRequestMessage(m)
+}
+
+func (c *syncCapturer) CaptureGRPCResponseStatus(s *status.Status) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureGRPCResponseStatus(s)
+}
+
+func (c *syncCapturer) CaptureGRPCResponseHeaders(h map[string][]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureGRPCResponseHeaders(h)
+}
+
+func (c *syncCapturer) CaptureGRPCResponseMessage(m map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureGRPCResponseMessage(m)
+}
+
+func (c *syncCapturer) CaptureGRPCResponseTrailers(t map[string][]string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureGRPCResponseTrailers(t)
+}
+
+func (c *syncCapturer) CaptureGRPCClientClose() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureGRPCClientClose()
+}
+
+func (c *syncCapturer) CaptureGRPCEnd(name string, typ GRPCType, service, method string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureGRPCEnd(name, typ, service, method)
+}
+
+func (c *syncCapturer) CaptureCDPStart(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureCDPStart(name)
+}
+
+func (c *syncCapturer) CaptureCDPAction(a CDPAction) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureCDPAction(a)
+}
+
+func (c *syncCapturer) CaptureCDPResponse(a CDPAction, res map[string]any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureCDPResponse(a, res)
+}
+
+func (c *syncCapturer) CaptureCDPEnd(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureCDPEnd(name)
+}
+
+func (c *syncCapturer) CaptureSSHCommand(command string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureSSHCommand(command)
+}
+
+func (c *syncCapturer) CaptureSSHStdout(stdout string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureSSHStdout(stdout)
+}
+
+func (c *syncCapturer) CaptureSSHStderr(stderr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureSSHStderr(stderr)
+}
+
+func (c *syncCapturer) CaptureDBStatement(name string, stmt string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureDBStatement(name, stmt)
+}
+
+func (c *syncCapturer) CaptureDBResponse(name string, res *DBResponse) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureDBResponse(name, res)
+}
+
+func (c *syncCapturer) CaptureExecCommand(command, shell string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureExecCommand(command, shell)
+}
+
+func (c *syncCapturer) CaptureExecStdin(stdin string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureExecStdin(stdin)
+}
+
+func (c *syncCapturer) CaptureExecStdout(stdout string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureExecStdout(stdout)
+}
+
+func (c *syncCapturer) CaptureExecStderr(stderr string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.CaptureExecStderr(stderr)
+}
+
+func (c *syncCapturer) SetCurrentTrails(trs Trails) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.c.SetCurrentTrails(trs)
+}
+
+func (c *syncCapturer) Errs() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.c.Errs()
+}
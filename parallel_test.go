@@ -0,0 +1,148 @@
+package runn
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestParallel(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	tests := []struct {
+		book string
+	}{
+		{"testdata/book/parallel.yml"},
+		{"testdata/book/parallel_map.yml"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			o, err := New(Book(tt.book))
+			if err != nil {
+				t.Fatal(err)
+			}
+			start := time.Now()
+			if err := o.Run(ctx); err != nil {
+				t.Fatal(err)
+			}
+			// Each child step takes 0.5 sec
+			if spent := time.Since(start); spent > 1*time.Second {
+				t.Errorf("the child steps should run concurrently: spent %v", spent)
+			}
+		})
+	}
+}
+
+func TestParallelFailure(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.AppendStep(0, "", map[string]any{
+		"parallel": []any{
+			map[string]any{
+				"exec": map[string]any{"command": "exit 1"},
+				"test": "current.exit_code == 0",
+			},
+			map[string]any{
+				"exec": map[string]any{"command": "echo ok"},
+			},
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err == nil {
+		t.Error("want error")
+	}
+	steps, ok := o.store.steps[0]["steps"].([]any)
+	if !ok || len(steps) != 2 {
+		t.Fatalf("invalid steps: %v", o.store.steps[0])
+	}
+	if got := steps[0].(map[string]any)["outcome"]; got != resultFailure {
+		t.Errorf("got %v\nwant %v", got, resultFailure)
+	}
+	if got := steps[1].(map[string]any)["stdout"]; got != "ok\n" {
+		t.Errorf("got %v\nwant %v", got, "ok\n")
+	}
+}
+
+func TestParallelDB(t *testing.T) {
+	ctx := context.Background()
+	db, dsn := testutil.SQLite(t)
+	if _, err := db.Exec("CREATE TABLE users (id INTEGER PRIMARY KEY AUTOINCREMENT, username TEXT NOT NULL);"); err != nil {
+		t.Fatal(err)
+	}
+	// The DB runner is shared by the child steps, and connects lazily in them
+	o, err := New(Runner("db", dsn))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var children []any
+	for i := 0; i < 4; i++ {
+		children = append(children, map[string]any{
+			"db":   map[string]any{"query": "SELECT COUNT(*) AS c FROM users;"},
+			"test": "current.rows[0].c == 0",
+		})
+	}
+	if err := o.AppendStep(0, "", map[string]any{"parallel": children}); err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+	steps, ok := o.store.steps[0]["steps"].([]any)
+	if !ok || len(steps) != len(children) {
+		t.Fatalf("invalid steps: %v", o.store.steps[0])
+	}
+}
+
+func TestParseParallelConfig(t *testing.T) {
+	tests := []struct {
+		in       any
+		wantKeys []string
+		wantErr  bool
+	}{
+		{[]any{map[string]any{"test": true}, map[string]any{"test": true}}, []string{"0", "1"}, false},
+		{map[string]any{"b": map[string]any{"test": true}, "a": map[string]any{"test": true}}, []string{"a", "b"}, false},
+		{[]any{}, nil, true},
+		{[]any{"test"}, nil, true},
+		{"test", nil, true},
+		{[]any{map[string]any{"req": map[string]any{}, "exec": map[string]any{}}}, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseParallelConfig(tt.in)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if len(got.keys) != len(tt.wantKeys) {
+			t.Fatalf("got %v\nwant %v", got.keys, tt.wantKeys)
+		}
+		for i := range got.keys {
+			if got.keys[i] != tt.wantKeys[i] {
+				t.Errorf("got %v\nwant %v", got.keys, tt.wantKeys)
+			}
+		}
+	}
+}
@@ -1,12 +1,17 @@
 package runn
 
 import (
+	"errors"
 	"fmt"
+	"math"
 	"net/http"
 	"regexp"
+	"runtime"
 	"strings"
 	"time"
 
+	"github.com/dustin/go-humanize"
+	"github.com/goccy/go-json"
 	"github.com/goccy/go-yaml"
 	"github.com/k1LoW/duration"
 	"google.golang.org/grpc/metadata"
@@ -114,18 +119,52 @@ func parseDBQuery(v map[string]any) (*dbQuery, error) {
 	if err != nil {
 		return nil, err
 	}
-	if len(v) != 1 {
-		return nil, fmt.Errorf("invalid query: %s", string(part))
+	for k := range v {
+		switch k {
+		case "query", "insert", "stream", "trace", "timeout", "expect":
+		default:
+			return nil, fmt.Errorf("invalid query: %s", string(part))
+		}
 	}
 	s, ok := v["query"]
-	if !ok {
+	i, iok := v["insert"]
+	switch {
+	case ok && !iok:
+		stmt, ok := s.(string)
+		if !ok || strings.Trim(stmt, " ") == "" {
+			return nil, fmt.Errorf("invalid query: %s", string(part))
+		}
+		q.stmt = strings.Trim(stmt, " \n")
+	case !ok && iok:
+		q.insert, err = parseDBInsert(i)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query: %s: %w", string(part), err)
+		}
+	default:
 		return nil, fmt.Errorf("invalid query: %s", string(part))
 	}
-	stmt, ok := s.(string)
-	if !ok || strings.Trim(stmt, " ") == "" {
-		return nil, fmt.Errorf("invalid query: %s", string(part))
+	sm, ok := v["stream"]
+	if ok {
+		switch v := sm.(type) {
+		case bool:
+			if v {
+				q.stream = &dbStream{}
+			}
+		case map[string]any:
+			q.stream = &dbStream{}
+			e, ok := v["each"]
+			if ok {
+				q.stream.each, ok = e.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid query: %s", string(part))
+				}
+			}
+		default:
+			if v != nil {
+				return nil, fmt.Errorf("invalid query: %s", string(part))
+			}
+		}
 	}
-	q.stmt = strings.Trim(stmt, " \n")
 	tm, ok := v["trace"]
 	if ok {
 		switch v := tm.(type) {
@@ -137,9 +176,84 @@ func parseDBQuery(v map[string]any) (*dbQuery, error) {
 			}
 		}
 	}
+	ex, ok := v["expect"]
+	if ok {
+		if q.insert != nil || q.stream != nil {
+			return nil, fmt.Errorf("invalid query: %s: expect cannot be used with insert or stream", string(part))
+		}
+		q.expect, err = parseDBExpect(ex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query: %s: %w", string(part), err)
+		}
+	}
+	to, ok := v["timeout"]
+	if ok {
+		tos, ok := to.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid query: %s", string(part))
+		}
+		q.timeout, err = duration.Parse(tos)
+		if err != nil {
+			return nil, fmt.Errorf("invalid query: %s: %w", string(part), err)
+		}
+	}
 	return q, nil
 }
 
+func parseDBInsert(v any) (*dbInsert, error) {
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("invalid insert: %v", v)
+	}
+	in := &dbInsert{}
+	for k := range m {
+		switch k {
+		case "table", "rows":
+		default:
+			return nil, fmt.Errorf("invalid insert key: %s", k)
+		}
+	}
+	in.table, ok = m["table"].(string)
+	if !ok || in.table == "" {
+		return nil, errors.New("table is required")
+	}
+	rows, ok := m["rows"].([]any)
+	if !ok {
+		return nil, fmt.Errorf("rows should be a list of maps: %v", m["rows"])
+	}
+	for _, r := range rows {
+		row, ok := r.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("rows should be a list of maps: %v", r)
+		}
+		in.rows = append(in.rows, row)
+	}
+	return in, nil
+}
+
+// parseDBExpect parses the expected table declared inline ( list of maps ) or in a CSV file ( path ).
+func parseDBExpect(v any) (*dbExpect, error) {
+	switch vv := v.(type) {
+	case string:
+		if vv == "" {
+			return nil, errors.New("path of expected table is empty")
+		}
+		return &dbExpect{path: vv}, nil
+	case []any:
+		e := &dbExpect{rows: []map[string]any{}}
+		for _, r := range vv {
+			row, ok := r.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("expected table should be a list of maps: %v", r)
+			}
+			e.rows = append(e.rows, row)
+		}
+		return e, nil
+	default:
+		return nil, fmt.Errorf("invalid expected table: %v", v)
+	}
+}
+
 func parseGrpcRequest(v map[string]any, expand func(any) (any, error)) (*grpcRequest, error) {
 	v = trimDelimiter(v)
 	req := &grpcRequest{
@@ -389,18 +503,56 @@ func parseExecCommand(v map[string]any) (*execCommand, error) {
 	if !ok {
 		return nil, fmt.Errorf("invalid command: %s", string(part))
 	}
-	command, ok := cs.(string)
-	if !ok || strings.Trim(command, " ") == "" {
+	if variants, ok := cs.(map[string]any); ok {
+		// Commands for each platform
+		cs, err = execCommandForOS(variants, runtime.GOOS)
+		if err != nil {
+			return nil, fmt.Errorf("invalid command: %s: %w", string(part), err)
+		}
+	}
+	switch command := cs.(type) {
+	case string:
+		if strings.Trim(command, " ") == "" {
+			return nil, fmt.Errorf("invalid command: %s", string(part))
+		}
+		c.command = strings.Trim(command, " \n")
+	case []any:
+		// Execute the command directly without shell
+		if len(command) == 0 {
+			return nil, fmt.Errorf("invalid command: %s", string(part))
+		}
+		for _, a := range command {
+			if a == nil {
+				return nil, fmt.Errorf("invalid command: %s", string(part))
+			}
+			c.args = append(c.args, fmt.Sprint(a))
+		}
+		if _, ok := v["shell"]; ok {
+			return nil, fmt.Errorf("invalid command: %s: shell cannot be used with the list of arguments", string(part))
+		}
+	default:
 		return nil, fmt.Errorf("invalid command: %s", string(part))
 	}
-	c.command = strings.Trim(command, " \n")
 	is, ok := v["stdin"]
 	if ok {
-		stdin, ok := is.(string)
-		if !ok {
-			return nil, fmt.Errorf("invalid stdin: %s", string(part))
+		switch stdin := is.(type) {
+		case string:
+			if strings.HasPrefix(stdin, execStdinFileScheme) {
+				c.stdinFile = strings.TrimPrefix(stdin, execStdinFileScheme)
+			} else {
+				c.stdin = stdin
+			}
+		case nil:
+		case map[string]any, []any:
+			// value from the store
+			b, err := json.Marshal(stdin)
+			if err != nil {
+				return nil, fmt.Errorf("invalid stdin: %s: %w", string(part), err)
+			}
+			c.stdin = string(b)
+		default:
+			c.stdin = fmt.Sprint(stdin)
 		}
-		c.stdin = stdin
 	}
 	ss, ok := v["shell"]
 	if ok {
@@ -410,9 +562,218 @@ func parseExecCommand(v map[string]any) (*execCommand, error) {
 		}
 		c.shell = sh
 	}
+	es, ok := v["env"]
+	if ok {
+		env, ok := es.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid env: %s", string(part))
+		}
+		c.env = map[string]string{}
+		for k, ev := range env {
+			if ev == nil {
+				c.env[k] = ""
+				continue
+			}
+			c.env[k] = fmt.Sprint(ev)
+		}
+	}
+	bs, ok := v["background"]
+	if ok {
+		bg, ok := bs.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid background: %s", string(part))
+		}
+		c.background = bg
+	}
+	tts, ok := v["tty"]
+	if ok {
+		tty, ok := tts.(bool)
+		if !ok {
+			return nil, fmt.Errorf("invalid tty: %s", string(part))
+		}
+		c.tty = tty
+	}
+	ts, ok := v["timeout"]
+	if ok {
+		tss, ok := ts.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid timeout: %s", string(part))
+		}
+		c.timeout, err = duration.Parse(tss)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timeout: %s: %w", string(part), err)
+		}
+	}
+	sigs, ok := v["signal"]
+	if ok {
+		sig, ok := sigs.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid signal: %s", string(part))
+		}
+		c.signal, err = parseSignal(sig)
+		if err != nil {
+			return nil, fmt.Errorf("invalid signal: %s: %w", string(part), err)
+		}
+	}
+	gps, ok := v["gracePeriod"]
+	if ok {
+		gp, ok := gps.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid gracePeriod: %s", string(part))
+		}
+		c.gracePeriod, err = duration.Parse(gp)
+		if err != nil {
+			return nil, fmt.Errorf("invalid gracePeriod: %s: %w", string(part), err)
+		}
+	}
+	ds, ok := v["dir"]
+	if ok {
+		dir, ok := ds.(string)
+		if !ok {
+			return nil, fmt.Errorf("invalid dir: %s", string(part))
+		}
+		c.dir = dir
+	}
+	for _, k := range []struct {
+		key  string
+		dest []*int64
+	}{
+		{"maxOutputSize", []*int64{&c.maxStdoutSize, &c.maxStderrSize}},
+		{"maxStdoutSize", []*int64{&c.maxStdoutSize}},
+		{"maxStderrSize", []*int64{&c.maxStderrSize}},
+	} {
+		ms, ok := v[k.key]
+		if !ok {
+			continue
+		}
+		size, err := parseByteSize(ms)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %s: %w", k.key, string(part), err)
+		}
+		for _, d := range k.dest {
+			*d = size
+		}
+	}
+	rs, ok := v["retry"]
+	if ok {
+		if c.background {
+			return nil, fmt.Errorf("invalid retry: %s: retry cannot be used with background", string(part))
+		}
+		c.retry, err = parseRetry(rs, execRetryDefaultUntil)
+		if err != nil {
+			return nil, fmt.Errorf("invalid retry: %s: %w", string(part), err)
+		}
+	}
+	ws, ok := v["waitFor"]
+	if ok {
+		c.waitFor, err = parseExecWaitFor(ws)
+		if err != nil {
+			return nil, fmt.Errorf("invalid waitFor: %s: %w", string(part), err)
+		}
+	}
 	return c, nil
 }
 
+// parseByteSize parses the size in bytes ( e.g. 1024, 64KB, 1MiB ).
+func parseByteSize(v any) (int64, error) {
+	var size uint64
+	switch vv := v.(type) {
+	case uint64:
+		size = vv
+	case int64:
+		if vv < 0 {
+			return 0, fmt.Errorf("negative size: %d", vv)
+		}
+		size = uint64(vv)
+	case int:
+		if vv < 0 {
+			return 0, fmt.Errorf("negative size: %d", vv)
+		}
+		size = uint64(vv)
+	case string:
+		var err error
+		size, err = humanize.ParseBytes(vv)
+		if err != nil {
+			return 0, err
+		}
+	default:
+		return 0, fmt.Errorf("invalid size: %v", v)
+	}
+	if size > math.MaxInt64 {
+		return 0, fmt.Errorf("too large size: %d", size)
+	}
+	return int64(size), nil
+}
+
+// execCommandForOS returns the command for goos from the commands for each platform ( e.g. linux, darwin, windows ).
+// `default` is used when there is no command for goos.
+func execCommandForOS(variants map[string]any, goos string) (any, error) {
+	for k := range variants {
+		if k != execDefaultOSKey && !execKnownOS[k] {
+			return nil, fmt.Errorf("unknown platform: %s", k)
+		}
+	}
+	if c, ok := variants[goos]; ok {
+		return c, nil
+	}
+	if c, ok := variants[execDefaultOSKey]; ok {
+		return c, nil
+	}
+	return nil, fmt.Errorf("no command for the platform: %s", goos)
+}
+
+func parseExecWaitFor(v any) (*execWaitFor, error) {
+	w := &execWaitFor{timeout: execWaitForDefaultTimeout}
+	switch vv := v.(type) {
+	case string:
+		// shorthand of match
+		re, err := regexp.Compile(vv)
+		if err != nil {
+			return nil, err
+		}
+		w.match = re
+	case map[string]any:
+		ms, mok := vv["match"]
+		cs, cok := vv["cond"]
+		if mok == cok {
+			return nil, errors.New("either match or cond is required")
+		}
+		if mok {
+			m, ok := ms.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid match: %v", ms)
+			}
+			re, err := regexp.Compile(m)
+			if err != nil {
+				return nil, err
+			}
+			w.match = re
+		}
+		if cok {
+			cond, ok := cs.(string)
+			if !ok || strings.TrimSpace(cond) == "" {
+				return nil, fmt.Errorf("invalid cond: %v", cs)
+			}
+			w.cond = cond
+		}
+		ts, ok := vv["timeout"]
+		if ok {
+			tss, ok := ts.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid timeout: %v", ts)
+			}
+			d, err := duration.Parse(tss)
+			if err != nil {
+				return nil, err
+			}
+			w.timeout = d
+		}
+	default:
+		return nil, fmt.Errorf("invalid waitFor: %v", v)
+	}
+	return w, nil
+}
+
 func parseIncludeConfig(v any) (*includeConfig, error) {
 	c := &includeConfig{vars: map[string]any{}}
 	switch vv := v.(type) {
@@ -449,6 +810,52 @@ func parseIncludeConfig(v any) (*includeConfig, error) {
 				return nil, fmt.Errorf("invalid include condig: %v", v)
 			}
 		}
+		checksum, ok := vv["checksum"]
+		if ok {
+			c.checksum, ok = checksum.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid include condig: %v", v)
+			}
+			if c.isGlob() {
+				return nil, fmt.Errorf("invalid include condig: checksum cannot be used with glob pattern: %v", v)
+			}
+		}
+		step, ok := vv["step"]
+		if ok {
+			switch sv := step.(type) {
+			case string:
+				c.stepKeys = []string{sv}
+			case []any:
+				for _, k := range sv {
+					kk, ok := k.(string)
+					if !ok {
+						return nil, fmt.Errorf("invalid include condig: %v", v)
+					}
+					c.stepKeys = append(c.stepKeys, kk)
+				}
+			default:
+				return nil, fmt.Errorf("invalid include condig: %v", v)
+			}
+		}
+		isolate, ok := vv["isolate"]
+		if ok {
+			c.isolate, ok = isolate.(bool)
+			if !ok {
+				return nil, fmt.Errorf("invalid include condig: %v", v)
+			}
+		}
+		runners, ok := vv["runners"]
+		if ok {
+			c.runners, ok = runners.(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid include condig: %v", v)
+			}
+			for k := range c.runners {
+				if err := validateRunnerKey(k); err != nil {
+					return nil, fmt.Errorf("invalid include condig: %w", err)
+				}
+			}
+		}
 		return c, nil
 	default:
 		return nil, fmt.Errorf("invalid include condig: %v", v)
@@ -2,6 +2,8 @@ package runn
 
 import (
 	"net/http"
+	"regexp"
+	"syscall"
 	"testing"
 	"time"
 
@@ -208,6 +210,117 @@ query: |
 			},
 			false,
 		},
+		{
+			`
+query: SELECT * FROM users;
+stream: true
+`,
+			&dbQuery{
+				stmt:   "SELECT * FROM users;",
+				stream: &dbStream{},
+			},
+			false,
+		},
+		{
+			`
+query: SELECT * FROM users;
+stream:
+  each: row.id > 0
+`,
+			&dbQuery{
+				stmt:   "SELECT * FROM users;",
+				stream: &dbStream{each: "row.id > 0"},
+			},
+			false,
+		},
+		{
+			`
+query: SELECT * FROM users;
+unknown: true
+`,
+			nil,
+			true,
+		},
+		{
+			`
+insert:
+  table: users
+  rows:
+    -
+      username: alice
+    -
+      username: bob
+`,
+			&dbQuery{
+				insert: &dbInsert{
+					table: "users",
+					rows: []map[string]any{
+						{"username": "alice"},
+						{"username": "bob"},
+					},
+				},
+			},
+			false,
+		},
+		{
+			`
+query: SELECT * FROM users;
+timeout: 3sec
+`,
+			&dbQuery{
+				stmt:    "SELECT * FROM users;",
+				timeout: 3 * time.Second,
+			},
+			false,
+		},
+		{
+			`
+query: SELECT * FROM users;
+expect: users.csv
+`,
+			&dbQuery{
+				stmt:   "SELECT * FROM users;",
+				expect: &dbExpect{path: "users.csv"},
+			},
+			false,
+		},
+		{
+			`
+query: SELECT * FROM users;
+stream: true
+expect: users.csv
+`,
+			nil,
+			true,
+		},
+		{
+			`
+query: SELECT * FROM users;
+timeout: invalid
+`,
+			nil,
+			true,
+		},
+		{
+			`
+query: SELECT * FROM users;
+insert:
+  table: users
+  rows: []
+`,
+			nil,
+			true,
+		},
+		{
+			`
+insert:
+  table: users
+  rows:
+    - alice
+`,
+			nil,
+			true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -225,7 +338,7 @@ query: |
 		if tt.wantErr {
 			t.Error("want error")
 		}
-		opts := cmp.AllowUnexported(dbQuery{})
+		opts := cmp.AllowUnexported(dbQuery{}, dbInsert{}, dbStream{}, dbExpect{})
 		if diff := cmp.Diff(got, tt.want, opts); diff != "" {
 			t.Error(diff)
 		}
@@ -425,6 +538,99 @@ stdin: |
 		},
 		{
 			`
+command: echo $FOO
+env:
+  FOO: foo
+  BAR: 1
+dir: path/to/dir
+`,
+			&execCommand{
+				command: "echo $FOO",
+				env:     map[string]string{"FOO": "foo", "BAR": "1"},
+				dir:     "path/to/dir",
+			},
+			false,
+		},
+		{
+			`
+command:
+  - echo
+  - hello world
+  - 1
+`,
+			&execCommand{
+				args: []string{"echo", "hello world", "1"},
+			},
+			false,
+		},
+		{
+			`
+command:
+  - echo
+shell: bash
+`,
+			nil,
+			true,
+		},
+		{
+			`
+command: ./batch
+timeout: 10sec
+signal: SIGINT
+gracePeriod: 3sec
+`,
+			&execCommand{
+				command:     "./batch",
+				timeout:     10 * time.Second,
+				signal:      syscall.SIGINT,
+				gracePeriod: 3 * time.Second,
+			},
+			false,
+		},
+		{
+			`
+command: ./batch
+signal: SIGUNKNOWN
+`,
+			nil,
+			true,
+		},
+		{
+			`
+command: ./server
+background: true
+`,
+			&execCommand{
+				command:    "./server",
+				background: true,
+			},
+			false,
+		},
+		{
+			`
+command: cat
+stdin: file://path/to/input.txt
+`,
+			&execCommand{
+				command:   "cat",
+				stdinFile: "path/to/input.txt",
+			},
+			false,
+		},
+		{
+			`
+command: jq .name
+stdin:
+  name: alice
+`,
+			&execCommand{
+				command: "jq .name",
+				stdin:   `{"name":"alice"}`,
+			},
+			false,
+		},
+		{
+			`
 stdin: |
   alice
   bob
@@ -433,6 +639,139 @@ stdin: |
 			nil,
 			true,
 		},
+		{
+			`
+command: ./server
+background: true
+waitFor: listening on :(\d+)
+`,
+			&execCommand{
+				command:    "./server",
+				background: true,
+				waitFor: &execWaitFor{
+					match:   regexp.MustCompile(`listening on :(\d+)`),
+					timeout: execWaitForDefaultTimeout,
+				},
+			},
+			false,
+		},
+		{
+			`
+command: ./server
+waitFor:
+  cond: line contains "ready"
+  timeout: 10sec
+`,
+			&execCommand{
+				command: "./server",
+				waitFor: &execWaitFor{
+					cond:    `line contains "ready"`,
+					timeout: 10 * time.Second,
+				},
+			},
+			false,
+		},
+		{
+			`
+command: ./server
+waitFor:
+  match: ready
+  cond: line contains "ready"
+`,
+			nil,
+			true,
+		},
+		{
+			`
+command: ./server
+waitFor: "("
+`,
+			nil,
+			true,
+		},
+		{
+			`
+command:
+  linux: ./server
+  macos: ./server
+`,
+			nil,
+			true,
+		},
+		{
+			`
+command: ./batch
+maxOutputSize: 1MB
+maxStderrSize: 1024
+`,
+			&execCommand{
+				command:       "./batch",
+				maxStdoutSize: 1000000,
+				maxStderrSize: 1024,
+			},
+			false,
+		},
+		{
+			`
+command: ./batch
+maxStdoutSize: large
+`,
+			nil,
+			true,
+		},
+		{
+			`
+command: curl -sf http://localhost:8080/healthz
+retry:
+  count: 10
+  interval: 500ms
+  until: 'current.stdout contains "ok"'
+`,
+			&execCommand{
+				command: "curl -sf http://localhost:8080/healthz",
+				retry: &retryConfig{
+					count:    10,
+					interval: 500 * time.Millisecond,
+					until:    `current.stdout contains "ok"`,
+				},
+			},
+			false,
+		},
+		{
+			`
+command: pg_isready
+retry: 5
+`,
+			&execCommand{
+				command: "pg_isready",
+				retry: &retryConfig{
+					count:    5,
+					interval: defaultRetryInterval,
+					until:    execRetryDefaultUntil,
+				},
+			},
+			false,
+		},
+		{
+			`
+command: ./server
+background: true
+retry: 5
+`,
+			nil,
+			true,
+		},
+		{
+			`
+command: ./interactive
+tty: true
+`,
+			&execCommand{
+				command: "./interactive",
+				tty:     true,
+			},
+			false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -450,8 +789,46 @@ stdin: |
 		if tt.wantErr {
 			t.Error("want error")
 		}
-		opts := cmp.AllowUnexported(execCommand{})
-		if diff := cmp.Diff(got, tt.want, opts); diff != "" {
+		opts := []cmp.Option{
+			cmp.AllowUnexported(execCommand{}, execWaitFor{}, retryConfig{}),
+			cmp.Comparer(func(x, y *regexp.Regexp) bool {
+				if x == nil || y == nil {
+					return x == y
+				}
+				return x.String() == y.String()
+			}),
+		}
+		if diff := cmp.Diff(got, tt.want, opts...); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestExecCommandForOS(t *testing.T) {
+	variants := map[string]any{
+		"windows": "dir",
+		"darwin":  []any{"ls", "-G"},
+		"default": "ls",
+	}
+	tests := []struct {
+		variants map[string]any
+		goos     string
+		want     any
+		wantErr  bool
+	}{
+		{variants, "windows", "dir", false},
+		{variants, "darwin", []any{"ls", "-G"}, false},
+		{variants, "linux", "ls", false},
+		{map[string]any{"windows": "dir"}, "linux", nil, true},
+		{map[string]any{"win": "dir", "default": "ls"}, "linux", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := execCommandForOS(tt.variants, tt.goos)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("got %v\nwantErr %v", err, tt.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
 			t.Error(diff)
 		}
 	}
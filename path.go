@@ -19,16 +19,18 @@ import (
 const (
 	schemeHttps  = "https"
 	schemeGitHub = "github"
+	schemeGit    = "git"
 )
 
 const (
 	prefixHttps  = schemeHttps + "://"
 	prefixGitHub = schemeGitHub + "://"
+	prefixGit    = schemeGit + "::"
 )
 
 // hasRemotePrefix returns true if the path has remote file prefix.
 func hasRemotePrefix(u string) bool {
-	return strings.HasPrefix(u, prefixHttps) || strings.HasPrefix(u, prefixGitHub)
+	return strings.HasPrefix(u, prefixHttps) || strings.HasPrefix(u, prefixGitHub) || strings.HasPrefix(u, prefixGit)
 }
 
 // ShortenPath shorten path.
@@ -57,6 +59,18 @@ func fetchPaths(pathp string) ([]string, error) {
 	var paths []string
 	listp := splitList(pathp)
 	for _, pp := range listp {
+		if strings.HasPrefix(pp, prefixGit) {
+			// git::
+			if !globalScopes.readRemote {
+				return nil, fmt.Errorf("scope error: remote file not allowed. 'read:remote' scope is required : %s", pp)
+			}
+			ps, err := fetchPathsViaGit(pp)
+			if err != nil {
+				return nil, err
+			}
+			paths = append(paths, ps...)
+			continue
+		}
 		base, pattern := doublestar.SplitPattern(filepath.ToSlash(pp))
 		switch {
 		case strings.HasPrefix(base, prefixHttps):
@@ -356,8 +370,14 @@ func readFileViaGitHub(urlstr string) ([]byte, error) {
 
 // splitList splits the path list by os.PathListSeparator while keeping schemes.
 func splitList(pathp string) []string {
-	rep := strings.NewReplacer(prefixHttps, repKey(prefixHttps), prefixGitHub, repKey(prefixGitHub))
-	per := strings.NewReplacer(repKey(prefixHttps), prefixHttps, repKey(prefixGitHub), prefixGitHub)
+	var reps, pers []string
+	// The schemes of the repository URL of git:: are also kept
+	for _, p := range []string{prefixHttps, prefixGitHub, prefixGit, "http://", "ssh://", "file://"} {
+		reps = append(reps, p, repKey(p))
+		pers = append(pers, repKey(p), p)
+	}
+	rep := strings.NewReplacer(reps...)
+	per := strings.NewReplacer(pers...)
 	var listp []string
 	for _, p := range filepath.SplitList(rep.Replace(pathp)) {
 		listp = append(listp, per.Replace(p))
@@ -367,7 +387,7 @@ func splitList(pathp string) []string {
 
 func splitKeyAndPath(kp string) (string, string) {
 	const sep = ":"
-	if !strings.Contains(kp, sep) || strings.HasPrefix(kp, prefixHttps) || strings.HasPrefix(kp, prefixGitHub) {
+	if !strings.Contains(kp, sep) || hasRemotePrefix(kp) {
 		return "", kp
 	}
 	pair := strings.SplitN(kp, sep, 2)
@@ -375,7 +395,7 @@ func splitKeyAndPath(kp string) (string, string) {
 }
 
 func repKey(in string) string {
-	return fmt.Sprintf("RUNN_%s_SCHEME", strings.TrimSuffix(strings.ToUpper(in), "://"))
+	return fmt.Sprintf("RUNN_%s_SCHEME", strings.TrimRight(strings.ToUpper(in), ":/"))
 }
 
 func unique(in []string) []string {
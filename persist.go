@@ -0,0 +1,108 @@
+package runn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/samber/lo"
+)
+
+// persistentValues returns the values of the store to be persisted ( vars and the values bound by `bind:` ).
+func (o *operator) persistentValues() map[string]any {
+	vars := map[string]any{}
+	for k, v := range o.store.vars {
+		if _, ok := v.(*lazyVar); ok {
+			// Not evaluated
+			continue
+		}
+		vars[k] = v
+	}
+	m := map[string]any{
+		storeRootKeyVars: vars,
+	}
+	for k, v := range o.store.bindVars {
+		m[k] = v
+	}
+	return m
+}
+
+// DumpStore writes the vars and the values bound by `bind:` as JSON to be loaded by LoadStore in later runs.
+func (o *operator) DumpStore(w io.Writer) error {
+	return dumpStore(w, o.persistentValues())
+}
+
+// DumpStore writes the vars and the values bound by `bind:` of the runbooks as JSON to be loaded by LoadStore in later runs.
+// The values of the later runbook take precedence.
+func (ops *operators) DumpStore(w io.Writer) error {
+	m := map[string]any{
+		storeRootKeyVars: map[string]any{},
+	}
+	for _, o := range ops.ops {
+		if o.Skipped() {
+			continue
+		}
+		for k, v := range o.persistentValues() {
+			if k != storeRootKeyVars {
+				m[k] = v
+				continue
+			}
+			for kk, vv := range v.(map[string]any) {
+				m[storeRootKeyVars].(map[string]any)[kk] = vv
+			}
+		}
+	}
+	return dumpStore(w, m)
+}
+
+func dumpStore(w io.Writer, m map[string]any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("failed to dump store: %w", err)
+	}
+	return nil
+}
+
+// loadStore loads the store file dumped by DumpStore.
+func loadStore(p string) (map[string]any, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load store %s: %w", p, err)
+	}
+	m := map[string]any{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("invalid store %s: %w", p, err)
+	}
+	if v, ok := m[storeRootKeyVars]; ok {
+		if _, ok := v.(map[string]any); !ok {
+			return nil, fmt.Errorf("invalid store %s: invalid vars: %v", p, v)
+		}
+	}
+	for k := range m {
+		if k != storeRootKeyVars && lo.Contains(reservedStoreRootKeys, k) {
+			return nil, fmt.Errorf("invalid store %s: %q is reserved", p, k)
+		}
+	}
+	return m, nil
+}
+
+// bindStoreSeed binds the values loaded by LoadStore.
+// The vars assigned by `vars:`, `consts:` or `--var` take precedence.
+func (bk *book) bindStoreSeed() map[string]any {
+	bindVars := map[string]any{}
+	for k, v := range bk.storeSeed {
+		if k != storeRootKeyVars {
+			bindVars[k] = v
+			continue
+		}
+		for kk, vv := range v.(map[string]any) {
+			if _, ok := bk.vars[kk]; ok {
+				continue
+			}
+			bk.vars[kk] = vv
+		}
+	}
+	return bindVars
+}
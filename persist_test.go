@@ -0,0 +1,110 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDumpAndLoadStore(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	const provision = `desc: Provision
+vars:
+  name: alice
+steps:
+  -
+    bind:
+      userId: 'len(vars.name) * 10'
+      user:
+        name: vars.name
+`
+	const verify = `desc: Verify
+vars:
+  name: bob
+steps:
+  -
+    test: |
+      userId == 50
+      && user.name == "alice"
+      && vars.name == "bob"
+`
+	ctx := context.Background()
+	dir := t.TempDir()
+	pp := filepath.Join(dir, "provision.yml")
+	if err := os.WriteFile(pp, []byte(provision), 0600); err != nil {
+		t.Fatal(err)
+	}
+	vp := filepath.Join(dir, "verify.yml")
+	if err := os.WriteFile(vp, []byte(verify), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	ops, err := Load(pp, Scopes(ScopeAllowReadParent))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := ops.RunN(ctx); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := ops.DumpStore(buf); err != nil {
+		t.Fatal(err)
+	}
+	sp := filepath.Join(dir, "store.json")
+	if err := os.WriteFile(sp, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := New(LoadStore(sp), Book(vp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Error(err)
+	}
+	got := map[string]any{}
+	for k, v := range o.persistentValues() {
+		got[k] = v
+	}
+	want := map[string]any{
+		"vars":   map[string]any{"name": "bob"},
+		"userId": float64(50),
+		"user":   map[string]any{"name": "alice"},
+	}
+	if diff := cmp.Diff(got, want); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestLoadStoreInvalid(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		wantErr string
+	}{
+		{"not JSON", `vars: {}`, "invalid store"},
+		{"invalid vars", `{"vars": 1}`, "invalid vars"},
+		{"reserved key", `{"steps": []}`, `"steps" is reserved`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(t.TempDir(), "store.json")
+			if err := os.WriteFile(p, []byte(tt.in), 0600); err != nil {
+				t.Fatal(err)
+			}
+			_, err := New(LoadStore(p))
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v\nwant %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package runn
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+// PlannedStep is the step planned to be run by the dry-run.
+type PlannedStep struct {
+	// BookPath - Path of the runbook
+	BookPath string `json:"book_path"`
+	// Step - Reference to the step ( e.g. steps[0], steps.login )
+	Step string `json:"step"`
+	// Desc - `desc:` of the step
+	Desc string `json:"desc,omitempty"`
+	// RunnerType - Type of the runner of the step
+	RunnerType RunnerType `json:"runner_type,omitempty"`
+	// RunnerKey - Key of the runner of the step
+	RunnerKey string `json:"runner_key,omitempty"`
+	// Method - Method of the HTTP request ( or the method of the gRPC request )
+	Method string `json:"method,omitempty"`
+	// URL - URL of the HTTP request
+	URL string `json:"url,omitempty"`
+	// Query - Query parameters of the HTTP request
+	Query url.Values `json:"query,omitempty"`
+	// Detail - Statement of the DB query, the command of the exec/SSH runner or the path of the included runbook
+	Detail string `json:"detail,omitempty"`
+	// If - `if:` of the step
+	If string `json:"if,omitempty"`
+	// Skip - The step is skipped by `skip:`, `only:` or the step selection
+	Skip bool `json:"skip,omitempty"`
+}
+
+// Plan returns the steps of the selected runbooks planned to be run, without running them ( dry-run ).
+// The variables that can be evaluated before running ( e.g. vars ) are substituted.
+// The other expressions ( e.g. the references to the results of the previous steps ) remain as they are.
+func (ops *operators) Plan() ([]*PlannedStep, error) {
+	sops, err := ops.SelectedOperators()
+	if err != nil {
+		return nil, err
+	}
+	var planned []*PlannedStep
+	for _, o := range sops {
+		p, err := o.plan()
+		if err != nil {
+			return nil, err
+		}
+		planned = append(planned, p...)
+	}
+	return planned, nil
+}
+
+func (o *operator) plan() ([]*PlannedStep, error) {
+	selected, err := o.selectSteps()
+	if err != nil {
+		return nil, err
+	}
+	store := o.store.toMap()
+	store[storeRootKeyIncluded] = o.included
+	var planned []*PlannedStep
+	for i, s := range o.steps {
+		ref := fmt.Sprintf("steps[%d]", s.idx)
+		if o.useMap {
+			ref = fmt.Sprintf("steps.%s", s.key)
+		}
+		tr := s.generateTrail()
+		p := &PlannedStep{
+			BookPath:   o.bookPath,
+			Step:       ref,
+			Desc:       s.desc,
+			RunnerType: tr.StepRunnerType,
+			RunnerKey:  s.runnerKey,
+			If:         planDetail(s.ifCond),
+			Skip:       s.skip || (o.hasOnly && !s.only) || (selected != nil && !selected[i]),
+		}
+		switch tr.StepRunnerType {
+		case RunnerTypeHTTP:
+			r, ok := expandResolvable(s.httpRequest, store).(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("invalid http request (%s %s): %v", o.bookPath, ref, s.httpRequest)
+			}
+			req, err := parseHTTPRequest(r)
+			if err != nil {
+				return nil, fmt.Errorf("invalid http request (%s %s): %w", o.bookPath, ref, err)
+			}
+			p.Method = req.method
+			p.URL = req.path
+			if s.httpRunner.endpoint != nil {
+				p.URL = strings.TrimSuffix(s.httpRunner.endpoint.String(), "/") + req.path
+				if !strings.Contains(req.path, delimStart) {
+					u, err := mergeURL(s.httpRunner.endpoint, req.path)
+					if err != nil {
+						return nil, fmt.Errorf("invalid http request (%s %s): %w", o.bookPath, ref, err)
+					}
+					p.URL = u.String()
+				}
+			}
+			if u, err := url.Parse(p.URL); err == nil && u.RawQuery != "" {
+				p.Query = u.Query()
+			}
+		case RunnerTypeGRPC:
+			for k := range s.grpcRequest {
+				p.Method = strings.TrimPrefix(k, "/")
+			}
+		case RunnerTypeDB:
+			p.Detail = planDetail(expandResolvable(s.dbQuery["query"], store))
+		case RunnerTypeSSH:
+			p.Detail = planDetail(expandResolvable(s.sshCommand["command"], store))
+		case RunnerTypeExec:
+			p.Detail = planDetail(expandResolvable(s.execCommand["command"], store))
+		case RunnerTypeInclude:
+			p.Detail = s.includeConfig.path
+		case RunnerTypeTest:
+			p.Detail = planDetail(s.testCond)
+		}
+		planned = append(planned, p)
+	}
+	return planned, nil
+}
+
+// planDetail returns the value as the single line.
+func planDetail(v any) string {
+	if v == nil {
+		return ""
+	}
+	return strings.Join(strings.Fields(fmt.Sprintf("%v", v)), " ")
+}
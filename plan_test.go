@@ -0,0 +1,48 @@
+package runn
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestPlan(t *testing.T) {
+	const bookPath = "testdata/book/dry_run.yml"
+	tests := []struct {
+		opts []Option
+		want []*PlannedStep
+	}{
+		{
+			nil,
+			[]*PlannedStep{
+				{BookPath: bookPath, Step: "steps[0]", Desc: "Login", RunnerType: RunnerTypeHTTP, RunnerKey: "req", Method: "POST", URL: "https://example.com/api/login"},
+				{BookPath: bookPath, Step: "steps[1]", RunnerType: RunnerTypeHTTP, RunnerKey: "req", Method: "GET", URL: "https://example.com/api/users?page=2&token={{ steps[0].res.body.token }}", Query: url.Values{"page": []string{"2"}, "token": []string{"{{ steps[0].res.body.token }}"}}},
+				{BookPath: bookPath, Step: "steps[2]", RunnerType: RunnerTypeExec, RunnerKey: "exec", Detail: "echo alice", If: `vars.username == "bob"`},
+				{BookPath: bookPath, Step: "steps[3]", RunnerType: RunnerTypeTest, Detail: "current.res.status == 200 && true", Skip: true},
+			},
+		},
+		{
+			[]Option{RunStep("1")},
+			[]*PlannedStep{
+				{BookPath: bookPath, Step: "steps[0]", Desc: "Login", RunnerType: RunnerTypeHTTP, RunnerKey: "req", Method: "POST", URL: "https://example.com/api/login", Skip: true},
+				{BookPath: bookPath, Step: "steps[1]", RunnerType: RunnerTypeHTTP, RunnerKey: "req", Method: "GET", URL: "https://example.com/api/users?page=2&token={{ steps[0].res.body.token }}", Query: url.Values{"page": []string{"2"}, "token": []string{"{{ steps[0].res.body.token }}"}}},
+				{BookPath: bookPath, Step: "steps[2]", RunnerType: RunnerTypeExec, RunnerKey: "exec", Detail: "echo alice", If: `vars.username == "bob"`, Skip: true},
+				{BookPath: bookPath, Step: "steps[3]", RunnerType: RunnerTypeTest, Detail: "current.res.status == 200 && true", Skip: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		ops, err := Load(bookPath, tt.opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ops.Plan()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
@@ -0,0 +1,329 @@
+package runn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const postmanSchema = "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+
+var (
+	// postmanVarRe - Reference to the variable of runn ( e.g. {{ vars.username }} ) that is converted to the collection variable of Postman ( e.g. {{username}} )
+	postmanVarRe = regexp.MustCompile(`\{\{\s*vars\.([a-zA-Z_][a-zA-Z0-9_]*)\s*\}\}`)
+	// postmanStrLitRe - String literal of the expression
+	postmanStrLitRe = regexp.MustCompile(`"(?:\\.|[^"\\])*"|'(?:\\.|[^'\\])*'`)
+	// postmanIdentRe - Identifier at the root of the expression ( not the member access )
+	postmanIdentRe = regexp.MustCompile(`(?:^|[^.\w])([a-zA-Z_]\w*)`)
+)
+
+// postmanAllowedIdents - Identifiers of the expressions that can be converted to the scripts of Postman.
+var postmanAllowedIdents = []string{"current", "vars", "true", "false", "nil", "and", "or", "not"}
+
+// postmanHeaderRe - Reference to the response header ( e.g. current.res.headers["Content-Type"][0] )
+var postmanHeaderRe = regexp.MustCompile(`current\.res\.headers\[("[^"]+"|'[^']+')\]\[0\]`)
+
+// postmanExprReplacer - Replacements from the expression of runn to the JavaScript of Postman ( applied in order outside the string literals ).
+var postmanExprReplacer = []struct {
+	re  *regexp.Regexp
+	rep string
+}{
+	{regexp.MustCompile(`current\.res\.status\b`), "pm.response.code"},
+	{regexp.MustCompile(`current\.res\.rawBody\b`), "pm.response.text()"},
+	{regexp.MustCompile(`current\.res\.body\b`), "pm.response.json()"},
+	{regexp.MustCompile(`(^|[^.\w])vars\.([a-zA-Z_]\w*)`), `${1}pm.collectionVariables.get("${2}")`},
+	{regexp.MustCompile(`\band\b`), "&&"},
+	{regexp.MustCompile(`\bor\b`), "||"},
+	{regexp.MustCompile(`\bnot\s+`), "!"},
+	{regexp.MustCompile(`\bnil\b`), "null"},
+	{regexp.MustCompile(`([=!])=([^=])`), "$1==$2"},
+}
+
+// postmanCollection - Postman Collection ( v2.1 ). Only the fields required to represent the runbooks are defined.
+type postmanCollection struct {
+	Info     postmanInfo        `json:"info"`
+	Item     []*postmanItem     `json:"item"`
+	Variable []*postmanKeyValue `json:"variable,omitempty"`
+}
+
+type postmanInfo struct {
+	Name   string `json:"name"`
+	Schema string `json:"schema"`
+}
+
+type postmanItem struct {
+	Name    string          `json:"name"`
+	Item    []*postmanItem  `json:"item,omitempty"`
+	Request *postmanRequest `json:"request,omitempty"`
+	Event   []*postmanEvent `json:"event,omitempty"`
+}
+
+type postmanRequest struct {
+	Method string             `json:"method"`
+	Header []*postmanKeyValue `json:"header"`
+	Body   *postmanBody       `json:"body,omitempty"`
+	URL    string             `json:"url"`
+}
+
+type postmanBody struct {
+	Mode       string             `json:"mode"`
+	Raw        string             `json:"raw,omitempty"`
+	URLEncoded []*postmanKeyValue `json:"urlencoded,omitempty"`
+	FormData   []*postmanKeyValue `json:"formdata,omitempty"`
+	File       *postmanFile       `json:"file,omitempty"`
+	Options    map[string]any     `json:"options,omitempty"`
+}
+
+type postmanFile struct {
+	Src string `json:"src"`
+}
+
+type postmanKeyValue struct {
+	Key   string `json:"key"`
+	Value string `json:"value,omitempty"`
+	Type  string `json:"type,omitempty"`
+	Src   string `json:"src,omitempty"`
+}
+
+type postmanEvent struct {
+	Listen string        `json:"listen"`
+	Script postmanScript `json:"script"`
+}
+
+type postmanScript struct {
+	Type string   `json:"type"`
+	Exec []string `json:"exec"`
+}
+
+// ExportPostmanCollection writes the runbooks as the Postman Collection ( v2.1 ) named name.
+// Each runbook is converted to the folder, each HTTP step to the request, and `test:` of the step to pm.test().
+// The variables of the runbooks are converted to the collection variables.
+func (ops *operators) ExportPostmanCollection(w io.Writer, name string) error {
+	c := &postmanCollection{
+		Info: postmanInfo{
+			Name:   name,
+			Schema: postmanSchema,
+		},
+		Item: []*postmanItem{},
+	}
+	vars := map[string]string{}
+	for _, o := range ops.ops {
+		f, err := o.postmanFolder()
+		if err != nil {
+			return err
+		}
+		c.Item = append(c.Item, f)
+		for k, v := range o.store.vars {
+			switch v.(type) {
+			case map[string]any, []any:
+				// Only the scalar values can be the collection variables
+				continue
+			}
+			if _, ok := vars[k]; ok {
+				continue
+			}
+			vars[k] = fmt.Sprintf("%v", v)
+		}
+	}
+	keys := make([]string, 0, len(vars))
+	for k := range vars {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		c.Variable = append(c.Variable, &postmanKeyValue{Key: k, Value: vars[k]})
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	return enc.Encode(c)
+}
+
+func (o *operator) postmanFolder() (*postmanItem, error) {
+	name := o.desc
+	if name == "" {
+		name = o.bookPath
+	}
+	f := &postmanItem{Name: name, Item: []*postmanItem{}}
+	for _, s := range o.steps {
+		if s.httpRunner == nil || s.httpRequest == nil || s.httpRunner.endpoint == nil {
+			continue
+		}
+		ref := fmt.Sprintf("steps[%d]", s.idx)
+		if o.useMap {
+			ref = fmt.Sprintf("steps.%s", s.key)
+		}
+		req, err := parseHTTPRequest(s.httpRequest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid http request (%s %s): %w", o.bookPath, ref, err)
+		}
+		req.root = o.root
+		preq, err := req.postmanRequest(s.httpRunner)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert to postman request (%s %s): %w", o.bookPath, ref, err)
+		}
+		item := &postmanItem{Name: s.desc, Request: preq}
+		if item.Name == "" {
+			item.Name = ref
+		}
+		if s.testRunner != nil && s.testCond != "" {
+			item.Event = append(item.Event, &postmanEvent{
+				Listen: "test",
+				Script: postmanScript{
+					Type: "text/javascript",
+					Exec: postmanTestScript(s.testCond),
+				},
+			})
+		}
+		f.Item = append(f.Item, item)
+	}
+	return f, nil
+}
+
+func (r *httpRequest) postmanRequest(rnr *httpRunner) (*postmanRequest, error) {
+	u := strings.TrimSuffix(rnr.endpoint.String(), "/") + r.path
+	if !strings.Contains(r.path, delimStart) {
+		m, err := mergeURL(rnr.endpoint, r.path)
+		if err != nil {
+			return nil, err
+		}
+		u = m.String()
+	}
+	preq := &postmanRequest{
+		Method: r.method,
+		Header: []*postmanKeyValue{},
+		URL:    postmanVar(u),
+	}
+	keys := make([]string, 0, len(r.headers))
+	for k := range r.headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range r.headers[k] {
+			preq.Header = append(preq.Header, &postmanKeyValue{Key: k, Value: postmanVar(v)})
+		}
+	}
+	if r.body == nil {
+		return preq, nil
+	}
+	if r.mediaType != "" && !r.isMultipartFormDataMediaType() && r.headers.Get("Content-Type") == "" {
+		preq.Header = append(preq.Header, &postmanKeyValue{Key: "Content-Type", Value: r.mediaType})
+	}
+
+	switch {
+	case r.isMultipartFormDataMediaType():
+		fields, err := r.multipartFields()
+		if err != nil {
+			return nil, err
+		}
+		b := &postmanBody{Mode: "formdata"}
+		for _, f := range fields {
+			if f.file {
+				b.FormData = append(b.FormData, &postmanKeyValue{Key: f.key, Type: "file", Src: f.value})
+				continue
+			}
+			b.FormData = append(b.FormData, &postmanKeyValue{Key: f.key, Value: postmanVar(f.value), Type: "text"})
+		}
+		preq.Body = b
+	case r.mediaType == MediaTypeApplicationFormUrlencoded:
+		values, ok := r.body.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid body: %v", r.body)
+		}
+		keys := make([]string, 0, len(values))
+		for k := range values {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		b := &postmanBody{Mode: "urlencoded"}
+		for _, k := range keys {
+			b.URLEncoded = append(b.URLEncoded, &postmanKeyValue{Key: k, Value: postmanVar(fmt.Sprintf("%v", values[k]))})
+		}
+		preq.Body = b
+	case r.mediaType == MediaTypeApplicationJSON:
+		bb, err := json.MarshalIndent(r.body, "", "  ")
+		if err != nil {
+			return nil, err
+		}
+		preq.Body = &postmanBody{
+			Mode:    "raw",
+			Raw:     postmanVar(string(bb)),
+			Options: map[string]any{"raw": map[string]any{"language": "json"}},
+		}
+	default:
+		if m, ok := r.body.(map[string]any); ok && r.mediaType == MediaTypeApplicationOctetStream {
+			if fn, ok := m["filename"].(string); ok {
+				preq.Body = &postmanBody{Mode: "file", File: &postmanFile{Src: filepath.Join(r.root, fn)}}
+				return preq, nil
+			}
+		}
+		b, err := r.encodeBody()
+		if err != nil {
+			return nil, err
+		}
+		bb, err := io.ReadAll(b)
+		if err != nil {
+			return nil, err
+		}
+		preq.Body = &postmanBody{Mode: "raw", Raw: postmanVar(string(bb))}
+	}
+	return preq, nil
+}
+
+// postmanVar converts the references to the variables of runn to the collection variables of Postman.
+func postmanVar(s string) string {
+	return postmanVarRe.ReplaceAllString(s, "{{$1}}")
+}
+
+// postmanTestScript converts the test condition to the script of pm.test().
+// The condition that cannot be converted ( e.g. the references to the other steps, the built-in functions ) is left as the comment.
+func postmanTestScript(cond string) []string {
+	cond = strings.Join(strings.Fields(cond), " ")
+	js, ok := postmanExpr(cond)
+	if !ok {
+		return []string{fmt.Sprintf("// runn test (not converted): %s", cond)}
+	}
+	name := new(strings.Builder)
+	enc := json.NewEncoder(name)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(cond)
+	return []string{
+		fmt.Sprintf("pm.test(%s, function () {", strings.TrimSuffix(name.String(), "\n")),
+		fmt.Sprintf("    pm.expect(%s).to.be.true;", js),
+		"});",
+	}
+}
+
+func postmanExpr(cond string) (string, bool) {
+	for _, m := range postmanIdentRe.FindAllStringSubmatch(postmanStrLitRe.ReplaceAllString(cond, `""`), -1) {
+		if !contains(postmanAllowedIdents, m[1]) {
+			return "", false
+		}
+	}
+	cond = postmanHeaderRe.ReplaceAllString(cond, "pm.response.headers.get($1)")
+	var (
+		js  strings.Builder
+		pos int
+	)
+	replace := func(s string) string {
+		for _, r := range postmanExprReplacer {
+			s = r.re.ReplaceAllString(s, r.rep)
+		}
+		return s
+	}
+	for _, loc := range postmanStrLitRe.FindAllStringIndex(cond, -1) {
+		js.WriteString(replace(cond[pos:loc[0]]))
+		js.WriteString(cond[loc[0]:loc[1]])
+		pos = loc[1]
+	}
+	js.WriteString(replace(cond[pos:]))
+	if strings.Contains(js.String(), "current") {
+		return "", false
+	}
+	return js.String(), true
+}
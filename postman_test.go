@@ -0,0 +1,55 @@
+package runn
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/tenntenn/golden"
+)
+
+func TestExportPostmanCollection(t *testing.T) {
+	ops, err := Load("testdata/book/postman_export.yml", LoadOnly())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := new(bytes.Buffer)
+	if err := ops.ExportPostmanCollection(got, "runn"); err != nil {
+		t.Fatal(err)
+	}
+	f := "postman_export.collection"
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		golden.Update(t, "testdata", f, got)
+		return
+	}
+	if diff := golden.Diff(t, "testdata", f, got); diff != "" {
+		t.Error(diff)
+	}
+}
+
+func TestPostmanExpr(t *testing.T) {
+	tests := []struct {
+		in     string
+		want   string
+		wantOK bool
+	}{
+		{"current.res.status == 200", "pm.response.code === 200", true},
+		{`current.res.headers["Content-Type"][0] != "text/plain"`, `pm.response.headers.get("Content-Type") !== "text/plain"`, true},
+		{`current.res.body.message == "foo and bar" or not current.res.body.ok`, `pm.response.json().message === "foo and bar" || !pm.response.json().ok`, true},
+		{"current.res.body.id == vars.id", `pm.response.json().id === pm.collectionVariables.get("id")`, true},
+		{"current.res.status >= 200 && current.res.rawBody != nil", "pm.response.code >= 200 && pm.response.text() !== null", true},
+		{"steps.login.res.status == 200", "", false},
+		{"len(current.res.body.users) > 0", "", false},
+		{"current.res.cookies.session != nil", "", false},
+	}
+	for _, tt := range tests {
+		got, ok := postmanExpr(tt.in)
+		if ok != tt.wantOK {
+			t.Errorf("%s: got %v want %v", tt.in, ok, tt.wantOK)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: got %v want %v", tt.in, got, tt.want)
+		}
+	}
+}
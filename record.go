@@ -0,0 +1,159 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"os"
+	"sync"
+
+	"gopkg.in/yaml.v2"
+)
+
+// recordIgnoreHeaders - Headers of the proxied requests that are not recorded because they are set by the HTTP client or the proxy.
+var recordIgnoreHeaders = []string{"Host", "Content-Length", "Connection", "Accept-Encoding", "Proxy-Connection", "Proxy-Authorization", "Keep-Alive", "Te", "Trailer", "Transfer-Encoding", "Upgrade"}
+
+type recordEntryKey struct{}
+
+// recordEntry - Request sent to the upstream and the status code of the response.
+type recordEntry struct {
+	method string
+	url    *url.URL
+	header http.Header
+	body   []byte
+	status int
+	failed bool
+}
+
+// Recorder is the HTTP proxy that records the requests passing through it as the steps of the runbook.
+// If the target is set, it works as the reverse proxy to the target. Otherwise it works as the forward proxy ( HTTP only ).
+type Recorder struct {
+	rb     *runbook
+	target *url.URL
+	proxy  *httputil.ReverseProxy
+	stderr io.Writer
+	mu     sync.Mutex
+}
+
+// NewRecorder returns the Recorder. target is the URL of the upstream of the reverse proxy ( empty for the forward proxy ).
+func NewRecorder(desc, target string) (*Recorder, error) {
+	if desc == "" {
+		desc = "Generated by `runn record`"
+	}
+	r := &Recorder{
+		rb:     NewRunbook(desc),
+		stderr: os.Stderr,
+	}
+	if target != "" {
+		u, err := url.Parse(target)
+		if err != nil {
+			return nil, err
+		}
+		if u.Scheme == "" || u.Host == "" {
+			return nil, fmt.Errorf("invalid target: %s", target)
+		}
+		r.target = u
+	}
+	r.proxy = &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if r.target != nil {
+				pr.SetURL(r.target)
+			}
+			if e, ok := pr.In.Context().Value(recordEntryKey{}).(*recordEntry); ok {
+				e.method = pr.Out.Method
+				e.url = pr.Out.URL
+				e.header = pr.Out.Header.Clone()
+			}
+		},
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			if e, ok := req.Context().Value(recordEntryKey{}).(*recordEntry); ok {
+				e.failed = true
+			}
+			_, _ = fmt.Fprintf(r.stderr, "proxy error: %v\n", err)
+			w.WriteHeader(http.StatusBadGateway)
+		},
+		ModifyResponse: func(res *http.Response) error {
+			if e, ok := res.Request.Context().Value(recordEntryKey{}).(*recordEntry); ok {
+				e.status = res.StatusCode
+			}
+			return nil
+		},
+	}
+	return r, nil
+}
+
+// ServeHTTP proxies the request and records it.
+func (r *Recorder) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method == http.MethodConnect {
+		http.Error(w, "CONNECT is not supported. Use the reverse proxy mode for HTTPS", http.StatusMethodNotAllowed)
+		return
+	}
+	if r.target == nil && !req.URL.IsAbs() {
+		http.Error(w, "the request is not for the forward proxy", http.StatusBadRequest)
+		return
+	}
+	b, err := io.ReadAll(req.Body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	req.Body = io.NopCloser(bytes.NewReader(b))
+	e := &recordEntry{body: b}
+	r.proxy.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), recordEntryKey{}, e)))
+	if e.failed || e.url == nil {
+		return
+	}
+	if err := r.record(e); err != nil {
+		_, _ = fmt.Fprintf(r.stderr, "failed to record %s %s: %v\n", e.method, e.url, err)
+	}
+}
+
+func (r *Recorder) record(e *recordEntry) error {
+	var body io.Reader
+	if len(e.body) > 0 {
+		body = bytes.NewReader(e.body)
+	}
+	req, err := http.NewRequest(e.method, e.url.String(), body)
+	if err != nil {
+		return err
+	}
+	for k, vs := range e.header {
+		if contains(recordIgnoreHeaders, http.CanonicalHeaderKey(k)) {
+			continue
+		}
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	dsn := fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host)
+	key := r.rb.setRunner(dsn)
+	step, err := CreateHTTPStepMapSlice(key, req)
+	if err != nil {
+		return err
+	}
+	step = append(step, yaml.MapItem{Key: testRunnerKey, Value: fmt.Sprintf("current.res.status == %d", e.status)})
+	r.rb.Steps = append(r.rb.Steps, step)
+	return nil
+}
+
+// NumberOfSteps returns the number of the recorded steps.
+func (r *Recorder) NumberOfSteps() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.rb.Steps)
+}
+
+// WriteRunbook writes the recorded runbook as YAML.
+func (r *Recorder) WriteRunbook(w io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	enc := yaml.NewEncoder(w)
+	defer enc.Close()
+	return enc.Encode(r.rb)
+}
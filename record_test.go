@@ -0,0 +1,127 @@
+package runn
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRecorder(t *testing.T) {
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/users":
+			w.WriteHeader(http.StatusCreated)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		_, _ = io.Copy(io.Discard, r.Body)
+	}))
+	t.Cleanup(upstream.Close)
+
+	tests := []struct {
+		name    string
+		reverse bool
+	}{
+		{"reverse proxy", true},
+		{"forward proxy", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := ""
+			if tt.reverse {
+				target = upstream.URL + "/api"
+			}
+			rec, err := NewRecorder("recorded", target)
+			if err != nil {
+				t.Fatal(err)
+			}
+			proxy := httptest.NewServer(rec)
+			t.Cleanup(proxy.Close)
+
+			client := &http.Client{}
+			base := proxy.URL
+			if !tt.reverse {
+				pu, err := url.Parse(proxy.URL)
+				if err != nil {
+					t.Fatal(err)
+				}
+				client.Transport = &http.Transport{Proxy: http.ProxyURL(pu)}
+				base = upstream.URL + "/api"
+			}
+			req, err := http.NewRequest(http.MethodPost, base+"/users", strings.NewReader(`{"name":"alice"}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("X-Token", "xxxxx")
+			res, err := client.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = res.Body.Close()
+			if res.StatusCode != http.StatusCreated {
+				t.Errorf("got %v want %v", res.StatusCode, http.StatusCreated)
+			}
+			res, err = client.Get(base + "/missing?page=1")
+			if err != nil {
+				t.Fatal(err)
+			}
+			_ = res.Body.Close()
+
+			if got := rec.NumberOfSteps(); got != 2 {
+				t.Errorf("got %v want %v", got, 2)
+			}
+			buf := new(bytes.Buffer)
+			if err := rec.WriteRunbook(buf); err != nil {
+				t.Fatal(err)
+			}
+			got := strings.ReplaceAll(buf.String(), upstream.URL, "http://upstream")
+			want := `desc: recorded
+runners:
+  req: http://upstream
+steps:
+- req:
+    /api/users:
+      post:
+        headers:
+          User-Agent: Go-http-client/1.1
+          X-Token: xxxxx
+        body:
+          application/json:
+            name: alice
+  test: current.res.status == 201
+- req:
+    /api/missing?page=1:
+      get:
+        headers:
+          User-Agent: Go-http-client/1.1
+        body: null
+  test: current.res.status == 404
+`
+			if diff := cmp.Diff(want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestRecorderRejectsConnect(t *testing.T) {
+	rec, err := NewRecorder("", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	w := httptest.NewRecorder()
+	rec.ServeHTTP(w, httptest.NewRequest(http.MethodConnect, "https://example.com:443", nil))
+	if w.Code != http.StatusMethodNotAllowed {
+		t.Errorf("got %v want %v", w.Code, http.StatusMethodNotAllowed)
+	}
+	if got := rec.NumberOfSteps(); got != 0 {
+		t.Errorf("got %v want %v", got, 0)
+	}
+}
@@ -21,11 +21,13 @@ const (
 // RunResult is the result of a runbook run.
 type RunResult struct {
 	// runbook ID
-	ID          string
-	Desc        string
-	Labels      []string
-	Path        string
-	Skipped     bool
+	ID      string
+	Desc    string
+	Labels  []string
+	Path    string
+	Skipped bool
+	// TimedOut - The run failed because `timeout:` of the runbook is exceeded
+	TimedOut    bool
 	Err         error
 	StepResults []*StepResult
 	Store       map[string]any
@@ -61,12 +63,13 @@ type runNResultSimplified struct {
 }
 
 type runResultSimplified struct {
-	ID      string                  `json:"id"`
-	Labels  []string                `json:"labels,omitempty"`
-	Path    string                  `json:"path"`
-	Result  result                  `json:"result"`
-	Steps   []*stepResultSimplified `json:"steps"`
-	Elapsed time.Duration           `json:"elapsed,omitempty"`
+	ID       string                  `json:"id"`
+	Labels   []string                `json:"labels,omitempty"`
+	Path     string                  `json:"path"`
+	Result   result                  `json:"result"`
+	TimedOut bool                    `json:"timed_out,omitempty"`
+	Steps    []*stepResultSimplified `json:"steps"`
+	Elapsed  time.Duration           `json:"elapsed,omitempty"`
 }
 
 type stepResultSimplified struct {
@@ -250,11 +253,12 @@ func simplifyRunResult(rr *RunResult) *runResultSimplified {
 	switch {
 	case rr.Err != nil:
 		return &runResultSimplified{
-			ID:      rr.ID,
-			Path:    rr.Path,
-			Result:  resultFailure,
-			Steps:   simplifyStepResults(rr.StepResults),
-			Elapsed: rr.Elapsed,
+			ID:       rr.ID,
+			Path:     rr.Path,
+			Result:   resultFailure,
+			TimedOut: rr.TimedOut,
+			Steps:    simplifyStepResults(rr.StepResults),
+			Elapsed:  rr.Elapsed,
 		}
 	case rr.Skipped:
 		return &runResultSimplified{
@@ -0,0 +1,234 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+const retrySectionKey = "retry"
+
+const (
+	defaultRetryCount    = 3
+	defaultRetryInterval = 1 * time.Second
+)
+
+// retryConfig - Settings of `retry:` of steps and exec commands.
+type retryConfig struct {
+	// count - Max number of attempts
+	count       int
+	interval    time.Duration
+	maxInterval time.Duration
+	// backoff - Multiplier of the interval for each attempt ( 0 means no backoff )
+	backoff float64
+	// until - Condition over the result of the attempt ( bound to `current` )
+	until string
+	// timeout - Deadline of all attempts of `eventually:` ( 0 means no deadline )
+	timeout time.Duration
+}
+
+// parseRetry parses `retry:`. `retry: N` is the short syntax of `max:`. `count:` is the alias of `max:`.
+func parseRetry(v any, defaultUntil string) (*retryConfig, error) {
+	r := &retryConfig{
+		count:    defaultRetryCount,
+		interval: defaultRetryInterval,
+		until:    defaultUntil,
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		// short syntax
+		c, err := parseRetryCount("max", v)
+		if err != nil {
+			return nil, err
+		}
+		r.count = c
+		return r, nil
+	}
+	_, hasMax := m["max"]
+	_, hasCount := m["count"]
+	if hasMax && hasCount {
+		return nil, errors.New("invalid keys: max and count cannot be used together")
+	}
+	for k, vv := range m {
+		switch k {
+		case "max", "count":
+			c, err := parseRetryCount(k, vv)
+			if err != nil {
+				return nil, err
+			}
+			r.count = c
+		case "interval", "maxInterval":
+			s, ok := vv.(string)
+			if !ok {
+				return nil, fmt.Errorf("invalid %s: %v", k, vv)
+			}
+			d, err := parseDuration(s)
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s: %w", k, err)
+			}
+			if k == "interval" {
+				r.interval = d
+			} else {
+				r.maxInterval = d
+			}
+		case "backoff":
+			b, err := parseRetryBackoff(vv)
+			if err != nil {
+				return nil, err
+			}
+			r.backoff = b
+		case "until":
+			u, ok := vv.(string)
+			if !ok || strings.TrimSpace(u) == "" {
+				return nil, fmt.Errorf("invalid until: %v", vv)
+			}
+			r.until = u
+		default:
+			return nil, fmt.Errorf("invalid key: %s", k)
+		}
+	}
+	return r, nil
+}
+
+func parseRetryCount(k string, v any) (int, error) {
+	var c int
+	switch vv := v.(type) {
+	case uint64:
+		c = int(vv)
+	case int64:
+		c = int(vv)
+	case int:
+		c = vv
+	case string:
+		// e.g. max: ${RETRY_MAX}
+		i, err := strconv.Atoi(strings.TrimSpace(vv))
+		if err != nil {
+			return 0, fmt.Errorf("invalid %s: %v", k, v)
+		}
+		c = i
+	default:
+		return 0, fmt.Errorf("invalid %s: %v", k, v)
+	}
+	if c < 1 {
+		return 0, fmt.Errorf("invalid %s: %v", k, v)
+	}
+	return c, nil
+}
+
+func parseRetryBackoff(v any) (float64, error) {
+	var b float64
+	switch vv := v.(type) {
+	case float64:
+		b = vv
+	case uint64:
+		b = float64(vv)
+	case int64:
+		b = float64(vv)
+	case int:
+		b = float64(vv)
+	default:
+		return 0, fmt.Errorf("invalid backoff: %v", v)
+	}
+	if b < 1 {
+		return 0, fmt.Errorf("invalid backoff: %v", v)
+	}
+	return b, nil
+}
+
+// wait returns the time to wait before the attempt n ( n >= 1 ).
+func (r *retryConfig) wait(n int) time.Duration {
+	d := r.interval
+	if r.backoff > 0 {
+		d = time.Duration(float64(r.interval) * math.Pow(r.backoff, float64(n-1)))
+	}
+	if r.maxInterval > 0 && d > r.maxInterval {
+		d = r.maxInterval
+	}
+	return d
+}
+
+// runStepWithRetry runs the step until it succeeds and retry.until is satisfied, up to retry.max times.
+// With the timeout of `eventually:`, the attempts are also canceled when the deadline is exceeded.
+func (o *operator) runStepWithRetry(ctx context.Context, i int, s *step, stepFn func(ctx context.Context, t *testing.T) error) error {
+	r := s.retry
+	rctx := ctx
+	if r.timeout > 0 {
+		var cancel context.CancelFunc
+		rctx, cancel = context.WithDeadline(ctx, time.Now().Add(r.timeout))
+		defer cancel()
+	}
+	var (
+		err error
+		bt  string
+		j   int
+	)
+	for ; j < r.count; j++ {
+		if j > 0 {
+			if d, ok := rctx.Deadline(); ok && r.timeout > 0 && time.Now().Add(r.wait(j)).After(d) {
+				break
+			}
+			select {
+			case <-rctx.Done():
+				if ctx.Err() != nil {
+					return ctx.Err()
+				}
+			case <-time.After(r.wait(j)):
+			}
+			if rctx.Err() != nil {
+				break
+			}
+			if o.store.length() == i+1 {
+				// delete values of previous attempt
+				o.removeLatest()
+			}
+			if r.timeout > 0 {
+				o.Debugf(yellow("Eventually (attempt %d) on %s\n"), j+1, o.stepName(i))
+			} else {
+				o.Debugf(yellow("Retry (%d/%d) on %s\n"), j+1, r.count, o.stepName(i))
+			}
+		}
+		err = stepFn(rctx, o.thisT)
+		if errors.Is(errStepSkiped, err) {
+			return err
+		}
+		if err != nil {
+			continue
+		}
+		if r.until == "" {
+			return nil
+		}
+		store := o.store.toMap()
+		store[storeRootKeyIncluded] = o.included
+		store[storeRootPrevious] = o.store.previous()
+		store[storeRootKeyCurrent] = o.store.latest()
+		bt, err = buildTree(r.until, store)
+		if err != nil {
+			return fmt.Errorf("retry failed on %s: %w", o.stepName(i), err)
+		}
+		tf, eerr := EvalCond(r.until, store)
+		if eerr != nil {
+			return fmt.Errorf("retry failed on %s: %w", o.stepName(i), eerr)
+		}
+		if tf {
+			return nil
+		}
+		err = fmt.Errorf("(%s) is not true\n%s", r.until, bt)
+	}
+	if r.timeout > 0 {
+		return fmt.Errorf("eventually failed on %s.eventually (timeout: %v, interval: %v, attempts: %d): %w", o.stepName(i), r.timeout, r.interval, j, err)
+	}
+	return fmt.Errorf("retry failed on %s.retry (max: %d, interval: %v): %w", o.stepName(i), r.count, r.interval, err)
+}
+
+func (o *operator) removeLatest() {
+	if o.useMap {
+		o.store.removeLatestAsMapped()
+		return
+	}
+	o.store.steps = o.store.steps[:o.store.length()-1]
+}
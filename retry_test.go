@@ -0,0 +1,140 @@
+package runn
+
+import (
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestStepRetry(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/retry.yml"), Var("countFile", filepath.Join(t.TempDir(), "count.txt")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if want := 3; len(o.store.steps) != want {
+		t.Errorf("got %v\nwant %v", len(o.store.steps), want)
+	}
+}
+
+func TestStepRetryFailure(t *testing.T) {
+	tests := []struct {
+		name  string
+		step  map[string]any
+		want  string
+		steps int
+	}{
+		{
+			"test failure",
+			map[string]any{
+				"retry": map[string]any{"max": 2, "interval": "1ms"},
+				"test":  "false",
+			},
+			"retry failed on \"\".steps[0].retry (max: 2, interval: 1ms)",
+			1,
+		},
+		{
+			"until",
+			map[string]any{
+				"retry": map[string]any{"max": 2, "interval": "1ms", "until": "current.run == false"},
+				"bind":  map[string]any{"v": "1"},
+			},
+			"(current.run == false) is not true",
+			1,
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.AppendStep(0, "", tt.step); err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if err == nil {
+				t.Fatal("want error")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("got %v\nwant %v", err, tt.want)
+			}
+			if len(o.store.steps) != tt.steps {
+				t.Errorf("got %v\nwant %v", len(o.store.steps), tt.steps)
+			}
+		})
+	}
+}
+
+func TestParseRetry(t *testing.T) {
+	tests := []struct {
+		in        any
+		wantCount int
+		wantUntil string
+		wantWaits []time.Duration
+		wantErr   bool
+	}{
+		{5, 5, "", []time.Duration{time.Second, time.Second}, false},
+		{"5", 5, "", []time.Duration{time.Second, time.Second}, false},
+		{map[string]any{}, defaultRetryCount, "", []time.Duration{time.Second, time.Second}, false},
+		{map[string]any{"max": 4, "interval": "100ms", "backoff": 2}, 4, "", []time.Duration{100 * time.Millisecond, 200 * time.Millisecond, 400 * time.Millisecond}, false},
+		{map[string]any{"interval": "1sec", "backoff": 3, "maxInterval": "5sec"}, defaultRetryCount, "", []time.Duration{time.Second, 3 * time.Second, 5 * time.Second}, false},
+		{map[string]any{"backoff": 1.5, "interval": "100ms"}, defaultRetryCount, "", []time.Duration{100 * time.Millisecond, 150 * time.Millisecond}, false},
+		{map[string]any{"until": "current.res.status == 200"}, defaultRetryCount, "current.res.status == 200", nil, false},
+		{map[string]any{"backoff": 0.5}, 0, "", nil, true},
+		{map[string]any{"interval": "invalid"}, 0, "", nil, true},
+		{map[string]any{"count": 4}, 4, "", nil, false},
+		{map[string]any{"max": "${RETRY_MAX}"}, 0, "", nil, true},
+		{map[string]any{"max": 0}, 0, "", nil, true},
+		{map[string]any{"count": 0}, 0, "", nil, true},
+		{map[string]any{"max": 3, "count": 3}, 0, "", nil, true},
+		{map[string]any{"until": ""}, 0, "", nil, true},
+		{map[string]any{"intreval": "1sec"}, 0, "", nil, true},
+		{"invalid", 0, "", nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseRetry(tt.in, "")
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: got %v\nwantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.count != tt.wantCount {
+			t.Errorf("got %v\nwant %v", got.count, tt.wantCount)
+		}
+		if got.until != tt.wantUntil {
+			t.Errorf("got %v\nwant %v", got.until, tt.wantUntil)
+		}
+		for i, want := range tt.wantWaits {
+			if w := got.wait(i + 1); w != want {
+				t.Errorf("wait(%d): got %v\nwant %v", i+1, w, want)
+			}
+		}
+	}
+}
+
+func TestParseRetryDefaultUntil(t *testing.T) {
+	got, err := parseRetry(5, execRetryDefaultUntil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.until != execRetryDefaultUntil {
+		t.Errorf("got %v\nwant %v", got.until, execRetryDefaultUntil)
+	}
+}
@@ -39,40 +39,68 @@ type areas struct {
 }
 
 type runbook struct {
+	ID          string          `yaml:"id,omitempty"`
 	Desc        string          `yaml:"desc"`
 	Labels      []string        `yaml:"labels,omitempty"`
 	Runners     map[string]any  `yaml:"runners,omitempty"`
 	Vars        map[string]any  `yaml:"vars,omitempty"`
+	Consts      map[string]any  `yaml:"consts,omitempty"`
+	VarsSchema  map[string]any  `yaml:"varsSchema,omitempty"`
+	LazyVars    map[string]any  `yaml:"lazyVars,omitempty"`
+	Secrets     []string        `yaml:"secrets,omitempty"`
 	Steps       []yaml.MapSlice `yaml:"steps"`
 	HostRules   yaml.MapSlice   `yaml:"hostRules,omitempty"`
 	Debug       bool            `yaml:"debug,omitempty"`
 	Interval    string          `yaml:"interval,omitempty"`
+	Timeout     string          `yaml:"timeout,omitempty"`
 	If          string          `yaml:"if,omitempty"`
 	SkipTest    bool            `yaml:"skipTest,omitempty"`
 	Loop        any             `yaml:"loop,omitempty"`
+	Cases       any             `yaml:"cases,omitempty"`
 	Concurrency any             `yaml:"concurrency,omitempty"`
 	Force       bool            `yaml:"force,omitempty"`
 	Trace       bool            `yaml:"trace,omitempty"`
+	Hooks       *runbookHooks   `yaml:"hooks,omitempty"`
+	Templates   map[string]any  `yaml:"templates,omitempty"`
+	EnvFiles    []string        `yaml:"envFiles,omitempty"`
 
 	useMap   bool
 	stepKeys []string
+	// harAllowHosts - Hosts of the HAR entries to be converted to steps
+	harAllowHosts []string
 }
 
 type runbookMapped struct {
+	ID          string         `yaml:"id,omitempty"`
 	Desc        string         `yaml:"desc,omitempty"`
 	Labels      []string       `yaml:"labels,omitempty"`
 	Runners     map[string]any `yaml:"runners,omitempty"`
 	Vars        map[string]any `yaml:"vars,omitempty"`
+	Consts      map[string]any `yaml:"consts,omitempty"`
+	VarsSchema  map[string]any `yaml:"varsSchema,omitempty"`
+	LazyVars    map[string]any `yaml:"lazyVars,omitempty"`
+	Secrets     []string       `yaml:"secrets,omitempty"`
 	Steps       yaml.MapSlice  `yaml:"steps,omitempty"`
 	HostRules   yaml.MapSlice  `yaml:"hostRules,omitempty"`
 	Debug       bool           `yaml:"debug,omitempty"`
 	Interval    string         `yaml:"interval,omitempty"`
+	Timeout     string         `yaml:"timeout,omitempty"`
 	If          string         `yaml:"if,omitempty"`
 	SkipTest    bool           `yaml:"skipTest,omitempty"`
 	Loop        any            `yaml:"loop,omitempty"`
+	Cases       any            `yaml:"cases,omitempty"`
 	Concurrency any            `yaml:"concurrency,omitempty"`
 	Force       bool           `yaml:"force,omitempty"`
 	Trace       bool           `yaml:"trace,omitempty"`
+	Hooks       *runbookHooks  `yaml:"hooks,omitempty"`
+	Templates   map[string]any `yaml:"templates,omitempty"`
+	EnvFiles    []string       `yaml:"envFiles,omitempty"`
+}
+
+// runbookHooks - Steps run around every step of the runbook.
+type runbookHooks struct {
+	BeforeEach []yaml.MapSlice `yaml:"beforeEach,omitempty"`
+	AfterEach  []yaml.MapSlice `yaml:"afterEach,omitempty"`
 }
 
 func NewRunbook(desc string) *runbook {
@@ -94,17 +122,20 @@ func ParseRunbook(in io.Reader) (*runbook, error) {
 	if err != nil {
 		return nil, err
 	}
-	return parseRunbook(b)
+	return parseRunbook(b, os.LookupEnv)
 }
 
-func parseRunbook(b []byte) (*runbook, error) {
-	rb := NewRunbook("")
-
-	repFn := expand.InterpolateRepFn(os.LookupEnv)
+func parseRunbook(b []byte, lookupEnv func(string) (string, bool)) (*runbook, error) {
+	repFn := expand.InterpolateRepFn(lookupEnv)
 	rep, err := expand.ReplaceYAML(string(b), repFn)
 	if err != nil {
 		return nil, err
 	}
+	return parseRunbookYAML(rep)
+}
+
+func parseRunbookYAML(rep string) (*runbook, error) {
+	rb := NewRunbook("")
 
 	flattened, err := flattenYamlAliases([]byte(rep))
 	if err != nil {
@@ -157,17 +188,27 @@ func parseRunbookMapped(b []byte, rb *runbook) error {
 		return err
 	}
 	rb.useMap = true
+	rb.ID = m.ID
 	rb.Desc = m.Desc
 	rb.Labels = m.Labels
 	rb.Runners = m.Runners
 	rb.Vars = m.Vars
+	rb.Consts = m.Consts
+	rb.VarsSchema = m.VarsSchema
+	rb.LazyVars = m.LazyVars
+	rb.Secrets = m.Secrets
 	rb.HostRules = m.HostRules
 	rb.Debug = m.Debug
 	rb.Interval = m.Interval
+	rb.Timeout = m.Timeout
 	rb.If = m.If
 	rb.SkipTest = m.SkipTest
 	rb.Force = m.Force
 	rb.Trace = m.Trace
+	rb.Hooks = m.Hooks
+	rb.Templates = m.Templates
+	rb.EnvFiles = m.EnvFiles
+	rb.Cases = m.Cases
 
 	keys := map[string]struct{}{}
 	for _, s := range m.Steps {
@@ -194,6 +235,18 @@ func (rb *runbook) AppendStep(in ...string) error {
 	if len(in) == 0 {
 		return errors.New("no argument")
 	}
+	if isHARFile(in...) {
+		// The HAR is converted to the multiple steps
+		return rb.harToSteps(in[0])
+	}
+	if e, ok := parseInsomniaExport(in...); ok {
+		// The Insomnia export is converted to the multiple steps
+		return rb.insomniaToSteps(e)
+	}
+	if isFetchSnippet(in...) {
+		// The snippet copied by "Copy all as fetch" can contain the multiple fetch() calls
+		return rb.fetchToSteps(in[0])
+	}
 	if rb.useMap {
 		key := fmt.Sprintf("%s%d", in[0], len(rb.stepKeys))
 		rb.stepKeys = append(rb.stepKeys, key)
@@ -203,6 +256,10 @@ func (rb *runbook) AppendStep(in ...string) error {
 		return rb.curlToStep(in...)
 	case strings.HasPrefix(in[0], "grpcurl"):
 		return rb.grpcurlToStep(in...)
+	case isHTTPieCommand(in...):
+		return rb.httpieToStep(in...)
+	case isWgetCommand(in...):
+		return rb.wgetToStep(in...)
 	default:
 		if len(in) == 1 {
 			if err := rb.axsLogToStep(in...); err == nil {
@@ -221,17 +278,27 @@ func (rb *runbook) MarshalYAML() (any, error) {
 		return nil, errors.New("invalid runbook")
 	}
 	m := &runbookMapped{}
+	m.ID = rb.ID
 	m.Desc = rb.Desc
 	m.Labels = rb.Labels
 	m.Runners = rb.Runners
 	m.Vars = rb.Vars
+	m.Consts = rb.Consts
+	m.VarsSchema = rb.VarsSchema
+	m.LazyVars = rb.LazyVars
+	m.Secrets = rb.Secrets
 	m.HostRules = rb.HostRules
 	m.Debug = rb.Debug
 	m.Interval = rb.Interval
+	m.Timeout = rb.Timeout
 	m.If = rb.If
 	m.SkipTest = rb.SkipTest
 	m.Force = rb.Force
 	m.Trace = rb.Trace
+	m.Hooks = rb.Hooks
+	m.Templates = rb.Templates
+	m.EnvFiles = rb.EnvFiles
+	m.Cases = rb.Cases
 	ms := yaml.MapSlice{}
 	for i, k := range rb.stepKeys {
 		ms = append(ms, yaml.MapItem{
@@ -393,14 +460,21 @@ func (rb *runbook) cmdToStep(in ...string) error {
 	return nil
 }
 
+const invalidIDChars = " \t\n\r"
+
 var invalidLabelTokens = []string{" ", "\n", "\r", "!", "+", "=", "|", ".", "*", "%", "^", "?", ">", "<"}
 
 func (rb *runbook) validate() error {
-	// labels:
-	if len(rb.Labels) == 0 {
-		return nil
+	// id:
+	if strings.ContainsAny(rb.ID, invalidIDChars) {
+		return fmt.Errorf("invalid id: %q", rb.ID)
 	}
-	for _, l := range rb.Labels {
+	// labels:
+	return validateLabels(rb.Labels)
+}
+
+func validateLabels(labels []string) error {
+	for _, l := range labels {
 		for _, t := range invalidLabelTokens {
 			if strings.Contains(l, t) {
 				return fmt.Errorf("invalid label: %q", l)
@@ -416,6 +490,7 @@ func (rb *runbook) toBook() (*book, error) {
 		err error
 	)
 	bk := newBook()
+	bk.id = rb.ID
 	bk.desc = rb.Desc
 	bk.labels = rb.Labels
 	bk.runners, ok = normalize(rb.Runners).(map[string]any)
@@ -426,6 +501,32 @@ func (rb *runbook) toBook() (*book, error) {
 	if !ok {
 		return nil, fmt.Errorf("failed to normalize vars: %v", rb.Vars)
 	}
+	bk.consts, ok = normalize(rb.Consts).(map[string]any)
+	if !ok {
+		return nil, fmt.Errorf("failed to normalize consts: %v", rb.Consts)
+	}
+	for k, v := range rb.LazyVars {
+		e, ok := v.(string)
+		if !ok || e == "" {
+			return nil, fmt.Errorf("invalid lazyVars.%s: %v", k, v)
+		}
+		if bk.lazyVars == nil {
+			bk.lazyVars = map[string]string{}
+		}
+		bk.lazyVars[k] = e
+	}
+	for _, p := range rb.Secrets {
+		if strings.TrimSpace(p) == "" {
+			return nil, fmt.Errorf("invalid secrets: %v", rb.Secrets)
+		}
+		bk.secrets = append(bk.secrets, p)
+	}
+	if rb.VarsSchema != nil {
+		bk.varsSchema, ok = normalize(rb.VarsSchema).(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("failed to normalize varsSchema: %v", rb.VarsSchema)
+		}
+	}
 	for _, s := range rb.Steps {
 		v, ok := normalize(s).(map[string]any)
 		if !ok {
@@ -433,6 +534,32 @@ func (rb *runbook) toBook() (*book, error) {
 		}
 		bk.rawSteps = append(bk.rawSteps, v)
 	}
+	if rb.Hooks != nil {
+		for _, s := range rb.Hooks.BeforeEach {
+			v, ok := normalize(s).(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("failed to normalize hook step values: %v", s)
+			}
+			bk.beforeEachSteps = append(bk.beforeEachSteps, v)
+		}
+		for _, s := range rb.Hooks.AfterEach {
+			v, ok := normalize(s).(map[string]any)
+			if !ok {
+				return nil, fmt.Errorf("failed to normalize hook step values: %v", s)
+			}
+			bk.afterEachSteps = append(bk.afterEachSteps, v)
+		}
+	}
+	for k, t := range rb.Templates {
+		v, ok := normalize(t).(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid templates.%s: %v", k, t)
+		}
+		if bk.templates == nil {
+			bk.templates = map[string]map[string]any{}
+		}
+		bk.templates[k] = v
+	}
 	for _, r := range rb.HostRules {
 		host, ok := r.Key.(string)
 		if !ok {
@@ -446,6 +573,7 @@ func (rb *runbook) toBook() (*book, error) {
 	}
 	bk.debug = rb.Debug
 	bk.intervalStr = rb.Interval
+	bk.timeoutStr = rb.Timeout
 	bk.ifCond = rb.If
 	bk.skipTest = rb.SkipTest
 	bk.force = rb.Force
@@ -455,7 +583,11 @@ func (rb *runbook) toBook() (*book, error) {
 		if err != nil {
 			return nil, err
 		}
+		if bk.loop.Items != nil {
+			return nil, fmt.Errorf("invalid loop: items cannot be used in the loop of runbook")
+		}
 	}
+	bk.rawCases = normalize(rb.Cases)
 	if rb.Concurrency != nil {
 		bk.concurrency, err = newConcurrency(rb.Concurrency)
 		if err != nil {
@@ -553,7 +685,7 @@ func detectRunbookAreas(in string) *areas {
 	a := &areas{}
 	tokens := lexer.Tokenize(in)
 	parsed, err := parser.Parse(tokens, 0)
-	if err != nil {
+	if err != nil || len(parsed.Docs) == 0 {
 		return a
 	}
 	m, ok := parsed.Docs[0].Body.(*ast.MappingNode)
@@ -586,7 +718,7 @@ func detectRunbookAreas(in string) *areas {
 					aa := detectAreaFromNode(v)
 					// Get `-` token
 					t := v.GetToken()
-					for {
+					for t != nil {
 						if t.Value == "-" {
 							aa.Start = &position{
 								Line: t.Position.Line,
@@ -633,6 +765,9 @@ func (d *areaDetector) Visit(node ast.Node) ast.Visitor {
 func detectAreaFromNode(node ast.Node) *area {
 	d := &areaDetector{}
 	ast.Walk(d, node)
+	if d.start == nil {
+		return &area{Start: &position{}, End: &position{}}
+	}
 	a := &area{
 		Start: &position{
 			Line: d.start.Position.Line,
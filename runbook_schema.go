@@ -0,0 +1,393 @@
+package runn
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	goyaml "github.com/goccy/go-yaml"
+	"github.com/xeipuuv/gojsonschema"
+)
+
+// RunbookSchemaID - ID of the JSON Schema of the runbook.
+const RunbookSchemaID = "https://raw.githubusercontent.com/k1LoW/runn/main/runbook.schema.json"
+
+// RunbookValidationError - Error of the runbook that does not conform to the JSON Schema.
+type RunbookValidationError struct {
+	// Location - Location of the invalid value ( e.g. `steps[0].loop` )
+	Location string
+	// Message - Description of the error
+	Message string
+}
+
+func (e *RunbookValidationError) Error() string {
+	if e.Location == "" {
+		return e.Message
+	}
+	return fmt.Sprintf("%s: %s", e.Location, e.Message)
+}
+
+// RunbookJSONSchema returns the JSON Schema of the runbook ( both list-form and map-form steps ).
+func RunbookJSONSchema() []byte {
+	b, err := json.MarshalIndent(runbookSchema(), "", "  ")
+	if err != nil {
+		panic(err)
+	}
+	return append(b, '\n')
+}
+
+// Validate validates the runbook against the JSON Schema of the runbook.
+// The runbook is validated as written ( the environment variables like `${VAR}` are not expanded ), in the same way as editors do.
+// It returns nil if the runbook is valid.
+func Validate(b []byte) []error {
+	var v any
+	if err := goyaml.Unmarshal(b, &v); err != nil {
+		return []error{err}
+	}
+	v = normalizeSchemaValue(v)
+	s, err := runbookJSONSchemaLoader()
+	if err != nil {
+		return []error{err}
+	}
+	r, err := s.Validate(gojsonschema.NewGoLoader(v))
+	if err != nil {
+		return []error{err}
+	}
+	if r.Valid() {
+		return nil
+	}
+	var verrs []*RunbookValidationError
+	for _, re := range r.Errors() {
+		verrs = append(verrs, &RunbookValidationError{
+			Location: schemaLocation(v, re.Field()),
+			Message:  re.Description(),
+		})
+	}
+	sort.SliceStable(verrs, func(i, j int) bool {
+		return verrs[i].Error() < verrs[j].Error()
+	})
+	errs := make([]error, len(verrs))
+	for i, e := range verrs {
+		errs[i] = e
+	}
+	return errs
+}
+
+func runbookJSONSchemaLoader() (*gojsonschema.Schema, error) {
+	return gojsonschema.NewSchema(gojsonschema.NewGoLoader(runbookSchema()))
+}
+
+// normalizeSchemaValue converts the keys of the maps to string so that the value can be validated as JSON.
+func normalizeSchemaValue(v any) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		m := map[string]any{}
+		for k, vvv := range vv {
+			m[k] = normalizeSchemaValue(vvv)
+		}
+		return m
+	case map[any]any:
+		m := map[string]any{}
+		for k, vvv := range vv {
+			m[fmt.Sprint(k)] = normalizeSchemaValue(vvv)
+		}
+		return m
+	case []any:
+		s := make([]any, len(vv))
+		for i, vvv := range vv {
+			s[i] = normalizeSchemaValue(vvv)
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+// schemaLocation converts the field of the validation error ( e.g. `steps.0.loop` ) to the location of the runbook ( e.g. `steps[0].loop` ).
+func schemaLocation(v any, field string) string {
+	if field == gojsonschema.STRING_ROOT_SCHEMA_PROPERTY {
+		return ""
+	}
+	var loc string
+	rest := field
+	for rest != "" {
+		switch vv := v.(type) {
+		case []any:
+			idx, tail, _ := strings.Cut(rest, ".")
+			i, err := strconv.Atoi(idx)
+			if err != nil || i < 0 || i >= len(vv) {
+				return joinLocation(loc, rest)
+			}
+			loc = fmt.Sprintf("%s[%d]", loc, i)
+			v, rest = vv[i], tail
+		case map[string]any:
+			// The key may contain `.` ( e.g. `/users.json` )
+			var keys []string
+			for k := range vv {
+				if rest == k || strings.HasPrefix(rest, k+".") {
+					keys = append(keys, k)
+				}
+			}
+			if len(keys) == 0 {
+				return joinLocation(loc, rest)
+			}
+			sort.Slice(keys, func(i, j int) bool {
+				return len(keys[i]) > len(keys[j])
+			})
+			k := keys[0]
+			loc = joinLocation(loc, k)
+			v, rest = vv[k], strings.TrimPrefix(strings.TrimPrefix(rest, k), ".")
+		default:
+			return joinLocation(loc, rest)
+		}
+	}
+	return loc
+}
+
+func joinLocation(loc, key string) string {
+	if loc == "" {
+		return key
+	}
+	return loc + "." + key
+}
+
+func runbookSchema() map[string]any {
+	str := func(desc string) map[string]any {
+		return map[string]any{"type": "string", "description": desc}
+	}
+	// The environment variable ( `${VAR}` ) is expanded before parsing the runbook
+	const envPattern = `^\$\{[^}]+\}$`
+	boolean := func(desc string) map[string]any {
+		return map[string]any{"type": []string{"boolean", "string"}, "pattern": envPattern, "description": desc}
+	}
+	duration := func(desc string) map[string]any {
+		return map[string]any{"type": []string{"string", "number"}, "description": desc}
+	}
+	object := func(desc string) map[string]any {
+		return map[string]any{"type": "object", "description": desc}
+	}
+	strs := func(desc string) map[string]any {
+		return map[string]any{"type": "array", "items": map[string]any{"type": "string"}, "description": desc}
+	}
+	stepRef := map[string]any{"$ref": "#/definitions/step"}
+	steps := func(desc string) map[string]any {
+		return map[string]any{
+			"type":                 []string{"array", "object"},
+			"description":          desc,
+			"items":                stepRef,
+			"additionalProperties": stepRef,
+		}
+	}
+
+	loop := map[string]any{
+		"type":        []string{"string", "integer", "array", "object"},
+		"description": "Loop setting. The short syntax is the count ( or the list of items )",
+		"properties": map[string]any{
+			"count":       map[string]any{"type": []string{"string", "integer"}, "description": "Max number of loops"},
+			"interval":    duration("Interval of loops"),
+			"minInterval": duration("Min interval of loops with exponential backoff"),
+			"maxInterval": duration("Max interval of loops with exponential backoff"),
+			"jitter":      map[string]any{"type": "number", "description": "Jitter of the interval"},
+			"multiplier":  map[string]any{"type": "number", "description": "Multiplier of the interval"},
+			"until":       str("Condition to exit the loop"),
+			"breakIf":     str("Condition to exit the loop before the remaining runs"),
+			"continueIf":  str("Condition to skip the remaining runs of the iteration"),
+			"while":       str("Condition to continue the loop"),
+			"items":       map[string]any{"type": []string{"array", "string"}, "description": "Items to iterate over"},
+		},
+		"additionalProperties": false,
+	}
+	gotoItem := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"to": str("Key of the step to jump to"),
+			"if": str("Condition to jump"),
+		},
+		"required":             []string{"to"},
+		"additionalProperties": false,
+	}
+
+	step := map[string]any{
+		"type":        "object",
+		"description": "Step. The other key is the runner ( the key of `runners:` ) and the value is the request",
+		"properties": map[string]any{
+			descSectionKey: str("Description of the step"),
+			nameSectionKey: str("Name of the step ( list-form steps only )"),
+			ifSectionKey:   str("Condition to run the step"),
+			loopSectionKey: loop,
+			retrySectionKey: map[string]any{
+				"type":        []string{"integer", "string", "object"},
+				"description": "Retry setting. The short syntax is the max number of attempts",
+				"properties": map[string]any{
+					"max":         map[string]any{"type": []string{"integer", "string"}, "pattern": envPattern, "description": "Max number of attempts"},
+					"count":       map[string]any{"type": []string{"integer", "string"}, "pattern": envPattern, "description": "Alias of `max`"},
+					"interval":    duration("Interval of retries"),
+					"maxInterval": duration("Max interval of retries with backoff"),
+					"backoff":     map[string]any{"type": "number", "description": "Multiplier of the interval"},
+					"until":       str("Condition to stop retrying"),
+				},
+				"additionalProperties": false,
+			},
+			eventuallySectionKey: map[string]any{
+				"type":        []string{"string", "number", "object"},
+				"description": "Run the step until the test passes. The short syntax is the timeout",
+				"properties": map[string]any{
+					"timeout":     duration("Timeout"),
+					"max":         map[string]any{"type": []string{"integer", "string"}, "pattern": envPattern, "description": "Max number of attempts"},
+					"count":       map[string]any{"type": []string{"integer", "string"}, "pattern": envPattern, "description": "Alias of `max`"},
+					"interval":    duration("Interval of runs"),
+					"maxInterval": duration("Max interval of runs with backoff"),
+					"backoff":     map[string]any{"type": "number", "description": "Multiplier of the interval"},
+					"until":       str("Condition to stop running"),
+				},
+				"additionalProperties": false,
+			},
+			maxLatencySectionKey:  duration("Max latency of the runner of the step"),
+			expectErrorSectionKey: map[string]any{"type": []string{"string", "integer"}, "description": "Expected failure of the step ( e.g. `4xx`, `NotFound` or the condition )"},
+			needsSectionKey:       map[string]any{"type": []string{"string", "integer", "array"}, "description": "Keys of the preceding steps that the step needs"},
+			skipSectionKey:        boolean("Skip the step"),
+			onlySectionKey:        boolean("Run only the steps with `only: true`"),
+			forceSectionKey:       boolean("Run the step even if the previous steps fail"),
+			deferSectionKey:       boolean("Run the step after all the steps"),
+			gotoSectionKey: map[string]any{
+				"type":                 []string{"string", "object", "array"},
+				"description":          "Key of the step to jump to ( map-form steps only )",
+				"properties":           gotoItem["properties"],
+				"required":             gotoItem["required"],
+				"additionalProperties": false,
+				"items":                gotoItem,
+			},
+			useSectionKey:  str("Name of the template of `templates:`"),
+			withSectionKey: object("Values bound to the template"),
+			testRunnerKey: map[string]any{
+				"type":        []string{"boolean", "string", "array"},
+				"description": "Condition to test",
+				"items": map[string]any{
+					"type": []string{"string", "boolean", "object"},
+					"properties": map[string]any{
+						"cond":    map[string]any{"type": []string{"string", "boolean"}, "description": "Condition to test"},
+						"message": str("Message of the failure"),
+					},
+					"additionalProperties": false,
+				},
+			},
+			dumpRunnerKey: map[string]any{
+				"type":        []string{"string", "object"},
+				"description": "Expression to dump",
+				"properties": map[string]any{
+					"expr": str("Expression to dump"),
+					"out":  str("Path of the file to dump to"),
+				},
+				"required":             []string{"expr"},
+				"additionalProperties": false,
+			},
+			bindRunnerKey: object("Variables to bind"),
+			snapshotRunnerKey: map[string]any{
+				"type":        []string{"string", "object"},
+				"description": "Path of the snapshot file",
+				"properties": map[string]any{
+					"path":    str("Path of the snapshot file"),
+					"expr":    str("Expression of the value to compare"),
+					"ignores": strs("Paths of the values to ignore"),
+				},
+				"additionalProperties": false,
+			},
+			includeRunnerKey: map[string]any{
+				"type":        []string{"string", "object"},
+				"description": "Path of the runbook to include",
+				"properties": map[string]any{
+					"path":     str("Path of the runbook to include"),
+					"vars":     object("Variables passed to the included runbook"),
+					"skipTest": boolean("Skip `test:` of the included runbook"),
+					"force":    boolean("Run all the steps of the included runbook"),
+					"checksum": str("Checksum of the included runbook"),
+					"step":     map[string]any{"type": []string{"string", "array"}, "items": map[string]any{"type": "string"}, "description": "Keys of the steps to run"},
+					"isolate":  boolean("Run the included runbook with the isolated store"),
+					"runners":  object("Runners overridden in the included runbook"),
+				},
+				"required":             []string{"path"},
+				"additionalProperties": false,
+			},
+			parallelRunnerKey: steps("Steps run concurrently"),
+			groupRunnerKey: map[string]any{
+				"type":        "object",
+				"description": "Group of the steps",
+				"properties": map[string]any{
+					"steps":    map[string]any{"type": "array", "items": stepRef, "description": "Steps of the group"},
+					"interval": duration("Interval of the steps"),
+					"labels":   strs("Labels of the group"),
+					"runners":  object("Runners used by the steps of the group"),
+				},
+				"required":             []string{"steps"},
+				"additionalProperties": false,
+			},
+			execRunnerKey: object("Command to execute"),
+		},
+		"additionalProperties": object("Request of the runner of `runners:` ( HTTP, gRPC, DB, CDP, SSH )"),
+	}
+
+	return map[string]any{
+		"$schema":     "http://json-schema.org/draft-07/schema#",
+		"$id":         RunbookSchemaID,
+		"title":       "runn runbook",
+		"description": "Runbook ( runn scenario file )",
+		"type":        "object",
+		"properties": map[string]any{
+			"id":     str("ID of the runbook"),
+			"desc":   str("Description of the runbook"),
+			"labels": strs("Labels of the runbook"),
+			"runners": map[string]any{
+				"type":        "object",
+				"description": "Runners. The value is the endpoint ( or the DSN ) or the detailed setting",
+				"additionalProperties": map[string]any{
+					"type": []string{"string", "object"},
+				},
+			},
+			"hostRules": map[string]any{
+				"type":                 "object",
+				"description":          "Rules of the host resolution",
+				"additionalProperties": map[string]any{"type": "string"},
+			},
+			"vars":         object("Variables"),
+			"lazyVars":     object("Variables evaluated at the first reference"),
+			"varsSchema":   object("JSON Schema of `vars:`"),
+			"consts":       object("Constants"),
+			"secrets":      strs("Paths of the values to be masked"),
+			"envFiles":     strs("Paths of the dotenv files"),
+			"templates":    object("Templates of the steps"),
+			"debug":        boolean("Debug mode"),
+			"interval":     duration("Interval of the steps"),
+			"timeout":      duration("Timeout of the runbook"),
+			ifSectionKey:   map[string]any{"type": []string{"string", "boolean"}, "description": "Condition to run the runbook"},
+			"skipTest":     boolean("Skip `test:` of all the steps"),
+			"force":        boolean("Run all the steps even if the previous steps fail"),
+			"trace":        boolean("Add the trace header to the requests"),
+			loopSectionKey: loop,
+			"cases": map[string]any{
+				"type":        []string{"array", "string"},
+				"description": "Cases of the runbook ( the list of values or the path of the file )",
+				"items":       map[string]any{"type": "object"},
+			},
+			"concurrency": map[string]any{
+				"type":        []string{"string", "array"},
+				"description": "Keys of the shared resources that the runbook uses exclusively",
+				"items":       map[string]any{"type": "string"},
+			},
+			"hooks": map[string]any{
+				"type":        "object",
+				"description": "Steps run around every step",
+				"properties": map[string]any{
+					beforeEachHookKey: map[string]any{"type": "array", "items": stepRef, "description": "Steps run before every step"},
+					afterEachHookKey:  map[string]any{"type": "array", "items": stepRef, "description": "Steps run after every step"},
+				},
+				"additionalProperties": false,
+			},
+			"steps": steps("Steps ( list-form or map-form )"),
+		},
+		"definitions": map[string]any{
+			"step": step,
+		},
+	}
+}
@@ -0,0 +1,132 @@
+package runn
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestRunbookJSONSchema(t *testing.T) {
+	got := RunbookJSONSchema()
+	if os.Getenv("UPDATE_GOLDEN") != "" {
+		if err := os.WriteFile("runbook.schema.json", got, 0600); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile("runbook.schema.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff := cmp.Diff(string(got), string(want)); diff != "" {
+		t.Errorf("runbook.schema.json is outdated. run `make doc`\n%s", diff)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{
+			`desc: list-form
+runners:
+  req: https://example.com
+steps:
+  -
+    name: get
+    req:
+      /users:
+        get:
+          body: null
+    test: current.res.status == 200
+`,
+			nil,
+		},
+		{
+			`desc: map-form
+steps:
+  hello:
+    exec:
+      command: echo hello
+    loop: 3
+    retry:
+      max: 2
+  world:
+    include:
+      path: hello.yml
+      vars:
+        name: world
+`,
+			nil,
+		},
+		{
+			`desc: environment variables
+debug: ${DEBUG}
+steps:
+  -
+    test: true
+`,
+			nil,
+		},
+		{
+			`desc: invalid
+labels: http
+steps:
+  -
+    skip: yes please
+    loop:
+      cont: 3
+  -
+    include:
+      vars:
+        name: world
+`,
+			[]string{
+				"labels: Invalid type. Expected: array, given: string",
+				"steps[0].loop: Additional property cont is not allowed",
+				`steps[0].skip: Does not match pattern '^\$\{[^}]+\}$'`,
+				"steps[1].include: path is required",
+			},
+		},
+		{
+			`desc: invalid map-form
+steps:
+  get.users:
+    goto:
+      if: true
+`,
+			[]string{
+				"steps.get.users.goto.if: Invalid type. Expected: string, given: boolean",
+				"steps.get.users.goto: to is required",
+			},
+		},
+	}
+	for _, tt := range tests {
+		var got []string
+		for _, err := range Validate([]byte(tt.in)) {
+			got = append(got, err.Error())
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestValidateTestdata(t *testing.T) {
+	paths, err := filepath.Glob("testdata/book/*.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, err := range Validate(b) {
+			t.Errorf("%s: %v", p, err)
+		}
+	}
+}
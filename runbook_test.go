@@ -47,7 +47,7 @@ func TestParseRunbook(t *testing.T) {
 			if err != nil {
 				t.Error(err)
 			}
-			rb2, err := parseRunbook(b)
+			rb2, err := parseRunbook(b, os.LookupEnv)
 			if err != nil {
 				t.Error(err)
 			}
@@ -64,6 +64,30 @@ func TestAppendStep(t *testing.T) {
 		ins  [][]string
 	}{
 		{"curl_command", [][]string{{"curl", "https://example.com/path/to/index?foo=bar&baz=qux", "-XPOST", "-H", "Content-Type: application/json", "-d", `{"username": "alice"}`}}},
+		{"httpie_command", [][]string{{"http", "-a", "alice:passw0rd", "PUT", "example.com/users/1", "X-Request-Id:abc", "name=alice", "age:=20", "verbose==true"}}},
+		{"httpie_form_command", [][]string{{`https --form :8443/upload name=alice 'note=hello world'`}}},
+		{"httpie_multipart_command", [][]string{{"http", "-f", "POST", "example.com/upload", "name=alice", "avatar@../dummy.png"}}},
+		{"wget_command", [][]string{{"wget", "-qO-", "--method=PUT", "--header", "Content-Type: application/json", "--body-data", `{"name": "alice"}`, "--user=alice", "--password=passw0rd", "https://example.com/users/1?verbose=true"}}},
+		{"wget_post_data_command", [][]string{{`wget --post-data 'username=alice&password=passw0rd' -U runn example.com/login`}}},
+		{"fetch_snippet", [][]string{{`fetch("https://example.com/api/users?page=2", {
+  "headers": {
+    "accept": "application/json",
+    "accept-language": "en-US,en;q=0.9",
+    "content-type": "application/json"
+  },
+  "referrer": "https://example.com/",
+  "body": "{\"name\":\"alice\"}",
+  "method": "POST",
+  "mode": "cors",
+  "credentials": "include"
+});
+fetch("https://example.com/api/users/1", {
+  "headers": {
+    "accept": "application/json"
+  },
+  "body": null,
+  "method": "GET"
+});`}}},
 		{"grpc_command", [][]string{{"grpcurl", "-d", `{"id": 1234, "tags": ["foo","bar"]}`, "grpc.server.com:443", "my.custom.server.Service/Method"}}},
 		{"exec_command", [][]string{{"echo", "hello", "world"}}},
 		{"multiple_http_runner", [][]string{
@@ -74,6 +98,8 @@ func TestAppendStep(t *testing.T) {
 			{"echo", "hello", "world"},
 			{"echo", "hello", "world2"},
 		}},
+		{"har", [][]string{{"testdata/har/example.har"}}},
+		{"insomnia", [][]string{{"testdata/insomnia/export.json"}}},
 		{"axslog", [][]string{
 			// from https://github.com/Songmu/axslogparser/blob/master/axslogparser_test.go
 			{`10.0.0.11 - - [11/Jun/2017:05:56:04 +0900] "GET / HTTP/1.1" 200 741 "-" "mackerel-http-checker/0.0.1" "-"`},
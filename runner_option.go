@@ -5,9 +5,11 @@ import (
 	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"github.com/getkin/kin-openapi/openapi3"
 	"github.com/goccy/go-yaml"
+	"github.com/k1LoW/duration"
 )
 
 type httpRunnerConfig struct {
@@ -15,6 +17,7 @@ type httpRunnerConfig struct {
 	OpenApi3DocLocation  string `yaml:"openapi3,omitempty"`
 	SkipValidateRequest  bool   `yaml:"skipValidateRequest,omitempty"`
 	SkipValidateResponse bool   `yaml:"skipValidateResponse,omitempty"`
+	SchemaDrift          string `yaml:"schemaDrift,omitempty"`
 	NotFollowRedirect    bool   `yaml:"notFollowRedirect,omitempty"`
 	MultipartBoundary    string `yaml:"multipartBoundary,omitempty"`
 	CACert               string `yaml:"cacert,omitempty"`
@@ -50,8 +53,16 @@ type grpcRunnerConfig struct {
 }
 
 type dbRunnerConfig struct {
-	DSN   string `yaml:"dsn"`
-	Trace *bool  `yaml:"trace,omitempty"`
+	DSN             string `yaml:"dsn"`
+	Trace           *bool  `yaml:"trace,omitempty"`
+	MaxOpenConns    *int   `yaml:"maxOpenConns,omitempty"`
+	MaxIdleConns    *int   `yaml:"maxIdleConns,omitempty"`
+	ConnMaxLifetime string `yaml:"connMaxLifetime,omitempty"`
+	Snapshot        bool   `yaml:"snapshot,omitempty"`
+	SSLMode         string `yaml:"sslMode,omitempty"`
+	CACert          string `yaml:"cacert,omitempty"`
+	Cert            string `yaml:"cert,omitempty"`
+	Key             string `yaml:"key,omitempty"`
 }
 
 type sshRunnerConfig struct {
@@ -80,6 +91,42 @@ type dbRunnerOption func(*dbRunnerConfig) error
 
 type sshRunnerOption func(*sshRunnerConfig) error
 
+func (c *dbRunnerConfig) connPool() (*dbConnPool, error) {
+	p := &dbConnPool{
+		maxOpenConns: c.MaxOpenConns,
+		maxIdleConns: c.MaxIdleConns,
+	}
+	if c.ConnMaxLifetime != "" {
+		d, err := duration.Parse(c.ConnMaxLifetime)
+		if err != nil {
+			return nil, fmt.Errorf("connMaxLifetime in DBRunnerConfig is invalid: %w", err)
+		}
+		p.connMaxLifetime = &d
+	}
+	return p, nil
+}
+
+// dbTLS returns the TLS settings. The paths of certificates are resolved from root.
+func (c *dbRunnerConfig) dbTLS(root string) (*dbTLS, error) {
+	if c.SSLMode == "" && c.CACert == "" && c.Cert == "" && c.Key == "" {
+		return nil, nil
+	}
+	if (c.Cert == "") != (c.Key == "") {
+		return nil, fmt.Errorf("cert and key in DBRunnerConfig must be set together")
+	}
+	t := &dbTLS{sslMode: c.SSLMode}
+	if c.CACert != "" {
+		t.cacert = fp(c.CACert, root)
+	}
+	if c.Cert != "" {
+		t.cert = fp(c.Cert, root)
+	}
+	if c.Key != "" {
+		t.key = fp(c.Key, root)
+	}
+	return t, nil
+}
+
 func (c *sshRunnerConfig) validate() error {
 	if c.Host == "" && c.Hostname == "" {
 		return fmt.Errorf("host or hostname is required")
@@ -131,6 +178,17 @@ func SkipValidateResponse(skip bool) httpRunnerOption {
 	}
 }
 
+// SchemaDrift sets the mode to report the fields of HTTP response not described in OpenAPI Document, and vice versa ( `warn` or `fail` ).
+func SchemaDrift(mode string) httpRunnerOption {
+	return func(c *httpRunnerConfig) error {
+		if err := validateSchemaDriftMode(mode); err != nil {
+			return err
+		}
+		c.SchemaDrift = mode
+		return nil
+	}
+}
+
 func NotFollowRedirect(nf bool) httpRunnerOption {
 	return func(c *httpRunnerConfig) error {
 		c.NotFollowRedirect = nf
@@ -273,6 +331,70 @@ func DBTrace(trace bool) dbRunnerOption {
 	}
 }
 
+// DBSnapshot enable/disable to take the snapshot of the database once and restore it before each runbook.
+func DBSnapshot(enable bool) dbRunnerOption {
+	return func(c *dbRunnerConfig) error {
+		c.Snapshot = enable
+		return nil
+	}
+}
+
+// DBSSLMode sets the SSL mode of the connection (disable, require, verify-ca or verify-full).
+func DBSSLMode(mode string) dbRunnerOption {
+	return func(c *dbRunnerConfig) error {
+		c.SSLMode = mode
+		return nil
+	}
+}
+
+// DBCACert sets the path of the CA certificate to verify the server certificate.
+func DBCACert(path string) dbRunnerOption {
+	return func(c *dbRunnerConfig) error {
+		c.CACert = path
+		return nil
+	}
+}
+
+// DBCert sets the path of the client certificate.
+func DBCert(path string) dbRunnerOption {
+	return func(c *dbRunnerConfig) error {
+		c.Cert = path
+		return nil
+	}
+}
+
+// DBKey sets the path of the client key.
+func DBKey(path string) dbRunnerOption {
+	return func(c *dbRunnerConfig) error {
+		c.Key = path
+		return nil
+	}
+}
+
+// DBMaxOpenConns sets the maximum number of open connections to the database.
+func DBMaxOpenConns(n int) dbRunnerOption {
+	return func(c *dbRunnerConfig) error {
+		c.MaxOpenConns = &n
+		return nil
+	}
+}
+
+// DBMaxIdleConns sets the maximum number of connections in the idle connection pool.
+func DBMaxIdleConns(n int) dbRunnerOption {
+	return func(c *dbRunnerConfig) error {
+		c.MaxIdleConns = &n
+		return nil
+	}
+}
+
+// DBConnMaxLifetime sets the maximum amount of time a connection may be reused.
+func DBConnMaxLifetime(d time.Duration) dbRunnerOption {
+	return func(c *dbRunnerConfig) error {
+		c.ConnMaxLifetime = d.String()
+		return nil
+	}
+}
+
 func SSHConfig(p string) sshRunnerOption {
 	return func(c *sshRunnerConfig) error {
 		c.SSHConfig = p
@@ -52,6 +52,22 @@ func TestSkipValidateResponse(t *testing.T) {
 	}
 }
 
+func TestSchemaDrift(t *testing.T) {
+	c := &httpRunnerConfig{}
+	opt := SchemaDrift("fail")
+	if err := opt(c); err != nil {
+		t.Fatal(err)
+	}
+	got := c.SchemaDrift
+	want := "fail"
+	if got != want {
+		t.Errorf("got %v\nwant %v", got, want)
+	}
+	if err := SchemaDrift("invalid")(c); err == nil {
+		t.Error("want error")
+	}
+}
+
 func TestMultipartBoundary(t *testing.T) {
 	c := &httpRunnerConfig{}
 	want := "123456789012345678901234567890abcdefghijklmnopqrstuvwxyz"
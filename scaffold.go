@@ -0,0 +1,355 @@
+package runn
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	// ScaffoldByOperation - Scaffold one runbook per operation of the OpenAPI document.
+	ScaffoldByOperation = "operation"
+	// ScaffoldByTag - Scaffold one runbook per tag of the OpenAPI document. The operations without tags are grouped as `default`.
+	ScaffoldByTag = "tag"
+)
+
+const (
+	scaffoldDefaultTag      = "default"
+	scaffoldDefaultEndpoint = "http://localhost:8080"
+	// scaffoldMaxDepth - Max depth of the schema to generate the example values ( for recursive schemas )
+	scaffoldMaxDepth = 8
+)
+
+var scaffoldKeyRe = regexp.MustCompile(`[^a-zA-Z0-9_\-]+`)
+
+// ScaffoldRunbooks generates the skeleton runbooks from the OpenAPI document at the location l.
+// The runbooks are keyed by the file names. ref is the location of the document referred to by `openapi3:` of the runner ( omitted if empty ).
+func ScaffoldRunbooks(l, ref, by string) (map[string]*runbook, error) {
+	if by != ScaffoldByOperation && by != ScaffoldByTag {
+		return nil, fmt.Errorf("invalid scaffold unit: %s", by)
+	}
+	var (
+		doc *openapi3.T
+		err error
+	)
+	if strings.HasPrefix(l, "https://") || strings.HasPrefix(l, "http://") {
+		u, err := url.Parse(l)
+		if err != nil {
+			return nil, err
+		}
+		doc, err = oasLoader.LoadFromURI(u)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		doc, err = oasLoader.LoadFromFile(l)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := doc.Validate(oasLoader.Context); err != nil {
+		return nil, fmt.Errorf("openapi3 document validation error: %w", err)
+	}
+	if len(doc.Paths) == 0 {
+		return nil, errors.New("no operations in the openapi3 document")
+	}
+
+	runner := yaml.MapSlice{{Key: "endpoint", Value: scaffoldEndpoint(doc)}}
+	if ref != "" {
+		runner = append(runner, yaml.MapItem{Key: "openapi3", Value: ref})
+	}
+	rbs := map[string]*runbook{}
+	newRunbook := func(name, desc string) *runbook {
+		if rb, ok := rbs[name]; ok {
+			return rb
+		}
+		rb := NewRunbook(desc)
+		rb.Runners["req"] = runner
+		rb.useMap = true
+		rbs[name] = rb
+		return rb
+	}
+
+	paths := make([]string, 0, len(doc.Paths))
+	for p := range doc.Paths {
+		paths = append(paths, p)
+	}
+	sort.Strings(paths)
+	for _, p := range paths {
+		ops := doc.Paths[p].Operations()
+		methods := make([]string, 0, len(ops))
+		for m := range ops {
+			methods = append(methods, m)
+		}
+		sort.Strings(methods)
+		for _, m := range methods {
+			op := ops[m]
+			key := scaffoldStepKey(m, p, op)
+			step := scaffoldStep(m, p, op, doc.Paths[p].Parameters)
+			var rb *runbook
+			switch by {
+			case ScaffoldByOperation:
+				desc := op.Summary
+				if desc == "" {
+					desc = fmt.Sprintf("%s %s", m, p)
+				}
+				rb = newRunbook(key, desc)
+			case ScaffoldByTag:
+				tag := scaffoldDefaultTag
+				if len(op.Tags) > 0 {
+					tag = op.Tags[0]
+				}
+				rb = newRunbook(scaffoldKeyRe.ReplaceAllString(tag, "_"), tag)
+			}
+			rb.stepKeys = append(rb.stepKeys, key)
+			rb.Steps = append(rb.Steps, step)
+		}
+	}
+
+	named := map[string]*runbook{}
+	for n, rb := range rbs {
+		named[fmt.Sprintf("%s.yml", n)] = rb
+	}
+	return named, nil
+}
+
+func scaffoldEndpoint(doc *openapi3.T) string {
+	if len(doc.Servers) == 0 || doc.Servers[0] == nil {
+		return scaffoldDefaultEndpoint
+	}
+	s := doc.Servers[0]
+	u := s.URL
+	for k, v := range s.Variables {
+		u = strings.ReplaceAll(u, fmt.Sprintf("{%s}", k), v.Default)
+	}
+	if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+		// Relative server URL
+		u = scaffoldDefaultEndpoint + "/" + strings.TrimPrefix(u, "/")
+	}
+	return strings.TrimSuffix(u, "/")
+}
+
+func scaffoldStepKey(method, path string, op *openapi3.Operation) string {
+	if op.OperationID != "" {
+		return scaffoldKeyRe.ReplaceAllString(op.OperationID, "_")
+	}
+	k := strings.ToLower(method) + "_" + scaffoldKeyRe.ReplaceAllString(strings.Trim(path, "/"), "_")
+	return strings.Trim(k, "_")
+}
+
+// scaffoldStep generates the step of the operation. The parameters of the path item are overridden by the parameters of the operation.
+func scaffoldStep(method, path string, op *openapi3.Operation, params openapi3.Parameters) yaml.MapSlice {
+	desc := op.Summary
+	if desc == "" {
+		desc = fmt.Sprintf("%s %s", method, path)
+	}
+	var (
+		query   = url.Values{}
+		headers = map[string]string{}
+	)
+	for _, pr := range append(append(openapi3.Parameters{}, params...), op.Parameters...) {
+		if pr.Value == nil {
+			continue
+		}
+		p := pr.Value
+		v := fmt.Sprintf("%v", scaffoldParameterExample(p))
+		switch p.In {
+		case openapi3.ParameterInPath:
+			path = strings.ReplaceAll(path, fmt.Sprintf("{%s}", p.Name), url.PathEscape(v))
+		case openapi3.ParameterInQuery:
+			if p.Required {
+				query.Set(p.Name, v)
+			}
+		case openapi3.ParameterInHeader:
+			if p.Required {
+				headers[p.Name] = v
+			}
+		}
+	}
+	if len(query) > 0 {
+		path = fmt.Sprintf("%s?%s", path, query.Encode())
+	}
+
+	req := yaml.MapSlice{}
+	if len(headers) > 0 {
+		req = append(req, yaml.MapItem{Key: "headers", Value: headers})
+	}
+	var body any
+	if op.RequestBody != nil && op.RequestBody.Value != nil && len(op.RequestBody.Value.Content) > 0 {
+		ct, mt := scaffoldMediaType(op.RequestBody.Value.Content)
+		body = yaml.MapSlice{{Key: ct, Value: scaffoldMediaTypeExample(mt)}}
+	}
+	req = append(req, yaml.MapItem{Key: "body", Value: body})
+
+	return yaml.MapSlice{
+		{Key: descSectionKey, Value: desc},
+		{Key: "req", Value: yaml.MapSlice{
+			{Key: path, Value: yaml.MapSlice{
+				{Key: strings.ToLower(method), Value: req},
+			}},
+		}},
+		{Key: testRunnerKey, Value: fmt.Sprintf("current.res.status == %d", scaffoldStatus(op))},
+	}
+}
+
+// scaffoldMediaType returns the media type to be used for the request body ( application/json preferred ).
+func scaffoldMediaType(c openapi3.Content) (string, *openapi3.MediaType) {
+	if mt, ok := c[MediaTypeApplicationJSON]; ok {
+		return MediaTypeApplicationJSON, mt
+	}
+	cts := make([]string, 0, len(c))
+	for ct := range c {
+		cts = append(cts, ct)
+	}
+	sort.Strings(cts)
+	return cts[0], c[cts[0]]
+}
+
+// scaffoldStatus returns the lowest 2xx status code of the responses of the operation.
+func scaffoldStatus(op *openapi3.Operation) int {
+	st := 0
+	for k := range op.Responses {
+		c, err := strconv.Atoi(k)
+		if err != nil || c < 200 || c >= 300 {
+			continue
+		}
+		if st == 0 || c < st {
+			st = c
+		}
+	}
+	if st == 0 {
+		return 200
+	}
+	return st
+}
+
+func scaffoldParameterExample(p *openapi3.Parameter) any {
+	if p.Example != nil {
+		return p.Example
+	}
+	for _, k := range sortedExampleKeys(p.Examples) {
+		if e := p.Examples[k]; e.Value != nil && e.Value.Value != nil {
+			return e.Value.Value
+		}
+	}
+	if p.Schema != nil {
+		return scaffoldSchemaExample(p.Schema.Value, 0)
+	}
+	return "string"
+}
+
+func scaffoldMediaTypeExample(mt *openapi3.MediaType) any {
+	if mt == nil {
+		return nil
+	}
+	if mt.Example != nil {
+		return mt.Example
+	}
+	for _, k := range sortedExampleKeys(mt.Examples) {
+		if e := mt.Examples[k]; e.Value != nil && e.Value.Value != nil {
+			return e.Value.Value
+		}
+	}
+	if mt.Schema != nil {
+		return scaffoldSchemaExample(mt.Schema.Value, 0)
+	}
+	return nil
+}
+
+func sortedExampleKeys(es openapi3.Examples) []string {
+	keys := make([]string, 0, len(es))
+	for k, e := range es {
+		if e == nil {
+			continue
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// scaffoldSchemaExample generates the example value of the schema ( example, default, enum or the zero-like value of the type ).
+func scaffoldSchemaExample(s *openapi3.Schema, depth int) any {
+	if s == nil || depth > scaffoldMaxDepth {
+		return nil
+	}
+	switch {
+	case s.Example != nil:
+		return s.Example
+	case s.Default != nil:
+		return s.Default
+	case len(s.Enum) > 0:
+		return s.Enum[0]
+	case len(s.AllOf) > 0:
+		m := map[string]any{}
+		for _, sr := range s.AllOf {
+			if v, ok := scaffoldSchemaExample(sr.Value, depth+1).(map[string]any); ok {
+				for k, vv := range v {
+					m[k] = vv
+				}
+			}
+		}
+		return m
+	case len(s.OneOf) > 0:
+		return scaffoldSchemaExample(s.OneOf[0].Value, depth+1)
+	case len(s.AnyOf) > 0:
+		return scaffoldSchemaExample(s.AnyOf[0].Value, depth+1)
+	}
+	switch s.Type {
+	case openapi3.TypeObject, "":
+		if len(s.Properties) == 0 {
+			if s.Type == "" {
+				return nil
+			}
+			return map[string]any{}
+		}
+		m := map[string]any{}
+		for k, sr := range s.Properties {
+			if sr == nil {
+				continue
+			}
+			m[k] = scaffoldSchemaExample(sr.Value, depth+1)
+		}
+		return m
+	case openapi3.TypeArray:
+		if s.Items == nil {
+			return []any{}
+		}
+		return []any{scaffoldSchemaExample(s.Items.Value, depth+1)}
+	case openapi3.TypeString:
+		switch s.Format {
+		case "date":
+			return "2024-01-01"
+		case "date-time":
+			return "2024-01-01T00:00:00Z"
+		case "email":
+			return "user@example.com"
+		case "uuid":
+			return "00000000-0000-0000-0000-000000000000"
+		case "uri", "url":
+			return "https://example.com"
+		default:
+			return "string"
+		}
+	case openapi3.TypeInteger:
+		if s.Min != nil {
+			return int(*s.Min)
+		}
+		return 0
+	case openapi3.TypeNumber:
+		if s.Min != nil {
+			return *s.Min
+		}
+		return 0
+	case openapi3.TypeBoolean:
+		return false
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package runn
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"gopkg.in/yaml.v2"
+)
+
+func TestScaffoldRunbooks(t *testing.T) {
+	tests := []struct {
+		by   string
+		want map[string][]string
+	}{
+		{
+			ScaffoldByOperation,
+			map[string][]string{
+				"createUser.yml": {"createUser"},
+				"getUser.yml":    {"getUser"},
+				"get_health.yml": {"get_health"},
+				"listUsers.yml":  {"listUsers"},
+			},
+		},
+		{
+			ScaffoldByTag,
+			map[string][]string{
+				"default.yml": {"get_health"},
+				"users.yml":   {"listUsers", "createUser", "getUser"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.by, func(t *testing.T) {
+			rbs, err := ScaffoldRunbooks("testdata/scaffold/openapi3.yml", "openapi3.yml", tt.by)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := map[string][]string{}
+			for n, rb := range rbs {
+				// The scaffolded runbooks can be parsed as runbooks
+				b := new(bytes.Buffer)
+				if err := yaml.NewEncoder(b).Encode(rb); err != nil {
+					t.Fatal(err)
+				}
+				parsed, err := ParseRunbook(b)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got[n] = parsed.stepKeys
+			}
+			if diff := cmp.Diff(tt.want, got); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestScaffoldStep(t *testing.T) {
+	rbs, err := ScaffoldRunbooks("testdata/scaffold/openapi3.yml", "", ScaffoldByOperation)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		want string
+	}{
+		{
+			"listUsers.yml",
+			`desc: List users
+runners:
+  req:
+    endpoint: https://dev.example.com/api
+steps:
+  listUsers:
+    desc: List users
+    req:
+      /users?limit=1:
+        get:
+          body: null
+    test: current.res.status == 200
+`,
+		},
+		{
+			"createUser.yml",
+			`desc: Create user
+runners:
+  req:
+    endpoint: https://dev.example.com/api
+steps:
+  createUser:
+    desc: Create user
+    req:
+      /users:
+        post:
+          body:
+            application/json:
+              email: user@example.com
+              role: admin
+              tags:
+              - string
+              username: alice
+    test: current.res.status == 201
+`,
+		},
+		{
+			"get_health.yml",
+			`desc: GET /health
+runners:
+  req:
+    endpoint: https://dev.example.com/api
+steps:
+  get_health:
+    desc: GET /health
+    req:
+      /health:
+        get:
+          headers:
+            X-Request-Id: 00000000-0000-0000-0000-000000000000
+          body: null
+    test: current.res.status == 204
+`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := new(bytes.Buffer)
+			if err := yaml.NewEncoder(b).Encode(rbs[tt.name]); err != nil {
+				t.Fatal(err)
+			}
+			if diff := cmp.Diff(tt.want, b.String()); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestScaffoldRunbooksInvalidUnit(t *testing.T) {
+	if _, err := ScaffoldRunbooks("testdata/scaffold/openapi3.yml", "", "path"); err == nil {
+		t.Error("want error")
+	}
+}
@@ -0,0 +1,369 @@
+package runn
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/expr-lang/expr"
+	"github.com/spf13/cast"
+	"google.golang.org/grpc/status"
+)
+
+const maskedValue = "*****"
+
+// minSecretLength - Secret values shorter than this are not masked, because masking them corrupts unrelated outputs.
+const minSecretLength = 4
+
+// secretValues returns the values of `secrets:` of the operator and the parent operators.
+func (o *operator) secretValues() []string {
+	var values []string
+	if o.parent != nil && o.parent.parent != nil {
+		values = o.parent.parent.secretValues()
+	}
+	if len(o.secrets) == 0 {
+		return values
+	}
+	store := o.store.toMap()
+	for _, p := range o.secrets {
+		// Use expr.Eval directly not to evaluate lazyVars
+		v, err := expr.Eval(p, store)
+		if (err != nil || v == nil) && !strings.ContainsAny(p, ".[") {
+			v, err = expr.Eval(storeRootKeyVars+"."+p, store)
+		}
+		if err != nil {
+			continue
+		}
+		values = append(values, secretStrings(v)...)
+	}
+	return values
+}
+
+func secretStrings(v any) []string {
+	switch vv := v.(type) {
+	case nil, *lazyVar:
+		return nil
+	case map[string]any:
+		var values []string
+		for _, vvv := range vv {
+			values = append(values, secretStrings(vvv)...)
+		}
+		return values
+	case []any:
+		var values []string
+		for _, vvv := range vv {
+			values = append(values, secretStrings(vvv)...)
+		}
+		return values
+	default:
+		s, err := cast.ToStringE(v)
+		if err != nil || len(s) < minSecretLength {
+			return nil
+		}
+		return []string{s}
+	}
+}
+
+// masker returns the replacer to mask the secret values. It returns nil if there is no secret value.
+// The replacer is built once and reused until the secret values may be changed ( see resetMasker ).
+func (o *operator) masker() *strings.Replacer {
+	o.maskMu.Lock()
+	defer o.maskMu.Unlock()
+	if o.maskBuilt {
+		return o.maskReplacer
+	}
+	o.maskReplacer = nil
+	o.maskBuilt = true
+	values := o.secretValues()
+	if len(values) == 0 {
+		return nil
+	}
+	// Replace longer values first
+	sort.Slice(values, func(i, j int) bool { return len(values[i]) > len(values[j]) })
+	var oldnew []string
+	for _, v := range values {
+		oldnew = append(oldnew, v, maskedValue)
+	}
+	o.maskReplacer = strings.NewReplacer(oldnew...)
+	return o.maskReplacer
+}
+
+// resetMasker discards the replacer built by masker to rebuild it with the current secret values.
+func (o *operator) resetMasker() {
+	o.maskMu.Lock()
+	defer o.maskMu.Unlock()
+	o.maskReplacer = nil
+	o.maskBuilt = false
+}
+
+func (o *operator) mask(s string) string {
+	m := o.masker()
+	if m == nil {
+		return s
+	}
+	return m.Replace(s)
+}
+
+// maskErr returns the error whose message is masked.
+func (o *operator) maskErr(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := o.mask(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &maskedError{err: err, msg: msg}
+}
+
+// maskRunResult masks the error messages of the run result.
+func (o *operator) maskRunResult(r *RunResult) {
+	if r == nil || o.masker() == nil {
+		return
+	}
+	r.Err = o.maskErr(r.Err)
+	for _, sr := range r.StepResults {
+		if sr == nil {
+			continue
+		}
+		sr.Err = o.maskErr(sr.Err)
+		o.maskRunResult(sr.IncludedRunResult)
+	}
+}
+
+// maskedError - Error whose message is masked by `secrets:`.
+type maskedError struct {
+	err error
+	msg string
+}
+
+func (e *maskedError) Error() string {
+	return e.msg
+}
+
+func (e *maskedError) Unwrap() error {
+	return e.err
+}
+
+// maskWriter - Writer that masks the secret values.
+type maskWriter struct {
+	o *operator
+	w io.Writer
+}
+
+func (w *maskWriter) Write(p []byte) (int, error) {
+	if _, err := w.w.Write([]byte(w.o.mask(string(p)))); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+func (o *operator) maskValue(v any) any {
+	switch vv := v.(type) {
+	case string:
+		return o.mask(vv)
+	case map[string]any:
+		m := map[string]any{}
+		for k, vvv := range vv {
+			m[k] = o.maskValue(vvv)
+		}
+		return m
+	case []any:
+		s := make([]any, 0, len(vv))
+		for _, vvv := range vv {
+			s = append(s, o.maskValue(vvv))
+		}
+		return s
+	default:
+		return v
+	}
+}
+
+func (o *operator) maskMap(m map[string]any) map[string]any {
+	if m == nil {
+		return nil
+	}
+	mm, _ := o.maskValue(m).(map[string]any)
+	return mm
+}
+
+func (o *operator) maskHeader(h map[string][]string) map[string][]string {
+	if h == nil {
+		return nil
+	}
+	mh := map[string][]string{}
+	for k, vs := range h {
+		for _, v := range vs {
+			mh[k] = append(mh[k], o.mask(v))
+		}
+	}
+	return mh
+}
+
+// maskBody reads the body, restores it and returns the masked copy.
+func (o *operator) maskBody(body *io.ReadCloser) (io.ReadCloser, int64) {
+	if *body == nil || *body == http.NoBody {
+		return *body, 0
+	}
+	b, err := io.ReadAll(*body)
+	if err != nil {
+		return http.NoBody, 0
+	}
+	_ = (*body).Close()
+	*body = io.NopCloser(bytes.NewReader(b))
+	mb := []byte(o.mask(string(b)))
+	return io.NopCloser(bytes.NewReader(mb)), int64(len(mb))
+}
+
+// maskCapturer masks the secret values of `secrets:` passed to the capturer.
+type maskCapturer struct {
+	c Capturer
+	o *operator
+}
+
+func newMaskCapturers(cs capturers, o *operator) capturers {
+	mcs := make(capturers, 0, len(cs))
+	for _, c := range cs {
+		mcs = append(mcs, &maskCapturer{c: c, o: o})
+	}
+	return mcs
+}
+
+func (c *maskCapturer) CaptureStart(trs Trails, bookPath, desc string) {
+	c.c.CaptureStart(trs, bookPath, desc)
+}
+
+func (c *maskCapturer) CaptureResult(trs Trails, result *RunResult) {
+	c.o.maskRunResult(result)
+	c.c.CaptureResult(trs, result)
+}
+
+func (c *maskCapturer) CaptureEnd(trs Trails, bookPath, desc string) {
+	c.c.CaptureEnd(trs, bookPath, desc)
+}
+
+func (c *maskCapturer) CaptureResultByStep(trs Trails, result *RunResult) {
+	c.o.maskRunResult(result)
+	c.c.CaptureResultByStep(trs, result)
+}
+
+func (c *maskCapturer) CaptureHTTPRequest(name string, req *http.Request) {
+	r := req.Clone(req.Context())
+	r.Header = c.o.maskHeader(req.Header)
+	if u, err := url.Parse(c.o.mask(req.URL.String())); err == nil {
+		r.URL = u
+	}
+	r.Body, r.ContentLength = c.o.maskBody(&req.Body)
+	c.c.CaptureHTTPRequest(name, r)
+}
+
+func (c *maskCapturer) CaptureHTTPResponse(name string, res *http.Response) {
+	r := *res
+	r.Header = c.o.maskHeader(res.Header)
+	r.Body, r.ContentLength = c.o.maskBody(&res.Body)
+	c.c.CaptureHTTPResponse(name, &r)
+}
+
+func (c *maskCapturer) CaptureGRPCStart(name string, typ GRPCType, service, method string) {
+	c.c.CaptureGRPCStart(name, typ, service, method)
+}
+
+func (c *maskCapturer) CaptureGRPCRequestHeaders(h map[string][]string) {
+	c.c.CaptureGRPCRequestHeaders(c.o.maskHeader(h))
+}
+
+func (c *maskCapturer) CaptureGRPCRequestMessage(m map[string]any) {
+	c.c.CaptureGRPCRequestMessage(c.o.maskMap(m))
+}
+
+func (c *maskCapturer) CaptureGRPCResponseStatus(s *status.Status) {
+	c.c.CaptureGRPCResponseStatus(s)
+}
+
+func (c *maskCapturer) CaptureGRPCResponseHeaders(h map[string][]string) {
+	c.c.CaptureGRPCResponseHeaders(c.o.maskHeader(h))
+}
+
+func (c *maskCapturer) CaptureGRPCResponseMessage(m map[string]any) {
+	c.c.CaptureGRPCResponseMessage(c.o.maskMap(m))
+}
+
+func (c *maskCapturer) CaptureGRPCResponseTrailers(t map[string][]string) {
+	c.c.CaptureGRPCResponseTrailers(c.o.maskHeader(t))
+}
+
+func (c *maskCapturer) CaptureGRPCClientClose() {
+	c.c.CaptureGRPCClientClose()
+}
+
+func (c *maskCapturer) CaptureGRPCEnd(name string, typ GRPCType, service, method string) {
+	c.c.CaptureGRPCEnd(name, typ, service, method)
+}
+
+func (c *maskCapturer) CaptureCDPStart(name string) {
+	c.c.CaptureCDPStart(name)
+}
+
+func (c *maskCapturer) CaptureCDPAction(a CDPAction) {
+	c.c.CaptureCDPAction(CDPAction{Fn: a.Fn, Args: c.o.maskMap(a.Args)})
+}
+
+func (c *maskCapturer) CaptureCDPResponse(a CDPAction, res map[string]any) {
+	c.c.CaptureCDPResponse(CDPAction{Fn: a.Fn, Args: c.o.maskMap(a.Args)}, c.o.maskMap(res))
+}
+
+func (c *maskCapturer) CaptureCDPEnd(name string) {
+	c.c.CaptureCDPEnd(name)
+}
+
+func (c *maskCapturer) CaptureSSHCommand(command string) {
+	c.c.CaptureSSHCommand(c.o.mask(command))
+}
+
+func (c *maskCapturer) CaptureSSHStdout(stdout string) {
+	c.c.CaptureSSHStdout(c.o.mask(stdout))
+}
+
+func (c *maskCapturer) CaptureSSHStderr(stderr string) {
+	c.c.CaptureSSHStderr(c.o.mask(stderr))
+}
+
+func (c *maskCapturer) CaptureDBStatement(name string, stmt string) {
+	c.c.CaptureDBStatement(name, c.o.mask(stmt))
+}
+
+func (c *maskCapturer) CaptureDBResponse(name string, res *DBResponse) {
+	r := *res
+	r.Rows = nil
+	for _, row := range res.Rows {
+		r.Rows = append(r.Rows, c.o.maskMap(row))
+	}
+	c.c.CaptureDBResponse(name, &r)
+}
+
+func (c *maskCapturer) CaptureExecCommand(command, shell string) {
+	c.c.CaptureExecCommand(c.o.mask(command), shell)
+}
+
+func (c *maskCapturer) CaptureExecStdin(stdin string) {
+	c.c.CaptureExecStdin(c.o.mask(stdin))
+}
+
+func (c *maskCapturer) CaptureExecStdout(stdout string) {
+	c.c.CaptureExecStdout(c.o.mask(stdout))
+}
+
+func (c *maskCapturer) CaptureExecStderr(stderr string) {
+	c.c.CaptureExecStderr(c.o.mask(stderr))
+}
+
+func (c *maskCapturer) SetCurrentTrails(trs Trails) {
+	c.c.SetCurrentTrails(trs)
+}
+
+func (c *maskCapturer) Errs() error {
+	return c.c.Errs()
+}
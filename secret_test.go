@@ -0,0 +1,141 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/k1LoW/runn/testutil"
+)
+
+func TestSecrets(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	const book = `desc: Secrets
+vars:
+  token: s3cr3t-t0ken
+secrets:
+  - token
+  - key
+steps:
+  login:
+    req:
+      /users?token={{ vars.token }}:
+        post:
+          headers:
+            Authorization: 'Bearer {{ vars.token }}'
+          body:
+            application/json:
+              username: alice
+              password: '{{ vars.token }}'
+    bind:
+      key: '"k3y-" + vars.token'
+  check:
+    test: vars.token == "invalid"
+`
+	ctx := context.Background()
+	p := filepath.Join(t.TempDir(), "secrets.yml")
+	if err := os.WriteFile(p, []byte(book), 0600); err != nil {
+		t.Fatal(err)
+	}
+	hs := testutil.HTTPServer(t)
+	debug := new(bytes.Buffer)
+	capture := new(bytes.Buffer)
+	o, err := New(
+		Scopes(ScopeAllowReadParent),
+		Book(p),
+		HTTPRunner("req", hs.URL, hs.Client()),
+		Debug(true),
+		Stderr(debug),
+		Capture(NewDebugger(capture)),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = o.Run(ctx)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if strings.Contains(err.Error(), "s3cr3t-t0ken") {
+		t.Errorf("error message contains the secret: %v", err)
+	}
+	if !strings.Contains(err.Error(), maskedValue) {
+		t.Errorf("error message does not contain %q: %v", maskedValue, err)
+	}
+	var mErr *maskedError
+	if !errors.As(err, &mErr) {
+		t.Errorf("got %T, want *maskedError", err)
+	}
+	for _, out := range []string{debug.String(), capture.String(), o.Result().Err.Error()} {
+		if out == "" {
+			t.Error("empty output")
+		}
+		if strings.Contains(out, "s3cr3t-t0ken") {
+			t.Errorf("output contains the secret:\n%s", out)
+		}
+	}
+	if !strings.Contains(debug.String(), "Bearer "+maskedValue) {
+		t.Errorf("debug output does not contain the masked header:\n%s", debug.String())
+	}
+}
+
+func TestMask(t *testing.T) {
+	o, err := New(Var("token", "t0ken"), Var("tokens", []any{"t0ken-long", "p4ss", "abc", 1}), Secrets("token", "vars.tokens", "vars.undefined"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"", ""},
+		{"no secret", "no secret"},
+		{"Bearer t0ken", "Bearer *****"},
+		{"t0ken-long and t0ken", "***** and *****"},
+		{"p4ssw0rd", "*****w0rd"},
+		{"abc 1", "abc 1"},
+	}
+	for _, tt := range tests {
+		if got := o.mask(tt.in); got != tt.want {
+			t.Errorf("got %q\nwant %q", got, tt.want)
+		}
+	}
+}
+
+func TestMaskerReset(t *testing.T) {
+	o, err := New(Var("token", "t0ken"), Secrets("token"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := o.masker()
+	if got := o.masker(); got != m {
+		t.Error("the masker should be reused")
+	}
+	o.store.vars["token"] = "r3fr3shed"
+	if got := o.mask("r3fr3shed"); got != "r3fr3shed" {
+		t.Errorf("got %q\nwant %q", got, "r3fr3shed")
+	}
+	o.resetMasker()
+	if got := o.mask("r3fr3shed"); got != maskedValue {
+		t.Errorf("got %q\nwant %q", got, maskedValue)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			o.resetMasker()
+			_ = o.mask("r3fr3shed")
+		}()
+	}
+	wg.Wait()
+}
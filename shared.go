@@ -0,0 +1,61 @@
+package runn
+
+import (
+	"errors"
+	"strings"
+	"sync"
+)
+
+// sharedStore - Values of `shared.*` shared across runbooks in one RunN.
+type sharedStore struct {
+	values map[string]any
+	mu     sync.Mutex
+}
+
+func newSharedStore() *sharedStore {
+	return &sharedStore{
+		values: map[string]any{},
+	}
+}
+
+func (s *sharedStore) toMap() map[string]any {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := make(map[string]any, len(s.values))
+	for k, v := range s.values {
+		m[k] = v
+	}
+	return m
+}
+
+// bind evaluates the bind key ( e.g. `shared.token`, `shared[key]` ) and the value, and merges it into the shared store.
+func (s *sharedStore) bind(k string, v any, store map[string]any) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kv, err := evalBindKeyValue(map[string]any{storeRootKeyShared: s.values}, k, v, store)
+	if err != nil {
+		return err
+	}
+	switch vv := kv[storeRootKeyShared].(type) {
+	case map[string]any:
+		s.values = vv
+	case map[any]any:
+		m := make(map[string]any, len(vv))
+		for kk, vvv := range vv {
+			ks, ok := kk.(string)
+			if !ok {
+				return errors.New("invalid key of shared: the keys of shared must be strings")
+			}
+			m[ks] = vvv
+		}
+		s.values = m
+	default:
+		return errors.New("invalid value of shared: shared must be a map")
+	}
+	return nil
+}
+
+// isSharedKey returns true if the bind key is for the shared store.
+func isSharedKey(k string) bool {
+	return k == storeRootKeyShared || strings.HasPrefix(k, storeRootKeyShared+".") || strings.HasPrefix(k, storeRootKeyShared+"[")
+}
@@ -0,0 +1,68 @@
+package runn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSharedStore(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	books := map[string]string{
+		"01_setup.yml": `desc: Setup
+steps:
+  -
+    bind:
+      shared.token: '"t0ken"'
+      shared[vars.key]: 'len("t0ken")'
+vars:
+  key: length
+`,
+		"02_use.yml": `desc: Use
+steps:
+  -
+    test: |
+      shared.token == "t0ken"
+      && shared.length == 5
+  -
+    bind:
+      shared.token: shared.token + "-renewed"
+`,
+		"03_use_renewed.yml": `desc: Use renewed
+steps:
+  -
+    test: shared.token == "t0ken-renewed"
+`,
+	}
+	tests := []struct {
+		enable      bool
+		wantFailure bool
+	}{
+		{true, false},
+		{false, true},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		dir := t.TempDir()
+		for n, b := range books {
+			if err := os.WriteFile(filepath.Join(dir, n), []byte(b), 0600); err != nil {
+				t.Fatal(err)
+			}
+		}
+		ops, err := Load(filepath.Join(dir, "*.yml"), Scopes(ScopeAllowReadParent), SharedStore(tt.enable))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := ops.RunN(ctx); err != nil {
+			t.Fatal(err)
+		}
+		if got := ops.Result().HasFailure(); got != tt.wantFailure {
+			t.Errorf("enable %v: got %v\nwant %v", tt.enable, got, tt.wantFailure)
+		}
+	}
+}
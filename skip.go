@@ -0,0 +1,6 @@
+package runn
+
+const (
+	skipSectionKey = "skip"
+	onlySectionKey = "only"
+)
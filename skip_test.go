@@ -0,0 +1,49 @@
+package runn
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSkipAndOnly(t *testing.T) {
+	tests := []struct {
+		book        string
+		wantSkipped []bool
+	}{
+		{"testdata/book/skip.yml", []bool{false, true, false}},
+		{"testdata/book/only.yml", []bool{true, false, false, true}},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.book, func(t *testing.T) {
+			o, err := New(Book(tt.book))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); err != nil {
+				t.Fatal(err)
+			}
+			rs := o.StepResults()
+			if len(rs) != len(tt.wantSkipped) {
+				t.Fatalf("got %v\nwant %v", len(rs), len(tt.wantSkipped))
+			}
+			for i, want := range tt.wantSkipped {
+				if rs[i].Skipped != want {
+					t.Errorf("steps[%d]: got %v\nwant %v", i, rs[i].Skipped, want)
+				}
+			}
+		})
+	}
+}
+
+func TestSkipInvalid(t *testing.T) {
+	for _, k := range []string{"skip", "only"} {
+		o, err := New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.AppendStep(0, "0", map[string]any{k: "true", "test": "true"}); err == nil {
+			t.Errorf("%s: want error", k)
+		}
+	}
+}
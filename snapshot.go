@@ -0,0 +1,193 @@
+package runn
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/k1LoW/runn/builtin"
+	"github.com/spf13/cast"
+)
+
+const snapshotRunnerKey = "snapshot"
+
+const defaultSnapshotExpr = storeRootKeyCurrent
+
+type snapshotRunner struct{}
+
+type snapshotConfig struct {
+	// path - Path of the snapshot ( golden ) file. Relative to the runbook
+	path string
+	// expr - Expression of the value to compare
+	expr string
+	// ignores - Keys or paths to ignore ( e.g. `updatedAt`, `.items[*].id` )
+	ignores []string
+}
+
+type snapshotMismatchError struct {
+	path string
+	diff string
+}
+
+func (e *snapshotMismatchError) Error() string {
+	return fmt.Sprintf("snapshot does not match %s\n\nDiff:\n%s", e.path, e.diff)
+}
+
+func newSnapshotRunner() *snapshotRunner {
+	return &snapshotRunner{}
+}
+
+// parseSnapshotConfig parses `snapshot:`. The value is the path of the snapshot file or the map of `path:`, `expr:` and `ignores:`.
+func parseSnapshotConfig(v any) (*snapshotConfig, error) {
+	c := &snapshotConfig{
+		expr: defaultSnapshotExpr,
+	}
+	switch vv := v.(type) {
+	case string:
+		c.path = vv
+	case map[string]any:
+		for k, vvv := range vv {
+			switch k {
+			case "path":
+				p, ok := vvv.(string)
+				if !ok {
+					return nil, fmt.Errorf("invalid snapshot path: %v", vvv)
+				}
+				c.path = p
+			case "expr":
+				e, ok := vvv.(string)
+				if !ok || e == "" {
+					return nil, fmt.Errorf("invalid snapshot expr: %v", vvv)
+				}
+				c.expr = e
+			case "ignores":
+				ignores, err := cast.ToStringSliceE(vvv)
+				if err != nil {
+					return nil, fmt.Errorf("invalid snapshot ignores: %v", vvv)
+				}
+				c.ignores = ignores
+			default:
+				return nil, fmt.Errorf("invalid snapshot: invalid key: %s", k)
+			}
+		}
+	default:
+		return nil, fmt.Errorf("invalid snapshot: %v", v)
+	}
+	if c.path == "" {
+		return nil, fmt.Errorf("invalid snapshot: path is required: %v", v)
+	}
+	return c, nil
+}
+
+func (rnr *snapshotRunner) Run(ctx context.Context, s *step, first bool) error {
+	o := s.parent
+	c := s.snapshotConfig
+	store := o.store.toMap()
+	store[storeRootKeyIncluded] = o.included
+	if first {
+		store[storeRootPrevious] = o.store.latest()
+	} else {
+		store[storeRootPrevious] = o.store.previous()
+		store[storeRootKeyCurrent] = o.store.latest()
+	}
+	pv, err := EvalExpand(c.path, store)
+	if err != nil {
+		return err
+	}
+	p, ok := pv.(string)
+	if !ok {
+		return fmt.Errorf("invalid snapshot path: %v", pv)
+	}
+	p = fp(p, o.root)
+	got, err := Eval(c.expr, store)
+	if err != nil {
+		return err
+	}
+	b, err := os.ReadFile(p)
+	switch {
+	case o.updateSnapshots && (err == nil || errors.Is(err, fs.ErrNotExist)):
+		if err := validateSnapshotPath(p, o.root); err != nil {
+			return err
+		}
+		o.Debugf(yellow("Write snapshot %s\n"), p)
+		if err := writeSnapshot(p, got); err != nil {
+			return err
+		}
+	case errors.Is(err, fs.ErrNotExist):
+		return fmt.Errorf("snapshot %s does not exist. To create it, use the --update-snapshots option", p)
+	case err != nil:
+		return fmt.Errorf("failed to read snapshot %s: %w", p, err)
+	default:
+		var want any
+		if err := json.Unmarshal(b, &want); err != nil {
+			return fmt.Errorf("invalid snapshot %s: %w", p, err)
+		}
+		if d := builtin.Diff(want, got, c.ignores...); d != "" {
+			return &snapshotMismatchError{path: p, diff: d}
+		}
+	}
+	if first {
+		o.record(nil)
+	}
+	return nil
+}
+
+// validateSnapshotPath validates that the snapshot file to be written is under the root directory of the runbook.
+func validateSnapshotPath(p, root string) error {
+	abs, err := evalSymlinksExisting(p)
+	if err != nil {
+		return err
+	}
+	aroot, err := evalSymlinksExisting(root)
+	if err != nil {
+		return err
+	}
+	rel, err := filepath.Rel(aroot, abs)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return fmt.Errorf("invalid snapshot path: writing the snapshot outside of the runbook directory ( %s ) is not allowed: %s", root, p)
+	}
+	return nil
+}
+
+// evalSymlinksExisting returns the absolute path of p whose existing part is evaluated the symbolic links.
+func evalSymlinksExisting(p string) (string, error) {
+	abs, err := filepath.Abs(p)
+	if err != nil {
+		return "", err
+	}
+	var rest []string
+	for {
+		e, err := filepath.EvalSymlinks(abs)
+		if err == nil {
+			return filepath.Join(append([]string{e}, rest...)...), nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return "", err
+		}
+		d := filepath.Dir(abs)
+		if d == abs {
+			return "", err
+		}
+		rest = append([]string{filepath.Base(abs)}, rest...)
+		abs = d
+	}
+}
+
+func writeSnapshot(p string, v any) error {
+	b, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to write snapshot %s: %w", p, err)
+	}
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return fmt.Errorf("failed to write snapshot %s: %w", p, err)
+	}
+	if err := os.WriteFile(p, append(b, '\n'), 0600); err != nil {
+		return fmt.Errorf("failed to write snapshot %s: %w", p, err)
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSnapshot(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	const book = `desc: Snapshot
+steps:
+  -
+    exec:
+      command: |
+        echo '{"id": {{ vars.id }}, "name": "{{ vars.name }}"}'
+    snapshot:
+      path: snapshots/user.json
+      expr: fromJSON(current.stdout)
+      ignores:
+        - id
+`
+	tests := []struct {
+		name     string
+		snapshot string
+		update   bool
+		wantErr  string
+		want     string
+	}{
+		{
+			"fail if not exists",
+			"",
+			false,
+			"does not exist",
+			"",
+		},
+		{
+			"create snapshot if not exists with update",
+			"",
+			true,
+			"",
+			"{\n  \"id\": 2,\n  \"name\": \"alice\"\n}\n",
+		},
+		{
+			"match ignoring id",
+			`{"id": 1, "name": "alice"}`,
+			false,
+			"",
+			`{"id": 1, "name": "alice"}`,
+		},
+		{
+			"mismatch",
+			`{"id": 1, "name": "bob"}`,
+			false,
+			`"bob"`,
+			`{"id": 1, "name": "bob"}`,
+		},
+		{
+			"update snapshot",
+			`{"id": 1, "name": "bob"}`,
+			true,
+			"",
+			"{\n  \"id\": 2,\n  \"name\": \"alice\"\n}\n",
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			p := filepath.Join(dir, "snapshot.yml")
+			if err := os.WriteFile(p, []byte(book), 0600); err != nil {
+				t.Fatal(err)
+			}
+			sp := filepath.Join(dir, "snapshots", "user.json")
+			if tt.snapshot != "" {
+				if err := os.MkdirAll(filepath.Dir(sp), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(sp, []byte(tt.snapshot), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			o, err := New(Scopes(ScopeAllowReadParent, ScopeAllowRunExec), Book(p), Var("id", 2), Var("name", "alice"), UpdateSnapshots(tt.update))
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = o.Run(ctx)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Errorf("got %v\nwant %s", err, tt.wantErr)
+				}
+			} else if err != nil {
+				t.Error(err)
+			}
+			got, err := os.ReadFile(sp)
+			if tt.want == "" {
+				if !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("the snapshot should not be created: %v", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSnapshotOutsideRoot(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	dir := t.TempDir()
+	outside := t.TempDir()
+	if err := os.Symlink(outside, filepath.Join(dir, "linked")); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name string
+		path string
+	}{
+		{"parent directory", "../outside.json"},
+		{"absolute path", filepath.Join(outside, "outside.json")},
+		{"symbolic link", "linked/outside.json"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := filepath.Join(dir, "snapshot.yml")
+			book := "desc: Snapshot\nsteps:\n  -\n    snapshot:\n      path: " + tt.path + "\n      expr: vars\n"
+			if err := os.WriteFile(p, []byte(book), 0600); err != nil {
+				t.Fatal(err)
+			}
+			o, err := New(Scopes(ScopeAllowReadParent), Book(p), UpdateSnapshots(true))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := o.Run(ctx); err == nil || !strings.Contains(err.Error(), "invalid snapshot path") {
+				t.Errorf("got %v\nwant invalid snapshot path error", err)
+			}
+			if _, err := os.Stat(filepath.Join(outside, "outside.json")); !errors.Is(err, fs.ErrNotExist) {
+				t.Errorf("the snapshot should not be written outside of the runbook directory: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseSnapshotConfig(t *testing.T) {
+	tests := []struct {
+		in      any
+		want    *snapshotConfig
+		wantErr bool
+	}{
+		{"snapshots/a.json", &snapshotConfig{path: "snapshots/a.json", expr: "current"}, false},
+		{map[string]any{"path": "a.json", "expr": "current.res.body", "ignores": []any{"id", ".items[*].id"}}, &snapshotConfig{path: "a.json", expr: "current.res.body", ignores: []string{"id", ".items[*].id"}}, false},
+		{map[string]any{"expr": "current.res.body"}, nil, true},
+		{map[string]any{"path": "a.json", "unknown": true}, nil, true},
+		{1, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := parseSnapshotConfig(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%v: want error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if got.path != tt.want.path || got.expr != tt.want.expr || strings.Join(got.ignores, ",") != strings.Join(tt.want.ignores, ",") {
+			t.Errorf("got %#v\nwant %#v", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package runn
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/cli/safeexec"
+	"github.com/goccy/go-yaml"
+)
+
+const (
+	sopsFormatYAML   = "yaml"
+	sopsFormatJSON   = "json"
+	sopsFormatDotenv = "dotenv"
+)
+
+// isSOPSEncrypted returns true if the content is the file encrypted by SOPS.
+func isSOPSEncrypted(b []byte, format string) bool {
+	if format == sopsFormatDotenv {
+		for _, l := range strings.Split(string(b), "\n") {
+			if strings.HasPrefix(strings.TrimSpace(l), "sops_mac=") {
+				return true
+			}
+		}
+		return false
+	}
+	if !bytes.Contains(b, []byte("sops")) {
+		return false
+	}
+	m := map[string]any{}
+	if err := yaml.Unmarshal(b, &m); err != nil {
+		return false
+	}
+	meta, ok := m["sops"].(map[string]any)
+	if !ok {
+		return false
+	}
+	_, ok = meta["mac"]
+	return ok
+}
+
+// decryptSOPS decrypts the file encrypted by SOPS using the sops command.
+// The keys ( age, PGP, KMS, etc. ) are resolved by sops itself ( e.g. SOPS_AGE_KEY_FILE ).
+func decryptSOPS(p, format string) ([]byte, error) {
+	bin, err := safeexec.LookPath("sops")
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: sops command is required: %w", p, err)
+	}
+	stdout := new(bytes.Buffer)
+	stderr := new(bytes.Buffer)
+	cmd := exec.Command(bin, "--decrypt", "--input-type", format, "--output-type", format, p)
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("failed to decrypt %s: %w: %s", p, err, strings.TrimSpace(stderr.String()))
+	}
+	return stdout.Bytes(), nil
+}
+
+// decryptSOPSIfEncrypted decrypts the content if it is encrypted by SOPS.
+func decryptSOPSIfEncrypted(b []byte, p, format string) ([]byte, error) {
+	if !isSOPSEncrypted(b, format) {
+		return b, nil
+	}
+	return decryptSOPS(p, format)
+}
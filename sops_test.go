@@ -0,0 +1,83 @@
+package runn
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestIsSOPSEncrypted(t *testing.T) {
+	tests := []struct {
+		in     string
+		format string
+		want   bool
+	}{
+		{"foo: bar\n", sopsFormatYAML, false},
+		{"foo: ENC[AES256_GCM,data:xxx,type:str]\nsops:\n  mac: ENC[AES256_GCM,data:xxx,type:str]\n  version: 3.9.4\n", sopsFormatYAML, true},
+		{"sops: disabled\n", sopsFormatYAML, false},
+		{`{"foo": "ENC[AES256_GCM,data:xxx,type:str]", "sops": {"mac": "ENC[AES256_GCM,data:xxx,type:str]"}}`, sopsFormatJSON, true},
+		{`{"sops": "bar"}`, sopsFormatJSON, false},
+		{"FOO=ENC[AES256_GCM,data:xxx,type:str]\nsops_mac=ENC[AES256_GCM,data:xxx,type:str]\n", sopsFormatDotenv, true},
+		{"FOO=bar\n", sopsFormatDotenv, false},
+	}
+	for _, tt := range tests {
+		if got := isSOPSEncrypted([]byte(tt.in), tt.format); got != tt.want {
+			t.Errorf("got %v\nwant %v: %s", got, tt.want, tt.in)
+		}
+	}
+}
+
+func TestDecryptSOPS(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("the fake sops command is a shell script")
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	// Fake sops command that outputs the decrypted file prepared in advance
+	bin := t.TempDir()
+	if err := os.WriteFile(filepath.Join(bin, "sops"), []byte("#!/bin/sh\nfor p; do :; done\ncat \"$p.decrypted\"\n"), 0700); err != nil { //nolint:gosec
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", bin+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"secrets.yml":           "password: ENC[AES256_GCM,data:xxx,type:str]\nsops:\n  mac: ENC[AES256_GCM,data:xxx,type:str]\n",
+		"secrets.yml.decrypted": "password: p@ssw0rd\n",
+		"book.yml": `desc: Encrypted vars
+vars:
+  secrets: yaml://secrets.yml
+  token: ENC[AES256_GCM,data:xxx,type:str]
+steps:
+  -
+    test: vars.secrets.password == "p@ssw0rd" && vars.token == "t0ken"
+sops:
+  mac: ENC[AES256_GCM,data:xxx,type:str]
+`,
+		"book.yml.decrypted": `desc: Encrypted vars
+vars:
+  secrets: yaml://secrets.yml
+  token: t0ken
+steps:
+  -
+    test: vars.secrets.password == "p@ssw0rd" && vars.token == "t0ken"
+`,
+	}
+	for f, c := range files {
+		if err := os.WriteFile(filepath.Join(dir, f), []byte(c), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	o, err := New(Scopes(ScopeAllowReadParent), Book(filepath.Join(dir, "book.yml")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Error(err)
+	}
+}
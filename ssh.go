@@ -13,6 +13,7 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/Songmu/prompter"
@@ -41,6 +42,8 @@ type sshRunner struct {
 	sessCancel   context.CancelFunc
 	opts         []sshc.Option
 	hostRules    hostRules
+	// mu - Guard the lazy connection and the session kept across the steps run concurrently ( e.g. parallel: )
+	mu sync.Mutex
 }
 
 type sshLocalForward struct {
@@ -177,6 +180,8 @@ func (rnr *sshRunner) closeSession() error {
 }
 
 func (rnr *sshRunner) Close() error {
+	rnr.mu.Lock()
+	defer rnr.mu.Unlock()
 	if rnr.client != nil {
 		if err := rnr.client.Close(); err != nil {
 			return err
@@ -211,27 +216,42 @@ func (rnr *sshRunner) Renew() error {
 	return nil
 }
 
-func (rnr *sshRunner) run(ctx context.Context, c *sshCommand, s *step) error {
-	o := s.parent
-	if rnr.client == nil {
-		if len(rnr.hostRules) > 0 {
-			rnr.opts = append(rnr.opts, sshc.DialTimeoutFunc(rnr.hostRules.dialTimeoutFunc()))
-		}
-		client, err := connectSSH(rnr.addr, rnr.opts...)
-		if err != nil {
+// connect connects to the host ( and starts the session kept across the steps ) only once.
+func (rnr *sshRunner) connect() error {
+	if rnr.client != nil {
+		return nil
+	}
+	if len(rnr.hostRules) > 0 {
+		rnr.opts = append(rnr.opts, sshc.DialTimeoutFunc(rnr.hostRules.dialTimeoutFunc()))
+	}
+	client, err := connectSSH(rnr.addr, rnr.opts...)
+	if err != nil {
+		return err
+	}
+	rnr.client = client
+	if rnr.keepSession {
+		if err := rnr.startSession(); err != nil {
 			return err
 		}
-		rnr.client = client
-		if rnr.keepSession {
-			if err := rnr.startSession(); err != nil {
-				return err
-			}
-		}
 	}
+	return nil
+}
 
+func (rnr *sshRunner) run(ctx context.Context, c *sshCommand, s *step) error {
+	o := s.parent
+	rnr.mu.Lock()
+	if err := rnr.connect(); err != nil {
+		rnr.mu.Unlock()
+		return err
+	}
 	if !rnr.keepSession {
-		return rnr.runOnce(ctx, c, s)
+		// *ssh.Client can open the sessions concurrently
+		client := rnr.client
+		rnr.mu.Unlock()
+		return rnr.runOnce(ctx, client, c, s)
 	}
+	// The commands are run one by one in the session kept across the steps
+	defer rnr.mu.Unlock()
 
 	o.capturers.captureSSHCommand(c.command)
 	stdout := ""
@@ -273,23 +293,22 @@ L:
 	return nil
 }
 
-func (rnr *sshRunner) runOnce(ctx context.Context, c *sshCommand, s *step) error {
+func (rnr *sshRunner) runOnce(ctx context.Context, client *ssh.Client, c *sshCommand, s *step) error {
 	o := s.parent
 	o.capturers.captureSSHCommand(c.command)
 	stdout := new(bytes.Buffer)
 	stderr := new(bytes.Buffer)
-	sess, err := rnr.client.NewSession()
+	sess, err := client.NewSession()
 	if err != nil {
 		return err
 	}
 	sess.Stdout = stdout
 	sess.Stderr = stderr
-	rnr.sess = sess
 	defer func() {
-		_ = rnr.closeSession()
+		_ = sess.Close()
 	}()
 
-	_ = rnr.sess.Run(c.command)
+	_ = sess.Run(c.command)
 
 	o.capturers.captureSSHStdout(stdout.String())
 	o.capturers.captureSSHStderr(stderr.String())
@@ -2,15 +2,23 @@ package runn
 
 import (
 	"errors"
+	"time"
 )
 
 type step struct {
 	idx       int    // index of step in operator
 	key       string // key of step in operator
+	name      string // name of step in list-form steps
 	runnerKey string
 	desc      string
 	ifCond    string
 	loop      *Loop
+	// retry - Settings of `retry:` or `eventually:` ( with the timeout )
+	retry *retryConfig
+	// maxLatency - Fail the step if the latency of the runner exceeds it
+	maxLatency *time.Duration
+	// expectError - The runner of the step is expected to fail in the specific way
+	expectError *expectError
 	// loopIndex - Index of the loop is dynamically recorded at runtime
 	loopIndex     *int
 	httpRunner    *httpRunner
@@ -33,6 +41,31 @@ type step struct {
 	bindCond      map[string]any
 	includeRunner *includeRunner
 	includeConfig *includeConfig
+	// parallelRunner - Run the child steps concurrently
+	parallelRunner *parallelRunner
+	parallelConfig *parallelConfig
+	// snapshotRunner - Compare the value with the snapshot file
+	snapshotRunner *snapshotRunner
+	snapshotConfig *snapshotConfig
+	// testAssertions - Conditions of `test:` in the list form with their failure messages
+	testAssertions []*testAssertion
+	// groupRunner - Run the child steps in order
+	groupRunner *groupRunner
+	groupConfig *groupConfig
+	// needs - Indexes of the steps that the step needs. nil means all the preceding steps
+	needs []int
+	// deferred - Run the step at the end of the runbook
+	deferred bool
+	// skip - Skip the step
+	skip bool
+	// only - Run only the steps with `only: true`
+	only bool
+	// gotos - Jump to the following step after the step runs
+	gotos []stepGoto
+	// force - Tolerate the failure of the step. The failure is recorded but the runbook does not fail
+	force bool
+	// with - Parameters of the template of `use:`
+	with map[string]any
 	// operator related to step
 	parent *operator
 	debug  bool
@@ -66,6 +99,10 @@ func (s *step) generateTrail() Trail {
 		tr.StepRunnerType = RunnerTypeExec
 	case s.includeRunner != nil && s.includeConfig != nil:
 		tr.StepRunnerType = RunnerTypeInclude
+	case s.parallelRunner != nil && s.parallelConfig != nil:
+		tr.StepRunnerType = RunnerTypeParallel
+	case s.groupRunner != nil && s.groupConfig != nil:
+		tr.StepRunnerType = RunnerTypeGroup
 	case s.dumpRunner != nil && s.dumpRequest != nil:
 		tr.StepRunnerType = RunnerTypeDump
 	case s.bindRunner != nil && s.bindCond != nil:
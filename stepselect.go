@@ -0,0 +1,180 @@
+package runn
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+)
+
+const replayStepsKey = "steps"
+
+// selectSteps resolves the steps selected by StartStep, EndStep and RunStep.
+// It returns nil if no steps are selected ( all the steps are run ).
+func (o *operator) selectSteps() ([]bool, error) {
+	if o.startStep == "" && o.endStep == "" && len(o.runSteps) == 0 {
+		return nil, nil
+	}
+	selected := make([]bool, len(o.steps))
+	if o.startStep != "" || o.endStep != "" {
+		start, end := 0, len(o.steps)-1
+		if o.startStep != "" {
+			i, err := o.stepIndex(o.startStep)
+			if err != nil {
+				return nil, fmt.Errorf("invalid start step: %w", err)
+			}
+			start = i
+		}
+		if o.endStep != "" {
+			i, err := o.stepIndex(o.endStep)
+			if err != nil {
+				return nil, fmt.Errorf("invalid end step: %w", err)
+			}
+			end = i
+		}
+		if start > end {
+			return nil, fmt.Errorf("invalid step range: the start step (%s) is after the end step (%s)", o.startStep, o.endStep)
+		}
+		for i := start; i <= end; i++ {
+			selected[i] = true
+		}
+	}
+	for _, s := range o.runSteps {
+		i, err := o.stepIndex(s)
+		if err != nil {
+			return nil, fmt.Errorf("invalid step: %w", err)
+		}
+		selected[i] = true
+	}
+	return selected, nil
+}
+
+// stepIndex returns the index of the step specified by the index, the key ( map-form steps ) or the name ( list-form steps ).
+func (o *operator) stepIndex(s string) (int, error) {
+	for i, st := range o.steps {
+		if o.useMap && st.key == s {
+			return i, nil
+		}
+		if !o.useMap && st.name != "" && st.name == s {
+			return i, nil
+		}
+	}
+	if i, err := strconv.Atoi(s); err == nil && i >= 0 && i < len(o.steps) {
+		return i, nil
+	}
+	return 0, fmt.Errorf("step not found (%s): %s", o.bookPath, s)
+}
+
+// stepUnselected returns true if the step is not selected by StartStep, EndStep and RunStep.
+func (o *operator) stepUnselected(i int) bool {
+	return o.stepSelected != nil && !o.stepSelected[i]
+}
+
+// replayedStep returns the stored result of the step to be replayed instead of running it.
+// Only the unselected steps before the last selected step are replayed.
+func (o *operator) replayedStep(i int) (map[string]any, bool) {
+	if !o.stepUnselected(i) || o.replaySteps == nil {
+		return nil, false
+	}
+	last := -1
+	for j, tf := range o.stepSelected {
+		if tf {
+			last = j
+		}
+	}
+	if i > last {
+		return nil, false
+	}
+	v, ok := o.replaySteps[o.id]
+	if !ok {
+		if len(o.replaySteps) != 1 {
+			return nil, false
+		}
+		// The steps of the single runbook are replayed regardless of the ID
+		for _, vv := range o.replaySteps {
+			v = vv
+		}
+	}
+	m, ok := v.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	var r any
+	if o.useMap {
+		steps, ok := m[replayStepsKey].(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		r = steps[o.steps[i].key]
+	} else {
+		steps, ok := m[replayStepsKey].([]any)
+		if !ok || i >= len(steps) {
+			return nil, false
+		}
+		r = steps[i]
+	}
+	res, ok := r.(map[string]any)
+	if !ok {
+		return nil, false
+	}
+	if run, ok := res[storeStepKeyRun].(bool); ok && !run {
+		return nil, false
+	}
+	return res, true
+}
+
+// DumpSteps writes the results of the steps as JSON to be replayed by ReplaySteps in later runs.
+func (o *operator) DumpSteps(w io.Writer) error {
+	return dumpSteps(w, map[string]any{o.id: o.stepValues()})
+}
+
+// DumpSteps writes the results of the steps of the runbooks as JSON to be replayed by ReplaySteps in later runs.
+func (ops *operators) DumpSteps(w io.Writer) error {
+	m := map[string]any{}
+	for _, o := range ops.ops {
+		if o.Skipped() {
+			continue
+		}
+		m[o.id] = o.stepValues()
+	}
+	return dumpSteps(w, m)
+}
+
+func (o *operator) stepValues() map[string]any {
+	if o.useMap {
+		return map[string]any{replayStepsKey: o.store.stepMap}
+	}
+	return map[string]any{replayStepsKey: o.store.steps}
+}
+
+func dumpSteps(w io.Writer, m map[string]any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(m); err != nil {
+		return fmt.Errorf("failed to dump steps: %w", err)
+	}
+	return nil
+}
+
+// loadReplaySteps loads the results of the steps dumped by DumpSteps.
+func loadReplaySteps(p string) (map[string]any, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load steps %s: %w", p, err)
+	}
+	m := map[string]any{}
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, fmt.Errorf("invalid steps %s: %w", p, err)
+	}
+	for id, v := range m {
+		vv, ok := v.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("invalid steps %s: %s", p, id)
+		}
+		if _, ok := vv[replayStepsKey]; !ok {
+			return nil, fmt.Errorf("invalid steps %s: %s does not have %q", p, id, replayStepsKey)
+		}
+	}
+	return m, nil
+}
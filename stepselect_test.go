@@ -0,0 +1,162 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestStepSelection(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	const listBook = `desc: List
+steps:
+  -
+    name: first
+    test: true
+  -
+    name: second
+    test: true
+  -
+    test: true
+  -
+    name: fourth
+    test: true
+`
+	const mapBook = `desc: Map
+steps:
+  first:
+    test: true
+  second:
+    test: true
+  third:
+    test: true
+  fourth:
+    test: true
+`
+	dir := t.TempDir()
+	lp := filepath.Join(dir, "list.yml")
+	if err := os.WriteFile(lp, []byte(listBook), 0600); err != nil {
+		t.Fatal(err)
+	}
+	mp := filepath.Join(dir, "map.yml")
+	if err := os.WriteFile(mp, []byte(mapBook), 0600); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		book    string
+		opts    []Option
+		want    []bool
+		wantErr bool
+	}{
+		{lp, nil, []bool{true, true, true, true}, false},
+		{lp, []Option{StartStep("second")}, []bool{false, true, true, true}, false},
+		{lp, []Option{EndStep("2")}, []bool{true, true, true, false}, false},
+		{lp, []Option{StartStep("1"), EndStep("second")}, []bool{false, true, false, false}, false},
+		{lp, []Option{RunStep("first", "fourth")}, []bool{true, false, false, true}, false},
+		{lp, []Option{EndStep("first"), RunStep("2")}, []bool{true, false, true, false}, false},
+		{lp, []Option{StartStep("fourth"), EndStep("first")}, nil, true},
+		{lp, []Option{RunStep("unknown")}, nil, true},
+		{lp, []Option{RunStep("4")}, nil, true},
+		{mp, []Option{StartStep("second"), EndStep("third")}, []bool{false, true, true, false}, false},
+		{mp, []Option{RunStep("fourth")}, []bool{false, false, false, true}, false},
+		{mp, []Option{RunStep("1")}, []bool{false, true, false, false}, false},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		opts := append([]Option{Scopes(ScopeAllowReadParent), Book(tt.book)}, tt.opts...)
+		o, err := New(opts...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			if !tt.wantErr {
+				t.Errorf("got error: %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Error("want error")
+			continue
+		}
+		var got []bool
+		for _, sr := range o.Result().StepResults {
+			got = append(got, !sr.Skipped)
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestDumpAndReplaySteps(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent, ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	const book = `desc: Replay
+steps:
+  -
+    exec:
+      command: echo hello
+  -
+    test: steps[0].stdout == "hello\n"
+`
+	ctx := context.Background()
+	dir := t.TempDir()
+	bp := filepath.Join(dir, "book.yml")
+	if err := os.WriteFile(bp, []byte(book), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	o, err := New(Scopes(ScopeAllowReadParent, ScopeAllowRunExec), Book(bp))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	buf := new(bytes.Buffer)
+	if err := o.DumpSteps(buf); err != nil {
+		t.Fatal(err)
+	}
+	sp := filepath.Join(dir, "steps.json")
+	if err := os.WriteFile(sp, buf.Bytes(), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("Run the selected step without replaying", func(t *testing.T) {
+		o, err := New(Scopes(ScopeAllowReadParent, ScopeAllowRunExec), Book(bp), RunStep("1"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err == nil {
+			t.Error("want error")
+		}
+	})
+
+	t.Run("Run the selected step replaying the previous steps", func(t *testing.T) {
+		o, err := New(Scopes(ScopeAllowReadParent, ScopeAllowRunExec), Book(bp), RunStep("1"), ReplaySteps(sp))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := o.Run(ctx); err != nil {
+			t.Error(err)
+		}
+		srs := o.Result().StepResults
+		if !srs[0].Skipped {
+			t.Error("the replayed step should not be run")
+		}
+		if srs[1].Skipped {
+			t.Error("the selected step should be run")
+		}
+	})
+}
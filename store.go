@@ -17,11 +17,14 @@ const (
 	storeRootPrevious    = "previous"
 	storeRootKeyEnv      = "env"
 	storeRootKeyCookie   = "cookies"
+	storeRootKeyShared   = "shared"
 )
 
 const (
 	storeStepKeyRun     = "run"
 	storeStepKeyOutcome = "outcome"
+	storeStepKeyLatency = "latency_ms"
+	storeStepKeyError   = "error"
 )
 
 const (
@@ -50,8 +53,14 @@ type store struct {
 	bindVars    map[string]any
 	parentVars  map[string]any
 	useMap      bool // Use map syntax in `steps:`.
-	loopIndex   *int
-	cookies     map[string]map[string]*http.Cookie
+	// stepNames - Indexes of list-form steps keyed by `name:`
+	stepNames map[string]int
+	loopIndex *int
+	loopItem  *loopItem
+	with      map[string]any
+	cookies   map[string]map[string]*http.Cookie
+	// shared - Store shared across runbooks in one RunN. nil if not enabled
+	shared *sharedStore
 }
 
 func (s *store) recordAsMapped(k string, v map[string]any) {
@@ -178,9 +187,18 @@ func (s *store) toNormalizedMap() map[string]any {
 	if s.loopIndex != nil {
 		store[storeRootKeyLoopCountIndex] = *s.loopIndex
 	}
+	if s.loopItem != nil {
+		s.loopItem.bind(store)
+	}
+	if s.with != nil {
+		store[storeRootKeyWith] = s.with
+	}
 	if s.cookies != nil {
 		store[storeRootKeyCookie] = s.cookies
 	}
+	if s.shared != nil {
+		store[storeRootKeyShared] = s.shared.toMap()
+	}
 	return store
 }
 
@@ -191,9 +209,13 @@ func (s *store) toMap() map[string]any {
 		store[k] = v
 	}
 	store[storeRootKeyVars] = s.vars
-	if s.useMap {
+	switch {
+	case s.useMap:
 		store[storeRootKeySteps] = s.stepMap
-	} else {
+	case len(s.stepNames) > 0:
+		// Alias the recorded values of the named steps
+		store[storeRootKeySteps] = newNamedSteps(s.steps, s.stepNames)
+	default:
 		store[storeRootKeySteps] = s.steps
 	}
 	if s.parentVars != nil {
@@ -205,9 +227,18 @@ func (s *store) toMap() map[string]any {
 	if s.loopIndex != nil {
 		store[storeRootKeyLoopCountIndex] = *s.loopIndex
 	}
+	if s.loopItem != nil {
+		s.loopItem.bind(store)
+	}
+	if s.with != nil {
+		store[storeRootKeyWith] = s.with
+	}
 	if s.cookies != nil {
 		store[storeRootKeyCookie] = s.cookies
 	}
+	if s.shared != nil {
+		store[storeRootKeyShared] = s.shared.toMap()
+	}
 	return store
 }
 
@@ -218,6 +249,94 @@ func (s *store) clearSteps() {
 	// keep vars, bindVars, cookies
 	s.parentVars = map[string]any{}
 	s.loopIndex = nil
+	s.loopItem = nil
+	s.with = nil
+}
+
+// rewind hides the values recorded from the step n, and returns the function to restore them.
+// The value recorded for the step n while rewound replaces the hidden one.
+func (s *store) rewind(n int) func() {
+	if s.useMap {
+		keys := s.stepMapKeys
+		hidden := map[string]map[string]any{}
+		for _, k := range keys[n:] {
+			hidden[k] = s.stepMap[k]
+			delete(s.stepMap, k)
+		}
+		s.stepMapKeys = keys[:n:n]
+		return func() {
+			for k, v := range hidden {
+				if _, ok := s.stepMap[k]; !ok {
+					s.stepMap[k] = v
+				}
+			}
+			s.stepMapKeys = keys
+		}
+	}
+	steps := s.steps
+	s.steps = steps[:n:n]
+	return func() {
+		if len(s.steps) > n {
+			steps[n] = s.steps[n]
+		}
+		s.steps = steps
+	}
+}
+
+// stepScopedKeys returns the keys of the values bound only while the step runs ( e.g. the loop of the step ).
+func (s *store) stepScopedKeys() []string {
+	var keys []string
+	if s.loopIndex != nil {
+		keys = append(keys, storeRootKeyLoopCountIndex)
+	}
+	if s.loopItem != nil {
+		keys = append(keys, storeRootKeyLoopItem, storeRootKeyLoopItemIndex)
+		if s.loopItem.key != nil {
+			keys = append(keys, storeRootKeyLoopItemKey)
+		}
+	}
+	if s.with != nil {
+		keys = append(keys, storeRootKeyWith)
+	}
+	return keys
+}
+
+// copyUntil returns the copy of the store with the values recorded before the step n.
+func (s *store) copyUntil(n int) store {
+	c := store{
+		steps:       []map[string]any{},
+		stepMapKeys: []string{},
+		stepMap:     map[string]map[string]any{},
+		vars:        s.vars,
+		funcs:       s.funcs,
+		bindVars:    map[string]any{},
+		parentVars:  s.parentVars,
+		useMap:      s.useMap,
+		stepNames:   s.stepNames,
+		cookies:     s.cookies,
+	}
+	if s.useMap {
+		for _, k := range s.stepMapKeys[:min(n, len(s.stepMapKeys))] {
+			c.stepMapKeys = append(c.stepMapKeys, k)
+			c.stepMap[k] = s.stepMap[k]
+		}
+	} else {
+		c.steps = append(c.steps, s.steps[:min(n, len(s.steps))]...)
+	}
+	for k, v := range s.bindVars {
+		c.bindVars[k] = v
+	}
+	if s.loopIndex != nil {
+		// The index of the loop of the parent step is not the index of the loop of the copied store
+		c.bindVars[storeRootKeyLoopCountIndex] = *s.loopIndex
+	}
+	if s.loopItem != nil {
+		s.loopItem.bind(c.bindVars)
+	}
+	if s.with != nil {
+		c.bindVars[storeRootKeyWith] = s.with
+	}
+	return c
 }
 
 func envMap() map[string]string {
@@ -0,0 +1,108 @@
+package runn
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	useSectionKey  = "use"
+	withSectionKey = "with"
+)
+
+// storeRootKeyWith - Key of the parameters of `with:` bound while the step using the template runs.
+const storeRootKeyWith = "with"
+
+// validateTemplate validates the step template of `templates:`.
+func validateTemplate(s map[string]any) error {
+	if _, ok := s[useSectionKey]; ok {
+		return fmt.Errorf("%s cannot be used in the template", useSectionKey)
+	}
+	if _, ok := s[withSectionKey]; ok {
+		return fmt.Errorf("%s cannot be used in the template", withSectionKey)
+	}
+	return validateStepKeys(s)
+}
+
+// applyTemplate returns the step that the template of `use:` is applied to.
+// The sections of the step override the sections of the template.
+func (o *operator) applyTemplate(s map[string]any) (map[string]any, map[string]any, error) {
+	v, ok := s[useSectionKey]
+	if !ok {
+		if _, ok := s[withSectionKey]; ok {
+			return nil, nil, fmt.Errorf("invalid with: %s can only be used with %s", withSectionKey, useSectionKey)
+		}
+		return s, nil, nil
+	}
+	name, ok := v.(string)
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid use: %v", v)
+	}
+	t, ok := o.templates[name]
+	if !ok {
+		return nil, nil, fmt.Errorf("invalid use: template %s is not found", name)
+	}
+	var with map[string]any
+	if w, ok := s[withSectionKey]; ok {
+		with, ok = w.(map[string]any)
+		if !ok {
+			return nil, nil, fmt.Errorf("invalid with: %v", w)
+		}
+	}
+	applied := map[string]any{}
+	for k, v := range t {
+		applied[k] = copyTemplateValue(v)
+	}
+	for k, v := range s {
+		if k == useSectionKey || k == withSectionKey {
+			continue
+		}
+		applied[k] = v
+	}
+	if err := validateStepKeys(applied); err != nil {
+		return nil, nil, fmt.Errorf("invalid use: %w", err)
+	}
+	if with == nil {
+		with = map[string]any{}
+	}
+	return applied, with, nil
+}
+
+// copyTemplateValue returns the deep copy of the value of the template, because the step sections are modified while parsing.
+func copyTemplateValue(v any) any {
+	switch vv := v.(type) {
+	case map[string]any:
+		c := make(map[string]any, len(vv))
+		for k, vvv := range vv {
+			c[k] = copyTemplateValue(vvv)
+		}
+		return c
+	case []any:
+		c := make([]any, len(vv))
+		for i, vvv := range vv {
+			c[i] = copyTemplateValue(vvv)
+		}
+		return c
+	default:
+		return v
+	}
+}
+
+// bindWith binds the parameters of `with:` to the store while the step runs, and returns the function to unbind them.
+func (o *operator) bindWith(s *step) (func(), error) {
+	if s.with == nil {
+		return func() {}, nil
+	}
+	e, err := o.expandBeforeRecord(s.with)
+	if err != nil {
+		return nil, fmt.Errorf("invalid with: %w", err)
+	}
+	with, ok := e.(map[string]any)
+	if !ok {
+		return nil, errors.New("invalid with: failed to expand")
+	}
+	o.store.with = with
+	return func() {
+		o.store.with = nil
+	}, nil
+}
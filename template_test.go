@@ -0,0 +1,58 @@
+package runn
+
+import (
+	"context"
+	"testing"
+)
+
+func TestTemplate(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	o, err := New(Book("testdata/book/template.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := o.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if got := o.Result().StepResults[0].Desc; got != "Echo the message" {
+		t.Errorf("got %v", got)
+	}
+}
+
+func TestApplyTemplate(t *testing.T) {
+	templates := map[string]map[string]any{
+		"ok": {"test": "true"},
+	}
+	tests := []struct {
+		in      map[string]any
+		wantErr bool
+	}{
+		{map[string]any{"use": "ok"}, false},
+		{map[string]any{"use": "ok", "with": map[string]any{"a": 1}}, false},
+		{map[string]any{"use": "ok", "desc": "override"}, false},
+		{map[string]any{"use": "notfound"}, true},
+		{map[string]any{"use": "ok", "with": "invalid"}, true},
+		{map[string]any{"with": map[string]any{"a": 1}, "test": "true"}, true},
+		{map[string]any{"use": "ok", "exec": map[string]any{"command": "echo"}, "req": map[string]any{}}, true},
+	}
+	for _, tt := range tests {
+		o, err := New()
+		if err != nil {
+			t.Fatal(err)
+		}
+		o.templates = templates
+		if err := o.AppendStep(0, "0", tt.in); (err != nil) != tt.wantErr {
+			t.Errorf("%v: got %v, want error %v", tt.in, err, tt.wantErr)
+		}
+	}
+	if got := templates["ok"]["test"]; got != "true" {
+		t.Errorf("the template should not be modified: %v", got)
+	}
+}
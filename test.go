@@ -2,7 +2,12 @@ package runn
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
+
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/parser"
 )
 
 const testRunnerKey = "test"
@@ -10,8 +15,17 @@ const testRunnerKey = "test"
 type testRunner struct{}
 
 type condFalseError struct {
+	cond    string
+	tree    string
+	diff    string
+	message string
+}
+
+// testAssertion is the condition of `test:` in the list form.
+type testAssertion struct {
 	cond string
-	tree string
+	// message - Failure message. Expressions in `{{ }}` are expanded
+	message string
 }
 
 func newCondFalseError(cond, tree string) *condFalseError {
@@ -22,8 +36,17 @@ func newCondFalseError(cond, tree string) *condFalseError {
 }
 
 func (fe *condFalseError) Error() string {
+	head := "condition is not true"
+	if fe.message != "" {
+		head = fmt.Sprintf("%s: %s", head, fe.message)
+	}
+	if fe.diff != "" {
+		cond := SprintMultilinef("  %s\n", "%s", fe.cond)
+		diff := SprintMultilinef("  %s\n", "%s", fe.diff)
+		return fmt.Sprintf("%s\n\nCondition:\n%s\nDiff:\n%s", head, cond, diff)
+	}
 	tree := SprintMultilinef("  %s\n", "%s", fe.tree)
-	return fmt.Sprintf("condition is not true\n\nCondition:\n%s", tree)
+	return fmt.Sprintf("%s\n\nCondition:\n%s", head, tree)
 }
 
 func newTestRunner() *testRunner {
@@ -33,6 +56,48 @@ func newTestRunner() *testRunner {
 func (rnr *testRunner) Run(ctx context.Context, s *step, first bool) error {
 	o := s.parent
 	cond := s.testCond
+	store := o.testStore(first)
+	if _, ok := store[fixtureFuncName].(fixtureFunc); ok {
+		// Expand the fixture file with the same store as the test
+		store[fixtureFuncName] = newFixtureFunc(o.root, o.fixtureStore(first))
+	}
+	as := s.testAssertions
+	if len(as) == 0 {
+		as = []*testAssertion{{cond: cond}}
+	}
+	var errs []error
+	for _, a := range as {
+		conds := []string{a.cond}
+		if o.softAssertions {
+			conds = splitConds(a.cond)
+		}
+		for _, c := range conds {
+			if err := evalTestCond(c, store); err != nil {
+				if a.message != "" {
+					var fe *condFalseError
+					if errors.As(err, &fe) {
+						fe.message = expandTestMessage(a.message, store)
+					}
+				}
+				if !o.softAssertions {
+					return err
+				}
+				errs = append(errs, err)
+			}
+		}
+	}
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+	if first {
+		o.record(nil)
+	}
+	return nil
+}
+
+// testStore returns the store to evaluate the test of the current step.
+// If first is true, the step has not been run by the other runners, so the latest values are `previous`.
+func (o *operator) testStore(first bool) map[string]any {
 	store := o.store.toMap()
 	store[storeRootKeyIncluded] = o.included
 	if first {
@@ -41,6 +106,10 @@ func (rnr *testRunner) Run(ctx context.Context, s *step, first bool) error {
 		store[storeRootPrevious] = o.store.previous()
 		store[storeRootKeyCurrent] = o.store.latest()
 	}
+	return store
+}
+
+func evalTestCond(cond string, store map[string]any) error {
 	t, err := buildTree(cond, store)
 	if err != nil {
 		return err
@@ -50,10 +119,93 @@ func (rnr *testRunner) Run(ctx context.Context, s *step, first bool) error {
 		return err
 	}
 	if !tf {
-		return newCondFalseError(cond, t)
-	}
-	if first {
-		o.record(nil)
+		fe := newCondFalseError(cond, t)
+		fe.diff = buildDiff(cond, store)
+		return fe
 	}
 	return nil
 }
+
+// parseTestAssertions parses the list form of `test:`.
+// Each element is a condition or a map of `cond:` and `message:`.
+func parseTestAssertions(v []any) ([]*testAssertion, error) {
+	var as []*testAssertion
+	for _, e := range v {
+		switch ee := e.(type) {
+		case string:
+			as = append(as, &testAssertion{cond: ee})
+		case bool:
+			as = append(as, &testAssertion{cond: fmt.Sprintf("%v", ee)})
+		case map[string]any:
+			a := &testAssertion{}
+			for k, vv := range ee {
+				switch k {
+				case "cond":
+					switch c := vv.(type) {
+					case string:
+						a.cond = c
+					case bool:
+						a.cond = fmt.Sprintf("%v", c)
+					default:
+						return nil, fmt.Errorf("invalid test condition: %v", vv)
+					}
+				case "message":
+					m, ok := vv.(string)
+					if !ok {
+						return nil, fmt.Errorf("invalid test message: %v", vv)
+					}
+					a.message = m
+				default:
+					return nil, fmt.Errorf("invalid test condition key: %s", k)
+				}
+			}
+			if a.cond == "" {
+				return nil, fmt.Errorf("invalid test condition: %v", ee)
+			}
+			as = append(as, a)
+		default:
+			return nil, fmt.Errorf("invalid test condition: %v", e)
+		}
+	}
+	if len(as) == 0 {
+		return nil, fmt.Errorf("invalid test condition: %v", v)
+	}
+	return as, nil
+}
+
+func joinTestAssertions(as []*testAssertion) string {
+	var conds []string
+	for _, a := range as {
+		conds = append(conds, fmt.Sprintf("(%s)", strings.TrimSpace(trimComment(a.cond))))
+	}
+	return strings.Join(conds, "\n&& ")
+}
+
+func expandTestMessage(m string, store map[string]any) string {
+	e, err := EvalExpand(m, store)
+	if err != nil {
+		return m
+	}
+	return fmt.Sprintf("%v", e)
+}
+
+// splitConds splits the condition into the sub-conditions joined by `&&` ( or `and` ).
+func splitConds(cond string) []string {
+	t, err := parser.Parse(trimComment(cond))
+	if err != nil {
+		return []string{cond}
+	}
+	var conds []string
+	for _, n := range conjunctNodes(t.Node) {
+		conds = append(conds, n.String())
+	}
+	return conds
+}
+
+func conjunctNodes(n ast.Node) []ast.Node {
+	b, ok := n.(*ast.BinaryNode)
+	if !ok || (b.Operator != "&&" && b.Operator != "and") {
+		return []ast.Node{n}
+	}
+	return append(conjunctNodes(b.Left), conjunctNodes(b.Right)...)
+}
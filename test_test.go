@@ -4,6 +4,9 @@ import (
 	"context"
 	"errors"
 	"testing"
+
+	"github.com/fatih/color"
+	"github.com/google/go-cmp/cmp"
 )
 
 func TestTestRun(t *testing.T) {
@@ -49,3 +52,212 @@ func TestTestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildDiff(t *testing.T) {
+	color.NoColor = true
+	store := map[string]any{
+		"vars": map[string]any{
+			"user": map[string]any{"id": 1, "name": "alice", "tags": []any{"a", "b"}},
+			"name": "alice",
+		},
+	}
+	tests := []struct {
+		cond string
+		want string
+	}{
+		{`vars.user == {"id": 1, "name": "alice", "tags": ["a", "b"]}`, ""},
+		{`vars.name == "bob"`, ""},
+		{
+			`vars.user == {"id": 1, "name": "bob", "tags": ["a", "c"]}`,
+			`vars.user == {"id": 1, "name": "bob", "tags": ["a", "c"]}
+  .name
+    - "alice"
+    + "bob"
+  .tags[1]
+    - "b"
+    + "c"`,
+		},
+		{
+			`vars.name == "alice" && vars.user == {"id": 1, "name": "alice"} # comment`,
+			`vars.user == {"id": 1, "name": "alice"}
+  .tags
+    - ["a","b"]
+    + (none)`,
+		},
+	}
+	for _, tt := range tests {
+		got := buildDiff(tt.cond, store)
+		if got != tt.want {
+			t.Errorf("%s:\ngot  %s\nwant %s", tt.cond, got, tt.want)
+		}
+	}
+}
+
+func TestTestRunSoftAssertions(t *testing.T) {
+	tests := []struct {
+		cond      string
+		soft      bool
+		wantConds []string
+	}{
+		{"vars.foo.bar == 'baz' && vars.foo.qux == 1", true, nil},
+		{"vars.foo.bar == 'xxx' && vars.foo.qux == 1", false, []string{"vars.foo.bar == 'xxx' && vars.foo.qux == 1"}},
+		{"vars.foo.bar == 'xxx'\n&& vars.foo.qux == 1\n&& vars.foo.qux == 2", true, []string{`vars.foo.bar == "xxx"`, "vars.foo.qux == 2"}},
+		{"vars.foo.bar == 'xxx' and (vars.foo.qux == 2 || vars.foo.qux == 3)", true, []string{`vars.foo.bar == "xxx"`, "vars.foo.qux == 2 || vars.foo.qux == 3"}},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.cond, func(t *testing.T) {
+			o, err := New(Var("foo", map[string]any{
+				"bar": "baz",
+				"qux": 1,
+			}), SoftAssertions(tt.soft))
+			if err != nil {
+				t.Fatal(err)
+			}
+			r := newTestRunner()
+			s := newStep(0, "stepKey", o)
+			s.testCond = tt.cond
+			err = r.Run(ctx, s, true)
+			if len(tt.wantConds) == 0 {
+				if err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("want error")
+			}
+			var got []string
+			if errs, ok := err.(interface{ Unwrap() []error }); ok {
+				for _, e := range errs.Unwrap() {
+					var fe *condFalseError
+					if errors.As(e, &fe) {
+						got = append(got, fe.cond)
+					}
+				}
+			} else {
+				var fe *condFalseError
+				if errors.As(err, &fe) {
+					got = append(got, fe.cond)
+				}
+			}
+			if diff := cmp.Diff(got, tt.wantConds); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestTestRunWithMessages(t *testing.T) {
+	tests := []struct {
+		name        string
+		test        []any
+		soft        bool
+		wantErr     bool
+		wantMessage []string
+	}{
+		{
+			"all true",
+			[]any{"vars.order.subtotal > 0", map[string]any{"cond": "vars.order.total == 110", "message": "order total should include tax"}},
+			false,
+			false,
+			nil,
+		},
+		{
+			"expand message",
+			[]any{map[string]any{"cond": "vars.order.total == 100", "message": "order total should include tax ( total: {{ vars.order.total }} )"}},
+			false,
+			true,
+			[]string{"order total should include tax ( total: 110 )"},
+		},
+		{
+			"stop at first failure",
+			[]any{map[string]any{"cond": "vars.order.tax == 0", "message": "no tax"}, map[string]any{"cond": "vars.order.total == 100", "message": "total"}},
+			false,
+			true,
+			[]string{"no tax"},
+		},
+		{
+			"soft assertions",
+			[]any{map[string]any{"cond": "vars.order.tax == 0", "message": "no tax"}, "vars.order.subtotal == 0", map[string]any{"cond": "vars.order.total == 100", "message": "total"}},
+			true,
+			true,
+			[]string{"no tax", "", "total"},
+		},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o, err := New(Var("order", map[string]any{
+				"subtotal": 100,
+				"tax":      10,
+				"total":    110,
+			}), SoftAssertions(tt.soft))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s := newStep(0, "stepKey", o)
+			as, err := parseTestAssertions(tt.test)
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.testAssertions = as
+			s.testCond = joinTestAssertions(as)
+			err = newTestRunner().Run(ctx, s, true)
+			if !tt.wantErr {
+				if err != nil {
+					t.Error(err)
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("want error")
+			}
+			errs := []error{err}
+			if u, ok := err.(interface{ Unwrap() []error }); ok {
+				errs = u.Unwrap()
+			}
+			var got []string
+			for _, e := range errs {
+				var fe *condFalseError
+				if errors.As(e, &fe) {
+					got = append(got, fe.message)
+				}
+			}
+			if diff := cmp.Diff(got, tt.wantMessage); diff != "" {
+				t.Error(diff)
+			}
+		})
+	}
+}
+
+func TestParseTestAssertions(t *testing.T) {
+	tests := []struct {
+		in      []any
+		want    string
+		wantErr bool
+	}{
+		{[]any{"a == 1", "b == 2"}, "(a == 1)\n&& (b == 2)", false},
+		{[]any{map[string]any{"cond": "a == 1 # comment\n", "message": "a should be 1"}, true}, "(a == 1)\n&& (true)", false},
+		{[]any{map[string]any{"message": "no cond"}}, "", true},
+		{[]any{map[string]any{"cond": "a == 1", "unknown": "x"}}, "", true},
+		{[]any{1}, "", true},
+		{[]any{}, "", true},
+	}
+	for _, tt := range tests {
+		as, err := parseTestAssertions(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%v: want error", tt.in)
+			}
+			continue
+		}
+		if err != nil {
+			t.Error(err)
+			continue
+		}
+		if got := joinTestAssertions(as); got != tt.want {
+			t.Errorf("got %q\nwant %q", got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package runn
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/expr-lang/expr/ast"
+	"github.com/expr-lang/expr/parser"
+	"github.com/goccy/go-json"
+	"github.com/k1LoW/runn/builtin"
+)
+
+// buildDiff returns the path-annotated diff of the failed comparisons of structures ( e.g. `current.res.body == {...}` ) in cond.
+// It returns an empty string if there is no failed comparison of structures.
+func buildDiff(cond string, store any) string {
+	if cond == "" {
+		return ""
+	}
+	t, err := parser.Parse(trimComment(cond))
+	if err != nil {
+		return ""
+	}
+	var diffs []string
+	for _, n := range comparisonNodes(t.Node) {
+		l, err := Eval(n.Left.String(), store)
+		if err != nil {
+			continue
+		}
+		r, err := Eval(n.Right.String(), store)
+		if err != nil {
+			continue
+		}
+		if !isStructure(l) || !isStructure(r) || builtin.Compare(l, r) {
+			continue
+		}
+		var b strings.Builder
+		_, _ = fmt.Fprintf(&b, "%s\n", n.String())
+		for _, c := range builtin.Changes(l, r) {
+			m, ok := c.(map[string]any)
+			if !ok {
+				continue
+			}
+			p, _ := m["path"].(string)
+			if p == "" {
+				p = "."
+			}
+			_, _ = fmt.Fprintf(&b, "  %s\n", p)
+			_, _ = fmt.Fprintf(&b, "    %s\n", red("- "+diffValue(m["x"])))
+			_, _ = fmt.Fprintf(&b, "    %s\n", green("+ "+diffValue(m["y"])))
+		}
+		diffs = append(diffs, strings.TrimSuffix(b.String(), "\n"))
+	}
+	return strings.Join(diffs, "\n\n")
+}
+
+// comparisonNodes returns the nodes of `==` joined by `&&` ( or `and` ).
+func comparisonNodes(n ast.Node) []*ast.BinaryNode {
+	var nodes []*ast.BinaryNode
+	for _, c := range conjunctNodes(n) {
+		if b, ok := c.(*ast.BinaryNode); ok && b.Operator == "==" {
+			nodes = append(nodes, b)
+		}
+	}
+	return nodes
+}
+
+func isStructure(v any) bool {
+	if v == nil {
+		return false
+	}
+	switch reflect.ValueOf(v).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return true
+	default:
+		return false
+	}
+}
+
+func diffValue(v any) string {
+	if v == nil {
+		return "(none)"
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprintf("%v", v)
+	}
+	return string(b)
+}
@@ -0,0 +1,41 @@
+package runn
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+var errRunbookTimeout = errors.New("runbook timeout")
+
+// deferredStepsTimeout - Time limit of the deferred steps run after the run of the runbook is canceled
+const deferredStepsTimeout = 30 * time.Second
+
+// withTimeout returns the context canceled when `timeout:` of the runbook is exceeded.
+func (o *operator) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if o.timeout <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeoutCause(ctx, o.timeout, errRunbookTimeout)
+}
+
+// withDeferredTimeout returns the context to run the deferred steps.
+// If the run is canceled ( e.g. `timeout:` of the runbook is exceeded ), the deferred steps still run within deferredStepsTimeout.
+func withDeferredTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if ctx.Err() == nil {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(context.WithoutCancel(ctx), deferredStepsTimeout)
+}
+
+// checkTimeout marks the result as timed out if the run failed because `timeout:` of the runbook is exceeded.
+func (o *operator) checkTimeout(ctx context.Context, err error) error {
+	if err == nil || !errors.Is(context.Cause(ctx), errRunbookTimeout) {
+		return err
+	}
+	err = fmt.Errorf("runbook timed out after %s: %w", o.timeout, err)
+	o.runResult.TimedOut = true
+	o.runResult.Err = err
+	return err
+}
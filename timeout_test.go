@@ -0,0 +1,123 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRunbookTimeout(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/timeout.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	err = o.Run(ctx)
+	if err == nil {
+		t.Fatal("want error")
+	}
+	if spent := time.Since(start); spent > 2*time.Second {
+		t.Errorf("the run should be canceled: spent %v", spent)
+	}
+	if want := "runbook timed out after 300ms"; !strings.Contains(err.Error(), want) {
+		t.Errorf("got %v\nwant %v", err, want)
+	}
+	r := o.Result()
+	if !r.TimedOut {
+		t.Error("the result should be marked as timed out")
+	}
+	if r.StepResults[1].Err == nil {
+		t.Error("the step after the timeout should be canceled")
+	}
+}
+
+func TestRunbookTimeoutDeferred(t *testing.T) {
+	if err := setScopes(ScopeAllowRunExec); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyRunExec); err != nil {
+			t.Fatal(err)
+		}
+	})
+	ctx := context.Background()
+	o, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.timeout = 300 * time.Millisecond
+	steps := []map[string]any{
+		{"exec": map[string]any{"command": "sleep 3"}, "test": "current.exit_code == 0"},
+		{"defer": true, "exec": map[string]any{"command": "echo cleanup"}},
+	}
+	for i, s := range steps {
+		if err := o.AppendStep(i, fmt.Sprintf("%d", i), s); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := o.Run(ctx); err == nil {
+		t.Fatal("want error")
+	}
+	if !o.Result().TimedOut {
+		t.Error("the result should be marked as timed out")
+	}
+	// The deferred step runs after the timeout
+	if got := o.store.steps[1]["stdout"]; got != "cleanup\n" {
+		t.Errorf("got %v\nwant %v", got, "cleanup\n")
+	}
+}
+
+func TestRunbookTimeoutNotExceeded(t *testing.T) {
+	ctx := context.Background()
+	o, err := New(Book("testdata/book/always_success.yml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	o.timeout = 10 * time.Second
+	if err := o.Run(ctx); err != nil {
+		t.Fatal(err)
+	}
+	if o.Result().TimedOut {
+		t.Error("the result should not be marked as timed out")
+	}
+}
+
+func TestParseTimeout(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    time.Duration
+		wantErr bool
+	}{
+		{"desc: test\ntimeout: 10sec\nsteps: []\n", 10 * time.Second, false},
+		{"desc: test\ntimeout: 1min\nsteps: []\n", time.Minute, false},
+		{"desc: test\nsteps: []\n", 0, false},
+		{"desc: test\ntimeout: invalid\nsteps: []\n", 0, true},
+	}
+	for _, tt := range tests {
+		bk, err := parseBook(strings.NewReader(tt.in))
+		if err != nil {
+			if !tt.wantErr {
+				t.Errorf("got error: %v", err)
+			}
+			continue
+		}
+		if tt.wantErr {
+			t.Errorf("want error: %s", tt.in)
+			continue
+		}
+		if bk.timeout != tt.want {
+			t.Errorf("got %v\nwant %v", bk.timeout, tt.want)
+		}
+	}
+}
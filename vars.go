@@ -2,6 +2,8 @@ package runn
 
 import (
 	"bytes"
+	"encoding/csv"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -12,6 +14,7 @@ import (
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/goccy/go-json"
 	"github.com/goccy/go-yaml"
+	"github.com/xeipuuv/gojsonschema"
 )
 
 const multiple = "*"
@@ -20,15 +23,19 @@ type evaluator struct {
 	scheme    string
 	exts      []string
 	unmarshal func(data []byte, v any) error
+	// sopsFormat - Format of the file to decrypt by SOPS. Empty means not supported
+	sopsFormat string
 }
 
 var (
-	jsonEvaluator = &evaluator{scheme: "json://", exts: []string{"json"}, unmarshal: json.Unmarshal}
-	yamlEvaluator = &evaluator{scheme: "yaml://", exts: []string{"yml", "yaml"}, unmarshal: yaml.Unmarshal}
+	jsonEvaluator = &evaluator{scheme: "json://", exts: []string{"json"}, unmarshal: json.Unmarshal, sopsFormat: sopsFormatJSON}
+	yamlEvaluator = &evaluator{scheme: "yaml://", exts: []string{"yml", "yaml"}, unmarshal: yaml.Unmarshal, sopsFormat: sopsFormatYAML}
+	csvEvaluator  = &evaluator{scheme: "csv://", exts: []string{"csv"}, unmarshal: unmarshalCSV}
 
 	evaluators = []*evaluator{
 		jsonEvaluator,
 		yamlEvaluator,
+		csvEvaluator,
 	}
 )
 
@@ -108,6 +115,12 @@ func evalutateFile(p string, store map[string]any, e *evaluator) (any, error) {
 	if err != nil {
 		return nil, fmt.Errorf("read external files error: %w", err)
 	}
+	if e.sopsFormat != "" {
+		b, err = decryptSOPSIfEncrypted(b, p, e.sopsFormat)
+		if err != nil {
+			return nil, err
+		}
+	}
 	if store != nil && hasTemplateSuffix(p, e.exts) {
 		tmpl, err := template.New(p).Parse(string(b))
 		if err != nil {
@@ -125,3 +138,52 @@ func evalutateFile(p string, store map[string]any, e *evaluator) (any, error) {
 	}
 	return out, nil
 }
+
+// unmarshalCSV unmarshals CSV with the header row into the list of maps.
+func unmarshalCSV(data []byte, v any) error {
+	out, ok := v.(*any)
+	if !ok {
+		return fmt.Errorf("unsupported type: %T", v)
+	}
+	records, err := csv.NewReader(bytes.NewReader(data)).ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return errors.New("no header row")
+	}
+	header := records[0]
+	rows := []any{}
+	for _, r := range records[1:] {
+		row := map[string]any{}
+		for i, k := range header {
+			row[k] = r[i]
+		}
+		rows = append(rows, row)
+	}
+	*out = rows
+	return nil
+}
+
+// validateVars validates vars using the JSON Schema of `varsSchema:`.
+func validateVars(schema, vars map[string]any) error {
+	if schema == nil {
+		return nil
+	}
+	s, err := gojsonschema.NewSchema(gojsonschema.NewGoLoader(schema))
+	if err != nil {
+		return fmt.Errorf("invalid varsSchema: %w", err)
+	}
+	r, err := s.Validate(gojsonschema.NewGoLoader(vars))
+	if err != nil {
+		return fmt.Errorf("invalid vars: %w", err)
+	}
+	if r.Valid() {
+		return nil
+	}
+	var errs []string
+	for _, e := range r.Errors() {
+		errs = append(errs, e.String())
+	}
+	return fmt.Errorf("invalid vars: %s", strings.Join(errs, ", "))
+}
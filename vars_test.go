@@ -1,9 +1,11 @@
 package runn
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -91,3 +93,66 @@ func TestEvaluateSchema(t *testing.T) {
 		})
 	}
 }
+
+func TestVarsSchema(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	included, err := os.ReadFile("testdata/book/vars_schema.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		name    string
+		opts    []Option
+		include string
+		wantErr string
+	}{
+		{"valid", nil, "", ""},
+		{"override with valid var", []Option{Var("userId", 2)}, "", ""},
+		{"wrong type", []Option{Var("userId", "one")}, "", "invalid vars: userId: Invalid type. Expected: integer, given: string"},
+		{"not in enum", []Option{Var("role", "guest")}, "", "invalid vars: role: role must be one of the following"},
+		{"too short", []Option{Var("token", "")}, "", "invalid vars: token: String length must be greater than or equal to 1"},
+		{"include with valid vars", nil, "userId: 3", ""},
+		{"include with wrong type", nil, "userId: three", "invalid vars: userId: Invalid type. Expected: integer, given: string"},
+	}
+	ctx := context.Background()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := "testdata/book/vars_schema.yml"
+			if tt.include != "" {
+				dir := t.TempDir()
+				p = filepath.Join(dir, "include_vars_schema.yml")
+				rb := fmt.Sprintf(`desc: Include the runbook with varsSchema
+steps:
+  -
+    include:
+      path: vars_schema.yml
+      vars:
+        %s
+`, tt.include)
+				if err := os.WriteFile(p, []byte(rb), 0600); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(filepath.Join(dir, "vars_schema.yml"), included, 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			o, err := New(append([]Option{Scopes(ScopeAllowReadParent), Book(p)}, tt.opts...)...)
+			if err == nil {
+				err = o.Run(ctx)
+			}
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got %v\nwant %s", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package runn
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultWatchInterval - Interval of polling the runbook files
+	defaultWatchInterval = 500 * time.Millisecond
+	// DefaultWatchDebounce - Duration to wait for the changes of the runbook files to settle before re-running
+	DefaultWatchDebounce = 300 * time.Millisecond
+)
+
+// Watcher re-runs the runbooks whenever the runbook files ( or the runbooks included by them ) change.
+// The changes are detected by polling the modification times of the local files.
+type Watcher struct {
+	pathp    string
+	opts     []Option
+	interval time.Duration
+	debounce time.Duration
+	// deps - Local files that each runbook depends on ( the runbook itself and the included runbooks )
+	deps map[string][]string
+	// mtimes - Modification times of the watched files
+	mtimes map[string]time.Time
+	out    io.Writer
+}
+
+// NewWatcher returns the Watcher of the runbooks of the path pattern ( like `path/to/a.yml;path/to/b/**/*.yml` ).
+// The runbooks are run with opts in the same way as Load.
+func NewWatcher(pathp string, debounce time.Duration, opts ...Option) *Watcher {
+	if debounce <= 0 {
+		debounce = DefaultWatchDebounce
+	}
+	return &Watcher{
+		pathp:    pathp,
+		opts:     opts,
+		interval: defaultWatchInterval,
+		debounce: debounce,
+		deps:     map[string][]string{},
+		mtimes:   map[string]time.Time{},
+		out:      os.Stdout,
+	}
+}
+
+// Run runs all the runbooks, then re-runs the affected runbooks on every change until ctx is canceled.
+func (w *Watcher) Run(ctx context.Context) error {
+	paths, err := fetchPaths(w.pathp)
+	if err != nil {
+		return err
+	}
+	w.run(ctx, paths)
+
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	changed := map[string]struct{}{}
+	var last time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		files, err := w.changedFiles()
+		if err != nil {
+			// The runbook may be being saved
+			w.printf("%v\n", err)
+			continue
+		}
+		if len(files) > 0 {
+			for _, f := range files {
+				changed[f] = struct{}{}
+			}
+			last = time.Now()
+			continue
+		}
+		if len(changed) == 0 || time.Since(last) < w.debounce {
+			continue
+		}
+		affected := w.affected(changed)
+		changed = map[string]struct{}{}
+		if len(affected) == 0 {
+			continue
+		}
+		w.run(ctx, affected)
+	}
+}
+
+// run runs the runbooks and prints the summary of the results.
+func (w *Watcher) run(ctx context.Context, paths []string) {
+	defer func() {
+		for _, p := range paths {
+			w.updateDeps(p)
+		}
+	}()
+	if len(paths) == 0 {
+		return
+	}
+	if len(paths) == 1 {
+		w.printf("Run %s\n", paths[0])
+	} else {
+		w.printf("Run %d runbooks\n", len(paths))
+	}
+	ops, err := Load(strings.Join(paths, string(filepath.ListSeparator)), w.opts...)
+	if err != nil {
+		w.printf(red("%v\n"), err)
+		return
+	}
+	if err := ops.RunN(ctx); err != nil {
+		w.printf(red("%v\n"), err)
+		return
+	}
+	w.printSummary(ops.Result())
+}
+
+// printSummary prints the compact summary of the results.
+func (w *Watcher) printSummary(r *runNResult) {
+	_, _ = fmt.Fprintln(w.out)
+	rs := r.simplify()
+	s := fmt.Sprintf("%d passed, %d failed, %d skipped", rs.Success, rs.Failure, rs.Skipped)
+	if r.HasFailure() {
+		w.printf(red("%s\n"), s)
+	} else {
+		w.printf(green("%s\n"), s)
+	}
+	for _, rr := range r.RunResults {
+		if rr.Err == nil {
+			continue
+		}
+		msg, _, _ := strings.Cut(rr.Err.Error(), "\n")
+		_, _ = fmt.Fprintf(w.out, "  %s %s: %s\n", red("FAIL"), rr.Path, msg)
+	}
+}
+
+func (w *Watcher) printf(format string, a ...any) {
+	_, _ = fmt.Fprintf(w.out, "[%s] "+format, append([]any{time.Now().Format(time.TimeOnly)}, a...)...)
+}
+
+// changedFiles returns the watched files that have changed and the runbooks newly matched the path pattern.
+func (w *Watcher) changedFiles() ([]string, error) {
+	paths, err := fetchPaths(w.pathp)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for _, p := range paths {
+		if _, ok := w.deps[p]; !ok {
+			w.deps[p] = []string{p}
+			if fi, err := os.Stat(p); err == nil {
+				w.mtimes[p] = fi.ModTime()
+			}
+			changed = append(changed, p)
+		}
+	}
+	for f, mt := range w.mtimes {
+		fi, err := os.Stat(f)
+		if err != nil {
+			// Removed
+			delete(w.mtimes, f)
+			changed = append(changed, f)
+			continue
+		}
+		if !fi.ModTime().Equal(mt) {
+			w.mtimes[f] = fi.ModTime()
+			changed = append(changed, f)
+		}
+	}
+	return changed, nil
+}
+
+// affected returns the runbooks that depend on the changed files.
+func (w *Watcher) affected(changed map[string]struct{}) []string {
+	paths, err := fetchPaths(w.pathp)
+	if err != nil {
+		w.printf("%v\n", err)
+		return nil
+	}
+	var affected []string
+	for _, p := range paths {
+		for _, d := range w.deps[p] {
+			if _, ok := changed[d]; ok {
+				affected = append(affected, p)
+				break
+			}
+		}
+	}
+	return affected
+}
+
+// updateDeps updates the files that the runbook depends on and their modification times.
+func (w *Watcher) updateDeps(p string) {
+	deps := watchDeps(p, map[string]struct{}{})
+	sort.Strings(deps)
+	w.deps[p] = deps
+	for _, d := range deps {
+		if _, ok := w.mtimes[d]; ok {
+			continue
+		}
+		fi, err := os.Stat(d)
+		if err != nil {
+			continue
+		}
+		w.mtimes[d] = fi.ModTime()
+	}
+}
+
+// watchDeps returns the local files that the runbook depends on ( the runbook itself and the included runbooks recursively ).
+func watchDeps(p string, seen map[string]struct{}) []string {
+	if _, ok := seen[p]; ok {
+		return nil
+	}
+	seen[p] = struct{}{}
+	deps := []string{p}
+	o, err := New(Book(p), LoadOnly(), included(true))
+	if err != nil {
+		// The runbook itself is watched even if it is broken
+		return deps
+	}
+	for _, s := range o.steps {
+		if s.includeConfig == nil || hasRemotePrefix(s.includeConfig.path) || strings.Contains(s.includeConfig.path, delimStart) {
+			continue
+		}
+		ps, err := s.includeConfig.bookPaths(o.root)
+		if err != nil {
+			continue
+		}
+		for _, ip := range ps {
+			deps = append(deps, watchDeps(ip, seen)...)
+		}
+	}
+	return deps
+}
@@ -0,0 +1,114 @@
+package runn
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestWatcher(t *testing.T) {
+	t.Cleanup(func() {
+		if err := setScopes(ScopeDenyReadParent); err != nil {
+			t.Fatal(err)
+		}
+	})
+	dir := t.TempDir()
+	books := map[string]string{
+		"a.yml": `desc: A
+steps:
+  -
+    test: true
+`,
+		"b.yml": `desc: B
+steps:
+  -
+    include: inc/c.yml
+`,
+		"inc/c.yml": `desc: C
+steps:
+  -
+    test: true
+`,
+	}
+	if err := os.Mkdir(filepath.Join(dir, "inc"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	for n, b := range books {
+		if err := os.WriteFile(filepath.Join(dir, n), []byte(b), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ap := filepath.Join(dir, "a.yml")
+	bp := filepath.Join(dir, "b.yml")
+	cp := filepath.Join(dir, "inc", "c.yml")
+	ctx := context.Background()
+	w := NewWatcher(filepath.Join(dir, "*.yml"), 0, Scopes(ScopeAllowReadParent))
+	out := new(bytes.Buffer)
+	w.out = out
+
+	w.run(ctx, []string{ap, bp})
+	if !strings.Contains(out.String(), "2 passed, 0 failed, 0 skipped") {
+		t.Errorf("got %q", out.String())
+	}
+	if diff := cmp.Diff(w.deps[bp], []string{bp, cp}); diff != "" {
+		t.Error(diff)
+	}
+
+	t.Run("Change the included runbook", func(t *testing.T) {
+		mt := time.Now().Add(time.Second)
+		if err := os.Chtimes(cp, mt, mt); err != nil {
+			t.Fatal(err)
+		}
+		changed := map[string]struct{}{}
+		files, err := w.changedFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range files {
+			changed[f] = struct{}{}
+		}
+		if diff := cmp.Diff(w.affected(changed), []string{bp}); diff != "" {
+			t.Error(diff)
+		}
+	})
+
+	t.Run("Add the runbook", func(t *testing.T) {
+		dp := filepath.Join(dir, "d.yml")
+		if err := os.WriteFile(dp, []byte("desc: D\nsteps:\n  -\n    test: false\n"), 0600); err != nil {
+			t.Fatal(err)
+		}
+		changed := map[string]struct{}{}
+		files, err := w.changedFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, f := range files {
+			changed[f] = struct{}{}
+		}
+		affected := w.affected(changed)
+		if diff := cmp.Diff(affected, []string{dp}); diff != "" {
+			t.Error(diff)
+		}
+		out.Reset()
+		w.run(ctx, affected)
+		if !strings.Contains(out.String(), "0 passed, 1 failed, 0 skipped") || !strings.Contains(out.String(), dp) {
+			t.Errorf("got %q", out.String())
+		}
+	})
+
+	t.Run("No changes", func(t *testing.T) {
+		files, err := w.changedFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(files) != 0 {
+			t.Errorf("got %v", files)
+		}
+	})
+}
@@ -0,0 +1,189 @@
+package runn
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/mattn/go-shellwords"
+)
+
+// wgetShortOptsWithValue - Short options of wget that take the value.
+var wgetShortOptsWithValue = "oaOePtTwQUiBD"
+
+// wgetOptsWithValue - Long options of wget that take the value ( when given without `=` ).
+var wgetOptsWithValue = []string{
+	"--output-file", "--append-output", "--input-file", "--base", "--config", "--tries", "--output-document",
+	"--timeout", "--dns-timeout", "--connect-timeout", "--read-timeout", "--wait", "--waitretry", "--quota",
+	"--limit-rate", "--bind-address", "--directory-prefix", "--user", "--password", "--http-user",
+	"--http-password", "--header", "--method", "--body-data", "--body-file", "--post-data", "--post-file",
+	"--user-agent", "--referer", "--load-cookies", "--save-cookies", "--certificate", "--private-key",
+	"--ca-certificate", "--ca-directory", "--level", "--execute", "--domains", "--exclude-domains",
+}
+
+func isWgetCommand(in ...string) bool {
+	if len(in) == 0 {
+		return false
+	}
+	cmd := in[0]
+	if len(in) == 1 {
+		cmd, _, _ = strings.Cut(strings.TrimSpace(in[0]), " ")
+	}
+	return cmd == "wget"
+}
+
+func (rb *runbook) wgetToStep(in ...string) error {
+	req, err := newRequestFromWget(in...)
+	if err != nil {
+		return err
+	}
+	dsn := fmt.Sprintf("%s://%s", req.URL.Scheme, req.URL.Host)
+	key := rb.setRunner(dsn)
+	step, err := CreateHTTPStepMapSlice(key, req)
+	if err != nil {
+		return err
+	}
+	rb.Steps = append(rb.Steps, step)
+	return nil
+}
+
+// newRequestFromWget creates the *http.Request from the wget command line ( `wget [option]... URL` ).
+func newRequestFromWget(in ...string) (*http.Request, error) {
+	args := in
+	if len(in) == 1 {
+		var err error
+		args, err = shellwords.Parse(in[0])
+		if err != nil {
+			return nil, err
+		}
+	}
+	if !isWgetCommand(args...) {
+		return nil, fmt.Errorf("not wget command: %v", in)
+	}
+	type opt struct {
+		name  string
+		value string
+	}
+	var (
+		opts []opt
+		urls []string
+	)
+	for i := 1; i < len(args); i++ {
+		a := args[i]
+		switch {
+		case a == "--":
+			urls = append(urls, args[i+1:]...)
+			i = len(args)
+		case strings.HasPrefix(a, "--"):
+			name, value, hasValue := strings.Cut(a, "=")
+			if contains(wgetOptsWithValue, name) && !hasValue {
+				if i+1 >= len(args) {
+					return nil, fmt.Errorf("no value for %s", name)
+				}
+				i++
+				value = args[i]
+			}
+			opts = append(opts, opt{name: name, value: value})
+		case strings.HasPrefix(a, "-") && a != "-":
+			// Short options can be combined ( e.g. -qO- )
+			for j := 1; j < len(a); j++ {
+				c := a[j : j+1]
+				if !strings.Contains(wgetShortOptsWithValue, c) {
+					opts = append(opts, opt{name: "-" + c})
+					continue
+				}
+				value := a[j+1:]
+				if value == "" {
+					if i+1 >= len(args) {
+						return nil, fmt.Errorf("no value for -%s", c)
+					}
+					i++
+					value = args[i]
+				}
+				opts = append(opts, opt{name: "-" + c, value: value})
+				break
+			}
+		default:
+			urls = append(urls, a)
+		}
+	}
+	switch {
+	case len(urls) == 0:
+		return nil, errors.New("no url")
+	case len(urls) > 1:
+		return nil, fmt.Errorf("multiple urls are not supported: %v", urls)
+	}
+
+	var (
+		method     string
+		body       io.Reader
+		hasBody    bool
+		user, pass string
+		headers    = http.Header{}
+	)
+	for _, o := range opts {
+		switch o.name {
+		case "--method":
+			method = strings.ToUpper(o.value)
+		case "--post-data", "--body-data":
+			body = strings.NewReader(o.value)
+			hasBody = true
+			if o.name == "--post-data" && method == "" {
+				method = http.MethodPost
+			}
+		case "--post-file", "--body-file":
+			b, err := os.ReadFile(filepath.Clean(o.value))
+			if err != nil {
+				return nil, err
+			}
+			body = strings.NewReader(string(b))
+			hasBody = true
+			if o.name == "--post-file" && method == "" {
+				method = http.MethodPost
+			}
+		case "--header":
+			k, v, ok := strings.Cut(o.value, ":")
+			if !ok {
+				return nil, fmt.Errorf("invalid header: %s", o.value)
+			}
+			headers.Add(strings.TrimSpace(k), strings.TrimSpace(v))
+		case "-U", "--user-agent":
+			headers.Set("User-Agent", o.value)
+		case "--referer":
+			headers.Set("Referer", o.value)
+		case "--user", "--http-user":
+			user = o.value
+		case "--password", "--http-password":
+			pass = o.value
+		}
+	}
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	u, err := completeURL("http", urls[0])
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest(method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range headers {
+		for _, v := range vs {
+			req.Header.Add(k, v)
+		}
+	}
+	if hasBody && req.Header.Get("Content-Type") == "" {
+		// wget sends the body as application/x-www-form-urlencoded by default
+		req.Header.Set("Content-Type", MediaTypeApplicationFormUrlencoded)
+	}
+	if user != "" {
+		req.SetBasicAuth(user, pass)
+	}
+	return req, nil
+}
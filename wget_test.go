@@ -0,0 +1,36 @@
+package runn
+
+import (
+	"testing"
+)
+
+func TestNewRequestFromWget(t *testing.T) {
+	tests := []struct {
+		in         []string
+		wantMethod string
+		wantURL    string
+		wantErr    bool
+	}{
+		{[]string{"wget", "example.com/users"}, "GET", "http://example.com/users", false},
+		{[]string{"wget -q -O - --post-data=name=alice https://example.com/users"}, "POST", "https://example.com/users", false},
+		{[]string{"wget", "--method", "delete", "-T", "10", "https://example.com/users/1"}, "DELETE", "https://example.com/users/1", false},
+		{[]string{"wget", "-qO-"}, "", "", true},
+		{[]string{"wget", "https://example.com/a", "https://example.com/b"}, "", "", true},
+	}
+	for _, tt := range tests {
+		got, err := newRequestFromWget(tt.in...)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%v: got error %v", tt.in, err)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		if got.Method != tt.wantMethod {
+			t.Errorf("%v: got %v want %v", tt.in, got.Method, tt.wantMethod)
+		}
+		if got.URL.String() != tt.wantURL {
+			t.Errorf("%v: got %v want %v", tt.in, got.URL.String(), tt.wantURL)
+		}
+	}
+}
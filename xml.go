@@ -0,0 +1,101 @@
+package runn
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"strings"
+)
+
+const (
+	xmlAttrPrefix = "@"
+	xmlTextKey    = "#text"
+)
+
+// isXMLContentType returns true if the Content-Type is XML ( e.g. `application/xml`, `text/xml`, `application/soap+xml`, `application/atom+xml` ).
+func isXMLContentType(ct string) bool {
+	mt := strings.ToLower(strings.TrimSpace(strings.Split(ct, ";")[0]))
+	return strings.HasSuffix(mt, "/xml") || strings.HasSuffix(mt, "+xml")
+}
+
+type xmlNode struct {
+	name     string
+	attrs    []xml.Attr
+	children []*xmlNode
+	text     strings.Builder
+}
+
+// decodeXML decodes the XML document into the map like JSON.
+// The element is the map of the attributes ( `@name` ), the child elements and the text ( `#text` ), or the text if it has neither attributes nor child elements.
+// The repeated child elements are the list. The namespace prefixes are dropped.
+func decodeXML(b []byte) (any, error) {
+	d := xml.NewDecoder(bytes.NewReader(b))
+	root := &xmlNode{}
+	stack := []*xmlNode{root}
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		cur := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			n := &xmlNode{name: t.Name.Local}
+			for _, a := range t.Attr {
+				if a.Name.Space == "xmlns" || a.Name.Local == "xmlns" {
+					continue
+				}
+				n.attrs = append(n.attrs, a)
+			}
+			cur.children = append(cur.children, n)
+			stack = append(stack, n)
+		case xml.EndElement:
+			if len(stack) == 1 {
+				return nil, errors.New("invalid xml: unexpected end element")
+			}
+			stack = stack[:len(stack)-1]
+		case xml.CharData:
+			_, _ = cur.text.Write(t)
+		}
+	}
+	if len(stack) != 1 {
+		return nil, errors.New("invalid xml: unclosed element")
+	}
+	if len(root.children) != 1 {
+		return nil, errors.New("invalid xml: no root element")
+	}
+	r := root.children[0]
+	return map[string]any{r.name: r.value()}, nil
+}
+
+func (n *xmlNode) value() any {
+	text := strings.TrimSpace(n.text.String())
+	if len(n.attrs) == 0 && len(n.children) == 0 {
+		return text
+	}
+	m := map[string]any{}
+	for _, a := range n.attrs {
+		m[xmlAttrPrefix+a.Name.Local] = a.Value
+	}
+	for _, c := range n.children {
+		v := c.value()
+		e, ok := m[c.name]
+		if !ok {
+			m[c.name] = v
+			continue
+		}
+		if l, ok := e.([]any); ok {
+			m[c.name] = append(l, v)
+			continue
+		}
+		m[c.name] = []any{e, v}
+	}
+	if text != "" {
+		m[xmlTextKey] = text
+	}
+	return m
+}
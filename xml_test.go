@@ -0,0 +1,83 @@
+package runn
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+)
+
+func TestDecodeXML(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    any
+		wantErr bool
+	}{
+		{
+			`<user id="1"><name>alice</name></user>`,
+			map[string]any{"user": map[string]any{"@id": "1", "name": "alice"}},
+			false,
+		},
+		{
+			`<?xml version="1.0" encoding="UTF-8"?>
+<rss version="2.0">
+  <channel>
+    <title>News</title>
+    <item><title>first</title></item>
+    <item><title>second</title></item>
+    <item><title>third</title></item>
+  </channel>
+</rss>`,
+			map[string]any{"rss": map[string]any{"@version": "2.0", "channel": map[string]any{
+				"title": "News",
+				"item": []any{
+					map[string]any{"title": "first"},
+					map[string]any{"title": "second"},
+					map[string]any{"title": "third"},
+				},
+			}}},
+			false,
+		},
+		{
+			`<soap:Envelope xmlns:soap="http://schemas.xmlsoap.org/soap/envelope/"><soap:Body><m:GetPriceResponse xmlns:m="https://example.com/prices"><m:Price currency="USD">1.90</m:Price></m:GetPriceResponse></soap:Body></soap:Envelope>`,
+			map[string]any{"Envelope": map[string]any{"Body": map[string]any{"GetPriceResponse": map[string]any{"Price": map[string]any{"@currency": "USD", "#text": "1.90"}}}}},
+			false,
+		},
+		{
+			`<empty/>`,
+			map[string]any{"empty": ""},
+			false,
+		},
+		{`<a><b></a>`, nil, true},
+		{`not xml`, nil, true},
+	}
+	for _, tt := range tests {
+		got, err := decodeXML([]byte(tt.in))
+		if (err != nil) != tt.wantErr {
+			t.Errorf("%s: got %v\nwantErr %v", tt.in, err, tt.wantErr)
+			continue
+		}
+		if diff := cmp.Diff(got, tt.want); diff != "" {
+			t.Error(diff)
+		}
+	}
+}
+
+func TestIsXMLContentType(t *testing.T) {
+	tests := []struct {
+		in   string
+		want bool
+	}{
+		{"application/xml", true},
+		{"text/xml; charset=utf-8", true},
+		{"application/soap+xml", true},
+		{"application/atom+xml", true},
+		{"application/json", false},
+		{"text/html", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := isXMLContentType(tt.in); got != tt.want {
+			t.Errorf("%s: got %v\nwant %v", tt.in, got, tt.want)
+		}
+	}
+}